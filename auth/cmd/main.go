@@ -14,6 +14,7 @@ import (
 	delivery "auth/internal/delivery/http"
 	"auth/internal/repository"
 	"auth/internal/usecase"
+	"shared/flags"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -51,6 +52,12 @@ func main() {
 	// репозиторий для админки (таксономия/связки)
 	adminRepo := repository.NewAdminRepository(dbpool) // <- подставь свой конструктор, если название другое
 
+	// feature flags: кеш в памяти, обновляется по NOTIFY до shutdown
+	flagsCtx, stopFlags := context.WithCancel(context.Background())
+	defer stopFlags()
+	flagClient := flags.New(dbpool, flags.Options{Refresh: cfg.Flags.Refresh})
+	go flagClient.Run(flagsCtx)
+
 	// --- usecases ---
 	authUC := usecase.NewAuthUseCase(
 		userRepo, sessRepo, otpRepo,
@@ -65,6 +72,7 @@ func main() {
 			OTPTTL:         cfg.OTP.TTL,
 			OTPLength:      cfg.OTP.Length,
 			MaxOTPAttempts: 5,
+			Flags:          flagClient,
 		},
 	)
 
@@ -72,6 +80,7 @@ func main() {
 
 	// --- http ---
 	router := mux.NewRouter()
+	router.Use(flags.Middleware)
 
 	h := delivery.NewAuthHandler(authUC, adminUC)
 	h.RegisterRoutes(router)      // /api/v1/auth/...
@@ -0,0 +1,214 @@
+// Команда taxonomy — выгрузка и загрузка справочников через admin API auth-сервиса.
+// Работает через HTTP, а не напрямую с БД: изменения попадают в audit_log от имени админа.
+//
+//	taxonomy -api https://auth.example.com -token $ADMIN_TOKEN export subjects -format csv -o subjects.csv
+//	taxonomy -token $ADMIN_TOKEN import subjects -file subjects.csv -dry-run
+//	taxonomy -token $ADMIN_TOKEN import subjects -file subjects.csv
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"auth/internal/catalog"
+)
+
+// kinds — сегмент пути admin API → вид справочника.
+var kinds = map[string]string{
+	"subjects":      catalog.KindSubject,
+	"directions":    catalog.KindDirection,
+	"subdirections": catalog.KindSubdirection,
+	"universities":  catalog.KindUniversity,
+}
+
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func main() {
+	global := flag.NewFlagSet("taxonomy", flag.ExitOnError)
+	api := global.String("api", envOr("AUTH_API_URL", "http://localhost:8080"), "auth service base URL")
+	token := global.String("token", os.Getenv("ADMIN_TOKEN"), "admin access token")
+	global.Usage = usage
+	_ = global.Parse(os.Args[1:])
+
+	args := global.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, kind := args[0], args[1]
+	if _, ok := kinds[kind]; !ok {
+		fail(fmt.Errorf("unknown taxonomy %q (subjects, directions, subdirections, universities)", kind))
+	}
+	if *token == "" {
+		fail(errors.New("admin token is required (-token or ADMIN_TOKEN)"))
+	}
+	c := &client{base: strings.TrimRight(*api, "/"), token: *token, http: &http.Client{Timeout: 2 * time.Minute}}
+
+	switch cmd {
+	case "export":
+		fail(c.export(kind, args[2:]))
+	case "import":
+		fail(c.importFile(kind, args[2:]))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: taxonomy [-api URL] [-token TOKEN] <command> <taxonomy> [flags]
+
+commands:
+  export <taxonomy> [-format csv|json] [-o file]
+  import <taxonomy> -file path [-format csv|json] [-dry-run]
+
+taxonomy: subjects | directions | subdirections | universities
+env: AUTH_API_URL, ADMIN_TOKEN`)
+}
+
+func (c *client) export(kind string, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", catalog.FormatCSV, "csv or json")
+	out := fs.String("o", "", "output file (default stdout)")
+	_ = fs.Parse(args)
+
+	q := url.Values{"format": {*format}}
+	resp, err := c.do(http.MethodGet, "/api/v1/admin/"+kind+"/export?"+q.Encode(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *client) importFile(kind string, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "CSV or JSON file")
+	format := fs.String("format", "", "csv or json (default: by file extension)")
+	dryRun := fs.Bool("dry-run", false, "only show the diff, change nothing")
+	_ = fs.Parse(args)
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	body, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	f, err := catalog.DetectFormat(*format, filepath.Ext(*file), body)
+	if err != nil {
+		return err
+	}
+
+	// ошибки формата видны сразу, без похода на сервер
+	if _, rowErrs, err := catalog.Parse(kinds[kind], f, bytes.NewReader(body)); err != nil {
+		return err
+	} else if len(rowErrs) > 0 {
+		printReport(&catalog.Report{Kind: kinds[kind], Errors: rowErrs})
+		return errors.New("file has invalid rows")
+	}
+
+	q := url.Values{"format": {f}, "dryRun": {fmt.Sprint(*dryRun)}}
+	resp, err := c.do(http.MethodPost, "/api/v1/admin/"+kind+"/import?"+q.Encode(), catalog.ContentType(f), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env struct {
+		Data  *catalog.Report `json:"data"`
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	if env.Data != nil {
+		printReport(env.Data)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s %s", resp.Status, env.Error.Code, env.Error.Message)
+	}
+	return nil
+}
+
+func (c *client) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.http.Do(req)
+}
+
+func apiError(resp *http.Response) error {
+	raw, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+}
+
+func printReport(rep *catalog.Report) {
+	for _, e := range rep.Errors {
+		fmt.Fprintf(os.Stderr, "row %d %s: %s\n", e.Row, e.Slug, e.Message)
+	}
+	for _, ch := range rep.Created {
+		fmt.Printf("+ %s %v\n", ch.Slug, ch.After.Name)
+	}
+	for _, ch := range rep.Updated {
+		fmt.Printf("~ %s (%s)\n", ch.Slug, strings.Join(ch.Fields, ", "))
+	}
+	state := "applied"
+	switch {
+	case len(rep.Errors) > 0:
+		state = "not applied"
+	case rep.DryRun:
+		state = "dry run, nothing changed"
+	}
+	fmt.Printf("%s: %d created, %d updated, %d unchanged, %d errors (%s)\n",
+		rep.Kind, len(rep.Created), len(rep.Updated), rep.Unchanged, len(rep.Errors), state)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func fail(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "taxonomy:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,400 @@
+// Package catalog — массовый импорт/экспорт справочников (CSV и JSON) и расчёт diff.
+// Форматы одинаковы для выгрузки и загрузки, так что каталог переносится между окружениями как есть.
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Виды справочников; значения совпадают с repository.TaxonomyKind.
+const (
+	KindSubject      = "subject"
+	KindDirection    = "direction"
+	KindSubdirection = "subdirection"
+	KindUniversity   = "university"
+)
+
+// bom — Excel сохраняет CSV в UTF-8 с BOM.
+const bom = "\ufeff"
+
+// MaxRecords — предел строк в одном файле: импорт идёт одной транзакцией.
+const MaxRecords = 5000
+
+var (
+	ErrUnknownFormat = errors.New("format must be csv or json")
+	ErrUnknownKind   = errors.New("unknown taxonomy")
+	ErrTooManyRows   = fmt.Errorf("file has more than %d records", MaxRecords)
+)
+
+var (
+	slugRe   = regexp.MustCompile(`^[a-z0-9]+(?:[-_][a-z0-9]+)*$`)
+	localeRe = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+// MaxSlugLen — предел длины slug.
+const MaxSlugLen = 64
+
+// NormalizeSlug приводит slug к нижнему регистру и проверяет формат. Одно правило
+// для импорта, создания и переименования записей справочника.
+func NormalizeSlug(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return s, len(s) <= MaxSlugLen && slugRe.MatchString(s)
+}
+
+// Record — запись справочника в файле. Direction — slug направления (только subdirection),
+// CountryCode/City — только university.
+type Record struct {
+	Slug        string            `json:"slug"`
+	Name        map[string]string `json:"name"`
+	Direction   string            `json:"direction,omitempty"`
+	CountryCode string            `json:"countryCode,omitempty"`
+	City        string            `json:"city,omitempty"`
+}
+
+// RowError — ошибка строки файла; Row считается с 1 (для CSV — без заголовка).
+type RowError struct {
+	Row     int    `json:"row"`
+	Slug    string `json:"slug,omitempty"`
+	Message string `json:"message"`
+}
+
+// Change — запись, которую импорт создаст или изменит.
+type Change struct {
+	Slug   string   `json:"slug"`
+	Fields []string `json:"fields,omitempty"` // что меняется; пусто у новых
+	Before *Record  `json:"before,omitempty"`
+	After  Record   `json:"after"`
+}
+
+// Report — результат импорта (или прогона с dryRun).
+type Report struct {
+	Kind      string     `json:"kind"`
+	DryRun    bool       `json:"dryRun"`
+	Applied   bool       `json:"applied"`
+	Created   []Change   `json:"created"`
+	Updated   []Change   `json:"updated"`
+	Unchanged int        `json:"unchanged"`
+	Errors    []RowError `json:"errors,omitempty"`
+}
+
+func validKind(kind string) bool {
+	switch kind {
+	case KindSubject, KindDirection, KindSubdirection, KindUniversity:
+		return true
+	}
+	return false
+}
+
+// columns — служебные колонки CSV вида; переводы идут колонками name_<locale>.
+func columns(kind string) []string {
+	switch kind {
+	case KindSubdirection:
+		return []string{"slug", "direction"}
+	case KindUniversity:
+		return []string{"slug", "country_code", "city"}
+	}
+	return []string{"slug"}
+}
+
+// Parse читает файл формата format; ошибки отдельных строк возвращаются в []RowError,
+// ошибка — только если файл не разобрать целиком.
+func Parse(kind, format string, r io.Reader) ([]Record, []RowError, error) {
+	if !validKind(kind) {
+		return nil, nil, ErrUnknownKind
+	}
+	var recs []Record
+	var err error
+	switch format {
+	case FormatCSV:
+		recs, err = parseCSV(kind, r)
+	case FormatJSON:
+		err = json.NewDecoder(r).Decode(&recs)
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(recs) > MaxRecords {
+		return nil, nil, ErrTooManyRows
+	}
+	return recs, Validate(kind, recs), nil
+}
+
+func parseCSV(kind string, r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	allowed := map[string]bool{}
+	for _, c := range columns(kind) {
+		allowed[c] = true
+	}
+	for i, c := range header {
+		c = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(c, bom)))
+		header[i] = c
+		if loc, ok := strings.CutPrefix(c, "name_"); ok && localeRe.MatchString(loc) {
+			continue
+		}
+		if !allowed[c] {
+			return nil, fmt.Errorf("unknown column %q (expected %s, name_<locale>)", c, strings.Join(columns(kind), ", "))
+		}
+	}
+
+	var out []Record
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := Record{Name: map[string]string{}}
+		for i, v := range row {
+			v = strings.TrimSpace(v)
+			switch c := header[i]; c {
+			case "slug":
+				rec.Slug = v
+			case "direction":
+				rec.Direction = v
+			case "country_code":
+				rec.CountryCode = v
+			case "city":
+				rec.City = v
+			default:
+				if v != "" {
+					rec.Name[strings.TrimPrefix(c, "name_")] = v
+				}
+			}
+		}
+		out = append(out, rec)
+	}
+}
+
+// Validate нормализует записи (регистр slug/кодов) и проверяет их.
+func Validate(kind string, recs []Record) []RowError {
+	var errs []RowError
+	seen := map[string]int{}
+	for i := range recs {
+		rec := &recs[i]
+		row := i + 1
+		fail := func(msg string) { errs = append(errs, RowError{Row: row, Slug: rec.Slug, Message: msg}) }
+
+		slug, slugOK := NormalizeSlug(rec.Slug)
+		rec.Slug = slug
+		rec.Direction = strings.ToLower(strings.TrimSpace(rec.Direction))
+		rec.CountryCode = strings.ToUpper(strings.TrimSpace(rec.CountryCode))
+		rec.City = strings.TrimSpace(rec.City)
+
+		switch {
+		case rec.Slug == "":
+			fail("slug is required")
+		case !slugOK:
+			fail(fmt.Sprintf("slug must be 1-%d chars of a-z, 0-9 separated by '-' or '_'", MaxSlugLen))
+		}
+		if prev, ok := seen[rec.Slug]; ok && rec.Slug != "" {
+			fail(fmt.Sprintf("duplicate slug, first seen in row %d", prev))
+		} else {
+			seen[rec.Slug] = row
+		}
+		for loc, v := range rec.Name {
+			if !localeRe.MatchString(loc) {
+				fail(fmt.Sprintf("bad locale %q in name", loc))
+			}
+			if strings.TrimSpace(v) == "" {
+				delete(rec.Name, loc)
+			}
+		}
+		if kind == KindSubdirection && rec.Direction == "" {
+			fail("direction is required")
+		}
+		if kind != KindSubdirection && rec.Direction != "" {
+			fail("direction is only allowed for subdirections")
+		}
+		if kind != KindUniversity && (rec.CountryCode != "" || rec.City != "") {
+			fail("countryCode and city are only allowed for universities")
+		}
+		if rec.CountryCode != "" && len(rec.CountryCode) != 2 {
+			fail("countryCode must be ISO 3166-1 alpha-2")
+		}
+	}
+	return errs
+}
+
+// Diff сравнивает файл с текущим содержимым справочника. Импорт только добавляет и
+// дополняет: переводы сливаются (пустая ячейка не стирает перевод), записи вне файла не трогаются.
+func Diff(kind string, existing, incoming []Record) *Report {
+	rep := &Report{Kind: kind, Created: []Change{}, Updated: []Change{}}
+	bySlug := make(map[string]Record, len(existing))
+	for _, e := range existing {
+		bySlug[e.Slug] = e
+	}
+	for i, in := range incoming {
+		cur, ok := bySlug[in.Slug]
+		if !ok {
+			if len(in.Name) == 0 {
+				rep.Errors = append(rep.Errors, RowError{Row: i + 1, Slug: in.Slug, Message: "name is required for a new entry"})
+				continue
+			}
+			rep.Created = append(rep.Created, Change{Slug: in.Slug, After: in})
+			continue
+		}
+		after := cur
+		after.Name = make(map[string]string, len(cur.Name)+len(in.Name))
+		for k, v := range cur.Name {
+			after.Name[k] = v
+		}
+		var fields []string
+		for _, loc := range sortedKeys(in.Name) {
+			if cur.Name[loc] != in.Name[loc] {
+				after.Name[loc] = in.Name[loc]
+				fields = append(fields, "name_"+loc)
+			}
+		}
+		for _, f := range []struct {
+			name     string
+			from, to string
+			dst      *string
+		}{
+			{"direction", cur.Direction, in.Direction, &after.Direction},
+			{"country_code", cur.CountryCode, in.CountryCode, &after.CountryCode},
+			{"city", cur.City, in.City, &after.City},
+		} {
+			if f.to != "" && f.to != f.from {
+				*f.dst = f.to
+				fields = append(fields, f.name)
+			}
+		}
+		if len(fields) == 0 {
+			rep.Unchanged++
+			continue
+		}
+		before := cur
+		rep.Updated = append(rep.Updated, Change{Slug: in.Slug, Fields: fields, Before: &before, After: after})
+	}
+	return rep
+}
+
+// Encode пишет записи в формате format (тот же, что принимает Parse).
+func Encode(kind, format string, w io.Writer, recs []Record) error {
+	if !validKind(kind) {
+		return ErrUnknownKind
+	}
+	switch format {
+	case FormatJSON:
+		if recs == nil {
+			recs = []Record{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recs)
+	case FormatCSV:
+		return encodeCSV(kind, w, recs)
+	}
+	return ErrUnknownFormat
+}
+
+func encodeCSV(kind string, w io.Writer, recs []Record) error {
+	locSet := map[string]bool{}
+	for _, r := range recs {
+		for loc := range r.Name {
+			locSet[loc] = true
+		}
+	}
+	locales := orderLocales(locSet)
+	header := columns(kind)
+	for _, loc := range locales {
+		header = append(header, "name_"+loc)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range recs {
+		row := []string{r.Slug}
+		switch kind {
+		case KindSubdirection:
+			row = append(row, r.Direction)
+		case KindUniversity:
+			row = append(row, r.CountryCode, r.City)
+		}
+		for _, loc := range locales {
+			row = append(row, r.Name[loc])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// orderLocales — сначала языки интерфейса (ru, kk, en), остальные по алфавиту.
+func orderLocales(set map[string]bool) []string {
+	var out []string
+	for _, loc := range []string{"ru", "kk", "en"} {
+		if set[loc] {
+			out = append(out, loc)
+			delete(set, loc)
+		}
+	}
+	return append(out, sortedKeys(set)...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DetectFormat — формат по явному параметру, затем по подсказке (расширение файла или
+// Content-Type), иначе по содержимому: JSON-файл — массив.
+func DetectFormat(explicit, hint string, body []byte) (string, error) {
+	if f := strings.ToLower(strings.TrimSpace(explicit)); f != "" {
+		if f != FormatCSV && f != FormatJSON {
+			return "", ErrUnknownFormat
+		}
+		return f, nil
+	}
+	hint = strings.ToLower(hint)
+	switch {
+	case strings.HasSuffix(hint, ".csv"), strings.Contains(hint, "text/csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(hint, ".json"), strings.Contains(hint, "application/json"):
+		return FormatJSON, nil
+	}
+	if b := bytes.TrimLeft(bytes.TrimPrefix(body, []byte(bom)), " \t\r\n"); len(b) > 0 && b[0] == '[' {
+		return FormatJSON, nil
+	}
+	return FormatCSV, nil
+}
+
+// ContentType — заголовок ответа выгрузки.
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
@@ -40,6 +40,9 @@ type Config struct {
 		TTL     time.Duration
 		Length  int
 	}
+	Flags struct {
+		Refresh time.Duration // полная перезагрузка кеша флагов помимо NOTIFY
+	}
 	CORS struct {
 		AllowedOrigins []string
 		AllowedMethods []string
@@ -80,6 +83,9 @@ func MustLoad() Config {
 	c.OTP.TTL = getEnvDur("OTP_TTL", "2m")
 	c.OTP.Length = getEnvInt("OTP_LENGTH", 6)
 
+	// Feature flags
+	c.Flags.Refresh = getEnvDur("FLAGS_REFRESH_INTERVAL", "1m")
+
 	// CORS
 	c.CORS.AllowedOrigins = getEnvList("CORS_ALLOWED_ORIGINS", "*")
 	c.CORS.AllowedMethods = getEnvList("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS")
@@ -2,11 +2,20 @@
 package http
 
 import (
+	"auth/internal/catalog"
 	"auth/internal/domain"
-	_ "auth/internal/repository"
+	"auth/internal/repository"
+	"auth/internal/usecase"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"shared/audit"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -34,7 +43,11 @@ func (h *AuthHandler) adminOnly() mux.MiddlewareFunc {
 				writeErr(w, http.StatusForbidden, "FORBIDDEN", "admin only")
 				return
 			}
-			next.ServeHTTP(w, r)
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+			// все изменения под админкой попадают в audit_log от имени этого админа
+			ctx = audit.WithActor(ctx, claims.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -56,6 +69,9 @@ func (h *AuthHandler) RegisterAdminRoutes(r *mux.Router) {
 	public.HandleFunc("/subdirections", h.adminListSubdirections).Methods(http.MethodGet)
 	public.HandleFunc("/universities", h.adminListUniversities).Methods(http.MethodGet)
 
+	// дерево каталога для витрины: направления → поднаправления → число репетиторов
+	r.HandleFunc("/api/v1/catalog/tree", h.catalogTree).Methods(http.MethodGet, http.MethodHead)
+
 	// tutor <-> taxonomy bindings (GET — публично)
 	public.HandleFunc("/tutors/{tutorID}/subjects", h.adminListTutorSubjects).Methods(http.MethodGet)
 	public.HandleFunc("/tutors/{tutorID}/languages", h.adminListTutorLanguages).Methods(http.MethodGet)
@@ -80,6 +96,25 @@ func (h *AuthHandler) RegisterAdminRoutes(r *mux.Router) {
 	protected.HandleFunc("/tutors/{tutorID}/subjects", h.adminUpsertTutorSubject).Methods(http.MethodPost, http.MethodOptions)
 	protected.HandleFunc("/tutors/{tutorID}/languages", h.adminUpsertTutorLanguage).Methods(http.MethodPost, http.MethodOptions)
 	protected.HandleFunc("/tutors/{tutorID}/subdirections", h.adminUpsertTutorSubdirection).Methods(http.MethodPost, http.MethodOptions)
+
+	// правка справочников: частичное обновление, безопасное удаление, слияние дублей
+	const taxonomyPath = "/{kind:languages|subjects|directions|subdirections|universities}/{id}"
+	protected.HandleFunc(taxonomyPath, h.adminUpdateTaxonomy).Methods(http.MethodPatch, http.MethodOptions)
+	protected.HandleFunc(taxonomyPath, h.adminDeleteTaxonomy).Methods(http.MethodDelete)
+	protected.HandleFunc(taxonomyPath+"/merge", h.adminMergeTaxonomy).Methods(http.MethodPost)
+
+	// массовая выгрузка/загрузка справочников: ?format=csv|json, импорт — ?dryRun=true для diff без записи
+	const bulkPath = "/{kind:subjects|directions|subdirections|universities}"
+	protected.HandleFunc(bulkPath+"/export", h.adminExportTaxonomy).Methods(http.MethodGet)
+	protected.HandleFunc(bulkPath+"/import", h.adminImportTaxonomy).Methods(http.MethodPost)
+
+	// верификация репетиторов (очередь + решение)
+	protected.HandleFunc("/tutors/verification", h.adminListTutorsForReview).Methods(http.MethodGet)
+	protected.HandleFunc("/tutors/{tutorID}/verification/approve", h.adminApproveTutor).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/tutors/{tutorID}/verification/reject", h.adminRejectTutor).Methods(http.MethodPost, http.MethodOptions)
+
+	// журнал аудита: ?entityType=&entityId=&actorId=&action=&from=&to= (RFC3339)
+	protected.HandleFunc("/audit", h.adminListAudit).Methods(http.MethodGet)
 }
 func (h *AuthHandler) adminCreateUniversity(w http.ResponseWriter, r *http.Request) {
 	var req universityDTO
@@ -88,7 +123,7 @@ func (h *AuthHandler) adminCreateUniversity(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	if err := h.adminUC.CreateUniversity(r.Context(), req.Slug, req.Name, req.CountryCode, req.City); err != nil {
-		writeErr(w, http.StatusConflict, "CREATE_FAILED", err.Error())
+		writeTaxonomyErr(w, err, "CREATE_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusCreated, map[string]any{"success": true})
@@ -149,6 +184,23 @@ type tutorLanguageDTO struct {
 	Proficiency string `json:"proficiency"` // A1..C2/native
 }
 
+// taxonomyPatchDTO — отсутствующие поля не меняются; name у языков — строка, у остальных — переводы.
+type taxonomyPatchDTO struct {
+	Slug        *string         `json:"slug,omitempty"`
+	Name        json.RawMessage `json:"name,omitempty"`
+	DirectionID *string         `json:"directionId,omitempty"`
+	CountryCode *string         `json:"countryCode,omitempty"`
+	City        *string         `json:"city,omitempty"`
+}
+
+type taxonomyMergeDTO struct {
+	Into string `json:"into"` // id (у языков — код) канонической записи
+}
+
+type verificationDecisionDTO struct {
+	Reason string `json:"reason"`
+}
+
 type tutorSubdirectionDTO struct {
 	SubdirectionID   string `json:"subdirectionId,omitempty"`
 	SubdirectionSlug string `json:"subdirectionSlug,omitempty"`
@@ -165,7 +217,7 @@ func (h *AuthHandler) adminCreateLanguage(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if err := h.adminUC.CreateLanguage(r.Context(), req.Code, req.Name); err != nil {
-		writeErr(w, http.StatusConflict, "CREATE_FAILED", err.Error())
+		writeTaxonomyErr(w, err, "CREATE_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusCreated, map[string]any{"success": true})
@@ -188,7 +240,7 @@ func (h *AuthHandler) adminCreateSubject(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if err := h.adminUC.CreateSubject(r.Context(), req.Slug, req.Name); err != nil {
-		writeErr(w, http.StatusConflict, "CREATE_FAILED", err.Error())
+		writeTaxonomyErr(w, err, "CREATE_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusCreated, map[string]any{"success": true})
@@ -211,7 +263,7 @@ func (h *AuthHandler) adminCreateDirection(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	if err := h.adminUC.CreateDirection(r.Context(), req.Slug, req.Name); err != nil {
-		writeErr(w, http.StatusConflict, "CREATE_FAILED", err.Error())
+		writeTaxonomyErr(w, err, "CREATE_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusCreated, map[string]any{"success": true})
@@ -233,7 +285,7 @@ func (h *AuthHandler) adminCreateSubdirection(w http.ResponseWriter, r *http.Req
 		return
 	}
 	if err := h.adminUC.CreateSubdirection(r.Context(), req.DirectionID, req.DirectionSlug, req.Slug, req.Name); err != nil {
-		writeErr(w, http.StatusConflict, "CREATE_FAILED", err.Error())
+		writeTaxonomyErr(w, err, "CREATE_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusCreated, map[string]any{"success": true})
@@ -249,6 +301,144 @@ func (h *AuthHandler) adminListSubdirections(w http.ResponseWriter, r *http.Requ
 	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": items})
 }
 
+// ------------ handlers: update / delete / merge ------------
+
+// taxonomyKinds — сегмент пути → справочник.
+var taxonomyKinds = map[string]repository.TaxonomyKind{
+	"languages":     repository.TaxonomyLanguage,
+	"subjects":      repository.TaxonomySubject,
+	"directions":    repository.TaxonomyDirection,
+	"subdirections": repository.TaxonomySubdirection,
+	"universities":  repository.TaxonomyUniversity,
+}
+
+func (h *AuthHandler) adminUpdateTaxonomy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kind := taxonomyKinds[vars["kind"]]
+	var req taxonomyPatchDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+
+	var err error
+	if kind == repository.TaxonomyLanguage {
+		var name string
+		if len(req.Name) > 0 && json.Unmarshal(req.Name, &name) != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", "name must be a string")
+			return
+		}
+		err = h.adminUC.UpdateLanguage(r.Context(), vars["id"], name)
+	} else {
+		p := repository.TaxonomyPatch{Slug: req.Slug, DirectionID: req.DirectionID, CountryCode: req.CountryCode, City: req.City}
+		if len(req.Name) > 0 && json.Unmarshal(req.Name, &p.Name) != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", `name must be an object like {"ru":"..."}`)
+			return
+		}
+		err = h.adminUC.UpdateTaxonomy(r.Context(), kind, vars["id"], p)
+	}
+	if err != nil {
+		writeTaxonomyErr(w, err, "UPDATE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+func (h *AuthHandler) adminDeleteTaxonomy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.adminUC.DeleteTaxonomy(r.Context(), taxonomyKinds[vars["kind"]], vars["id"]); err != nil {
+		writeTaxonomyErr(w, err, "DELETE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+func (h *AuthHandler) adminMergeTaxonomy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var req taxonomyMergeDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	if err := h.adminUC.MergeTaxonomy(r.Context(), taxonomyKinds[vars["kind"]], vars["id"], req.Into); err != nil {
+		writeTaxonomyErr(w, err, "MERGE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+// maxImportBytes — предел тела импорта (файл читается целиком: формат определяется по содержимому).
+const maxImportBytes = 10 << 20
+
+func (h *AuthHandler) adminExportTaxonomy(w http.ResponseWriter, r *http.Request) {
+	kind := taxonomyKinds[mux.Vars(r)["kind"]]
+	format, err := catalog.DetectFormat(r.URL.Query().Get("format"), catalog.FormatJSON, nil)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	// пишем в буфер: ошибка БД посреди выгрузки не должна превращаться в обрезанный файл с 200
+	var buf bytes.Buffer
+	if err := h.adminUC.ExportTaxonomy(r.Context(), kind, format, &buf); err != nil {
+		writeTaxonomyErr(w, err, "EXPORT_FAILED")
+		return
+	}
+	w.Header().Set("Content-Type", catalog.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+mux.Vars(r)["kind"]+"."+format+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (h *AuthHandler) adminImportTaxonomy(w http.ResponseWriter, r *http.Request) {
+	kind := taxonomyKinds[mux.Vars(r)["kind"]]
+	q := r.URL.Query()
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		writeErr(w, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", err.Error())
+		return
+	}
+	format, err := catalog.DetectFormat(q.Get("format"), r.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	dryRun, _ := strconv.ParseBool(q.Get("dryRun"))
+
+	rep, err := h.adminUC.ImportTaxonomy(r.Context(), kind, format, bytes.NewReader(body), dryRun)
+	if err != nil {
+		writeTaxonomyErr(w, err, "IMPORT_FAILED")
+		return
+	}
+	if len(rep.Errors) > 0 {
+		// отчёт нужен и при ошибках — по нему правят файл
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"success": false,
+			"error":   map[string]string{"code": "VALIDATION_ERROR", "message": "file has invalid rows, nothing was applied"},
+			"data":    rep,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rep})
+}
+
+func writeTaxonomyErr(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrEmptyPatch), errors.Is(err, usecase.ErrMergeTargetRequired),
+		errors.Is(err, repository.ErrInvalidSlug), errors.Is(err, repository.ErrMergeSameEntry),
+		errors.Is(err, repository.ErrUnknownTaxonomy), errors.Is(err, usecase.ErrInvalidFile),
+		errors.Is(err, catalog.ErrUnknownFormat), errors.Is(err, catalog.ErrTooManyRows):
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case errors.Is(err, repository.ErrTaxonomyNotFound), errors.Is(err, repository.ErrDirectionNotFound):
+		writeErr(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrSlugTaken):
+		writeErr(w, http.StatusConflict, "SLUG_TAKEN", err.Error())
+	case errors.Is(err, repository.ErrTaxonomyInUse):
+		writeErr(w, http.StatusConflict, "IN_USE", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, fallback, err.Error())
+	}
+}
+
 // ------------ handlers: tutor bindings ------------
 func (h *AuthHandler) adminUpsertTutorSubject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -322,3 +512,109 @@ func (h *AuthHandler) adminListTutorSubdirections(w http.ResponseWriter, r *http
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": items})
 }
+
+// ------------ handlers: tutor verification ------------
+func (h *AuthHandler) adminListTutorsForReview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	items, p, err := h.adminUC.ListTutorsForReview(r.Context(), q.Get("status"), page, limit)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"tutors": items, "pagination": p,
+	}})
+}
+
+func (h *AuthHandler) adminApproveTutor(w http.ResponseWriter, r *http.Request) {
+	tutorID := mux.Vars(r)["tutorID"]
+	adminID, _ := r.Context().Value(UserIDKey).(string)
+	if err := h.adminUC.ApproveTutor(r.Context(), tutorID, adminID); err != nil {
+		writeVerificationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+func (h *AuthHandler) adminRejectTutor(w http.ResponseWriter, r *http.Request) {
+	tutorID := mux.Vars(r)["tutorID"]
+	adminID, _ := r.Context().Value(UserIDKey).(string)
+	var req verificationDecisionDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	if err := h.adminUC.RejectTutor(r.Context(), tutorID, adminID, req.Reason); err != nil {
+		writeVerificationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+func writeVerificationErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrRejectReasonRequired):
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case errors.Is(err, repository.ErrTutorNotFound):
+		writeErr(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrOnboardingNotCompleted):
+		writeErr(w, http.StatusConflict, "ONBOARDING_NOT_COMPLETED", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "VERIFICATION_FAILED", err.Error())
+	}
+}
+
+// ------------ handlers: audit ------------
+func (h *AuthHandler) adminListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	f := repository.AuditFilter{
+		EntityType: strings.TrimSpace(q.Get("entityType")),
+		EntityID:   strings.TrimSpace(q.Get("entityId")),
+		ActorID:    strings.TrimSpace(q.Get("actorId")),
+		Action:     strings.TrimSpace(q.Get("action")),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", p.name+" must be RFC3339")
+			return
+		}
+		*p.dst = &t
+	}
+
+	items, pg, err := h.adminUC.ListAudit(r.Context(), f, page, limit)
+	if errors.Is(err, usecase.ErrInvalidAuditRange) {
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"entries": items, "pagination": pg,
+	}})
+}
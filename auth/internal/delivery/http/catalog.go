@@ -0,0 +1,71 @@
+package http
+
+import (
+	"auth/internal/repository"
+	"auth/internal/usecase"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// catalogTree отдаёт дерево каталога на языке из Accept-Language. Версия дерева дешевле
+// самого дерева, поэтому условные запросы проверяются до его построения.
+func (h *AuthHandler) catalogTree(w http.ResponseWriter, r *http.Request) {
+	locale := usecase.CatalogLocale(r.Header.Get("Accept-Language"))
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+
+	version, err := h.adminUC.CatalogVersion(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "CATALOG_FAILED", err.Error())
+		return
+	}
+	if notModified(r, catalogETag(version, locale), version.ChangedAt) {
+		setCatalogValidators(w, version, locale)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	tree, err := h.adminUC.CatalogTree(r.Context(), locale)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "CATALOG_FAILED", err.Error())
+		return
+	}
+	// версия берётся из того же снимка, что и дерево: между запросами она могла смениться
+	setCatalogValidators(w, tree.Version, locale)
+	w.Header().Set("Content-Language", locale)
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": tree})
+}
+
+func catalogETag(version repository.TreeVersion, locale string) string {
+	return fmt.Sprintf(`W/"tree-%x-%x-%s"`, version.ChangedAt.UnixNano(), version.Rows, locale)
+}
+
+func setCatalogValidators(w http.ResponseWriter, version repository.TreeVersion, locale string) {
+	w.Header().Set("ETag", catalogETag(version, locale))
+	w.Header().Set("Last-Modified", version.ChangedAt.UTC().Format(http.TimeFormat))
+}
+
+// notModified — If-None-Match имеет приоритет над If-Modified-Since (RFC 9110, 13.2.2).
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimSpace(t)
+			// слабое сравнение: W/ не учитывается
+			if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.Truncate(time.Second).After(ims)
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"auth/internal/domain"
 	"auth/internal/usecase"
 	"encoding/json"
+	"errors"
 	"net"
 	"net/http"
 
@@ -139,6 +140,10 @@ func (h *AuthHandler) sendOTP(w http.ResponseWriter, r *http.Request) {
 	}
 	// На MVP это можно no-op, но метод готов
 	if err := h.useCase.SendOTP(r.Context(), req.Phone); err != nil {
+		if errors.Is(err, usecase.ErrOTPDisabled) {
+			writeErr(w, http.StatusForbidden, "OTP_DISABLED", err.Error())
+			return
+		}
 		writeErr(w, http.StatusInternalServerError, "OTP_SEND_FAILED", err.Error())
 		return
 	}
@@ -154,6 +159,15 @@ func (h *AuthHandler) verifyOTP(w http.ResponseWriter, r *http.Request) {
 	ua := r.UserAgent()
 	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
 	pair, err := h.useCase.VerifyOTP(r.Context(), req.Phone, req.Code, ua, ip)
+	if errors.Is(err, usecase.ErrOTPDisabled) {
+		writeErr(w, http.StatusForbidden, "OTP_DISABLED", err.Error())
+		return
+	}
+	// код уже подтверждён — владельцу номера можно сказать, что аккаунт заблокирован
+	if errors.Is(err, usecase.ErrAccountBlocked) {
+		writeErr(w, http.StatusForbidden, "ACCOUNT_BLOCKED", err.Error())
+		return
+	}
 	if err != nil {
 		writeErr(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid OTP")
 		return
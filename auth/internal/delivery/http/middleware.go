@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	_ "auth/internal/usecase"
+	"shared/flags"
 )
 
 type CtxKey string
@@ -34,6 +35,7 @@ func (h *AuthHandler) JWTMiddleware(next http.Handler) http.Handler {
 		}
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = flags.WithUser(ctx, claims.UserID, string(claims.Role))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -2,10 +2,13 @@
 package repository
 
 import (
+	"auth/internal/catalog"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"shared/audit"
 	"strings"
 	"time"
 
@@ -69,6 +72,27 @@ type TutorSubdirectionView struct {
 	Currency       string `json:"currency"`
 }
 
+// Очередь модерации анкет репетиторов
+type TutorReviewItem struct {
+	TutorID               string     `json:"tutorId"`
+	FirstName             string     `json:"firstName"`
+	LastName              string     `json:"lastName"`
+	Email                 string     `json:"email"`
+	Phone                 string     `json:"phone"`
+	Bio                   string     `json:"bio"`
+	VideoURL              string     `json:"videoUrl"`
+	Verification          string     `json:"verification"`
+	VerificationReason    string     `json:"verificationReason,omitempty"`
+	OnboardingCompletedAt *time.Time `json:"onboardingCompletedAt"`
+	ReviewedAt            *time.Time `json:"reviewedAt,omitempty"`
+}
+
+var (
+	ErrTutorNotFound          = errors.New("tutor not found")
+	ErrOnboardingNotCompleted = errors.New("tutor onboarding is not completed")
+	ErrInvalidVerification    = errors.New("verification must be 'verified' or 'rejected'")
+)
+
 type AdminRepository interface {
 	// taxonomies
 	CreateLanguage(ctx context.Context, code, name string) error
@@ -94,12 +118,44 @@ type AdminRepository interface {
 	ListTutorSubdirections(ctx context.Context, tutorID string) ([]TutorSubdirectionView, error)
 	CreateUniversity(ctx context.Context, slug string, name map[string]any, countryCode, city string) error
 	ListUniversities(ctx context.Context, country, q string) ([]University, error)
+
+	// правка справочников: Create* не перезаписывают существующие записи (ErrSlugTaken)
+	UpdateLanguage(ctx context.Context, code, name string) error
+	UpdateTaxonomy(ctx context.Context, kind TaxonomyKind, id string, p TaxonomyPatch) error
+	DeleteTaxonomy(ctx context.Context, kind TaxonomyKind, id string) error
+	MergeTaxonomy(ctx context.Context, kind TaxonomyKind, id, into string) error
+	ExportTaxonomy(ctx context.Context, kind TaxonomyKind) ([]catalog.Record, error)
+	ImportTaxonomy(ctx context.Context, kind TaxonomyKind, recs []catalog.Record, dryRun bool) (*catalog.Report, error)
+
+	// публичное дерево каталога
+	CatalogVersion(ctx context.Context) (TreeVersion, error)
+	CatalogTree(ctx context.Context) (dirs, subdirs []CatalogNode, version TreeVersion, err error)
+
+	// tutor verification
+	ListTutorsForReview(ctx context.Context, status string, page, limit int) ([]TutorReviewItem, int, error)
+	SetTutorVerification(ctx context.Context, tutorID, adminID, status, reason string) error
+
+	// audit
+	ListAudit(ctx context.Context, f AuditFilter, page, limit int) ([]AuditEntry, int, error)
 }
 
 type adminRepo struct{ db *pgxpool.Pool }
 
 func NewAdminRepository(db *pgxpool.Pool) AdminRepository { return &adminRepo{db: db} }
 
+// inTx — изменения админки идут в транзакции вместе с записью в audit_log.
+func (r *adminRepo) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // ---------------- taxonomies ----------------
 
 func (r *adminRepo) CreateLanguage(ctx context.Context, code, name string) error {
@@ -107,10 +163,19 @@ func (r *adminRepo) CreateLanguage(ctx context.Context, code, name string) error
 	if code == "" {
 		return fmt.Errorf("empty code")
 	}
-	_, err := r.db.Exec(ctx, `
-INSERT INTO languages(code, name) VALUES ($1,$2)
-ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name`, code, name)
-	return err
+	return createErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "language",
+			action:     "language",
+			lockSQL:    `SELECT code, to_jsonb(l) FROM languages l WHERE code = $1 FOR UPDATE`,
+			lockArgs:   []any{code},
+			applySQL: `
+INSERT INTO languages AS l (code, name) VALUES ($1,$2)
+ON CONFLICT (code) DO NOTHING
+RETURNING l.code, to_jsonb(l)`,
+			applyArgs: []any{code, name},
+		})
+	}))
 }
 
 func (r *adminRepo) ListLanguages(ctx context.Context) ([]Language, error) {
@@ -131,12 +196,24 @@ func (r *adminRepo) ListLanguages(ctx context.Context) ([]Language, error) {
 }
 
 func (r *adminRepo) CreateSubject(ctx context.Context, slug string, name map[string]any) error {
-	slug = strings.ToLower(strings.TrimSpace(slug))
+	slug, err := normalizeSlug(slug)
+	if err != nil {
+		return err
+	}
 	b, _ := json.Marshal(name)
-	_, err := r.db.Exec(ctx, `
-INSERT INTO subjects(slug, name) VALUES ($1, $2::jsonb)
-ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name`, slug, string(b))
-	return err
+	return createErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "subject",
+			action:     "subject",
+			lockSQL:    `SELECT t.id::text, to_jsonb(t) FROM subjects t WHERE t.slug = $1 FOR UPDATE`,
+			lockArgs:   []any{slug},
+			applySQL: `
+INSERT INTO subjects AS t (slug, name) VALUES ($1, $2::jsonb)
+ON CONFLICT (slug) DO NOTHING
+RETURNING t.id::text, to_jsonb(t)`,
+			applyArgs: []any{slug, string(b)},
+		})
+	}))
 }
 
 func (r *adminRepo) ListSubjects(ctx context.Context) ([]Subject, error) {
@@ -159,12 +236,24 @@ func (r *adminRepo) ListSubjects(ctx context.Context) ([]Subject, error) {
 }
 
 func (r *adminRepo) CreateDirection(ctx context.Context, slug string, name map[string]any) error {
-	slug = strings.ToLower(strings.TrimSpace(slug))
+	slug, err := normalizeSlug(slug)
+	if err != nil {
+		return err
+	}
 	b, _ := json.Marshal(name)
-	_, err := r.db.Exec(ctx, `
-INSERT INTO directions(slug, name) VALUES ($1,$2::jsonb)
-ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name`, slug, string(b))
-	return err
+	return createErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "direction",
+			action:     "direction",
+			lockSQL:    `SELECT t.id::text, to_jsonb(t) FROM directions t WHERE t.slug = $1 FOR UPDATE`,
+			lockArgs:   []any{slug},
+			applySQL: `
+INSERT INTO directions AS t (slug, name) VALUES ($1, $2::jsonb)
+ON CONFLICT (slug) DO NOTHING
+RETURNING t.id::text, to_jsonb(t)`,
+			applyArgs: []any{slug, string(b)},
+		})
+	}))
 }
 
 func (r *adminRepo) ListDirections(ctx context.Context) ([]Direction, error) {
@@ -187,21 +276,33 @@ func (r *adminRepo) ListDirections(ctx context.Context) ([]Direction, error) {
 }
 
 func (r *adminRepo) CreateSubdirection(ctx context.Context, directionID, directionSlug, slug string, name map[string]any) error {
-	slug = strings.ToLower(strings.TrimSpace(slug))
+	slug, err := normalizeSlug(slug)
+	if err != nil {
+		return err
+	}
 	var dirID string
 	if directionID != "" {
 		dirID = directionID
 	} else {
 		// resolve by slug
 		if err := r.db.QueryRow(ctx, `SELECT id FROM directions WHERE slug=$1`, strings.ToLower(directionSlug)).Scan(&dirID); err != nil {
-			return fmt.Errorf("direction not found")
+			return ErrDirectionNotFound
 		}
 	}
 	b, _ := json.Marshal(name)
-	_, err := r.db.Exec(ctx, `
-INSERT INTO subdirections(direction_id, slug, name) VALUES ($1,$2,$3::jsonb)
-ON CONFLICT (slug) DO UPDATE SET direction_id = EXCLUDED.direction_id, name = EXCLUDED.name`, dirID, slug, string(b))
-	return err
+	return createErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "subdirection",
+			action:     "subdirection",
+			lockSQL:    `SELECT t.id::text, to_jsonb(t) FROM subdirections t WHERE t.slug = $1 FOR UPDATE`,
+			lockArgs:   []any{slug},
+			applySQL: `
+INSERT INTO subdirections AS t (direction_id, slug, name) VALUES ($1,$2,$3::jsonb)
+ON CONFLICT (slug) DO NOTHING
+RETURNING t.id::text, to_jsonb(t)`,
+			applyArgs: []any{dirID, slug, string(b)},
+		})
+	}))
 }
 
 func (r *adminRepo) ListSubdirections(ctx context.Context, directionSlug string) ([]Subdirection, error) {
@@ -246,13 +347,23 @@ func (r *adminRepo) UpsertTutorSubject(ctx context.Context, tutorID, subjectID,
 	if currency == "" {
 		currency = "KZT"
 	}
-	_, err := r.db.Exec(ctx, `
-INSERT INTO tutor_subjects (tutor_id, subject_id, level, price_minor, currency)
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "tutor",
+			entityID:   tutorID,
+			action:     "tutor_subject",
+			lockSQL: `SELECT t.tutor_id::text, to_jsonb(t) FROM tutor_subjects t
+WHERE t.tutor_id = $1 AND t.subject_id = $2 FOR UPDATE`,
+			lockArgs: []any{tutorID, subjectID},
+			applySQL: `
+INSERT INTO tutor_subjects AS t (tutor_id, subject_id, level, price_minor, currency)
 VALUES ($1,$2,$3,$4,$5)
 ON CONFLICT (tutor_id, subject_id) DO UPDATE
-SET level=EXCLUDED.level, price_minor=EXCLUDED.price_minor, currency=EXCLUDED.currency`,
-		tutorID, subjectID, nullIfEmpty(level), price, strings.ToUpper(currency))
-	return err
+SET level=EXCLUDED.level, price_minor=EXCLUDED.price_minor, currency=EXCLUDED.currency
+RETURNING t.tutor_id::text, to_jsonb(t)`,
+			applyArgs: []any{tutorID, subjectID, nullIfEmpty(level), price, strings.ToUpper(currency)},
+		})
+	})
 }
 
 func (r *adminRepo) ListTutorSubjects(ctx context.Context, tutorID string) ([]TutorSubjectView, error) {
@@ -282,17 +393,27 @@ func (r *adminRepo) UpsertTutorLanguage(ctx context.Context, tutorID, code, prof
 	if code == "" {
 		return fmt.Errorf("empty language code")
 	}
-	// seed if missing
-	if _, err := r.db.Exec(ctx, `INSERT INTO languages(code,name) VALUES ($1,$2) ON CONFLICT (code) DO NOTHING`, code, code); err != nil {
-		return err
-	}
 	prof := normalizeProficiency(proficiency)
-	_, err := r.db.Exec(ctx, `
-INSERT INTO tutor_languages (tutor_id, lang_code, proficiency)
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		// seed if missing
+		if _, err := tx.Exec(ctx, `INSERT INTO languages(code,name) VALUES ($1,$2) ON CONFLICT (code) DO NOTHING`, code, code); err != nil {
+			return err
+		}
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "tutor",
+			entityID:   tutorID,
+			action:     "tutor_language",
+			lockSQL: `SELECT t.tutor_id::text, to_jsonb(t) FROM tutor_languages t
+WHERE t.tutor_id = $1 AND t.lang_code = $2 FOR UPDATE`,
+			lockArgs: []any{tutorID, code},
+			applySQL: `
+INSERT INTO tutor_languages AS t (tutor_id, lang_code, proficiency)
 VALUES ($1,$2,$3)
-ON CONFLICT (tutor_id, lang_code) DO UPDATE SET proficiency = EXCLUDED.proficiency`,
-		tutorID, code, prof)
-	return err
+ON CONFLICT (tutor_id, lang_code) DO UPDATE SET proficiency = EXCLUDED.proficiency
+RETURNING t.tutor_id::text, to_jsonb(t)`,
+			applyArgs: []any{tutorID, code, prof},
+		})
+	})
 }
 
 func (r *adminRepo) ListTutorLanguages(ctx context.Context, tutorID string) ([]TutorLanguageView, error) {
@@ -326,13 +447,23 @@ func (r *adminRepo) UpsertTutorSubdirection(ctx context.Context, tutorID, subdir
 	if currency == "" {
 		currency = "KZT"
 	}
-	_, err := r.db.Exec(ctx, `
-INSERT INTO tutor_subdirections (tutor_id, subdirection_id, level, price_minor, currency)
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "tutor",
+			entityID:   tutorID,
+			action:     "tutor_subdirection",
+			lockSQL: `SELECT t.tutor_id::text, to_jsonb(t) FROM tutor_subdirections t
+WHERE t.tutor_id = $1 AND t.subdirection_id = $2 FOR UPDATE`,
+			lockArgs: []any{tutorID, subdirID},
+			applySQL: `
+INSERT INTO tutor_subdirections AS t (tutor_id, subdirection_id, level, price_minor, currency)
 VALUES ($1,$2,$3,$4,$5)
 ON CONFLICT (tutor_id, subdirection_id) DO UPDATE
-SET level=EXCLUDED.level, price_minor=EXCLUDED.price_minor, currency=EXCLUDED.currency`,
-		tutorID, subdirID, nullIfEmpty(level), price, strings.ToUpper(currency))
-	return err
+SET level=EXCLUDED.level, price_minor=EXCLUDED.price_minor, currency=EXCLUDED.currency
+RETURNING t.tutor_id::text, to_jsonb(t)`,
+			applyArgs: []any{tutorID, subdirID, nullIfEmpty(level), price, strings.ToUpper(currency)},
+		})
+	})
 }
 
 func (r *adminRepo) ListTutorSubdirections(ctx context.Context, tutorID string) ([]TutorSubdirectionView, error) {
@@ -360,20 +491,28 @@ ORDER BY sd.slug`, tutorID)
 // ---------------- universities ----------------
 
 func (r *adminRepo) CreateUniversity(ctx context.Context, slug string, name map[string]any, countryCode, city string) error {
-	slug = strings.ToLower(strings.TrimSpace(slug))
-	if slug == "" || len(name) == 0 {
-		return fmt.Errorf("slug and name are required")
+	slug, err := normalizeSlug(slug)
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		return fmt.Errorf("name is required")
 	}
 	b, _ := json.Marshal(name)
-	_, err := r.db.Exec(ctx, `
-INSERT INTO universities (slug, name, country_code, city)
+	return createErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "university",
+			action:     "university",
+			lockSQL:    `SELECT t.id::text, to_jsonb(t) FROM universities t WHERE t.slug = $1 FOR UPDATE`,
+			lockArgs:   []any{slug},
+			applySQL: `
+INSERT INTO universities AS t (slug, name, country_code, city)
 VALUES ($1, $2::jsonb, NULLIF($3,''), NULLIF($4,''))
-ON CONFLICT (slug) DO UPDATE SET
-  name = EXCLUDED.name,
-  country_code = EXCLUDED.country_code,
-  city = EXCLUDED.city
-`, slug, string(b), strings.TrimSpace(countryCode), strings.TrimSpace(city))
-	return err
+ON CONFLICT (slug) DO NOTHING
+RETURNING t.id::text, to_jsonb(t)`,
+			applyArgs: []any{slug, string(b), strings.TrimSpace(countryCode), strings.TrimSpace(city)},
+		})
+	}))
 }
 
 func (r *adminRepo) ListUniversities(ctx context.Context, country, q string) ([]University, error) {
@@ -417,6 +556,122 @@ WHERE 1=1`
 	return out, nil
 }
 
+// ---------------- tutor verification ----------------
+
+// ListTutorsForReview — анкеты, прошедшие онбординг (props.onboarding_completed_at),
+// в порядке очереди: кто раньше закончил, тот раньше рассматривается.
+func (r *adminRepo) ListTutorsForReview(ctx context.Context, status string, page, limit int) ([]TutorReviewItem, int, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		status = "pending"
+	}
+	offset := (page - 1) * limit
+
+	var total int
+	if err := r.db.QueryRow(ctx, `
+SELECT COUNT(*)
+FROM tutor_profiles tp
+JOIN users u ON u.id = tp.user_id
+WHERE tp.deleted_at IS NULL AND u.deleted_at IS NULL
+  AND tp.props ? 'onboarding_completed_at'
+  AND tp.verification = $1`, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+SELECT tp.user_id, COALESCE(u.first_name,''), COALESCE(u.last_name,''), COALESCE(u.email::text,''), COALESCE(u.phone_e164,''),
+       COALESCE(tp.bio,''), COALESCE(tp.video_url,''), tp.verification,
+       COALESCE(tp.props->>'verification_reason',''),
+       (tp.props->>'onboarding_completed_at')::timestamptz,
+       (tp.props->>'verification_reviewed_at')::timestamptz
+FROM tutor_profiles tp
+JOIN users u ON u.id = tp.user_id
+WHERE tp.deleted_at IS NULL AND u.deleted_at IS NULL
+  AND tp.props ? 'onboarding_completed_at'
+  AND tp.verification = $1
+ORDER BY (tp.props->>'onboarding_completed_at')::timestamptz ASC
+LIMIT $2 OFFSET $3`, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]TutorReviewItem, 0, limit)
+	for rows.Next() {
+		var it TutorReviewItem
+		if err := rows.Scan(&it.TutorID, &it.FirstName, &it.LastName, &it.Email, &it.Phone,
+			&it.Bio, &it.VideoURL, &it.Verification, &it.VerificationReason,
+			&it.OnboardingCompletedAt, &it.ReviewedAt); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, it)
+	}
+	return out, total, rows.Err()
+}
+
+// SetTutorVerification — одобрение/отклонение анкеты + уведомление репетитору в одной транзакции.
+func (r *adminRepo) SetTutorVerification(ctx context.Context, tutorID, adminID, status, reason string) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status != "verified" && status != "rejected" {
+		return ErrInvalidVerification
+	}
+	reason = strings.TrimSpace(reason)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var completed bool
+	var before []byte
+	err = tx.QueryRow(ctx, `
+SELECT props ? 'onboarding_completed_at',
+       jsonb_build_object('verification', verification, 'reason', props->'verification_reason')
+FROM tutor_profiles
+WHERE user_id = $1 AND deleted_at IS NULL
+FOR UPDATE`, tutorID).Scan(&completed, &before)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTutorNotFound
+		}
+		return err
+	}
+	if !completed {
+		return ErrOnboardingNotCompleted
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE tutor_profiles
+SET verification = $2,
+    props = jsonb_strip_nulls(
+              COALESCE(props,'{}'::jsonb) ||
+              jsonb_build_object(
+                'verification_reason',      to_jsonb($3::text),
+                'verification_reviewed_by', to_jsonb($4::text),
+                'verification_reviewed_at', to_char(now() AT TIME ZONE 'UTC','YYYY-MM-DD"T"HH24:MI:SS"Z"')
+              )
+            ),
+    updated_at = now()
+WHERE user_id = $1`, tutorID, status, nullIfEmpty(reason), nullIfEmpty(adminID)); err != nil {
+		return fmt.Errorf("update tutor_profiles: %w", err)
+	}
+
+	payload, _ := json.Marshal(map[string]any{"status": status, "reason": reason})
+	if _, err := tx.Exec(ctx, `
+INSERT INTO notifications (user_id, channel, template_key, payload, status)
+VALUES ($1, 'inapp', $2, $3::jsonb, 'queued')`, tutorID, "tutor.verification."+status, string(payload)); err != nil {
+		return fmt.Errorf("insert notification: %w", err)
+	}
+
+	after := map[string]any{"verification": status, "reason": nullIfEmpty(reason)}
+	if err := audit.Write(ctx, tx, adminID, "tutor", tutorID, "tutor.verification", before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 // helpers
 func nullIfEmpty(s string) *string {
 	s = strings.TrimSpace(s)
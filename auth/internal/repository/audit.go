@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"shared/audit"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditEntry struct {
+	ID         string          `json:"id"`
+	ActorID    string          `json:"actorId,omitempty"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// AuditFilter — пустые поля не фильтруют.
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     string
+	From, To   *time.Time
+}
+
+// auditedChange — изменение одной строки со снимками до/после. Оба запроса
+// возвращают (id, to_jsonb(строки)); отсутствие строки в lockSQL — создание.
+type auditedChange struct {
+	entityType string
+	entityID   string // пусто — id из запросов (сама строка и есть сущность)
+	action     string // префикс: к нему добавляется .create или .update
+	lockSQL    string // SELECT ... FOR UPDATE
+	lockArgs   []any
+	applySQL   string // INSERT/UPDATE ... RETURNING
+	applyArgs  []any
+}
+
+// applyAudited выполняет изменение и пишет audit_log в одной транзакции;
+// если строка не изменилась, записи в журнале нет.
+func applyAudited(ctx context.Context, tx pgx.Tx, c auditedChange) error {
+	var id string
+	var before, after []byte
+	err := tx.QueryRow(ctx, c.lockSQL, c.lockArgs...).Scan(&id, &before)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err := tx.QueryRow(ctx, c.applySQL, c.applyArgs...).Scan(&id, &after); err != nil {
+		return err
+	}
+	action := c.action + ".create"
+	if before != nil {
+		action = c.action + ".update"
+	}
+	entityID := c.entityID
+	if entityID == "" {
+		entityID = id
+	}
+	return audit.Change(ctx, tx, audit.Actor(ctx), c.entityType, entityID, action, before, after)
+}
+
+func (r *adminRepo) ListAudit(ctx context.Context, f AuditFilter, page, limit int) ([]AuditEntry, int, error) {
+	where := `
+WHERE ($1 = '' OR entity_type = $1)
+  AND ($2 = '' OR entity_id = $2)
+  AND ($3 = '' OR actor_id::text = $3)
+  AND ($4 = '' OR action = $4 OR action LIKE $4 || '.%')
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)`
+	args := []any{f.EntityType, f.EntityID, f.ActorID, f.Action, f.From, f.To}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(ctx, `
+SELECT id::text, COALESCE(actor_id::text,''), entity_type, entity_id, action, before, after, created_at
+FROM audit_log`+where+`
+ORDER BY created_at DESC, id DESC
+LIMIT $7 OFFSET $8`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.EntityType, &e.EntityID, &e.Action, &before, &after, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		e.Before, e.After = before, after
+		out = append(out, e)
+	}
+	return out, total, rows.Err()
+}
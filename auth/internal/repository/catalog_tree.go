@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CatalogNode — направление или поднаправление с сырыми переводами; язык выбирает usecase.
+type CatalogNode struct {
+	ID          string
+	Slug        string
+	Name        map[string]any
+	DirectionID string // только у поднаправлений
+	TutorCount  int    // активные (одобренные, не удалённые) репетиторы
+}
+
+// activeTutorBindings — привязки к поднаправлениям репетиторов, которых видно в каталоге.
+const activeTutorBindings = `
+WITH active AS (
+  SELECT ts.tutor_id, ts.subdirection_id
+  FROM tutor_subdirections ts
+  JOIN tutor_profiles tp ON tp.user_id = ts.tutor_id
+  JOIN users u ON u.id = tp.user_id
+  WHERE tp.deleted_at IS NULL AND u.deleted_at IS NULL AND tp.verification = 'verified'
+)`
+
+// TreeVersion — версия дерева каталога: последнее изменение среди его таблиц и число
+// их строк (удаление не двигает updated_at, но меняет счёт).
+type TreeVersion struct {
+	ChangedAt time.Time
+	Rows      int64
+}
+
+// catalogVersionSQL читает max() по индексам updated_at; отдельной строки-счётчика нет,
+// так что правки привязок разных репетиторов не ждут друг друга.
+const catalogVersionSQL = `
+SELECT GREATEST(
+         (SELECT max(updated_at) FROM directions),
+         (SELECT max(updated_at) FROM subdirections),
+         (SELECT max(updated_at) FROM tutor_subdirections),
+         (SELECT max(updated_at) FROM tutor_profiles),
+         (SELECT max(deleted_at) FROM users),
+         'epoch'::timestamptz),
+       (SELECT count(*) FROM directions) + (SELECT count(*) FROM subdirections) +
+       (SELECT count(*) FROM tutor_subdirections)`
+
+func (r *adminRepo) CatalogVersion(ctx context.Context) (TreeVersion, error) {
+	var v TreeVersion
+	err := r.db.QueryRow(ctx, catalogVersionSQL).Scan(&v.ChangedAt, &v.Rows)
+	return v, err
+}
+
+// CatalogTree — направления (счётчик — уникальные репетиторы по всем поднаправлениям),
+// поднаправления и версия дерева; всё из одного снимка, чтобы ETag соответствовал содержимому.
+func (r *adminRepo) CatalogTree(ctx context.Context) ([]CatalogNode, []CatalogNode, TreeVersion, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, nil, TreeVersion{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var version TreeVersion
+	if err := tx.QueryRow(ctx, catalogVersionSQL).Scan(&version.ChangedAt, &version.Rows); err != nil {
+		return nil, nil, TreeVersion{}, err
+	}
+	dirs, err := catalogNodes(ctx, tx, activeTutorBindings+`
+SELECT d.id::text, d.slug, d.name, '', COUNT(DISTINCT a.tutor_id)
+FROM directions d
+LEFT JOIN subdirections s ON s.direction_id = d.id
+LEFT JOIN active a ON a.subdirection_id = s.id
+GROUP BY d.id
+ORDER BY d.slug`)
+	if err != nil {
+		return nil, nil, TreeVersion{}, err
+	}
+	subs, err := catalogNodes(ctx, tx, activeTutorBindings+`
+SELECT s.id::text, s.slug, s.name, s.direction_id::text, COUNT(a.tutor_id)
+FROM subdirections s
+LEFT JOIN active a ON a.subdirection_id = s.id
+GROUP BY s.id
+ORDER BY s.slug`)
+	if err != nil {
+		return nil, nil, TreeVersion{}, err
+	}
+	return dirs, subs, version, nil
+}
+
+func catalogNodes(ctx context.Context, tx pgx.Tx, sql string) ([]CatalogNode, error) {
+	rows, err := tx.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CatalogNode
+	for rows.Next() {
+		var n CatalogNode
+		var jb []byte
+		if err := rows.Scan(&n.ID, &n.Slug, &jb, &n.DirectionID, &n.TutorCount); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(jb, &n.Name)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
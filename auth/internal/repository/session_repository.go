@@ -9,6 +9,8 @@ import (
 
 type SessionsRepository interface {
 	Create(ctx context.Context, id string, userID string, tokenHash string, expiresAt time.Time, ua, ip string) error
+	// GetByHash — сессия по хешу refresh-токена; сессии заблокированных и удалённых пользователей
+	// не находятся (ErrNoRows).
 	GetByHash(ctx context.Context, tokenHash string) (id string, userID string, expiresAt time.Time, revokedAt *time.Time, err error)
 	Revoke(ctx context.Context, id string) error
 	RevokeAllByUser(ctx context.Context, userID string) error
@@ -31,9 +33,10 @@ func (r *sessionsRepo) GetByHash(ctx context.Context, tokenHash string) (string,
 	var exp time.Time
 	var revokedAt *time.Time
 	err := r.db.QueryRow(ctx, `
-SELECT id, user_id, expires_at, revoked_at
-FROM auth_sessions
-WHERE refresh_token_hash = $1
+SELECT s.id, s.user_id, s.expires_at, s.revoked_at
+FROM auth_sessions s
+JOIN users u ON u.id = s.user_id
+WHERE s.refresh_token_hash = $1 AND u.status = 'active' AND u.deleted_at IS NULL
 `, tokenHash).Scan(&id, &userID, &exp, &revokedAt)
 	return id, userID, exp, revokedAt, err
 }
@@ -0,0 +1,520 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"auth/internal/catalog"
+	"shared/audit"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TaxonomyKind — справочник админки; значение совпадает с entity_type в audit_log.
+type TaxonomyKind string
+
+const (
+	TaxonomyLanguage     TaxonomyKind = "language"
+	TaxonomySubject      TaxonomyKind = "subject"
+	TaxonomyDirection    TaxonomyKind = "direction"
+	TaxonomySubdirection TaxonomyKind = "subdirection"
+	TaxonomyUniversity   TaxonomyKind = "university"
+)
+
+var (
+	ErrTaxonomyNotFound  = errors.New("taxonomy entry not found")
+	ErrUnknownTaxonomy   = errors.New("unknown taxonomy")
+	ErrSlugTaken         = errors.New("slug is already taken")
+	ErrInvalidSlug       = fmt.Errorf("slug must be 1-%d chars of a-z, 0-9 separated by '-' or '_'", catalog.MaxSlugLen)
+	ErrTaxonomyInUse     = errors.New("taxonomy entry is in use")
+	ErrMergeSameEntry    = errors.New("cannot merge an entry into itself")
+	ErrDirectionNotFound = errors.New("direction not found")
+)
+
+// TaxonomyPatch — частичное изменение записи; nil-поля не трогаются.
+// Name сливается с текущими переводами: {"kk": null} удаляет перевод.
+type TaxonomyPatch struct {
+	Slug        *string
+	Name        map[string]any
+	DirectionID *string // только subdirection
+	CountryCode *string // только university
+	City        *string // только university
+}
+
+// taxonomyRef — таблица, ссылающаяся на запись справочника.
+type taxonomyRef struct {
+	table  string
+	column string
+	// уникальность строки без учёта column: при слиянии дубль, который уже есть
+	// у канонической записи, удаляется, а не переносится
+	uniq []string
+	// action — префикс аудита привязки репетитора (пусто — не привязка, tutor_id нет)
+	action string
+}
+
+type taxonomySpec struct {
+	table string
+	key   string // id или code (languages)
+	refs  []taxonomyRef
+}
+
+var taxonomies = map[TaxonomyKind]taxonomySpec{
+	TaxonomyLanguage: {table: "languages", key: "code", refs: []taxonomyRef{
+		{table: "tutor_languages", column: "lang_code", uniq: []string{"tutor_id"}, action: "tutor_language"},
+	}},
+	TaxonomySubject: {table: "subjects", key: "id", refs: []taxonomyRef{
+		{table: "tutor_subjects", column: "subject_id", uniq: []string{"tutor_id"}, action: "tutor_subject"},
+		{table: "tutor_prices", column: "subject_id", uniq: []string{"tutor_id", "duration_minutes", "lessons_count", "is_trial"}, action: "tutor_price"},
+		{table: "bookings", column: "subject_id"},
+		{table: "lessons", column: "subject_id"},
+	}},
+	TaxonomyDirection: {table: "directions", key: "id", refs: []taxonomyRef{
+		// subdirections удаляются каскадом вместе с направлением — поэтому это тоже "использование"
+		{table: "subdirections", column: "direction_id"},
+	}},
+	TaxonomySubdirection: {table: "subdirections", key: "id", refs: []taxonomyRef{
+		{table: "tutor_subdirections", column: "subdirection_id", uniq: []string{"tutor_id"}, action: "tutor_subdirection"},
+		{table: "tutor_prices", column: "subdirection_id", uniq: []string{"tutor_id", "duration_minutes", "lessons_count", "is_trial"}, action: "tutor_price"},
+		{table: "bookings", column: "subdirection_id"},
+		{table: "lessons", column: "subdirection_id"},
+	}},
+	TaxonomyUniversity: {table: "universities", key: "id", refs: []taxonomyRef{
+		{table: "tutor_education", column: "university_id", action: "tutor_education"},
+	}},
+}
+
+func taxonomyOf(kind TaxonomyKind) (taxonomySpec, error) {
+	s, ok := taxonomies[kind]
+	if !ok {
+		return taxonomySpec{}, ErrUnknownTaxonomy
+	}
+	return s, nil
+}
+
+// taxonomyErr переводит ошибки Postgres в ошибки справочника.
+func taxonomyErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return ErrSlugTaken
+		case "22P02": // invalid_text_representation: id не uuid
+			return ErrTaxonomyNotFound
+		case "23503": // foreign_key_violation: на запись ссылается таблица вне refs
+			return fmt.Errorf("%w: %s", ErrTaxonomyInUse, pgErr.TableName)
+		}
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTaxonomyNotFound
+	}
+	return err
+}
+
+// createErr: Create* вставляют с ON CONFLICT DO NOTHING — пустой RETURNING значит, что slug занят.
+func createErr(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrSlugTaken
+	case errors.As(err, &pgErr) && (pgErr.Code == "23503" || pgErr.Code == "22P02"):
+		// direction_id не существует или не uuid
+		return ErrDirectionNotFound
+	}
+	return err
+}
+
+// normalizeSlug — то же правило, что и при импорте (catalog.NormalizeSlug).
+func normalizeSlug(s string) (string, error) {
+	s, ok := catalog.NormalizeSlug(s)
+	if !ok {
+		return "", ErrInvalidSlug
+	}
+	return s, nil
+}
+
+// UpdateLanguage меняет название языка; код — ключ, он не переименовывается.
+func (r *adminRepo) UpdateLanguage(ctx context.Context, code, name string) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return taxonomyErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: "language",
+			action:     "language",
+			lockSQL:    `SELECT code, to_jsonb(l) FROM languages l WHERE code = $1 FOR UPDATE`,
+			lockArgs:   []any{code},
+			applySQL:   `UPDATE languages AS l SET name = $2 WHERE l.code = $1 RETURNING l.code, to_jsonb(l)`,
+			applyArgs:  []any{code, strings.TrimSpace(name)},
+		})
+	}))
+}
+
+// UpdateTaxonomy — переименование slug и правка переводов subject/direction/subdirection/university.
+func (r *adminRepo) UpdateTaxonomy(ctx context.Context, kind TaxonomyKind, id string, p TaxonomyPatch) error {
+	spec, err := taxonomyOf(kind)
+	if err != nil || spec.key != "id" {
+		return ErrUnknownTaxonomy
+	}
+	var slug *string
+	if p.Slug != nil {
+		s, err := normalizeSlug(*p.Slug)
+		if err != nil {
+			return err
+		}
+		slug = &s
+	}
+	var name *string
+	if len(p.Name) > 0 {
+		b, _ := json.Marshal(p.Name)
+		s := string(b)
+		name = &s
+	}
+
+	set := []string{
+		"slug = COALESCE($2, t.slug)",
+		// переводы сливаются; если удалили все — оставляем прежние
+		"name = COALESCE(NULLIF(jsonb_strip_nulls(t.name || COALESCE($3::jsonb, '{}'::jsonb)), '{}'::jsonb), t.name)",
+	}
+	args := []any{id, slug, name}
+	switch kind {
+	case TaxonomySubdirection:
+		set = append(set, "direction_id = COALESCE($4::uuid, t.direction_id)")
+		args = append(args, p.DirectionID)
+	case TaxonomyUniversity:
+		set = append(set,
+			"country_code = CASE WHEN $4::text IS NULL THEN t.country_code ELSE NULLIF(upper(trim($4)), '') END",
+			"city = CASE WHEN $5::text IS NULL THEN t.city ELSE NULLIF(trim($5), '') END")
+		args = append(args, p.CountryCode, p.City)
+	}
+
+	err = r.inTx(ctx, func(tx pgx.Tx) error {
+		return applyAudited(ctx, tx, auditedChange{
+			entityType: string(kind),
+			action:     string(kind),
+			lockSQL:    fmt.Sprintf(`SELECT t.id::text, to_jsonb(t) FROM %s t WHERE t.id = $1 FOR UPDATE`, spec.table),
+			lockArgs:   []any{id},
+			applySQL: fmt.Sprintf(`UPDATE %s AS t SET %s WHERE t.id = $1 RETURNING t.id::text, to_jsonb(t)`,
+				spec.table, strings.Join(set, ", ")),
+			applyArgs: args,
+		})
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrDirectionNotFound
+	}
+	return taxonomyErr(err)
+}
+
+// DeleteTaxonomy удаляет запись, только если на неё никто не ссылается;
+// иначе ErrTaxonomyInUse с перечнем таблиц — такие записи нужно сливать (MergeTaxonomy).
+func (r *adminRepo) DeleteTaxonomy(ctx context.Context, kind TaxonomyKind, id string) error {
+	spec, err := taxonomyOf(kind)
+	if err != nil {
+		return err
+	}
+	id = taxonomyKey(kind, id)
+	return taxonomyErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		before, err := lockTaxonomy(ctx, tx, spec, id)
+		if err != nil {
+			return err
+		}
+		var used []string
+		for _, ref := range spec.refs {
+			var n int
+			if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT count(*) FROM %s WHERE %s = $1`, ref.table, ref.column), id).Scan(&n); err != nil {
+				return err
+			}
+			if n > 0 {
+				used = append(used, fmt.Sprintf("%s: %d", ref.table, n))
+			}
+		}
+		if len(used) > 0 {
+			return fmt.Errorf("%w (%s)", ErrTaxonomyInUse, strings.Join(used, ", "))
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, spec.table, spec.key), id); err != nil {
+			return err
+		}
+		return audit.Write(ctx, tx, audit.Actor(ctx), string(kind), id, string(kind)+".delete", before, nil)
+	}))
+}
+
+// MergeTaxonomy переносит все ссылки с дубля на каноническую запись и удаляет дубль.
+// Привязки репетитора, которые уже есть у канонической записи, остаются в её версии.
+func (r *adminRepo) MergeTaxonomy(ctx context.Context, kind TaxonomyKind, id, into string) error {
+	spec, err := taxonomyOf(kind)
+	if err != nil {
+		return err
+	}
+	id, into = taxonomyKey(kind, id), taxonomyKey(kind, into)
+	if id == into {
+		return ErrMergeSameEntry
+	}
+	return taxonomyErr(r.inTx(ctx, func(tx pgx.Tx) error {
+		// блокируем в одном порядке, чтобы встречные слияния не взаимоблокировались
+		first, second := id, into
+		if second < first {
+			first, second = second, first
+		}
+		snap := map[string][]byte{}
+		for _, k := range []string{first, second} {
+			b, err := lockTaxonomy(ctx, tx, spec, k)
+			if err != nil {
+				return err
+			}
+			snap[k] = b
+		}
+
+		moved := map[string]int64{}
+		tutors := map[string]map[string]bool{} // action → tutor_id
+		for _, ref := range spec.refs {
+			if len(ref.uniq) > 0 {
+				conds := make([]string, len(ref.uniq))
+				for i, c := range ref.uniq {
+					conds[i] = fmt.Sprintf("c.%s = d.%s", c, c)
+				}
+				if _, err := collectTutors(ctx, tx, ref, tutors, fmt.Sprintf(`
+DELETE FROM %[1]s d
+WHERE d.%[2]s = $1
+  AND EXISTS (SELECT 1 FROM %[1]s c WHERE c.%[2]s = $2 AND %[3]s)
+RETURNING d.tutor_id::text`, ref.table, ref.column, strings.Join(conds, " AND ")), id, into); err != nil {
+					return err
+				}
+			}
+			if ref.action != "" {
+				n, err := collectTutors(ctx, tx, ref, tutors, fmt.Sprintf(
+					`UPDATE %s SET %s = $2 WHERE %s = $1 RETURNING tutor_id::text`, ref.table, ref.column, ref.column), id, into)
+				if err != nil {
+					return err
+				}
+				moved[ref.table] += n
+				continue
+			}
+			tag, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET %s = $2 WHERE %s = $1`, ref.table, ref.column, ref.column), id, into)
+			if err != nil {
+				return err
+			}
+			moved[ref.table] += tag.RowsAffected()
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, spec.table, spec.key), id); err != nil {
+			return err
+		}
+
+		actor := audit.Actor(ctx)
+		// у привязок репетитора своя история (entity_type = tutor), как и при их изменении из админки
+		actions := make([]string, 0, len(tutors))
+		for a := range tutors {
+			actions = append(actions, a)
+		}
+		sort.Strings(actions)
+		for _, a := range actions {
+			ids := make([]string, 0, len(tutors[a]))
+			for t := range tutors[a] {
+				ids = append(ids, t)
+			}
+			sort.Strings(ids)
+			for _, t := range ids {
+				if err := audit.Write(ctx, tx, actor, "tutor", t, a+".merge",
+					map[string]any{string(kind): id}, map[string]any{string(kind): into}); err != nil {
+					return err
+				}
+			}
+		}
+		if err := audit.Write(ctx, tx, actor, string(kind), id, string(kind)+".merge",
+			snap[id], map[string]any{"into": into, "moved": moved}); err != nil {
+			return err
+		}
+		return nil
+	}))
+}
+
+// taxonomyKey — коды языков хранятся в нижнем регистре, uuid сравниваются как есть.
+func taxonomyKey(kind TaxonomyKind, key string) string {
+	key = strings.TrimSpace(key)
+	if kind == TaxonomyLanguage {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// lockTaxonomy блокирует запись и возвращает её снимок для аудита.
+func lockTaxonomy(ctx context.Context, tx pgx.Tx, spec taxonomySpec, key string) ([]byte, error) {
+	var b []byte
+	err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT to_jsonb(t) FROM %s t WHERE t.%s = $1 FOR UPDATE`, spec.table, spec.key), key).Scan(&b)
+	return b, err
+}
+
+// collectTutors выполняет запрос, возвращающий tutor_id затронутых строк, и запоминает репетиторов.
+func collectTutors(ctx context.Context, tx pgx.Tx, ref taxonomyRef, into map[string]map[string]bool, sql string, args ...any) (int64, error) {
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var n int64
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return 0, err
+		}
+		n++
+		if into[ref.action] == nil {
+			into[ref.action] = map[string]bool{}
+		}
+		into[ref.action][t] = true
+	}
+	return n, rows.Err()
+}
+
+// ---------------- bulk import / export ----------------
+
+// exportSQL — записи справочника в виде catalog.Record (slug, name, direction, country_code, city).
+var exportSQL = map[TaxonomyKind]string{
+	TaxonomySubject:   `SELECT slug, name, '', '', '' FROM subjects ORDER BY slug`,
+	TaxonomyDirection: `SELECT slug, name, '', '', '' FROM directions ORDER BY slug`,
+	TaxonomySubdirection: `
+SELECT s.slug, s.name, d.slug, '', ''
+FROM subdirections s JOIN directions d ON d.id = s.direction_id
+ORDER BY d.slug, s.slug`,
+	TaxonomyUniversity: `SELECT slug, name, '', COALESCE(country_code,''), COALESCE(city,'') FROM universities ORDER BY slug`,
+}
+
+// importSQL — upsert по slug; значения уже слиты с текущими в catalog.Diff.
+var importSQL = map[TaxonomyKind]string{
+	TaxonomySubject: `
+INSERT INTO subjects AS t (slug, name) VALUES ($1, $2::jsonb)
+ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name
+RETURNING t.id::text, to_jsonb(t)`,
+	TaxonomyDirection: `
+INSERT INTO directions AS t (slug, name) VALUES ($1, $2::jsonb)
+ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name
+RETURNING t.id::text, to_jsonb(t)`,
+	TaxonomySubdirection: `
+INSERT INTO subdirections AS t (slug, name, direction_id) VALUES ($1, $2::jsonb, $3)
+ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name, direction_id = EXCLUDED.direction_id
+RETURNING t.id::text, to_jsonb(t)`,
+	TaxonomyUniversity: `
+INSERT INTO universities AS t (slug, name, country_code, city)
+VALUES ($1, $2::jsonb, NULLIF($3,''), NULLIF($4,''))
+ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name, country_code = EXCLUDED.country_code, city = EXCLUDED.city
+RETURNING t.id::text, to_jsonb(t)`,
+}
+
+func (r *adminRepo) ExportTaxonomy(ctx context.Context, kind TaxonomyKind) ([]catalog.Record, error) {
+	return loadCatalog(ctx, r.db, kind)
+}
+
+func loadCatalog(ctx context.Context, q interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}, kind TaxonomyKind) ([]catalog.Record, error) {
+	query, ok := exportSQL[kind]
+	if !ok {
+		return nil, ErrUnknownTaxonomy
+	}
+	rows, err := q.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []catalog.Record{}
+	for rows.Next() {
+		var rec catalog.Record
+		var jb []byte
+		if err := rows.Scan(&rec.Slug, &jb, &rec.Direction, &rec.CountryCode, &rec.City); err != nil {
+			return nil, err
+		}
+		// в name бывают не только строки — в файл попадают только переводы
+		var name map[string]any
+		_ = json.Unmarshal(jb, &name)
+		rec.Name = make(map[string]string, len(name))
+		for loc, v := range name {
+			if s, ok := v.(string); ok && s != "" {
+				rec.Name[loc] = s
+			}
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// ImportTaxonomy сверяет записи с базой и, если ошибок нет и это не dryRun, применяет
+// все изменения одной транзакцией (с записью в audit_log). Справочник на время импорта
+// закрыт для записи, чтобы diff не устарел.
+func (r *adminRepo) ImportTaxonomy(ctx context.Context, kind TaxonomyKind, recs []catalog.Record, dryRun bool) (*catalog.Report, error) {
+	spec, err := taxonomyOf(kind)
+	if err != nil || importSQL[kind] == "" {
+		return nil, ErrUnknownTaxonomy
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE `+spec.table+` IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, err
+	}
+	existing, err := loadCatalog(ctx, tx, kind)
+	if err != nil {
+		return nil, err
+	}
+	rep := catalog.Diff(string(kind), existing, recs)
+	rep.DryRun = dryRun
+
+	dirIDs := map[string]string{}
+	if kind == TaxonomySubdirection {
+		rows, err := tx.Query(ctx, `SELECT slug, id::text FROM directions`)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var slug, id string
+			if err := rows.Scan(&slug, &id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			dirIDs[slug] = id
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		for i, rec := range recs {
+			if rec.Direction != "" && dirIDs[rec.Direction] == "" {
+				rep.Errors = append(rep.Errors, catalog.RowError{Row: i + 1, Slug: rec.Slug, Message: "unknown direction " + rec.Direction})
+			}
+		}
+	}
+	if dryRun || len(rep.Errors) > 0 {
+		return rep, nil
+	}
+
+	for _, ch := range append(append([]catalog.Change{}, rep.Created...), rep.Updated...) {
+		b, _ := json.Marshal(ch.After.Name)
+		args := []any{ch.Slug, string(b)}
+		switch kind {
+		case TaxonomySubdirection:
+			args = append(args, dirIDs[ch.After.Direction])
+		case TaxonomyUniversity:
+			args = append(args, ch.After.CountryCode, ch.After.City)
+		}
+		if err := applyAudited(ctx, tx, auditedChange{
+			entityType: string(kind),
+			action:     string(kind),
+			lockSQL:    fmt.Sprintf(`SELECT t.id::text, to_jsonb(t) FROM %s t WHERE t.slug = $1 FOR UPDATE`, spec.table),
+			lockArgs:   []any{ch.Slug},
+			applySQL:   importSQL[kind],
+			applyArgs:  args,
+		}); err != nil {
+			return nil, fmt.Errorf("import %s %s: %w", kind, ch.Slug, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	rep.Applied = true
+	return rep, nil
+}
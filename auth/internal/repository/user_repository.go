@@ -3,8 +3,10 @@ package repository
 import (
 	"auth/internal/domain"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"shared/audit"
 	"strings"
 	"time"
 
@@ -16,8 +18,12 @@ var ErrDuplicateEmail = errors.New("email already exists")
 var ErrDuplicatePhone = errors.New("phone already exists")
 var ErrNoRows = pgx.ErrNoRows
 
+// ErrUserInactive — пользователь заблокирован модерацией или ещё не активирован (users.status).
+var ErrUserInactive = errors.New("user is not active")
+
 type UserRepository interface {
 	Create(ctx context.Context, u *domain.User, passwordHash string) error
+	// GetByEmail и GetByPhone находят только активных: для остальных — ErrUserInactive.
 	GetByEmail(ctx context.Context, email string) (*domain.User, string, error)
 	GetByPhone(ctx context.Context, phone string) (*domain.User, error)
 }
@@ -64,6 +70,21 @@ VALUES ($1, LOWER($2), $3, $4, $5, 'ru', 'active', $6, $6, NULL, $7, $8)`
 		return fmt.Errorf("insert student_profiles: %w", err)
 	}
 
+	// событие user.registered — в той же транзакции, relay в tutor-сервисе отправит его в брокер
+	payload, _ := json.Marshal(map[string]any{"userId": u.ID, "role": u.Role, "locale": "ru", "registeredAt": now})
+	_, err = tx.Exec(ctx, `
+INSERT INTO outbox_events (aggregate, aggregate_id, event_type, payload)
+VALUES ('user', $1, 'user.registered', $2::jsonb)`, u.ID, string(payload))
+	if err != nil {
+		return fmt.Errorf("insert outbox_events: %w", err)
+	}
+
+	// роль и статус задаются только здесь; дальше статус меняет модерация (user.block)
+	if err := audit.Write(ctx, tx, u.ID, "user", u.ID, "user.create", nil,
+		map[string]any{"role": u.Role, "status": "active"}); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -73,27 +94,35 @@ VALUES ($1, LOWER($2), $3, $4, $5, 'ru', 'active', $6, $6, NULL, $7, $8)`
 
 func (r *userPostgresRepo) GetByEmail(ctx context.Context, email string) (*domain.User, string, error) {
 	row := r.db.QueryRow(ctx, `
-SELECT id, email, COALESCE(phone_e164,''), COALESCE(first_name,''), COALESCE(last_name,''), role, created_at, password_hash
+SELECT id, email, COALESCE(phone_e164,''), COALESCE(first_name,''), COALESCE(last_name,''), role, created_at,
+       COALESCE(password_hash,''), status
 FROM users WHERE email = LOWER($1) AND deleted_at IS NULL
 `, email)
 
 	var u domain.User
-	var passHash string
-	if err := row.Scan(&u.ID, &u.Email, &u.Phone, &u.FirstName, &u.LastName, &u.Role, &u.CreatedAt, &passHash); err != nil {
+	var passHash, status string
+	if err := row.Scan(&u.ID, &u.Email, &u.Phone, &u.FirstName, &u.LastName, &u.Role, &u.CreatedAt, &passHash, &status); err != nil {
 		return nil, "", err
 	}
+	if status != "active" {
+		return nil, "", ErrUserInactive
+	}
 	return &u, passHash, nil
 }
 
 func (r *userPostgresRepo) GetByPhone(ctx context.Context, phone string) (*domain.User, error) {
 	row := r.db.QueryRow(ctx, `
-SELECT id, email, COALESCE(phone_e164,''), COALESCE(first_name,''), COALESCE(last_name,''), role, created_at
+SELECT id, email, COALESCE(phone_e164,''), COALESCE(first_name,''), COALESCE(last_name,''), role, created_at, status
 FROM users WHERE phone_e164 = $1 AND deleted_at IS NULL
 `, phone)
 
 	var u domain.User
-	if err := row.Scan(&u.ID, &u.Email, &u.Phone, &u.FirstName, &u.LastName, &u.Role, &u.CreatedAt); err != nil {
+	var status string
+	if err := row.Scan(&u.ID, &u.Email, &u.Phone, &u.FirstName, &u.LastName, &u.Role, &u.CreatedAt, &status); err != nil {
 		return nil, err
 	}
+	if status != "active" {
+		return nil, ErrUserInactive
+	}
 	return &u, nil
 }
@@ -1,8 +1,22 @@
 package usecase
 
 import (
+	"auth/internal/catalog"
 	"auth/internal/repository"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+var (
+	ErrRejectReasonRequired = errors.New("reason is required to reject a tutor")
+	ErrInvalidAuditRange    = errors.New("from must be before to")
+	ErrEmptyPatch           = errors.New("nothing to update")
+	ErrMergeTargetRequired  = errors.New("into is required")
+	ErrInvalidFile          = errors.New("cannot parse taxonomy file")
 )
 
 type AdminUseCase interface {
@@ -29,6 +43,34 @@ type AdminUseCase interface {
 
 	CreateUniversity(ctx context.Context, slug string, name map[string]any, country, city string) error
 	ListUniversities(ctx context.Context, country, q string) ([]repository.University, error)
+
+	UpdateLanguage(ctx context.Context, code, name string) error
+	UpdateTaxonomy(ctx context.Context, kind repository.TaxonomyKind, id string, p repository.TaxonomyPatch) error
+	DeleteTaxonomy(ctx context.Context, kind repository.TaxonomyKind, id string) error
+	// MergeTaxonomy переносит привязки репетиторов с дубля id на into и удаляет дубль.
+	MergeTaxonomy(ctx context.Context, kind repository.TaxonomyKind, id, into string) error
+	// ExportTaxonomy пишет справочник в w в формате csv/json — том же, что принимает импорт.
+	ExportTaxonomy(ctx context.Context, kind repository.TaxonomyKind, format string, w io.Writer) error
+	// ImportTaxonomy разбирает файл и применяет его (или только считает diff при dryRun).
+	// Если в отчёте есть Errors, ничего не применено.
+	ImportTaxonomy(ctx context.Context, kind repository.TaxonomyKind, format string, r io.Reader, dryRun bool) (*catalog.Report, error)
+
+	// CatalogVersion — дешёвая проверка для условных запросов; CatalogTree — дерево на языке locale.
+	CatalogVersion(ctx context.Context) (repository.TreeVersion, error)
+	CatalogTree(ctx context.Context, locale string) (*CatalogTree, error)
+
+	ListTutorsForReview(ctx context.Context, status string, page, limit int) ([]repository.TutorReviewItem, *Pagination, error)
+	ApproveTutor(ctx context.Context, tutorID, adminID string) error
+	RejectTutor(ctx context.Context, tutorID, adminID, reason string) error
+
+	ListAudit(ctx context.Context, f repository.AuditFilter, page, limit int) ([]repository.AuditEntry, *Pagination, error)
+}
+
+type Pagination struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
 }
 
 type adminUseCase struct{ repo repository.AdminRepository }
@@ -90,3 +132,85 @@ func (uc *adminUseCase) CreateUniversity(ctx context.Context, slug string, name
 func (uc *adminUseCase) ListUniversities(ctx context.Context, country, q string) ([]repository.University, error) {
 	return uc.repo.ListUniversities(ctx, country, q)
 }
+
+func (uc *adminUseCase) UpdateLanguage(ctx context.Context, code, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrEmptyPatch
+	}
+	return uc.repo.UpdateLanguage(ctx, code, name)
+}
+func (uc *adminUseCase) UpdateTaxonomy(ctx context.Context, kind repository.TaxonomyKind, id string, p repository.TaxonomyPatch) error {
+	if p.Slug == nil && len(p.Name) == 0 && p.DirectionID == nil && p.CountryCode == nil && p.City == nil {
+		return ErrEmptyPatch
+	}
+	return uc.repo.UpdateTaxonomy(ctx, kind, id, p)
+}
+func (uc *adminUseCase) DeleteTaxonomy(ctx context.Context, kind repository.TaxonomyKind, id string) error {
+	return uc.repo.DeleteTaxonomy(ctx, kind, id)
+}
+func (uc *adminUseCase) MergeTaxonomy(ctx context.Context, kind repository.TaxonomyKind, id, into string) error {
+	if strings.TrimSpace(into) == "" {
+		return ErrMergeTargetRequired
+	}
+	return uc.repo.MergeTaxonomy(ctx, kind, id, into)
+}
+
+func (uc *adminUseCase) ExportTaxonomy(ctx context.Context, kind repository.TaxonomyKind, format string, w io.Writer) error {
+	recs, err := uc.repo.ExportTaxonomy(ctx, kind)
+	if err != nil {
+		return err
+	}
+	return catalog.Encode(string(kind), format, w, recs)
+}
+func (uc *adminUseCase) ImportTaxonomy(ctx context.Context, kind repository.TaxonomyKind, format string, r io.Reader, dryRun bool) (*catalog.Report, error) {
+	recs, rowErrs, err := catalog.Parse(string(kind), format, r)
+	if errors.Is(err, catalog.ErrUnknownFormat) || errors.Is(err, catalog.ErrUnknownKind) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(rowErrs) > 0 {
+		// файл с ошибками не сверяем с базой: исправлять всё равно придётся сначала их
+		return &catalog.Report{Kind: string(kind), DryRun: dryRun, Created: []catalog.Change{}, Updated: []catalog.Change{}, Errors: rowErrs}, nil
+	}
+	return uc.repo.ImportTaxonomy(ctx, kind, recs, dryRun)
+}
+
+func (uc *adminUseCase) ListTutorsForReview(ctx context.Context, status string, page, limit int) ([]repository.TutorReviewItem, *Pagination, error) {
+	items, total, err := uc.repo.ListTutorsForReview(ctx, status, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, &Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}, nil
+}
+func (uc *adminUseCase) ApproveTutor(ctx context.Context, tutorID, adminID string) error {
+	return uc.repo.SetTutorVerification(ctx, tutorID, adminID, "verified", "")
+}
+func (uc *adminUseCase) RejectTutor(ctx context.Context, tutorID, adminID, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrRejectReasonRequired
+	}
+	return uc.repo.SetTutorVerification(ctx, tutorID, adminID, "rejected", reason)
+}
+
+func (uc *adminUseCase) ListAudit(ctx context.Context, f repository.AuditFilter, page, limit int) ([]repository.AuditEntry, *Pagination, error) {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return nil, nil, ErrInvalidAuditRange
+	}
+	items, total, err := uc.repo.ListAudit(ctx, f, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, &Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}, nil
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"shared/flags"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,6 +20,8 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrInvalidRefresh     = errors.New("invalid refresh token")
+	ErrOTPDisabled        = errors.New("otp login is disabled")
+	ErrAccountBlocked     = errors.New("account is blocked")
 )
 
 type Claims struct {
@@ -61,6 +64,8 @@ type authUseCase struct {
 	otpTTL         time.Duration
 	otpLength      int
 	maxOTPAttempts int
+
+	flags flags.Client
 }
 
 type Config struct {
@@ -74,6 +79,7 @@ type Config struct {
 	OTPTTL         time.Duration
 	OTPLength      int
 	MaxOTPAttempts int
+	Flags          flags.Client // nil — флаги не проверяются
 }
 
 func NewAuthUseCase(
@@ -104,6 +110,8 @@ func NewAuthUseCase(
 		otpTTL:         cfg.OTPTTL,
 		otpLength:      cfg.OTPLength,
 		maxOTPAttempts: cfg.MaxOTPAttempts,
+
+		flags: cfg.Flags,
 	}
 }
 
@@ -133,6 +141,9 @@ func (uc *authUseCase) Login(ctx context.Context, email, password, ua, ip string
 		if errors.Is(err, repository.ErrNoRows) {
 			return TokenPair{}, ErrUserNotFound
 		}
+		if errors.Is(err, repository.ErrUserInactive) {
+			return TokenPair{}, ErrAccountBlocked
+		}
 		return TokenPair{}, err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(passHash), []byte(password)); err != nil {
@@ -141,10 +152,26 @@ func (uc *authUseCase) Login(ctx context.Context, email, password, ua, ip string
 	return uc.issueTokens(ctx, u.ID, u.Role, ua, ip)
 }
 
+// otpAllowed — вход по OTP за флагом auth.otp_login; для известного номера
+// правила по роли и rollout считаются от его пользователя.
+func (uc *authUseCase) otpAllowed(ctx context.Context, phone string) bool {
+	if uc.flags == nil {
+		return true
+	}
+	s := flags.FromContext(ctx)
+	if u, err := uc.users.GetByPhone(ctx, phone); err == nil {
+		s.UserID, s.Role = u.ID, string(u.Role)
+	}
+	return uc.flags.EnabledFor(ctx, flags.OTPLogin, s, true)
+}
+
 func (uc *authUseCase) SendOTP(ctx context.Context, phone string) error {
 	if !uc.otpEnabled {
 		return nil
 	}
+	if !uc.otpAllowed(ctx, phone) {
+		return ErrOTPDisabled
+	}
 	code := uc.generateNumericCode(uc.otpLength)
 	codeHash := uc.hashOpaque(code, uc.refreshSecret) // хеш не храним в открытом виде
 	if err := uc.otp.Upsert(ctx, phone, codeHash, repository.OTPPurposeLogin, time.Now().Add(uc.otpTTL)); err != nil {
@@ -156,6 +183,9 @@ func (uc *authUseCase) SendOTP(ctx context.Context, phone string) error {
 }
 
 func (uc *authUseCase) VerifyOTP(ctx context.Context, phone, code, ua, ip string) (TokenPair, error) {
+	if !uc.otpAllowed(ctx, phone) {
+		return TokenPair{}, ErrOTPDisabled
+	}
 	ch, _, attempts, err := uc.otp.GetActive(ctx, phone, repository.OTPPurposeLogin)
 	if err != nil {
 		return TokenPair{}, ErrInvalidCredentials
@@ -170,9 +200,12 @@ func (uc *authUseCase) VerifyOTP(ctx context.Context, phone, code, ua, ip string
 	}
 	_ = uc.otp.Consume(ctx, phone, repository.OTPPurposeLogin)
 
-	// если пользователя нет — создаём пустого (минимум)
+	// если пользователя нет — создаём пустого (минимум); заблокированному номер не даёт войти
 	u, err := uc.users.GetByPhone(ctx, phone)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserInactive) {
+			return TokenPair{}, ErrAccountBlocked
+		}
 		if errors.Is(err, repository.ErrNoRows) {
 			u = &domain.User{
 				ID:    uuid.NewString(),
@@ -200,7 +233,7 @@ func (uc *authUseCase) RefreshTokens(ctx context.Context, refreshToken, ua, ip s
 	if err != nil {
 		return TokenPair{}, ErrInvalidRefresh
 	}
-	// проверяем refresh по сессии
+	// проверяем refresh по сессии (сессии заблокированных пользователей не находятся)
 	hash := uc.hashOpaque(refreshToken, uc.refreshSecret)
 	sessID, userID, exp, revokedAt, err := uc.sessions.GetByHash(ctx, hash)
 	if err != nil || revokedAt != nil || time.Now().After(exp) || userID != claims.UserID {
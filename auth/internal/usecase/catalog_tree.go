@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"auth/internal/repository"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCatalogLocale — язык, если Accept-Language не указан или ни один не поддерживается.
+const DefaultCatalogLocale = "ru"
+
+// catalogFallback — цепочка языков для названия: сначала запрошенный, дальше по порядку.
+var catalogFallback = map[string][]string{
+	"ru": {"ru", "en", "kk"},
+	"kk": {"kk", "ru", "en"},
+	"en": {"en", "ru", "kk"},
+}
+
+type CatalogTree struct {
+	Locale     string                 `json:"locale"`
+	Version    repository.TreeVersion `json:"-"`
+	Directions []CatalogDirection     `json:"directions"`
+}
+
+type CatalogDirection struct {
+	ID            string                `json:"id"`
+	Slug          string                `json:"slug"`
+	Name          string                `json:"name"`
+	TutorCount    int                   `json:"tutorCount"`
+	Subdirections []CatalogSubdirection `json:"subdirections"`
+}
+
+type CatalogSubdirection struct {
+	ID         string `json:"id"`
+	Slug       string `json:"slug"`
+	Name       string `json:"name"`
+	TutorCount int    `json:"tutorCount"`
+}
+
+// CatalogLocale выбирает поддерживаемый язык из Accept-Language с учётом q:
+// "kk-KZ,ru;q=0.9,en;q=0.8" → kk, "de,en;q=0.5" → en.
+func CatalogLocale(acceptLanguage string) string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		lang := strings.ToLower(strings.TrimSpace(strings.Split(fields[0], "-")[0]))
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if p, err := strconv.ParseFloat(v, 64); err == nil {
+					q = p
+				}
+			}
+		}
+		if lang != "" && q > 0 {
+			tags = append(tags, tag{lang, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	for _, t := range tags {
+		if _, ok := catalogFallback[t.lang]; ok {
+			return t.lang
+		}
+	}
+	return DefaultCatalogLocale
+}
+
+// localizedName — перевод по цепочке языка; если нет ни одного, любой непустой, иначе slug.
+func localizedName(name map[string]any, locale, slug string) string {
+	for _, l := range catalogFallback[locale] {
+		if s, _ := name[l].(string); s != "" {
+			return s
+		}
+	}
+	keys := make([]string, 0, len(name))
+	for k := range name {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if s, _ := name[k].(string); s != "" {
+			return s
+		}
+	}
+	return slug
+}
+
+func (uc *adminUseCase) CatalogVersion(ctx context.Context) (repository.TreeVersion, error) {
+	return uc.repo.CatalogVersion(ctx)
+}
+
+func (uc *adminUseCase) CatalogTree(ctx context.Context, locale string) (*CatalogTree, error) {
+	if _, ok := catalogFallback[locale]; !ok {
+		locale = DefaultCatalogLocale
+	}
+	dirs, subs, version, err := uc.repo.CatalogTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byDir := map[string][]CatalogSubdirection{}
+	for _, s := range subs {
+		byDir[s.DirectionID] = append(byDir[s.DirectionID], CatalogSubdirection{
+			ID: s.ID, Slug: s.Slug, Name: localizedName(s.Name, locale, s.Slug), TutorCount: s.TutorCount,
+		})
+	}
+	tree := &CatalogTree{Locale: locale, Version: version, Directions: make([]CatalogDirection, 0, len(dirs))}
+	for _, d := range dirs {
+		children := byDir[d.ID]
+		if children == nil {
+			children = []CatalogSubdirection{}
+		}
+		sort.SliceStable(children, func(i, j int) bool { return strings.ToLower(children[i].Name) < strings.ToLower(children[j].Name) })
+		tree.Directions = append(tree.Directions, CatalogDirection{
+			ID: d.ID, Slug: d.Slug, Name: localizedName(d.Name, locale, d.Slug), TutorCount: d.TutorCount,
+			Subdirections: children,
+		})
+	}
+	sort.SliceStable(tree.Directions, func(i, j int) bool {
+		return strings.ToLower(tree.Directions[i].Name) < strings.ToLower(tree.Directions[j].Name)
+	})
+	return tree, nil
+}
@@ -0,0 +1,86 @@
+// Package audit — запись в audit_log в той же транзакции, что и само изменение.
+// Общий для сервисов auth, user и tutor: формат строк журнала у них один.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type actorKey struct{}
+
+// WithActor — кто выполняет изменение, если репозиторий не получает его аргументом
+// (ставит admin-middleware).
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// Actor — id из WithActor; "" — системное изменение (actor_id = NULL).
+func Actor(ctx context.Context) string {
+	id, _ := ctx.Value(actorKey{}).(string)
+	return id
+}
+
+// Write пишет запись журнала. before/after — произвольные значения, сериализуются в jsonb;
+// nil и пустые []byte/json.RawMessage пишутся как NULL, непустые — как есть.
+func Write(ctx context.Context, tx pgx.Tx, actorID, entityType, entityID, action string, before, after any) error {
+	b, err := encode(before)
+	if err != nil {
+		return err
+	}
+	a, err := encode(after)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+INSERT INTO public.audit_log (actor_id, entity_type, entity_id, action, before, after)
+VALUES (NULLIF($1,'')::uuid, $2, $3, $4, $5::jsonb, $6::jsonb)`,
+		actorID, entityType, entityID, action, b, a); err != nil {
+		return fmt.Errorf("audit %s %s: %w", entityType, action, err)
+	}
+	return nil
+}
+
+// Change пишет запись, только если снимки до и после различаются.
+func Change(ctx context.Context, tx pgx.Tx, actorID, entityType, entityID, action string, before, after []byte) error {
+	// jsonb приходит нормализованным — побайтового сравнения достаточно
+	if bytes.Equal(before, after) {
+		return nil
+	}
+	return Write(ctx, tx, actorID, entityType, entityID, action, before, after)
+}
+
+// Snapshot — jsonb-снимок для журнала по запросу, возвращающему одну колонку;
+// nil, если строки нет.
+func Snapshot(ctx context.Context, tx pgx.Tx, query string, args ...any) ([]byte, error) {
+	var b []byte
+	err := tx.QueryRow(ctx, query, args...).Scan(&b)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func encode(v any) ([]byte, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return nilIfEmpty(x), nil
+	case json.RawMessage:
+		return nilIfEmpty(x), nil
+	}
+	return json.Marshal(v)
+}
+
+func nilIfEmpty(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
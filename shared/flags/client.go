@@ -0,0 +1,265 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel — канал NOTIFY, в который пишет триггер на feature_flags.
+const Channel = "feature_flags"
+
+// Client — то, что внедряется в usecase'ы. Проверка флага не ходит в БД: значения
+// берутся из кеша, который Run обновляет по NOTIFY.
+type Client interface {
+	// Enabled вычисляет флаг для subject из ctx; def — если флага нет в таблице.
+	Enabled(ctx context.Context, key string, def bool) bool
+	EnabledFor(ctx context.Context, key string, s Subject, def bool) bool
+
+	List(ctx context.Context) ([]Flag, error)
+	Get(ctx context.Context, key string) (*Flag, error)
+	Save(ctx context.Context, f Flag) (*Flag, error)
+	Delete(ctx context.Context, key string) error
+
+	// Run загружает флаги и держит кеш свежим до отмены ctx (переподключается сам).
+	Run(ctx context.Context)
+}
+
+type Options struct {
+	// Refresh — полная перезагрузка на случай пропущенных NOTIFY (разрыв соединения).
+	Refresh time.Duration
+}
+
+type client struct {
+	db   *pgxpool.Pool
+	opts Options
+
+	mu    sync.RWMutex
+	flags map[string]Flag
+	// countries — users.country_code по user id; сбрасывается при каждой полной перезагрузке.
+	countries map[string]string
+}
+
+func New(db *pgxpool.Pool, opts Options) Client {
+	if opts.Refresh <= 0 {
+		opts.Refresh = time.Minute
+	}
+	return &client{db: db, opts: opts, flags: map[string]Flag{}, countries: map[string]string{}}
+}
+
+func (c *client) Enabled(ctx context.Context, key string, def bool) bool {
+	return c.EnabledFor(ctx, key, FromContext(ctx), def)
+}
+
+func (c *client) EnabledFor(ctx context.Context, key string, s Subject, def bool) bool {
+	c.mu.RLock()
+	f, ok := c.flags[key]
+	c.mu.RUnlock()
+	if !ok {
+		return def
+	}
+	if len(f.Rules.Countries) > 0 && s.CountryCode == "" && s.UserID != "" {
+		s.CountryCode = c.country(ctx, s.UserID)
+	}
+	return f.Evaluate(s)
+}
+
+// country — страна из профиля пользователя; при ошибке БД — "", т.е. правило по стране не выполнено.
+func (c *client) country(ctx context.Context, userID string) string {
+	c.mu.RLock()
+	code, ok := c.countries[userID]
+	c.mu.RUnlock()
+	if ok {
+		return code
+	}
+	err := c.db.QueryRow(ctx, `
+SELECT upper(COALESCE(country_code, '')) FROM public.users WHERE id = $1`, userID).Scan(&code)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("[FLAGS] country of %s: %v", userID, err)
+		return ""
+	}
+	c.mu.Lock()
+	c.countries[userID] = code
+	c.mu.Unlock()
+	return code
+}
+
+const flagColumns = `key, enabled, rules, updated_at`
+
+func scanFlag(row pgx.Row) (*Flag, error) {
+	var f Flag
+	var rules []byte
+	if err := row.Scan(&f.Key, &f.Enabled, &rules, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rules, &f.Rules); err != nil {
+		log.Printf("[FLAGS] %s: bad rules: %v", f.Key, err)
+	}
+	return &f, nil
+}
+
+func (c *client) List(ctx context.Context) ([]Flag, error) {
+	rows, err := c.db.Query(ctx, `SELECT `+flagColumns+` FROM public.feature_flags ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []Flag{}
+	for rows.Next() {
+		f, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *f)
+	}
+	return list, rows.Err()
+}
+
+func (c *client) Get(ctx context.Context, key string) (*Flag, error) {
+	f, err := scanFlag(c.db.QueryRow(ctx, `SELECT `+flagColumns+` FROM public.feature_flags WHERE key = $1`, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFlagNotFound
+	}
+	return f, err
+}
+
+// Save создаёт или заменяет флаг целиком; кеши всех инстансов обновит триггер.
+func (c *client) Save(ctx context.Context, f Flag) (*Flag, error) {
+	if !keyRe.MatchString(f.Key) {
+		return nil, ErrInvalidKey
+	}
+	if err := f.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	rules, err := json.Marshal(f.Rules)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := scanFlag(c.db.QueryRow(ctx, `
+INSERT INTO public.feature_flags (key, enabled, rules, updated_at)
+VALUES ($1, $2, $3::jsonb, now())
+ON CONFLICT (key) DO UPDATE SET enabled = EXCLUDED.enabled, rules = EXCLUDED.rules, updated_at = now()
+RETURNING `+flagColumns, f.Key, f.Enabled, rules))
+	if err != nil {
+		return nil, err
+	}
+	c.put(*saved)
+	return saved, nil
+}
+
+func (c *client) Delete(ctx context.Context, key string) error {
+	tag, err := c.db.Exec(ctx, `DELETE FROM public.feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFlagNotFound
+	}
+	c.mu.Lock()
+	delete(c.flags, key)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *client) put(f Flag) {
+	c.mu.Lock()
+	c.flags[f.Key] = f
+	c.mu.Unlock()
+}
+
+func (c *client) reload(ctx context.Context) error {
+	list, err := c.List(ctx)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]Flag, len(list))
+	for _, f := range list {
+		m[f.Key] = f
+	}
+	c.mu.Lock()
+	c.flags = m
+	c.countries = map[string]string{}
+	c.mu.Unlock()
+	return nil
+}
+
+// refreshKey перечитывает один флаг после NOTIFY с его ключом.
+func (c *client) refreshKey(ctx context.Context, key string) {
+	f, err := c.Get(ctx, key)
+	switch {
+	case errors.Is(err, ErrFlagNotFound):
+		c.mu.Lock()
+		delete(c.flags, key)
+		c.mu.Unlock()
+	case err != nil:
+		log.Printf("[FLAGS] refresh %s: %v", key, err)
+	default:
+		c.put(*f)
+	}
+}
+
+func (c *client) Run(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(c.opts.Refresh)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := c.reload(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("[FLAGS] reload: %v", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		err := c.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("[FLAGS] listener stopped: %v; reconnecting", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (c *client) listen(ctx context.Context) error {
+	conn, err := c.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	defer func() {
+		// соединение вернётся в пул — снимаем подписку отдельным контекстом
+		uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = conn.Exec(uctx, "UNLISTEN "+Channel)
+	}()
+	// перечитываем всё после подписки: изменения, пока слушателя не было, не потеряются
+	if err := c.reload(ctx); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		c.refreshKey(ctx, n.Payload)
+	}
+}
@@ -0,0 +1,149 @@
+// Package flags — feature flags из таблицы feature_flags: правила таргетинга, кеш
+// с обновлением через LISTEN/NOTIFY и CRUD для админки. Общий для сервисов auth, user и tutor.
+package flags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Ключи флагов, которые проверяет код.
+const (
+	OTPLogin           = "auth.otp_login"
+	TutorsSearch       = "tutors.search"
+	AvatarDirectUpload = "users.avatar_direct_upload"
+)
+
+var (
+	ErrFlagNotFound = errors.New("feature flag not found")
+	ErrInvalidKey   = errors.New("flag key must match [a-z0-9][a-z0-9._-]{0,63}")
+	ErrInvalidRules = errors.New("invalid flag rules")
+)
+
+var keyRe = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// Rules — условия включения. Все заданные условия должны выполниться (AND),
+// внутри списка достаточно одного совпадения (OR). Пустые правила = включено всем.
+type Rules struct {
+	UserIDs   []string `json:"userIds,omitempty"`   // всегда включено этим пользователям
+	Roles     []string `json:"roles,omitempty"`     // student | tutor | admin
+	Countries []string `json:"countries,omitempty"` // ISO 3166-1 alpha-2
+	Locales   []string `json:"locales,omitempty"`   // ru | kk | en
+	// Percentage — доля пользователей 0..100; корзина считается от ключа флага и user id,
+	// поэтому у одного пользователя результат стабилен, а у разных флагов — независим.
+	Percentage *int `json:"percentage,omitempty"`
+}
+
+type Flag struct {
+	Key       string    `json:"key"`
+	Enabled   bool      `json:"enabled"`
+	Rules     Rules     `json:"rules"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Subject — для кого вычисляется флаг. Страну клиент берёт из профиля пользователя
+// (users.country_code), если её не задали явно: заголовкам запроса доверять нельзя.
+type Subject struct {
+	UserID      string `json:"userId,omitempty"`
+	Role        string `json:"role,omitempty"`
+	CountryCode string `json:"countryCode,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+}
+
+func (r Rules) Validate() error {
+	if r.Percentage != nil && (*r.Percentage < 0 || *r.Percentage > 100) {
+		return ErrInvalidRules
+	}
+	return nil
+}
+
+// Evaluate — выключенный флаг выключен для всех; иначе userIds включают сразу,
+// остальные условия проверяются вместе.
+func (f Flag) Evaluate(s Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	r := f.Rules
+	if s.UserID != "" && contains(r.UserIDs, s.UserID) {
+		return true
+	}
+	if len(r.Roles) > 0 && !contains(r.Roles, s.Role) {
+		return false
+	}
+	if len(r.Countries) > 0 && !contains(r.Countries, s.CountryCode) {
+		return false
+	}
+	if len(r.Locales) > 0 && !contains(r.Locales, s.Locale) {
+		return false
+	}
+	if r.Percentage != nil {
+		switch {
+		case *r.Percentage >= 100:
+		case *r.Percentage <= 0 || s.UserID == "":
+			// анонимы в частичный rollout не попадают
+			return false
+		default:
+			if bucket(f.Key, s.UserID) >= uint32(*r.Percentage) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func bucket(key, userID string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key + ":" + userID))
+	return h.Sum32() % 100
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+// WithSubject кладёт subject в контекст запроса — usecase достаёт его через FromContext.
+func WithSubject(ctx context.Context, s Subject) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+func FromContext(ctx context.Context) Subject {
+	s, _ := ctx.Value(ctxKey{}).(Subject)
+	return s
+}
+
+// WithUser дополняет subject из контекста данными авторизованного пользователя.
+func WithUser(ctx context.Context, userID, role string) context.Context {
+	s := FromContext(ctx)
+	s.UserID, s.Role = userID, role
+	return WithSubject(ctx, s)
+}
+
+// Middleware заполняет язык из Accept-Language. Пользователя добавляет JWT-middleware
+// через WithUser, страну клиент читает из его профиля при проверке флага.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := FromContext(r.Context())
+		s.Locale = primaryLanguage(r.Header.Get("Accept-Language"))
+		next.ServeHTTP(w, r.WithContext(WithSubject(r.Context(), s)))
+	})
+}
+
+// primaryLanguage — "kk-KZ,ru;q=0.9" → "kk".
+func primaryLanguage(h string) string {
+	first := strings.TrimSpace(strings.Split(h, ",")[0])
+	first = strings.Split(first, ";")[0]
+	first = strings.Split(first, "-")[0]
+	return strings.ToLower(strings.TrimSpace(first))
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalOptions — раздача объектов самим сервисом (Local.Handler).
+type LocalOptions struct {
+	BaseURL string   // публичный адрес, под которым смонтирован Handler, например http://localhost:8083/media/
+	Secret  string   // ключ подписи загрузок; пусто — случайный (подписи живут до рестарта)
+	Public  []string // префиксы ключей, которые Handler отдаёт без авторизации
+}
+
+// Local — объекты файлами в каталоге на диске (dev и одиночный инстанс).
+// Тип содержимого не хранится: берётся по расширению ключа.
+type Local struct {
+	dir    string
+	base   string
+	secret []byte
+	public []string
+}
+
+func NewLocal(dir string, opts LocalOptions) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("storage dir: %w", err)
+	}
+	secret := []byte(opts.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	base := opts.BaseURL
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return &Local{dir: dir, base: base, secret: secret, public: opts.Public}, nil
+}
+
+func (s *Local) path(key string) (string, error) {
+	k, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(k)), nil
+}
+
+func (s *Local) Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	// пишем во временный файл рядом и переименовываем: читатель не увидит недописанный объект
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("storage: wrote %d of %d bytes", n, size)
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *Local) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return f, localObject(key, st), nil
+}
+
+func (s *Local) Stat(ctx context.Context, key string) (*Object, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	st, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) || err == nil && st.IsDir() {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return localObject(key, st), nil
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Local) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	root := s.dir
+	if dir := path.Dir(prefix); dir != "." {
+		// обходим только подкаталог префикса, а не всё хранилище
+		p, err := s.path(dir)
+		if err != nil {
+			return err
+		}
+		root = p
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		st, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fn(*localObject(key, st))
+	})
+	return err
+}
+
+func (s *Local) URL(key string) string {
+	if s.base == "" {
+		return ""
+	}
+	return s.base + escapeKey(key)
+}
+
+// PresignPut подписывает PUT на Handler: ключ, тип, размер и срок входят в подпись.
+func (s *Local) PresignPut(ctx context.Context, key, contentType string, size int64, ttl time.Duration) (*PresignedUpload, error) {
+	if _, err := cleanKey(key); err != nil {
+		return nil, err
+	}
+	if s.base == "" {
+		return nil, errors.New("storage: local base URL is not configured")
+	}
+	exp := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp.Unix(), 10))
+	q.Set("size", strconv.FormatInt(size, 10))
+	q.Set("signature", s.sign(key, contentType, size, exp.Unix()))
+	return &PresignedUpload{
+		Method:    http.MethodPut,
+		URL:       s.URL(key) + "?" + q.Encode(),
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: exp,
+	}, nil
+}
+
+func (s *Local) sign(key, contentType string, size, expires int64) string {
+	m := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(m, "PUT\n%s\n%s\n%d\n%d", key, contentType, size, expires)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Handler отдаёт публичные объекты (GET/HEAD) и принимает подписанные загрузки (PUT).
+// Монтируется с http.StripPrefix: путь запроса — ключ объекта.
+func (s *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			s.serve(w, r, key)
+		case http.MethodPut:
+			s.receive(w, r, key)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (s *Local) serve(w http.ResponseWriter, r *http.Request, key string) {
+	public := false
+	for _, p := range s.public {
+		if strings.HasPrefix(key, p) {
+			public = true
+			break
+		}
+	}
+	if !public {
+		http.NotFound(w, r)
+		return
+	}
+	rc, obj, err := s.Get(r.Context(), key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer rc.Close()
+	// ключи случайные и не переиспользуются — объект можно кешировать навсегда
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", obj.ModTime, rc.(io.ReadSeeker))
+}
+
+func (s *Local) receive(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	exp, err1 := strconv.ParseInt(q.Get("expires"), 10, 64)
+	size, err2 := strconv.ParseInt(q.Get("size"), 10, 64)
+	ct := r.Header.Get("Content-Type")
+	if err1 != nil || err2 != nil || time.Now().Unix() > exp ||
+		!hmac.Equal([]byte(q.Get("signature")), []byte(s.sign(key, ct, size, exp))) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+	if r.ContentLength != size {
+		http.Error(w, "content length does not match the signed size", http.StatusBadRequest)
+		return
+	}
+	if err := s.Put(r.Context(), key, ct, http.MaxBytesReader(w, r.Body, size), size); err != nil {
+		http.Error(w, "upload failed", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// videoTypes — в alpine-образе нет /etc/mime.types, а встроенная таблица Go видео не знает.
+var videoTypes = map[string]string{".mp4": "video/mp4", ".webm": "video/webm"}
+
+func localObject(key string, st fs.FileInfo) *Object {
+	ct := mime.TypeByExtension(path.Ext(key))
+	if ct == "" {
+		ct = videoTypes[strings.ToLower(path.Ext(key))]
+	}
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	return &Object{Key: key, ContentType: ct, Size: st.Size(), ModTime: st.ModTime()}
+}
@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Options — S3-совместимый бакет (AWS S3, MinIO).
+type S3Options struct {
+	Endpoint  string // https://s3.eu-central-1.amazonaws.com или http://minio:9000
+	Region    string // us-east-1 для MinIO по умолчанию
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool   // endpoint/bucket/key вместо bucket.endpoint/key — нужно MinIO
+	PublicURL string // адрес для чтения объектов (CDN или публичный бакет); пусто — адрес объекта в endpoint
+	Client    *http.Client
+}
+
+// S3 — хранилище в бакете; запросы подписываются AWS Signature V4 без SDK.
+// Тело не хешируется (UNSIGNED-PAYLOAD): целостность обеспечивает TLS.
+type S3 struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+)
+
+func NewS3(o S3Options) (*S3, error) {
+	u, err := url.Parse(strings.TrimRight(o.Endpoint, "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint %q", o.Endpoint)
+	}
+	if o.Bucket == "" || o.AccessKey == "" || o.SecretKey == "" {
+		return nil, errors.New("storage: S3 bucket and credentials are required")
+	}
+	if o.Region == "" {
+		o.Region = "us-east-1"
+	}
+	o.PublicURL = strings.TrimRight(o.PublicURL, "/")
+	c := o.Client
+	if c == nil {
+		c = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &S3{opts: o, endpoint: u, client: c, now: time.Now}, nil
+}
+
+// location — хост и экранированный путь объекта (key пуст — сам бакет).
+func (s *S3) location(key string) (host, escapedPath string) {
+	if s.opts.PathStyle {
+		p := "/" + s3Escape(s.opts.Bucket, false)
+		if key != "" {
+			p += "/" + escapeKey(key)
+		}
+		return s.endpoint.Host, p
+	}
+	return s.opts.Bucket + "." + s.endpoint.Host, "/" + escapeKey(key)
+}
+
+func (s *S3) URL(key string) string {
+	if s.opts.PublicURL != "" {
+		return s.opts.PublicURL + "/" + escapeKey(key)
+	}
+	host, p := s.location(key)
+	if key == "" && s.opts.PathStyle {
+		p += "/"
+	}
+	return s.endpoint.Scheme + "://" + host + p
+}
+
+func (s *S3) Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error {
+	if size < 0 {
+		return errors.New("storage: S3 upload needs the object size")
+	}
+	h := http.Header{}
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, nil, h, r, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, s3Object(key, resp), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s3Object(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil, 0)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List — ListObjectsV2 постранично (по 1000 ключей).
+func (s *S3) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", q, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		var res s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("storage: list objects: %w", err)
+		}
+		for _, c := range res.Contents {
+			if err := fn(Object{Key: c.Key, Size: c.Size, ModTime: c.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// PresignPut — presigned URL (query-string auth): подписаны тип и размер, так что клиент
+// не загрузит под этим ключом ничего другого.
+func (s *S3) PresignPut(ctx context.Context, key, contentType string, size int64, ttl time.Duration) (*PresignedUpload, error) {
+	if _, err := cleanKey(key); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 || ttl > 7*24*time.Hour {
+		return nil, fmt.Errorf("storage: presign ttl %s out of range", ttl)
+	}
+	t := s.now().UTC()
+	host, p := s.location(key)
+	headers := map[string]string{
+		"content-length": strconv.FormatInt(size, 10),
+		"content-type":   contentType,
+		"host":           host,
+	}
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", s.opts.AccessKey+"/"+s.scope(t))
+	q.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(ttl/time.Second)))
+	q.Set("X-Amz-SignedHeaders", signedHeaders(headers))
+	q.Set("X-Amz-Signature", s.signature(http.MethodPut, p, q, headers, s3UnsignedPayload, t))
+	return &PresignedUpload{
+		Method:    http.MethodPut,
+		URL:       s.endpoint.Scheme + "://" + host + p + "?" + canonicalQuery(q),
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: t.Add(ttl),
+	}, nil
+}
+
+// do подписывает и выполняет запрос; ответы не 2xx превращаются в ошибку (404 — ErrNotFound).
+func (s *S3) do(ctx context.Context, method, key string, q url.Values, h http.Header, body io.Reader, size int64) (*http.Response, error) {
+	if key != "" {
+		if _, err := cleanKey(key); err != nil {
+			return nil, err
+		}
+	}
+	host, p := s.location(key)
+	raw := s.endpoint.Scheme + "://" + host + p
+	if len(q) > 0 {
+		raw += "?" + canonicalQuery(q)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, raw, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	t := s.now().UTC()
+	req.Header.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	signed := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           t.Format(s3TimeFormat),
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.opts.AccessKey, s.scope(t), signedHeaders(signed),
+		s.signature(method, p, q, signed, s3UnsignedPayload, t)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e)
+	return nil, fmt.Errorf("storage: s3 %s %s: %d %s %s", method, key, resp.StatusCode, e.Code, e.Message)
+}
+
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// signature — подпись SigV4: canonical request → string to sign → HMAC ключом, выведенным из секрета.
+// headers — подписываемые заголовки с именами в нижнем регистре.
+func (s *S3) signature(method, escapedPath string, q url.Values, headers map[string]string, payloadHash string, t time.Time) string {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var ch strings.Builder
+	for _, k := range names {
+		ch.WriteString(k + ":" + strings.TrimSpace(headers[k]) + "\n")
+	}
+	canonical := strings.Join([]string{
+		method, escapedPath, canonicalQuery(q), ch.String(), strings.Join(names, ";"), payloadHash,
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{s3Algorithm, t.Format(s3TimeFormat), s.scope(t), hex.EncodeToString(sum[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func signedHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ";")
+}
+
+// canonicalQuery — параметры, отсортированные по имени, в кодировке S3 (пробел — %20, а не +).
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+func s3Object(key string, resp *http.Response) *Object {
+	o := &Object{Key: key, ContentType: resp.Header.Get("Content-Type"), Size: resp.ContentLength}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		o.ModTime = t
+	}
+	return o
+}
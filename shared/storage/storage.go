@@ -0,0 +1,138 @@
+// Package storage — хранилище файлов сервисов (аватары, видео, сканы документов). Сервис оперирует ключами
+// объектов, а где лежат байты — решает реализация: локальный диск или S3-совместимый бакет (MinIO).
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object — метаданные сохранённого объекта.
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// PresignedUpload — разрешение клиенту загрузить объект напрямую в хранилище, минуя сервис.
+// Клиент отправляет файл методом Method на URL с заголовками Headers до ExpiresAt.
+type PresignedUpload struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+type Storage interface {
+	// Put сохраняет объект целиком; size — ожидаемый размер, -1 если неизвестен.
+	Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error
+	// Get открывает объект на чтение; ErrNotFound, если его нет.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Stat — метаданные без содержимого; ErrNotFound, если объекта нет.
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete идемпотентен: отсутствующий объект — не ошибка.
+	Delete(ctx context.Context, key string) error
+	// List обходит объекты с ключами на prefix; ошибка из fn прерывает обход.
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+
+	// URL — публичный адрес объекта (для аватаров и видео; закрытые объекты наружу не отдаются).
+	URL(key string) string
+	// PresignPut — подписанная загрузка ровно size байт типа contentType, действительна ttl.
+	PresignPut(ctx context.Context, key, contentType string, size int64, ttl time.Duration) (*PresignedUpload, error)
+}
+
+// NewKey — ключ нового объекта: prefix/<случайный id><ext>. Имя файла пользователя в ключ
+// не попадает — только расширение.
+func NewKey(prefix, ext string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	ext = strings.ToLower(ext)
+	if len(ext) > 8 || strings.ContainsAny(ext, `/\`) {
+		ext = ""
+	}
+	return path.Join(prefix, hex.EncodeToString(b)) + ext
+}
+
+// KeyFromURL — ключ объекта по его публичному адресу (обратное к Storage.URL).
+// ok=false, если адрес ведёт не в это хранилище.
+func KeyFromURL(s Storage, rawURL string) (string, bool) {
+	base := s.URL("")
+	if base == "" || !strings.HasPrefix(rawURL, base) {
+		return "", false
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(rawURL, base))
+	if err != nil {
+		return "", false
+	}
+	if _, err := cleanKey(key); err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// KeyFromPath — ключ объекта с префиксом prefix по адресу с любым базовым URL: прежним
+// MEDIA_PUBLIC_URL, CDN или адресом бакета. Так ссылки, сохранённые до смены публичного адреса,
+// по-прежнему указывают на свои объекты. ok=false, если в пути нет такого ключа.
+func KeyFromPath(rawURL, prefix string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || prefix == "" {
+		return "", false
+	}
+	p := u.Path
+	if !strings.HasPrefix(p, prefix) {
+		i := strings.Index(p, "/"+prefix)
+		if i < 0 {
+			return "", false
+		}
+		p = p[i+1:]
+	}
+	if _, err := cleanKey(p); err != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// cleanKey отсекает пустые, абсолютные ключи и выход за пределы хранилища ("..").
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return "", ErrInvalidKey
+	}
+	c := path.Clean(key)
+	if c != key || c == "." || strings.HasPrefix(c, "../") || c == ".." {
+		return "", ErrInvalidKey
+	}
+	return c, nil
+}
+
+// escapeKey кодирует ключ для пути URL по правилам S3: всё, кроме A-Z a-z 0-9 - _ . ~ и "/".
+func escapeKey(key string) string {
+	return s3Escape(key, true)
+}
+
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
@@ -12,8 +12,17 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // в alpine-образе нет zoneinfo, а часовые пояса нужны анкетам и напоминаниям
 
+	"shared/flags"
+	"shared/storage"
+	"tutor/internal/calendar"
+	"tutor/internal/chat"
 	httpapi "tutor/internal/delivery/http"
+	"tutor/internal/events"
+	"tutor/internal/notify"
+	"tutor/internal/payment"
+	"tutor/internal/payout"
 	"tutor/internal/repository"
 	"tutor/internal/usecase"
 
@@ -46,12 +55,139 @@ func main() {
 
 	// 3) UC wiring
 	tutorRepo := repository.NewTutorRepository(db)
+	pricingRepo := repository.NewPricingRepository(db)
 	tokenUC := usecase.NewTokenUseCase(strings.TrimSpace(cfg.JWT.AccessSecret))
-	tutorUC := usecase.NewTutorUseCase(tutorRepo)
+	pricingUC := usecase.NewPricingUseCase(pricingRepo)
+	// feature flags: кеш в памяти, обновляется по NOTIFY (Run стартует вместе с воркерами)
+	flagClient := flags.New(db, flags.Options{Refresh: cfg.Flags.Refresh})
+	// хранилище файлов: аватары и видео анкеты, сканы документов
+	documents, mediaFiles, err := openStorage(cfg)
+	if err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+	mediaUC := usecase.NewMediaUseCase(repository.NewMediaRepository(db), documents, usecase.MediaOptions{
+		MaxAvatarSize: cfg.Storage.MaxAvatarSize,
+		MaxVideoSize:  cfg.Storage.MaxVideoSize,
+		UploadTTL:     cfg.Storage.UploadTTL,
+		Grace:         cfg.Storage.GCGrace,
+	})
+	tutorUC := usecase.NewTutorUseCase(tutorRepo, pricingUC, usecase.Options{
+		HideUnverified: cfg.Listing.HideUnverified,
+		Flags:          flagClient,
+		Documents:      documents,
+		Media:          mediaUC,
+	})
+
+	// платёжные провайдеры: stripe — только при наличии ключа; fake — только по PAYMENTS_FAKE_ENABLED
+	var fakePay *payment.FakeProvider
+	var providers []payment.Provider
+	if cfg.Payments.StripeSecretKey != "" {
+		providers = append(providers, payment.NewStripeProvider(cfg.Payments.StripeAPIURL, cfg.Payments.StripeSecretKey, cfg.Payments.StripeWebhookSecret))
+	}
+	if cfg.Payments.FakeEnabled {
+		log.Printf("[BOOT] fake payment provider is enabled")
+		fakePay = payment.NewFakeProvider(cfg.Payments.FakeWebhookSecret, cfg.Payments.PublicBaseURL)
+		providers = append(providers, fakePay)
+	}
+	ledgerUC := usecase.NewLedgerUseCase(repository.NewLedgerRepository(db))
+	paymentRepo := repository.NewPaymentRepository(db)
+	paymentUC := usecase.NewPaymentUseCase(paymentRepo, payment.NewRegistry(providers...), cfg.Payments.Provider)
+	commissionUC := usecase.NewCommissionUseCase(repository.NewCommissionRepository(db), pricingUC)
+	// провайдер выплат: реального адаптера пока нет, fake — только по PAYOUTS_FAKE_ENABLED;
+	// без провайдера выплаты можно запросить, но не одобрить и не отправить
+	var payoutProvider payout.Provider
+	if cfg.Payouts.FakeEnabled {
+		log.Printf("[BOOT] fake payout provider is enabled")
+		payoutProvider = payout.NewFakeProvider()
+	}
+	payoutUC := usecase.NewPayoutUseCase(repository.NewPayoutRepository(db), payoutProvider, usecase.PayoutOptions{
+		MinAmountMinor: cfg.Payouts.MinAmountMinor,
+		Hold:           cfg.Payouts.Hold,
+	})
+	moderationUC := usecase.NewModerationUseCase(repository.NewModerationRepository(db))
+	lessonUC := usecase.NewLessonUseCase(repository.NewLessonRepository(db), paymentRepo, paymentUC, commissionUC)
+	cancelUC := usecase.NewCancellationUseCase(repository.NewCancellationRepository(db), paymentRepo, paymentUC, usecase.CancellationOptions{
+		RetryLease:       cfg.Cancellations.RetryBackoff,
+		RetryMaxAttempts: cfg.Cancellations.RetryMaxAttempts,
+	})
+	chatHub := chat.NewHub()
+	chatUC := usecase.NewChatUseCase(repository.NewChatRepository(db), chatHub, usecase.ChatOptions{
+		OffenderThreshold: cfg.Chat.OffenderThreshold,
+		OffenderWindow:    cfg.Chat.OffenderWindow,
+	})
+	notificationRepo := repository.NewNotificationRepository(db)
+	channels, fakeChannels := notifyChannels(cfg, notificationRepo)
+	notificationUC := usecase.NewNotificationUseCase(notificationRepo, channels, usecase.NotificationOptions{
+		MaxAttempts:     cfg.Notifications.MaxAttempts,
+		Backoff:         cfg.Notifications.Backoff,
+		DefaultTimezone: cfg.Reminders.DefaultTimezone,
+	})
+	reminderUC := usecase.NewReminderUseCase(repository.NewReminderRepository(db), usecase.ReminderOptions{
+		Leads:           cfg.Reminders.Leads,
+		DefaultTimezone: cfg.Reminders.DefaultTimezone,
+	})
+	// доменные события: outbox → брокер; in-process шина доступна подписчикам внутри сервиса
+	eventBus := events.NewBus()
+	publisher, err := events.New(cfg.Outbox.Broker, cfg.Outbox.URL, cfg.Outbox.Prefix, eventBus)
+	if err != nil {
+		log.Fatalf("outbox broker: %v", err)
+	}
+	defer publisher.Close()
+	outboxUC := usecase.NewOutboxUseCase(repository.NewOutboxRepository(db), publisher, usecase.OutboxOptions{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+	})
+	// календари: Google при наличии OAuth-клиента, иначе по CALENDAR_FAKE_GOOGLE — локальный fake API
+	var fakeGoogle *calendar.FakeGoogle
+	var calendars []calendar.Provider
+	if cfg.Calendar.GoogleClientID != "" {
+		calendars = append(calendars, calendar.NewGoogleProvider(calendar.GoogleConfig{
+			ClientID:     cfg.Calendar.GoogleClientID,
+			ClientSecret: cfg.Calendar.GoogleClientSecret,
+			RedirectURL:  cfg.Calendar.GoogleRedirectURL,
+		}))
+	} else if cfg.Calendar.FakeGoogle {
+		log.Printf("[BOOT] fake Google Calendar API is mounted at %s", httpapi.FakeGooglePrefix)
+		fakeGoogle = calendar.NewFakeGoogle(httpapi.FakeGooglePrefix)
+		gc := fakeGoogle.Config(cfg.Payments.PublicBaseURL)
+		gc.RedirectURL = cfg.Calendar.GoogleRedirectURL
+		calendars = append(calendars, calendar.NewGoogleProvider(gc))
+	}
+	calendarUC := usecase.NewCalendarUseCase(repository.NewCalendarRepository(db), calendar.NewRegistry(calendars...), usecase.CalendarOptions{
+		StateSecret:  cfg.JWT.AccessSecret,
+		MaxAttempts:  cfg.Calendar.MaxAttempts,
+		BusyInterval: cfg.Calendar.BusyInterval,
+		BusyWindow:   cfg.Calendar.BusyWindow,
+	})
+	feedUC := usecase.NewFeedUseCase(repository.NewFeedRepository(db), usecase.FeedOptions{
+		BaseURL:         cfg.Payments.PublicBaseURL,
+		DefaultTimezone: cfg.Reminders.DefaultTimezone,
+		Refresh:         cfg.Calendar.FeedRefresh,
+		BusyWindow:      cfg.Calendar.BusyWindow,
+	})
+	universityUC := usecase.NewUniversityUseCase(repository.NewUniversityRepository(db))
+	credentialUC := usecase.NewCredentialUseCase(repository.NewCredentialRepository(db), documents, usecase.CredentialOptions{
+		MaxDocumentSize: cfg.Storage.MaxDocumentSize,
+	})
 
 	// 4) Router + handlers
 	r := mux.NewRouter()
-	httpapi.NewTutorHandler(tutorUC, tokenUC).RegisterRoutes(r)
+	r.Use(flags.Middleware)
+	httpapi.NewTutorHandler(tutorUC, pricingUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewPaymentHandler(paymentUC, tokenUC, fakePay).RegisterRoutes(r)
+	httpapi.NewLedgerHandler(ledgerUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewLessonHandler(lessonUC, commissionUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewCancellationHandler(cancelUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewPayoutHandler(payoutUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewChatHandler(chatUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewModerationHandler(moderationUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewNotificationHandler(notificationUC, tokenUC, fakeChannels...).RegisterRoutes(r)
+	httpapi.NewOutboxHandler(outboxUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewFlagHandler(flagClient, tokenUC).RegisterRoutes(r)
+	httpapi.NewFeedHandler(feedUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewUniversityHandler(universityUC, tokenUC).RegisterRoutes(r)
+	httpapi.NewCredentialHandler(credentialUC, tokenUC, cfg.Storage.MaxDocumentSize).RegisterRoutes(r)
+	httpapi.NewMediaHandler(mediaUC, tokenUC, mediaFiles).RegisterRoutes(r)
+	httpapi.NewCalendarHandler(calendarUC, tokenUC, cfg.Calendar.ReturnURL, fakeGoogle).RegisterRoutes(r)
 
 	// 5) CORS (из конфигов)
 	cors := handlers.CORS(
@@ -77,6 +213,165 @@ func main() {
 	log.Printf("[BOOT] env=%s port=%d db_max_conns=%d jwt_fp=%s",
 		cfg.App.Env, cfg.App.Port, cfg.DB.MaxConns, fp(cfg.JWT.AccessSecret))
 
+	// SSE-потоки чата сами не завершаются — закрываем их при Shutdown
+	srv.RegisterOnShutdown(chatHub.Close)
+
+	// 6.1) Фоновые воркеры, останавливаются при shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	// слушатель LISTEN/NOTIFY для realtime-чата
+	go chatUC.Run(workerCtx)
+	// кеш feature flags
+	go flagClient.Run(workerCtx)
+	// выплаты: отправка одобренных и опрос статусов у провайдера
+	if cfg.Payouts.WorkerInterval > 0 && payoutProvider == nil {
+		log.Printf("[PAYOUT] worker: disabled, no payout provider configured")
+	}
+	if cfg.Payouts.WorkerInterval > 0 && payoutProvider != nil {
+		go func() {
+			t := time.NewTicker(cfg.Payouts.WorkerInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if sent, finished, err := payoutUC.Process(workerCtx); err != nil {
+						log.Printf("[PAYOUT] worker: %v", err)
+					} else if sent+finished > 0 {
+						log.Printf("[PAYOUT] worker: sent=%d finished=%d", sent, finished)
+					}
+				}
+			}
+		}()
+	}
+
+	// отмены: повтор возвратов и снятия авторизаций, не прошедших у провайдера;
+	// строки забираются через SKIP LOCKED с арендой, реплик может быть сколько угодно
+	if cfg.Cancellations.RetryInterval > 0 {
+		go func() {
+			t := time.NewTicker(cfg.Cancellations.RetryInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if settled, failed, err := cancelUC.RetrySettlements(workerCtx); err != nil {
+						log.Printf("[CANCEL] worker: %v", err)
+					} else if settled+failed > 0 {
+						log.Printf("[CANCEL] worker: settled=%d failed=%d", settled, failed)
+					}
+				}
+			}
+		}()
+	}
+
+	// диспетчер уведомлений: рендер, проверка настроек, отправка с ретраями
+	if cfg.Notifications.WorkerInterval > 0 {
+		go func() {
+			t := time.NewTicker(cfg.Notifications.WorkerInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if sent, failed, err := notificationUC.Dispatch(workerCtx); err != nil {
+						log.Printf("[NOTIFY] worker: %v", err)
+					} else if sent+failed > 0 {
+						log.Printf("[NOTIFY] worker: sent=%d failed=%d", sent, failed)
+					}
+				}
+			}
+		}()
+	}
+
+	// напоминания об уроках; at-most-once обеспечивает lesson_reminders, реплик может быть сколько угодно
+	if cfg.Reminders.WorkerInterval > 0 && len(cfg.Reminders.Leads) > 0 {
+		go func() {
+			t := time.NewTicker(cfg.Reminders.WorkerInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if n, err := reminderUC.Tick(workerCtx); err != nil {
+						log.Printf("[REMINDER] worker: %v", err)
+					} else if n > 0 {
+						log.Printf("[REMINDER] worker: queued=%d", n)
+					}
+				}
+			}
+		}()
+	}
+
+	// outbox relay: публикация доменных событий с ретраями и dead letter
+	if cfg.Outbox.WorkerInterval > 0 {
+		go func() {
+			t := time.NewTicker(cfg.Outbox.WorkerInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if sent, dead, err := outboxUC.Relay(workerCtx); err != nil {
+						log.Printf("[OUTBOX] relay: %v", err)
+					} else if sent+dead > 0 {
+						log.Printf("[OUTBOX] relay: sent=%d dead=%d", sent, dead)
+					}
+				}
+			}
+		}()
+	}
+
+	// синхронизация календарей: выгрузка уроков по calendar_events.status и чтение занятости
+	if cfg.Calendar.WorkerInterval > 0 && len(calendars) > 0 {
+		go func() {
+			t := time.NewTicker(cfg.Calendar.WorkerInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if synced, failed, err := calendarUC.Sync(workerCtx); err != nil {
+						log.Printf("[CALENDAR] sync: %v", err)
+					} else if synced+failed > 0 {
+						log.Printf("[CALENDAR] sync: synced=%d failed=%d", synced, failed)
+					}
+					if n, err := calendarUC.PullBusy(workerCtx); err != nil {
+						log.Printf("[CALENDAR] busy: %v", err)
+					} else if n > 0 {
+						log.Printf("[CALENDAR] busy: refreshed=%d", n)
+					}
+				}
+			}
+		}()
+	}
+
+	// уборка хранилища: файлы, на которые не сослались за период ожидания или которые заменили
+	if cfg.Storage.GCInterval > 0 {
+		go func() {
+			t := time.NewTicker(cfg.Storage.GCInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-workerCtx.Done():
+					return
+				case <-t.C:
+					if n, err := mediaUC.CollectGarbage(workerCtx); err != nil {
+						log.Printf("[STORAGE] gc: %v", err)
+					} else if n > 0 {
+						log.Printf("[STORAGE] gc: deleted=%d", n)
+					}
+				}
+			}
+		}()
+	}
+
 	// 7) Graceful shutdown
 	errCh := make(chan error, 1)
 	go func() {
@@ -96,6 +391,7 @@ func main() {
 		}
 	}
 
+	stopWorkers()
 	shCtx, shCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shCancel()
 	if err := srv.Shutdown(shCtx); err != nil {
@@ -104,6 +400,91 @@ func main() {
 	log.Printf("server stopped")
 }
 
+// notifyChannels собирает каналы уведомлений из конфига; ненастроенные вне prod
+// заменяются fake-каналами, которые перехватывают сообщения (видны в /v1/admin/notifications/captured).
+func notifyChannels(cfg config.Config, repo repository.NotificationRepository) (notify.Registry, []*notify.FakeChannel) {
+	nc := cfg.Notifications
+	dev := cfg.App.Env != "prod"
+	channels := []notify.Channel{notify.NewInAppChannel()}
+	var fakes []*notify.FakeChannel
+	fake := func(name string) {
+		if dev {
+			f := notify.NewFakeChannel(name)
+			fakes = append(fakes, f)
+			channels = append(channels, f)
+		}
+	}
+
+	if nc.SMTPHost != "" {
+		channels = append(channels, notify.NewSMTPChannel(nc.SMTPHost, nc.SMTPPort, nc.SMTPUser, nc.SMTPPassword, nc.SMTPFrom))
+	} else {
+		fake(notify.ChannelEmail)
+	}
+	if nc.SMSURL != "" {
+		channels = append(channels, notify.NewSMSChannel(nc.SMSURL, nc.SMSToken, nc.SMSSender))
+	} else {
+		fake(notify.ChannelSMS)
+	}
+
+	senders := map[string]notify.PushSender{}
+	if nc.FCMCredentialsFile != "" {
+		s, err := notify.NewFCMSender(nc.FCMCredentialsFile)
+		if err != nil {
+			log.Fatalf("fcm: %v", err)
+		}
+		senders[notify.PlatformFCM] = s
+	}
+	if nc.APNsKeyFile != "" {
+		s, err := notify.NewAPNsSender(nc.APNsKeyFile, nc.APNsKeyID, nc.APNsTeamID, nc.APNsTopic, nc.APNsSandbox)
+		if err != nil {
+			log.Fatalf("apns: %v", err)
+		}
+		senders[notify.PlatformAPNs] = s
+	}
+	if len(senders) > 0 {
+		// токены, отвергнутые провайдером, больше не используем
+		channels = append(channels, notify.NewPushChannel(senders, func(ctx context.Context, token string) {
+			if err := repo.DeleteDevice(ctx, "", token); err != nil {
+				log.Printf("[NOTIFY] drop push token: %v", err)
+			}
+		}))
+	} else {
+		fake(notify.ChannelPush)
+	}
+	return notify.NewRegistry(channels...), fakes
+}
+
+// openStorage — хранилище по STORAGE_BACKEND. Для local возвращает и обработчик /media/,
+// которым сервис сам раздаёт аватары и видео и принимает прямые загрузки.
+func openStorage(cfg config.Config) (storage.Storage, http.Handler, error) {
+	sc := cfg.Storage
+	switch sc.Backend {
+	case "s3":
+		s, err := storage.NewS3(storage.S3Options{
+			Endpoint:  sc.S3Endpoint,
+			Region:    sc.S3Region,
+			Bucket:    sc.S3Bucket,
+			AccessKey: sc.S3AccessKey,
+			SecretKey: sc.S3SecretKey,
+			PathStyle: sc.S3PathStyle,
+			PublicURL: sc.PublicURL,
+		})
+		return s, nil, err
+	case "local":
+		s, err := storage.NewLocal(sc.LocalDir, storage.LocalOptions{
+			BaseURL: sc.PublicURL,
+			Secret:  sc.SigningSecret,
+			Public:  []string{"tutors/"}, // сканы документов (credentials/) только через API
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Handler(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown STORAGE_BACKEND %q", sc.Backend)
+	}
+}
+
 // маленький helper (без strconv импортов)
 func itoa(i int) string {
 	return fmt.Sprintf("%d", i)
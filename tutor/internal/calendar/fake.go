@@ -0,0 +1,279 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeGoogle — локальная замена OAuth и Calendar API Google для dev/тестов.
+// Согласие выдаётся сразу (редирект с кодом), события и занятость хранятся в памяти.
+// Служебные ручки: GET /_events — события по календарям, POST /_busy — задать занятость,
+// POST /_revoke — отозвать все токены (проверка переподключения).
+type FakeGoogle struct {
+	prefix string
+
+	mu      sync.Mutex
+	codes   map[string]bool
+	access  map[string]bool
+	refresh map[string]bool
+	events  map[string]map[string]googleEvent // calendarID → eventID → событие
+	busy    map[string][]Interval
+}
+
+// NewFakeGoogle: prefix — путь, под которым смонтирован handler (например /v1/calendar/fake-google).
+func NewFakeGoogle(prefix string) *FakeGoogle {
+	return &FakeGoogle{
+		prefix:  strings.TrimRight(prefix, "/"),
+		codes:   map[string]bool{},
+		access:  map[string]bool{},
+		refresh: map[string]bool{},
+		events:  map[string]map[string]googleEvent{},
+		busy:    map[string][]Interval{},
+	}
+}
+
+// Config — адреса fake-API для GoogleProvider; baseURL — публичный адрес сервиса.
+func (f *FakeGoogle) Config(baseURL string) GoogleConfig {
+	base := strings.TrimRight(baseURL, "/") + f.prefix
+	return GoogleConfig{
+		ClientID:     "fake-client",
+		ClientSecret: "fake-secret",
+		AuthURL:      base + "/o/oauth2/auth",
+		TokenURL:     base + "/token",
+		UserInfoURL:  base + "/userinfo",
+		APIURL:       base + "/calendar/v3",
+	}
+}
+
+func (f *FakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, f.prefix)
+	switch {
+	case path == "/o/oauth2/auth" && r.Method == http.MethodGet:
+		f.authorize(w, r)
+	case path == "/token" && r.Method == http.MethodPost:
+		f.token(w, r)
+	case path == "/_events" && r.Method == http.MethodGet:
+		f.dump(w)
+	case path == "/_busy" && r.Method == http.MethodPost:
+		f.setBusy(w, r)
+	case path == "/_revoke" && r.Method == http.MethodPost:
+		f.mu.Lock()
+		f.access, f.refresh = map[string]bool{}, map[string]bool{}
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		if !f.authorized(r) {
+			fakeError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		f.api(w, r, path)
+	}
+}
+
+func (f *FakeGoogle) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" {
+		fakeError(w, http.StatusBadRequest, "redirect_uri required")
+		return
+	}
+	code := "fake_code_" + fakeID()
+	f.mu.Lock()
+	f.codes[code] = true
+	f.mu.Unlock()
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (f *FakeGoogle) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := map[string]any{"token_type": "Bearer", "expires_in": 3600, "scope": strings.Join(Scopes, " ")}
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		if !f.codes[code] {
+			writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(f.codes, code)
+		rt := "fake_rt_" + fakeID()
+		f.refresh[rt] = true
+		resp["refresh_token"] = rt
+	case "refresh_token":
+		if !f.refresh[r.PostForm.Get("refresh_token")] {
+			writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	default:
+		writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	at := "fake_at_" + fakeID()
+	f.access[at] = true
+	resp["access_token"] = at
+	writeFakeJSON(w, http.StatusOK, resp)
+}
+
+func (f *FakeGoogle) authorized(r *http.Request) bool {
+	tok := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.access[tok]
+}
+
+func (f *FakeGoogle) api(w http.ResponseWriter, r *http.Request, path string) {
+	if path == "/userinfo" {
+		writeFakeJSON(w, http.StatusOK, map[string]string{"email": "fake.user@gmail.com"})
+		return
+	}
+	path = strings.TrimPrefix(path, "/calendar/v3")
+	if path == "/freeBusy" && r.Method == http.MethodPost {
+		f.freeBusy(w, r)
+		return
+	}
+	// /calendars/{calendarId}/events[/{eventId}]
+	parts := strings.Split(strings.TrimPrefix(path, "/calendars/"), "/")
+	if !strings.HasPrefix(path, "/calendars/") || len(parts) < 2 || parts[1] != "events" {
+		fakeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	calID, _ := url.PathUnescape(parts[0])
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cal := f.events[calID]
+	if cal == nil {
+		cal = map[string]googleEvent{}
+		f.events[calID] = cal
+	}
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			fakeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		var ev googleEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil || ev.Start.DateTime == "" || ev.End.DateTime == "" {
+			fakeError(w, http.StatusBadRequest, "invalid event")
+			return
+		}
+		ev.ID = "evt" + fakeID()
+		ev.HTMLLink = "https://calendar.google.com/event?eid=" + ev.ID
+		cal[ev.ID] = ev
+		writeFakeJSON(w, http.StatusOK, ev)
+		return
+	}
+
+	eventID, _ := url.PathUnescape(parts[2])
+	ev, ok := cal[eventID]
+	if !ok {
+		fakeError(w, http.StatusNotFound, "event not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeFakeJSON(w, http.StatusOK, ev)
+	case http.MethodPut, http.MethodPatch:
+		var upd googleEvent
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			fakeError(w, http.StatusBadRequest, "invalid event")
+			return
+		}
+		upd.ID, upd.HTMLLink = ev.ID, ev.HTMLLink
+		cal[eventID] = upd
+		writeFakeJSON(w, http.StatusOK, upd)
+	case http.MethodDelete:
+		delete(cal, eventID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fakeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (f *FakeGoogle) freeBusy(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TimeMin time.Time `json:"timeMin"`
+		TimeMax time.Time `json:"timeMax"`
+		Items   []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fakeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cals := map[string]any{}
+	for _, it := range req.Items {
+		busy := []Interval{}
+		for _, b := range f.busy[it.ID] {
+			if b.End.After(req.TimeMin) && b.Start.Before(req.TimeMax) {
+				busy = append(busy, b)
+			}
+		}
+		cals[it.ID] = map[string]any{"busy": busy}
+	}
+	writeFakeJSON(w, http.StatusOK, map[string]any{"kind": "calendar#freeBusy", "calendars": cals})
+}
+
+func (f *FakeGoogle) dump(w http.ResponseWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := map[string][]googleEvent{}
+	for calID, cal := range f.events {
+		list := make([]googleEvent, 0, len(cal))
+		for _, ev := range cal {
+			list = append(list, ev)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Start.DateTime < list[j].Start.DateTime })
+		out[calID] = list
+	}
+	writeFakeJSON(w, http.StatusOK, out)
+}
+
+// setBusy заменяет занятость календаря: {"calendarId":"primary","busy":[{"start":..,"end":..}]}
+func (f *FakeGoogle) setBusy(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CalendarID string     `json:"calendarId"`
+		Busy       []Interval `json:"busy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fakeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	if req.CalendarID == "" {
+		req.CalendarID = "primary"
+	}
+	f.mu.Lock()
+	f.busy[req.CalendarID] = req.Busy
+	f.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func fakeError(w http.ResponseWriter, status int, msg string) {
+	writeFakeJSON(w, status, map[string]any{"error": map[string]any{"code": status, "message": msg}})
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func fakeID() string {
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,188 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakeGoogle поднимает FakeGoogle на httptest-сервере и подключает к нему GoogleProvider.
+func newFakeGoogle(t *testing.T) (*GoogleProvider, *httptest.Server) {
+	t.Helper()
+	fake := NewFakeGoogle("/fake-google")
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg := fake.Config(srv.URL)
+	cfg.RedirectURL = "https://app.example/calendar/callback"
+	return NewGoogleProvider(cfg), srv
+}
+
+// connect проходит OAuth-согласие так же, как браузер пользователя, и обменивает код на токены.
+func connect(t *testing.T, p *GoogleProvider) *Token {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(p.AuthCodeURL("state-1"))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d, want 302", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize: bad redirect: %v", err)
+	}
+	if got := loc.Query().Get("state"); got != "state-1" {
+		t.Fatalf("authorize: state %q, want state-1", got)
+	}
+	tok, err := p.Exchange(context.Background(), loc.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if tok.AccessToken == "" || tok.RefreshToken == "" {
+		t.Fatalf("exchange: incomplete token %+v", tok)
+	}
+	return tok
+}
+
+func fakeEvents(t *testing.T, srv *httptest.Server) map[string][]googleEvent {
+	t.Helper()
+	resp, err := http.Get(srv.URL + "/fake-google/_events")
+	if err != nil {
+		t.Fatalf("_events: %v", err)
+	}
+	defer resp.Body.Close()
+	var out map[string][]googleEvent
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("_events: %v", err)
+	}
+	return out
+}
+
+func TestFakeGoogleSyncEvents(t *testing.T) {
+	p, srv := newFakeGoogle(t)
+	tok := connect(t, p)
+	ctx := context.Background()
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	lesson := Event{LessonID: "lesson-1", Summary: "Math", Start: start, End: start.Add(time.Hour)}
+
+	ref, err := p.Insert(ctx, tok.AccessToken, "primary", lesson)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if ref.ID == "" || ref.HTMLLink == "" {
+		t.Fatalf("insert: empty ref %+v", ref)
+	}
+	if got := fakeEvents(t, srv)["primary"]; len(got) != 1 || got[0].Summary != "Math" {
+		t.Fatalf("after insert: events %+v", got)
+	}
+
+	lesson.Summary = "Math (moved)"
+	lesson.Start, lesson.End = start.Add(2*time.Hour), start.Add(3*time.Hour)
+	if _, err := p.Update(ctx, tok.AccessToken, "primary", ref.ID, lesson); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got := fakeEvents(t, srv)["primary"]
+	if len(got) != 1 || got[0].Summary != "Math (moved)" || got[0].ID != ref.ID {
+		t.Fatalf("after update: events %+v", got)
+	}
+
+	if err := p.Delete(ctx, tok.AccessToken, "primary", ref.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := fakeEvents(t, srv)["primary"]; len(got) != 0 {
+		t.Fatalf("after delete: events %+v", got)
+	}
+	// повторное удаление и обновление удалённого события
+	if err := p.Delete(ctx, tok.AccessToken, "primary", ref.ID); err != nil {
+		t.Fatalf("second delete: %v", err)
+	}
+	_, err = p.Update(ctx, tok.AccessToken, "primary", ref.ID, lesson)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrPermanent) {
+		t.Fatalf("update deleted: err %v, want ErrNotFound and ErrPermanent", err)
+	}
+}
+
+func TestFakeGoogleBusy(t *testing.T) {
+	p, srv := newFakeGoogle(t)
+	tok := connect(t, p)
+	ctx := context.Background()
+	day := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	body := `{"calendarId":"primary","busy":[
+		{"start":"2030-01-10T09:00:00Z","end":"2030-01-10T10:00:00Z"},
+		{"start":"2030-01-12T09:00:00Z","end":"2030-01-12T10:00:00Z"}]}`
+	resp, err := http.Post(srv.URL+"/fake-google/_busy", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("_busy: %v", err)
+	}
+	resp.Body.Close()
+
+	busy, err := p.Busy(ctx, tok.AccessToken, "primary", day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("busy: %v", err)
+	}
+	want := Interval{Start: day.Add(9 * time.Hour), End: day.Add(10 * time.Hour)}
+	if len(busy) != 1 || !busy[0].Start.Equal(want.Start) || !busy[0].End.Equal(want.End) {
+		t.Fatalf("busy: got %+v, want [%+v]", busy, want)
+	}
+
+	other, err := p.Busy(ctx, tok.AccessToken, "other@example.com", day, day.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("busy other: %v", err)
+	}
+	if len(other) != 0 {
+		t.Fatalf("busy other: got %+v, want none", other)
+	}
+}
+
+func TestFakeGoogleTokenRefresh(t *testing.T) {
+	p, srv := newFakeGoogle(t)
+	tok := connect(t, p)
+	ctx := context.Background()
+
+	fresh, err := p.Refresh(ctx, tok.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if fresh.AccessToken == "" || fresh.AccessToken == tok.AccessToken {
+		t.Fatalf("refresh: access token not renewed: %+v", fresh)
+	}
+	// Google не возвращает refresh_token при обновлении — прежний сохраняется
+	if fresh.RefreshToken != tok.RefreshToken {
+		t.Fatalf("refresh: refresh token %q, want %q", fresh.RefreshToken, tok.RefreshToken)
+	}
+	if fresh.Expired() {
+		t.Fatalf("refresh: token already expired: %v", fresh.Expiry)
+	}
+	if email, err := p.Email(ctx, fresh.AccessToken); err != nil || email == "" {
+		t.Fatalf("email with refreshed token: %q, %v", email, err)
+	}
+
+	if _, err := p.Refresh(ctx, "fake_rt_unknown"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("refresh unknown token: err %v, want ErrUnauthorized", err)
+	}
+	if _, err := p.Refresh(ctx, ""); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("refresh empty token: err %v, want ErrUnauthorized", err)
+	}
+
+	// отзыв доступа: и старый access token, и обновление перестают работать
+	resp, err := http.Post(srv.URL+"/fake-google/_revoke", "application/json", nil)
+	if err != nil {
+		t.Fatalf("_revoke: %v", err)
+	}
+	resp.Body.Close()
+	if _, err := p.Email(ctx, fresh.AccessToken); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("email after revoke: err %v, want ErrUnauthorized", err)
+	}
+	if _, err := p.Refresh(ctx, tok.RefreshToken); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("refresh after revoke: err %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,292 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Scopes — события календаря (создание/изменение своих), free/busy и адрес аккаунта.
+var Scopes = []string{
+	"openid", "email",
+	"https://www.googleapis.com/auth/calendar.events",
+	"https://www.googleapis.com/auth/calendar.freebusy",
+}
+
+// GoogleConfig — пустые URL означают боевые адреса Google; fake-API подставляет свои.
+type GoogleConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	APIURL      string
+}
+
+// GoogleProvider — Google Calendar API v3 через OAuth 2.0 (authorization code, offline access).
+type GoogleProvider struct {
+	cfg    GoogleConfig
+	client *http.Client
+}
+
+func NewGoogleProvider(cfg GoogleConfig) *GoogleProvider {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://accounts.google.com/o/oauth2/v2/auth"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = "https://oauth2.googleapis.com/token"
+	}
+	if cfg.UserInfoURL == "" {
+		cfg.UserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://www.googleapis.com/calendar/v3"
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	return &GoogleProvider{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *GoogleProvider) Name() string { return ProviderGoogle }
+
+func (p *GoogleProvider) AuthCodeURL(state string) string {
+	q := url.Values{
+		"client_id":     {p.cfg.ClientID},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(Scopes, " ")},
+		"access_type":   {"offline"},
+		// без prompt=consent повторное подключение не вернёт refresh_token
+		"prompt": {"consent"},
+		"state":  {state},
+	}
+	return p.cfg.AuthURL + "?" + q.Encode()
+}
+
+type googleToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+func (p *GoogleProvider) Exchange(ctx context.Context, code string) (*Token, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.cfg.RedirectURL},
+	})
+}
+
+func (p *GoogleProvider) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	if refreshToken == "" {
+		return nil, ErrUnauthorized
+	}
+	t, err := p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Google не присылает refresh_token при обновлении — старый остаётся в силе
+	if t.RefreshToken == "" {
+		t.RefreshToken = refreshToken
+	}
+	return t, nil
+}
+
+func (p *GoogleProvider) token(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var gt googleToken
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&gt)
+	if gt.Error == "invalid_grant" {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode/100 != 2 || gt.AccessToken == "" {
+		err := fmt.Errorf("google oauth: http %d: %s %s", resp.StatusCode, gt.Error, gt.Description)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, Permanent(err)
+		}
+		return nil, err
+	}
+	t := &Token{AccessToken: gt.AccessToken, RefreshToken: gt.RefreshToken, Scope: gt.Scope}
+	if gt.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(gt.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+func (p *GoogleProvider) Email(ctx context.Context, accessToken string) (string, error) {
+	var out struct {
+		Email string `json:"email"`
+	}
+	if err := p.do(ctx, accessToken, http.MethodGet, p.cfg.UserInfoURL, nil, &out); err != nil {
+		return "", err
+	}
+	return out.Email, nil
+}
+
+type googleTime struct {
+	DateTime string `json:"dateTime"`
+}
+
+type googleEvent struct {
+	ID                 string     `json:"id,omitempty"`
+	HTMLLink           string     `json:"htmlLink,omitempty"`
+	Summary            string     `json:"summary"`
+	Description        string     `json:"description,omitempty"`
+	Start              googleTime `json:"start"`
+	End                googleTime `json:"end"`
+	ExtendedProperties struct {
+		Private map[string]string `json:"private,omitempty"`
+	} `json:"extendedProperties"`
+}
+
+func toGoogle(e Event) googleEvent {
+	g := googleEvent{
+		Summary:     e.Summary,
+		Description: e.Description,
+		Start:       googleTime{DateTime: e.Start.UTC().Format(time.RFC3339)},
+		End:         googleTime{DateTime: e.End.UTC().Format(time.RFC3339)},
+	}
+	g.ExtendedProperties.Private = map[string]string{"alemLessonId": e.LessonID}
+	return g
+}
+
+func (p *GoogleProvider) eventsURL(calendarID string) string {
+	return p.cfg.APIURL + "/calendars/" + url.PathEscape(calendarID) + "/events"
+}
+
+func (p *GoogleProvider) Insert(ctx context.Context, accessToken, calendarID string, e Event) (*Ref, error) {
+	var out googleEvent
+	if err := p.do(ctx, accessToken, http.MethodPost, p.eventsURL(calendarID), toGoogle(e), &out); err != nil {
+		return nil, err
+	}
+	return &Ref{ID: out.ID, HTMLLink: out.HTMLLink}, nil
+}
+
+func (p *GoogleProvider) Update(ctx context.Context, accessToken, calendarID, eventID string, e Event) (*Ref, error) {
+	var out googleEvent
+	u := p.eventsURL(calendarID) + "/" + url.PathEscape(eventID)
+	if err := p.do(ctx, accessToken, http.MethodPut, u, toGoogle(e), &out); err != nil {
+		return nil, err
+	}
+	return &Ref{ID: out.ID, HTMLLink: out.HTMLLink}, nil
+}
+
+func (p *GoogleProvider) Delete(ctx context.Context, accessToken, calendarID, eventID string) error {
+	u := p.eventsURL(calendarID) + "/" + url.PathEscape(eventID)
+	err := p.do(ctx, accessToken, http.MethodDelete, u, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (p *GoogleProvider) Busy(ctx context.Context, accessToken, calendarID string, from, to time.Time) ([]Interval, error) {
+	body := map[string]any{
+		"timeMin": from.UTC().Format(time.RFC3339),
+		"timeMax": to.UTC().Format(time.RFC3339),
+		"items":   []map[string]string{{"id": calendarID}},
+	}
+	var out struct {
+		Calendars map[string]struct {
+			Busy []struct {
+				Start time.Time `json:"start"`
+				End   time.Time `json:"end"`
+			} `json:"busy"`
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"calendars"`
+	}
+	if err := p.do(ctx, accessToken, http.MethodPost, p.cfg.APIURL+"/freeBusy", body, &out); err != nil {
+		return nil, err
+	}
+	cal := out.Calendars[calendarID]
+	if len(cal.Errors) > 0 {
+		return nil, fmt.Errorf("google freebusy: %s", cal.Errors[0].Reason)
+	}
+	list := make([]Interval, 0, len(cal.Busy))
+	for _, b := range cal.Busy {
+		list = append(list, Interval{Start: b.Start, End: b.End})
+	}
+	return list, nil
+}
+
+type httpError struct {
+	status int
+	body   string
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("google calendar: http %d: %s", e.status, e.body)
+}
+
+// do: 401 — токен отозван, 404/410 — события нет, прочие 4xx (кроме 408/429) — постоянная
+// ошибка, остальное повторяем.
+func (p *GoogleProvider) do(ctx context.Context, accessToken, method, u string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		he := &httpError{status: resp.StatusCode, body: string(b)}
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized:
+			return fmt.Errorf("%w: %v", ErrUnauthorized, he)
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			return fmt.Errorf("%w: %w: %v", ErrNotFound, ErrPermanent, he)
+		case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+			return Permanent(he)
+		}
+		return he
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
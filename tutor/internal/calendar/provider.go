@@ -0,0 +1,93 @@
+// Package calendar — внешние календари участников уроков: OAuth-подключение,
+// публикация уроков событиями и чтение занятости (free/busy).
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const ProviderGoogle = "google"
+
+var (
+	// ErrUnauthorized — доступ отозван (invalid_grant / 401 после обновления токена):
+	// аккаунт нужно подключить заново.
+	ErrUnauthorized = errors.New("calendar access revoked")
+	ErrPermanent    = errors.New("permanent calendar error")
+	// ErrNotFound — события у провайдера нет (удалено пользователем); всегда вместе с ErrPermanent.
+	ErrNotFound        = errors.New("calendar event not found")
+	ErrUnknownProvider = errors.New("unknown calendar provider")
+)
+
+// Permanent помечает ошибку, повтор которой не поможет (4xx провайдера).
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %v", ErrPermanent, err)
+}
+
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+	Scope        string
+}
+
+// Expired — с запасом в минуту, чтобы токен не истёк посреди запроса.
+func (t Token) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(time.Minute).After(t.Expiry)
+}
+
+// Event — урок в виде события календаря; LessonID уходит в приватные свойства события.
+type Event struct {
+	LessonID    string
+	Summary     string
+	Description string
+	Start, End  time.Time
+}
+
+// Ref — созданное/обновлённое событие у провайдера.
+type Ref struct {
+	ID       string
+	HTMLLink string
+}
+
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type Provider interface {
+	Name() string
+
+	AuthCodeURL(state string) string
+	Exchange(ctx context.Context, code string) (*Token, error)
+	Refresh(ctx context.Context, refreshToken string) (*Token, error)
+	// Email — адрес подключённого аккаунта (для отображения пользователю).
+	Email(ctx context.Context, accessToken string) (string, error)
+
+	Insert(ctx context.Context, accessToken, calendarID string, e Event) (*Ref, error)
+	Update(ctx context.Context, accessToken, calendarID, eventID string, e Event) (*Ref, error)
+	// Delete идемпотентен: уже удалённое событие — не ошибка.
+	Delete(ctx context.Context, accessToken, calendarID, eventID string) error
+	Busy(ctx context.Context, accessToken, calendarID string, from, to time.Time) ([]Interval, error)
+}
+
+// Registry — провайдеры по имени (имя же хранится в calendar_accounts.provider и в URL подключения).
+type Registry map[string]Provider
+
+func NewRegistry(providers ...Provider) Registry {
+	r := Registry{}
+	for _, p := range providers {
+		r[p.Name()] = p
+	}
+	return r
+}
+
+func (r Registry) Get(name string) (Provider, error) {
+	p, ok := r[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
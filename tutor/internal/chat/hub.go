@@ -0,0 +1,71 @@
+package chat
+
+import (
+	"sync"
+
+	"tutor/internal/domain"
+)
+
+// Hub раздаёт события чата подключённым к этому инстансу клиентам (SSE-потокам).
+// События других инстансов приходят через LISTEN/NOTIFY и попадают сюда же.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan domain.ChatEvent]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[string]map[chan domain.ChatEvent]struct{}{}}
+}
+
+// Subscribe — поток событий пользователя; cancel обязателен при отключении клиента.
+func (h *Hub) Subscribe(userID string) (<-chan domain.ChatEvent, func()) {
+	ch := make(chan domain.ChatEvent, 32)
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[chan domain.ChatEvent]struct{}{}
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.subs[userID][ch]; !ok {
+				return // уже закрыт в Close
+			}
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Broadcast не блокируется: медленному клиенту событие не доставится (он дочитает историю по REST).
+func (h *Hub) Broadcast(ev domain.ChatEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, uid := range ev.Recipients {
+		for ch := range h.subs[uid] {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// Close закрывает все потоки (SSE-хендлеры завершаются) — вызывается при shutdown сервера.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for uid, chans := range h.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(h.subs, uid)
+	}
+}
@@ -28,6 +28,90 @@ type Config struct {
 		AllowedMethods []string
 		AllowedHeaders []string
 	}
+	Listing struct {
+		HideUnverified bool // показывать в каталоге только verified-анкеты
+	}
+	Payments struct {
+		Provider            string // fake | stripe — для новых платежей
+		FakeEnabled         bool   // симуляция оплаты; только явным PAYMENTS_FAKE_ENABLED и не в prod
+		FakeWebhookSecret   string
+		StripeSecretKey     string
+		StripeWebhookSecret string
+		StripeAPIURL        string
+		PublicBaseURL       string // для redirect URL fake-провайдера
+	}
+	Payouts struct {
+		MinAmountMinor int64
+		Hold           time.Duration
+		WorkerInterval time.Duration // 0 — воркер выключен, только ручной /process
+		FakeEnabled    bool          // симуляция переводов; только явным PAYOUTS_FAKE_ENABLED и не в prod
+	}
+	Cancellations struct {
+		RetryInterval    time.Duration // 0 — повтор неудавшихся возвратов выключен
+		RetryBackoff     time.Duration // пауза между попытками по одной отмене
+		RetryMaxAttempts int
+	}
+	Chat struct {
+		OffenderThreshold int           // сообщений с контактами до флага на модерацию
+		OffenderWindow    time.Duration // за какой период считаем
+	}
+	Notifications struct {
+		WorkerInterval time.Duration // 0 — диспетчер выключен
+		MaxAttempts    int
+		Backoff        time.Duration
+		// каналы без настроек вне prod заменяются fake-каналами
+		SMTPHost, SMTPUser, SMTPPassword, SMTPFrom string
+		SMTPPort                                   int
+		SMSURL, SMSToken, SMSSender                string
+		FCMCredentialsFile                         string // JSON сервисного аккаунта Firebase
+		APNsKeyFile, APNsKeyID, APNsTeamID         string // .p8-ключ Apple
+		APNsTopic                                  string // bundle id
+		APNsSandbox                                bool
+	}
+	Reminders struct {
+		Leads           []time.Duration // за сколько до урока напоминать
+		WorkerInterval  time.Duration   // 0 — планировщик выключен
+		DefaultTimezone string
+	}
+	Outbox struct {
+		Broker         string // inprocess | nats | redis | kafka
+		URL            string // nats://host:4222, redis://:pass@host:6379/0, http://kafka-rest:8082
+		Prefix         string // префикс subject/stream/topic
+		WorkerInterval time.Duration
+		MaxAttempts    int
+	}
+	Flags struct {
+		Refresh time.Duration // полная перезагрузка кеша флагов помимо NOTIFY
+	}
+	Calendar struct {
+		GoogleClientID, GoogleClientSecret string
+		FakeGoogle                         bool   // без GoogleClientID — fake Google API; только явно и не в prod
+		GoogleRedirectURL                  string // публичный адрес /v1/calendar/google/callback
+		ReturnURL                          string // фронт, куда вернуть пользователя после подключения
+		WorkerInterval                     time.Duration
+		MaxAttempts                        int
+		BusyInterval                       time.Duration // как часто перечитывать занятость
+		BusyWindow                         time.Duration // на сколько вперёд
+		FeedRefresh                        time.Duration // как часто клиенты перечитывают ICS-подписку
+	}
+	Storage struct {
+		Backend         string // local | s3
+		LocalDir        string // каталог для файлов при local
+		PublicURL       string // адрес для чтения медиа: при local — /media/ этого сервиса, при s3 — CDN или бакет
+		SigningSecret   string // подпись ссылок на загрузку при local; пусто (только APP_ENV=local) — случайный
+		S3Endpoint      string
+		S3Region        string
+		S3Bucket        string
+		S3AccessKey     string
+		S3SecretKey     string
+		S3PathStyle     bool  // MinIO
+		MaxDocumentSize int64 // байт
+		MaxAvatarSize   int64
+		MaxVideoSize    int64
+		UploadTTL       time.Duration // срок подписанной ссылки на загрузку
+		GCInterval      time.Duration // 0 — сборщик мусора выключен
+		GCGrace         time.Duration // сколько живёт загруженный файл, пока на него не сослались
+	}
 }
 
 func MustLoad() Config {
@@ -47,7 +131,120 @@ func MustLoad() Config {
 
 	c.CORS.AllowedOrigins = envList("CORS_ALLOWED_ORIGINS", "*")
 	c.CORS.AllowedMethods = envList("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS")
-	c.CORS.AllowedHeaders = envList("CORS_ALLOWED_HEADERS", "Content-Type,Authorization")
+	c.CORS.AllowedHeaders = envList("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,Idempotency-Key")
+
+	c.Listing.HideUnverified = envBool("TUTORS_HIDE_UNVERIFIED", false)
+
+	c.Payments.FakeEnabled = envBool("PAYMENTS_FAKE_ENABLED", false)
+	if c.Payments.FakeEnabled {
+		if c.App.Env == "prod" {
+			log.Fatalf("PAYMENTS_FAKE_ENABLED is not allowed with APP_ENV=prod")
+		}
+		c.Payments.FakeWebhookSecret = webhookSecret("PAYMENTS_FAKE_WEBHOOK_SECRET", c.App.Env)
+		c.Payments.Provider = env("PAYMENTS_PROVIDER", "fake")
+	} else {
+		c.Payments.Provider = env("PAYMENTS_PROVIDER", "stripe")
+		if c.Payments.Provider == "fake" {
+			log.Fatalf("PAYMENTS_PROVIDER=fake requires PAYMENTS_FAKE_ENABLED=true")
+		}
+	}
+	c.Payments.StripeSecretKey = env("STRIPE_SECRET_KEY", "")
+	if c.Payments.StripeSecretKey != "" {
+		c.Payments.StripeWebhookSecret = webhookSecret("STRIPE_WEBHOOK_SECRET", c.App.Env)
+	}
+	c.Payments.StripeAPIURL = env("STRIPE_API_URL", "https://api.stripe.com")
+	c.Payments.PublicBaseURL = strings.TrimRight(env("PUBLIC_BASE_URL", "http://localhost:8082"), "/")
+
+	c.Payouts.MinAmountMinor = int64(envInt("PAYOUT_MIN_MINOR", 500000))
+	c.Payouts.Hold = envDur("PAYOUT_HOLD", "72h")
+	c.Payouts.WorkerInterval = envDur("PAYOUT_WORKER_INTERVAL", "1m")
+	c.Payouts.FakeEnabled = envBool("PAYOUTS_FAKE_ENABLED", false)
+	if c.Payouts.FakeEnabled && c.App.Env == "prod" {
+		log.Fatalf("PAYOUTS_FAKE_ENABLED is not allowed with APP_ENV=prod")
+	}
+
+	c.Cancellations.RetryInterval = envDur("CANCEL_RETRY_INTERVAL", "5m")
+	c.Cancellations.RetryBackoff = envDur("CANCEL_RETRY_BACKOFF", "15m")
+	c.Cancellations.RetryMaxAttempts = envInt("CANCEL_RETRY_MAX_ATTEMPTS", 10)
+
+	c.Chat.OffenderThreshold = envInt("CHAT_OFFENDER_THRESHOLD", 3)
+	c.Chat.OffenderWindow = envDur("CHAT_OFFENDER_WINDOW", "720h")
+
+	c.Notifications.WorkerInterval = envDur("NOTIFY_WORKER_INTERVAL", "5s")
+	c.Notifications.MaxAttempts = envInt("NOTIFY_MAX_ATTEMPTS", 5)
+	c.Notifications.Backoff = envDur("NOTIFY_BACKOFF", "30s")
+	c.Notifications.SMTPHost = env("SMTP_HOST", "")
+	c.Notifications.SMTPPort = envInt("SMTP_PORT", 587)
+	c.Notifications.SMTPUser = env("SMTP_USER", "")
+	c.Notifications.SMTPPassword = env("SMTP_PASSWORD", "")
+	c.Notifications.SMTPFrom = env("SMTP_FROM", "Alem <no-reply@alem.kz>")
+	c.Notifications.SMSURL = env("SMS_GATEWAY_URL", "")
+	c.Notifications.SMSToken = env("SMS_GATEWAY_TOKEN", "")
+	c.Notifications.SMSSender = env("SMS_SENDER", "Alem")
+	c.Notifications.FCMCredentialsFile = env("FCM_CREDENTIALS_FILE", "")
+	c.Notifications.APNsKeyFile = env("APNS_KEY_FILE", "")
+	c.Notifications.APNsKeyID = env("APNS_KEY_ID", "")
+	c.Notifications.APNsTeamID = env("APNS_TEAM_ID", "")
+	c.Notifications.APNsTopic = env("APNS_TOPIC", "")
+	c.Notifications.APNsSandbox = envBool("APNS_SANDBOX", false)
+
+	c.Reminders.Leads = envDurList("REMINDER_LEADS", "24h,15m")
+	c.Reminders.WorkerInterval = envDur("REMINDER_WORKER_INTERVAL", "1m")
+	c.Reminders.DefaultTimezone = env("REMINDER_DEFAULT_TZ", "Asia/Almaty")
+
+	c.Outbox.Broker = env("OUTBOX_BROKER", "inprocess")
+	c.Outbox.URL = env("OUTBOX_BROKER_URL", "")
+	c.Outbox.Prefix = env("OUTBOX_TOPIC_PREFIX", "alem.")
+	c.Outbox.WorkerInterval = envDur("OUTBOX_WORKER_INTERVAL", "2s")
+	c.Outbox.MaxAttempts = envInt("OUTBOX_MAX_ATTEMPTS", 10)
+
+	c.Flags.Refresh = envDur("FLAGS_REFRESH_INTERVAL", "1m")
+
+	c.Calendar.GoogleClientID = env("GOOGLE_CLIENT_ID", "")
+	c.Calendar.GoogleClientSecret = env("GOOGLE_CLIENT_SECRET", "")
+	c.Calendar.FakeGoogle = envBool("CALENDAR_FAKE_GOOGLE", false)
+	if c.Calendar.FakeGoogle && c.App.Env == "prod" {
+		log.Fatalf("CALENDAR_FAKE_GOOGLE is not allowed with APP_ENV=prod")
+	}
+	c.Calendar.GoogleRedirectURL = env("GOOGLE_REDIRECT_URL", c.Payments.PublicBaseURL+"/v1/calendar/google/callback")
+	c.Calendar.ReturnURL = env("CALENDAR_RETURN_URL", "")
+	c.Calendar.WorkerInterval = envDur("CALENDAR_WORKER_INTERVAL", "30s")
+	c.Calendar.MaxAttempts = envInt("CALENDAR_MAX_ATTEMPTS", 8)
+	c.Calendar.BusyInterval = envDur("CALENDAR_BUSY_INTERVAL", "10m")
+	c.Calendar.BusyWindow = envDur("CALENDAR_BUSY_WINDOW", "720h")
+	c.Calendar.FeedRefresh = envDur("CALENDAR_FEED_REFRESH", "1h")
+
+	c.Storage.Backend = env("STORAGE_BACKEND", "local")
+	c.Storage.LocalDir = env("STORAGE_LOCAL_DIR", "./storage")
+	c.Storage.PublicURL = env("MEDIA_PUBLIC_URL", "")
+	c.Storage.SigningSecret = env("STORAGE_SIGNING_SECRET", "")
+	if c.Storage.Backend == "local" {
+		// адрес на localhost и случайный секрет подписи годятся только для локальной разработки
+		if c.App.Env != "local" && c.Storage.PublicURL == "" {
+			log.Fatalf("MEDIA_PUBLIC_URL is required outside APP_ENV=local")
+		}
+		if c.App.Env != "local" && c.Storage.SigningSecret == "" {
+			log.Fatalf("STORAGE_SIGNING_SECRET is required outside APP_ENV=local")
+		}
+		if c.Storage.PublicURL == "" {
+			c.Storage.PublicURL = c.Payments.PublicBaseURL + "/media/"
+		}
+	}
+	if c.Storage.SigningSecret != "" && c.Storage.SigningSecret == c.JWT.AccessSecret {
+		log.Fatalf("STORAGE_SIGNING_SECRET must differ from JWT_ACCESS_SECRET")
+	}
+	c.Storage.S3Endpoint = env("S3_ENDPOINT", "")
+	c.Storage.S3Region = env("S3_REGION", "us-east-1")
+	c.Storage.S3Bucket = env("S3_BUCKET", "")
+	c.Storage.S3AccessKey = env("S3_ACCESS_KEY", "")
+	c.Storage.S3SecretKey = env("S3_SECRET_KEY", "")
+	c.Storage.S3PathStyle = envBool("S3_PATH_STYLE", false)
+	c.Storage.MaxDocumentSize = int64(envInt("DOCUMENT_MAX_SIZE", 10<<20))
+	c.Storage.MaxAvatarSize = int64(envInt("AVATAR_MAX_SIZE", 5<<20))
+	c.Storage.MaxVideoSize = int64(envInt("VIDEO_MAX_SIZE", 200<<20))
+	c.Storage.UploadTTL = envDur("MEDIA_UPLOAD_TTL", "15m")
+	c.Storage.GCInterval = envDur("STORAGE_GC_INTERVAL", "1h")
+	c.Storage.GCGrace = envDur("STORAGE_GC_GRACE", "24h")
 	return c
 }
 
@@ -57,6 +254,23 @@ func env(k, d string) string {
 	}
 	return d
 }
+
+// devWebhookSecret — подпись вебхуков по умолчанию; допустима только при APP_ENV=local.
+const devWebhookSecret = "dev-webhook-secret"
+
+// webhookSecret — секрет подписи вебхуков провайдера. У каждого провайдера свой ключ:
+// знающий секрет fake-провайдера не подделает вебхук Stripe.
+func webhookSecret(k, appEnv string) string {
+	v := env(k, "")
+	if v != "" && v != devWebhookSecret {
+		return v
+	}
+	if appEnv != "local" {
+		log.Fatalf("%s must be set to a non-default value outside APP_ENV=local", k)
+	}
+	return devWebhookSecret + "-" + strings.ToLower(k)
+}
+
 func must(k string) string {
 	v := strings.TrimSpace(os.Getenv(k))
 	if v == "" {
@@ -72,6 +286,15 @@ func envInt(k string, d int) int {
 	}
 	return d
 }
+func envBool(k string, d bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(k))) {
+	case "1", "true", "yes", "y", "on":
+		return true
+	case "0", "false", "no", "n", "off":
+		return false
+	}
+	return d
+}
 func envDur(k, d string) time.Duration {
 	s := env(k, d)
 	dur, err := time.ParseDuration(s)
@@ -92,3 +315,14 @@ func envList(k, d string) []string {
 	}
 	return out
 }
+func envDurList(k, d string) []time.Duration {
+	var out []time.Duration
+	for _, s := range envList(k, d) {
+		dur, err := time.ParseDuration(s)
+		if err != nil || dur <= 0 {
+			log.Fatalf("bad duration in %s: %s", k, s)
+		}
+		out = append(out, dur)
+	}
+	return out
+}
@@ -0,0 +1,178 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"tutor/internal/calendar"
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// FakeGooglePrefix — куда монтируется fake Google API (CALENDAR_FAKE_GOOGLE).
+const FakeGooglePrefix = "/v1/calendar/fake-google"
+
+type CalendarHandler struct {
+	calendarUC usecase.CalendarUseCase
+	tokenUC    usecase.TokenUseCase
+	returnURL  string               // куда вернуть пользователя после OAuth; "" — JSON-ответ
+	fake       *calendar.FakeGoogle // nil — fake API выключен
+}
+
+func NewCalendarHandler(c usecase.CalendarUseCase, tok usecase.TokenUseCase, returnURL string, fake *calendar.FakeGoogle) *CalendarHandler {
+	return &CalendarHandler{calendarUC: c, tokenUC: tok, returnURL: returnURL, fake: fake}
+}
+
+func (h *CalendarHandler) RegisterRoutes(r *mux.Router) {
+	if h.fake != nil {
+		r.PathPrefix(FakeGooglePrefix + "/").Handler(h.fake)
+	}
+	// провайдер возвращает пользователя без JWT — он восстанавливается из подписанного state
+	r.HandleFunc("/v1/calendar/{provider}/callback", h.callback).Methods("GET")
+	// занятость репетитора видна только вошедшим пользователям
+	r.Handle("/v1/tutors/{id}/busy", jwtMiddleware(h.tokenUC)(http.HandlerFunc(h.tutorBusy))).Methods("GET")
+
+	pr := r.PathPrefix("/v1/calendar").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("", h.accounts).Methods("GET")
+	pr.HandleFunc("/busy", h.myBusy).Methods("GET")
+	pr.HandleFunc("/{provider}/connect", h.connect).Methods("GET")
+	pr.HandleFunc("/{provider}", h.disconnect).Methods("DELETE")
+}
+
+// ---------- handlers ----------
+
+// connect отдаёт ссылку на согласие; ?redirect=1 — сразу редирект (для перехода из браузера).
+func (h *CalendarHandler) connect(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	link, err := h.calendarUC.ConnectURL(r.Context(), uid, mux.Vars(r)["provider"])
+	if err != nil {
+		writeCalendarErr(w, err)
+		return
+	}
+	if r.URL.Query().Get("redirect") == "1" {
+		http.Redirect(w, r, link, http.StatusFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]string{"url": link}})
+}
+
+func (h *CalendarHandler) callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	provider := mux.Vars(r)["provider"]
+	if e := q.Get("error"); e != "" {
+		// пользователь отказал в доступе
+		h.finish(w, r, provider, "denied", http.StatusBadRequest, "CALENDAR_DENIED", e, nil)
+		return
+	}
+	acc, err := h.calendarUC.Callback(r.Context(), provider, q.Get("state"), q.Get("code"))
+	if err != nil {
+		if h.returnURL == "" {
+			writeCalendarErr(w, err)
+			return
+		}
+		h.finish(w, r, provider, "error", 0, "", "", nil)
+		return
+	}
+	h.finish(w, r, provider, "connected", http.StatusOK, "", "", acc)
+}
+
+// finish — редирект на фронт с ?calendar=<provider>&status=<...>, без returnURL — JSON.
+func (h *CalendarHandler) finish(w http.ResponseWriter, r *http.Request, provider, status string, code int, errCode, msg string, data any) {
+	if h.returnURL != "" {
+		u, err := url.Parse(h.returnURL)
+		if err == nil {
+			q := u.Query()
+			q.Set("calendar", provider)
+			q.Set("status", status)
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.String(), http.StatusFound)
+			return
+		}
+	}
+	if errCode != "" {
+		writeErr(w, code, errCode, msg)
+		return
+	}
+	writeJSON(w, code, map[string]any{"success": true, "data": data})
+}
+
+func (h *CalendarHandler) accounts(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	list, err := h.calendarUC.Accounts(r.Context(), uid)
+	if err != nil {
+		writeCalendarErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *CalendarHandler) disconnect(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	if err := h.calendarUC.Disconnect(r.Context(), uid, mux.Vars(r)["provider"]); err != nil {
+		writeCalendarErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func (h *CalendarHandler) myBusy(w http.ResponseWriter, r *http.Request) {
+	h.busy(w, r, r.Context().Value(userIDKey).(string), h.calendarUC.Busy)
+}
+
+// tutorBusy — занятость репетитора для выбора времени брони (без деталей событий).
+func (h *CalendarHandler) tutorBusy(w http.ResponseWriter, r *http.Request) {
+	h.busy(w, r, mux.Vars(r)["id"], h.calendarUC.TutorBusy)
+}
+
+type busyFunc func(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error)
+
+// busy: ?from&to в RFC3339, по умолчанию — ближайшие 7 дней.
+func (h *CalendarHandler) busy(w http.ResponseWriter, r *http.Request, userID string, load busyFunc) {
+	from, to := time.Now().UTC(), time.Now().UTC().Add(7*24*time.Hour)
+	q := r.URL.Query()
+	for _, p := range []struct {
+		key string
+		dst *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := q.Get(p.key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", p.key+" must be RFC3339")
+				return
+			}
+			*p.dst = t
+		}
+	}
+	list, err := load(r.Context(), userID, from, to)
+	if err != nil {
+		writeCalendarErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func writeCalendarErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, calendar.ErrUnknownProvider):
+		writeErr(w, http.StatusNotFound, "PROVIDER_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrBusyNotTutor):
+		writeErr(w, http.StatusNotFound, "TUTOR_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrCalendarNotConnected):
+		writeErr(w, http.StatusNotFound, "CALENDAR_NOT_CONNECTED", err.Error())
+	case errors.Is(err, usecase.ErrCalendarState):
+		writeErr(w, http.StatusBadRequest, "INVALID_STATE", err.Error())
+	case errors.Is(err, usecase.ErrBusyRange):
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case errors.Is(err, calendar.ErrUnauthorized), errors.Is(err, calendar.ErrPermanent):
+		writeErr(w, http.StatusBadGateway, "CALENDAR_PROVIDER_ERROR", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "CALENDAR_FAILED", err.Error())
+	}
+}
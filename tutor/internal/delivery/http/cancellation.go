@@ -0,0 +1,165 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type CancellationHandler struct {
+	cancelUC usecase.CancellationUseCase
+	tokenUC  usecase.TokenUseCase
+}
+
+func NewCancellationHandler(c usecase.CancellationUseCase, tok usecase.TokenUseCase) *CancellationHandler {
+	return &CancellationHandler{cancelUC: c, tokenUC: tok}
+}
+
+func (h *CancellationHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/v1/cancellation-policies", h.listPolicies).Methods("GET")
+
+	pr := r.NewRoute().Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("/v1/bookings/{id}/cancel", h.cancel).Methods("POST")
+	pr.HandleFunc("/v1/bookings/{id}/cancellation", h.getCancellation).Methods("GET")
+	pr.HandleFunc("/v1/bookings/{id}/cancellation/quote", h.quote).Methods("GET")
+	pr.HandleFunc("/v1/tutors/profile/cancellation-policy", h.setTutorPolicy).Methods("PUT")
+
+	adm := r.PathPrefix("/v1/admin/cancellation-policies").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.createPolicy).Methods("POST")
+	adm.HandleFunc("/{id}", h.updatePolicy).Methods("PUT")
+}
+
+// ---------- DTOs ----------
+type cancelBookingDTO struct {
+	Reason    string `json:"reason"`
+	Initiator string `json:"initiator"` // только для админа: student | tutor
+}
+
+type tutorPolicyDTO struct {
+	PolicyID string `json:"policyId"` // пусто — политика платформы по умолчанию
+}
+
+// ---------- handlers ----------
+func (h *CancellationHandler) listPolicies(w http.ResponseWriter, r *http.Request) {
+	list, err := h.cancelUC.Policies(r.Context())
+	if err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *CancellationHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	var req cancelBookingDTO
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+			return
+		}
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	c, err := h.cancelUC.Cancel(r.Context(), uid, role, mux.Vars(r)["id"], req.Reason, req.Initiator)
+	if err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": c})
+}
+
+func (h *CancellationHandler) getCancellation(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	c, err := h.cancelUC.Get(r.Context(), uid, role, mux.Vars(r)["id"])
+	if err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": c})
+}
+
+func (h *CancellationHandler) quote(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	o, err := h.cancelUC.Quote(r.Context(), uid, role, mux.Vars(r)["id"], r.URL.Query().Get("initiator"))
+	if err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": o})
+}
+
+func (h *CancellationHandler) setTutorPolicy(w http.ResponseWriter, r *http.Request) {
+	var req tutorPolicyDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	if err := h.cancelUC.SetTutorPolicy(r.Context(), uid, role, req.PolicyID); err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func (h *CancellationHandler) createPolicy(w http.ResponseWriter, r *http.Request) {
+	var req domain.CancellationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	p, err := h.cancelUC.CreatePolicy(r.Context(), req)
+	if err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": p})
+}
+
+func (h *CancellationHandler) updatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req domain.CancellationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	req.ID = mux.Vars(r)["id"]
+	p, err := h.cancelUC.UpdatePolicy(r.Context(), req)
+	if err != nil {
+		writeCancellationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": p})
+}
+
+func writeCancellationErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrPolicyNotFound):
+		writeErr(w, http.StatusNotFound, "POLICY_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrPolicySuperseded):
+		writeErr(w, http.StatusConflict, "POLICY_SUPERSEDED", err.Error())
+	case errors.Is(err, repository.ErrCancellationNotFound):
+		writeErr(w, http.StatusNotFound, "CANCELLATION_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrTutorProfileNotFound):
+		writeErr(w, http.StatusNotFound, "PROFILE_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrInvalidPolicy), errors.Is(err, domain.ErrInvalidCancellationRules):
+		writeErr(w, http.StatusBadRequest, "INVALID_POLICY", err.Error())
+	case errors.Is(err, usecase.ErrInvalidInitiator):
+		writeErr(w, http.StatusBadRequest, "INVALID_INITIATOR", err.Error())
+	case errors.Is(err, repository.ErrAlreadyCancelled):
+		writeErr(w, http.StatusConflict, "ALREADY_CANCELLED", err.Error())
+	case errors.Is(err, repository.ErrBookingNotCancelable), errors.Is(err, usecase.ErrCancellationClosed):
+		writeErr(w, http.StatusConflict, "INVALID_STATE", err.Error())
+	default:
+		writePaymentErr(w, err)
+	}
+}
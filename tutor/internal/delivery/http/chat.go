@@ -0,0 +1,287 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// sseHeartbeat — комментарий-пинг, чтобы прокси не закрывали простаивающий поток.
+const sseHeartbeat = 25 * time.Second
+
+type ChatHandler struct {
+	chatUC  usecase.ChatUseCase
+	tokenUC usecase.TokenUseCase
+}
+
+func NewChatHandler(c usecase.ChatUseCase, tok usecase.TokenUseCase) *ChatHandler {
+	return &ChatHandler{chatUC: c, tokenUC: tok}
+}
+
+func (h *ChatHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/chat").Subrouter()
+	// EventSource не умеет ставить заголовки — для потока токен можно передать в ?access_token=
+	pr.Use(tokenFromQuery("/v1/chat/stream"), jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("/stream", h.stream).Methods("GET")
+	pr.HandleFunc("/conversations", h.list).Methods("GET")
+	pr.HandleFunc("/conversations", h.start).Methods("POST")
+	pr.HandleFunc("/conversations/{id}", h.get).Methods("GET")
+	pr.HandleFunc("/conversations/{id}/approve", h.approve).Methods("POST")
+	pr.HandleFunc("/conversations/{id}/block", h.block).Methods("POST")
+	pr.HandleFunc("/conversations/{id}/messages", h.history).Methods("GET")
+	pr.HandleFunc("/conversations/{id}/messages", h.send).Methods("POST")
+	pr.HandleFunc("/conversations/{id}/read", h.read).Methods("POST")
+	pr.HandleFunc("/conversations/{id}/typing", h.typing).Methods("POST")
+
+	adm := r.PathPrefix("/v1/admin/chat").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("/offenders", h.offenders).Methods("GET")
+	adm.HandleFunc("/offenders/{userId}/review", h.reviewOffender).Methods("POST")
+}
+
+// tokenFromQuery переносит ?access_token= в Authorization только для указанного пути.
+func tokenFromQuery(path string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == path && r.Header.Get("Authorization") == "" {
+				if t := r.URL.Query().Get("access_token"); t != "" {
+					r.Header.Set("Authorization", "Bearer "+t)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ---------- DTOs ----------
+type startConversationDTO struct {
+	TutorID string `json:"tutorId"`
+	Message string `json:"message"`
+}
+
+type sendMessageDTO struct {
+	Body string `json:"body"`
+}
+
+type reviewOffenderDTO struct {
+	Note string `json:"note"`
+}
+
+// ---------- handlers ----------
+func (h *ChatHandler) start(w http.ResponseWriter, r *http.Request) {
+	var req startConversationDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TutorID == "" {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "tutorId is required")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	c, m, err := h.chatUC.Start(r.Context(), uid, role, req.TutorID, req.Message)
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": map[string]any{
+		"conversation": c, "message": m,
+	}})
+}
+
+func (h *ChatHandler) list(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	page, limit := pageParams(r)
+	list, p, err := h.chatUC.List(r.Context(), uid, r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"conversations": list, "pagination": p,
+	}})
+}
+
+func (h *ChatHandler) get(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	c, err := h.chatUC.Get(r.Context(), uid, role, mux.Vars(r)["id"])
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": c})
+}
+
+func (h *ChatHandler) approve(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	c, err := h.chatUC.Approve(r.Context(), uid, mux.Vars(r)["id"])
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": c})
+}
+
+func (h *ChatHandler) block(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	c, err := h.chatUC.Block(r.Context(), uid, mux.Vars(r)["id"])
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": c})
+}
+
+func (h *ChatHandler) history(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	msgs, next, err := h.chatUC.History(r.Context(), uid, role, mux.Vars(r)["id"], r.URL.Query().Get("before"), limit)
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"messages": msgs, "nextCursor": next,
+	}})
+}
+
+func (h *ChatHandler) send(w http.ResponseWriter, r *http.Request) {
+	var req sendMessageDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	m, err := h.chatUC.Send(r.Context(), uid, mux.Vars(r)["id"], req.Body)
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": m})
+}
+
+func (h *ChatHandler) read(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	if err := h.chatUC.MarkRead(r.Context(), uid, mux.Vars(r)["id"]); err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func (h *ChatHandler) typing(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	if err := h.chatUC.Typing(r.Context(), uid, mux.Vars(r)["id"]); err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) offenders(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+	onlyFlagged := r.URL.Query().Get("all") != "true"
+	list, p, err := h.chatUC.Offenders(r.Context(), onlyFlagged, page, limit)
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"offenders": list, "pagination": p,
+	}})
+}
+
+func (h *ChatHandler) reviewOffender(w http.ResponseWriter, r *http.Request) {
+	var req reviewOffenderDTO
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+			return
+		}
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	o, err := h.chatUC.ReviewOffender(r.Context(), uid, mux.Vars(r)["userId"], req.Note)
+	if err != nil {
+		writeChatErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": o})
+}
+
+// stream — SSE-поток событий всех диалогов пользователя (message/read/typing/conversation).
+func (h *ChatHandler) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErr(w, http.StatusInternalServerError, "STREAM_UNSUPPORTED", "streaming unsupported")
+		return
+	}
+	// поток живёт дольше HTTP_WRITE_TIMEOUT сервера
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	uid := r.Context().Value(userIDKey).(string)
+	events, cancel := h.chatUC.Subscribe(uid)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	ping := time.NewTicker(sseHeartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			ev.Recipients = nil
+			data, err := json.Marshal(ev)
+			if err != nil {
+				log.Printf("[CHAT] encode event: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+func writeChatErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrConversationNotFound):
+		writeErr(w, http.StatusNotFound, "CONVERSATION_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrOffenderNotFound):
+		writeErr(w, http.StatusNotFound, "OFFENDER_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrChatParticipant):
+		writeErr(w, http.StatusNotFound, "PROFILE_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeErr(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, usecase.ErrInvalidMessage):
+		writeErr(w, http.StatusBadRequest, "INVALID_MESSAGE", err.Error())
+	case errors.Is(err, usecase.ErrConversationBlocked):
+		writeErr(w, http.StatusForbidden, "CONVERSATION_BLOCKED", err.Error())
+	case errors.Is(err, usecase.ErrConversationPending):
+		writeErr(w, http.StatusConflict, "CONVERSATION_PENDING", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "CHAT_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,241 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// credentialKinds — сегмент пути → вид записи.
+var credentialKinds = map[string]string{
+	"education":    domain.CredentialEducation,
+	"certificates": domain.CredentialCertificate,
+}
+
+const credentialPath = "/{kind:education|certificates}"
+
+type CredentialHandler struct {
+	credentialUC usecase.CredentialUseCase
+	tokenUC      usecase.TokenUseCase
+	maxDocument  int64
+}
+
+func NewCredentialHandler(c usecase.CredentialUseCase, tok usecase.TokenUseCase, maxDocument int64) *CredentialHandler {
+	if maxDocument <= 0 {
+		maxDocument = usecase.DefaultMaxDocumentSize
+	}
+	return &CredentialHandler{credentialUC: c, tokenUC: tok, maxDocument: maxDocument}
+}
+
+func (h *CredentialHandler) RegisterRoutes(r *mux.Router) {
+	// записи анкеты по одной; PUT /v1/tutors/profile/education (шаг мастера целиком) — в TutorHandler
+	pr := r.PathPrefix("/v1/tutors/profile").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc(credentialPath, h.list).Methods("GET")
+	pr.HandleFunc(credentialPath, h.create).Methods("POST")
+	pr.HandleFunc(credentialPath+"/{id}", h.update).Methods("PUT")
+	pr.HandleFunc(credentialPath+"/{id}", h.delete).Methods("DELETE")
+	pr.HandleFunc(credentialPath+"/{id}/document", h.upload).Methods("PUT")
+	pr.HandleFunc(credentialPath+"/{id}/document", h.document).Methods("GET")
+	pr.HandleFunc(credentialPath+"/{id}/document", h.deleteDocument).Methods("DELETE")
+
+	adm := r.PathPrefix("/v1/admin/credentials").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.reviewQueue).Methods("GET")
+	adm.HandleFunc(credentialPath+"/{id}/document", h.document).Methods("GET")
+	adm.HandleFunc(credentialPath+"/{id}/review", h.review).Methods("POST")
+}
+
+type credentialReviewDTO struct {
+	Status string `json:"status"` // verified | rejected | pending
+	Note   string `json:"note"`   // обязательна при rejected, её видит репетитор
+}
+
+func (h *CredentialHandler) list(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	edu, certs, err := h.credentialUC.List(r.Context(), uid)
+	if err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	var data any = edu
+	if credentialKinds[mux.Vars(r)["kind"]] == domain.CredentialCertificate {
+		data = certs
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": data})
+}
+
+func (h *CredentialHandler) create(w http.ResponseWriter, r *http.Request) {
+	h.save(w, r, "", http.StatusCreated)
+}
+
+func (h *CredentialHandler) update(w http.ResponseWriter, r *http.Request) {
+	h.save(w, r, mux.Vars(r)["id"], http.StatusOK)
+}
+
+// save — создание (id пуст) или правка записи; тело — Education или Certification по виду.
+func (h *CredentialHandler) save(w http.ResponseWriter, r *http.Request, id string, status int) {
+	uid := r.Context().Value(userIDKey).(string)
+	var saved any
+	var err error
+	switch credentialKinds[mux.Vars(r)["kind"]] {
+	case domain.CredentialEducation:
+		var req domain.Education
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+			return
+		}
+		req.ID = id
+		saved, err = h.credentialUC.SaveEducation(r.Context(), uid, req)
+	default:
+		var req domain.Certification
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+			return
+		}
+		req.ID = id
+		saved, err = h.credentialUC.SaveCertificate(r.Context(), uid, req)
+	}
+	if err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	writeJSON(w, status, map[string]any{"success": true, "data": saved})
+}
+
+func (h *CredentialHandler) delete(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	v := mux.Vars(r)
+	if err := h.credentialUC.Delete(r.Context(), uid, credentialKinds[v["kind"]], v["id"]); err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+// upload принимает multipart/form-data с полем file (PDF или изображение).
+func (h *CredentialHandler) upload(w http.ResponseWriter, r *http.Request) {
+	// запас на заголовки multipart; точный размер файла проверяет usecase
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxDocument+1<<20)
+	file, fh, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeErr(w, http.StatusRequestEntityTooLarge, "DOCUMENT_TOO_LARGE", usecase.ErrDocumentTooLarge.Error())
+			return
+		}
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "multipart form with a file field is required")
+		return
+	}
+	defer file.Close()
+
+	uid := r.Context().Value(userIDKey).(string)
+	v := mux.Vars(r)
+	doc, err := h.credentialUC.UploadDocument(r.Context(), uid, credentialKinds[v["kind"]], v["id"], fh.Filename, fh.Size, file)
+	if err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": doc})
+}
+
+// document отдаёт скан владельцу записи (маршрут анкеты) или админу (маршрут /v1/admin).
+func (h *CredentialHandler) document(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	v := mux.Vars(r)
+	rc, doc, err := h.credentialUC.OpenDocument(r.Context(), uid, role, credentialKinds[v["kind"]], v["id"])
+	if err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.Name}))
+	w.Header().Set("Content-Length", strconv.FormatInt(doc.Size, 10))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, rc)
+}
+
+func (h *CredentialHandler) deleteDocument(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	v := mux.Vars(r)
+	if err := h.credentialUC.DeleteDocument(r.Context(), uid, credentialKinds[v["kind"]], v["id"]); err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+// reviewQueue — записи на проверку, старые первыми: ?status=pending&kind=education&tutorId=&hasDocument=true
+func (h *CredentialHandler) reviewQueue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := domain.CredentialFilter{
+		Status:      q.Get("status"),
+		TutorID:     q.Get("tutorId"),
+		HasDocument: q.Get("hasDocument") == "true",
+	}
+	if k := q.Get("kind"); k != "" {
+		var ok bool
+		if f.Kind, ok = credentialKinds[k]; !ok {
+			writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", "kind must be education or certificates")
+			return
+		}
+	}
+	page, limit := pageParams(r)
+	list, p, err := h.credentialUC.ListForReview(r.Context(), f, page, limit)
+	if err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"entries": list, "pagination": p,
+	}})
+}
+
+func (h *CredentialHandler) review(w http.ResponseWriter, r *http.Request) {
+	var req credentialReviewDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	v := mux.Vars(r)
+	e, err := h.credentialUC.Review(r.Context(), uid, credentialKinds[v["kind"]], v["id"], req.Status, req.Note)
+	if err != nil {
+		writeCredentialErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": e})
+}
+
+func writeCredentialErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrCredentialNotFound):
+		writeErr(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrNoDocument):
+		writeErr(w, http.StatusNotFound, "DOCUMENT_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrUniversityNotFound):
+		writeErr(w, http.StatusBadRequest, "UNIVERSITY_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrInvalidCredential), errors.Is(err, usecase.ErrReviewStatus),
+		errors.Is(err, repository.ErrUnknownCredential):
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case errors.Is(err, usecase.ErrDocumentType):
+		writeErr(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_DOCUMENT_TYPE", err.Error())
+	case errors.Is(err, usecase.ErrDocumentTooLarge):
+		writeErr(w, http.StatusRequestEntityTooLarge, "DOCUMENT_TOO_LARGE", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "CREDENTIAL_FAILED", err.Error())
+	}
+}
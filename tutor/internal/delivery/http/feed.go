@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type FeedHandler struct {
+	feedUC  usecase.FeedUseCase
+	tokenUC usecase.TokenUseCase
+}
+
+func NewFeedHandler(f usecase.FeedUseCase, tok usecase.TokenUseCase) *FeedHandler {
+	return &FeedHandler{feedUC: f, tokenUC: tok}
+}
+
+// RegisterRoutes — до CalendarHandler: иначе /v1/calendar/feed перехватит /v1/calendar/{provider}.
+func (h *FeedHandler) RegisterRoutes(r *mux.Router) {
+	// подписки календарей ходят без заголовков авторизации — доступ по токену в пути
+	r.HandleFunc("/v1/ics/{token}/lessons.ics", h.lessons).Methods("GET", "HEAD")
+	r.HandleFunc("/v1/ics/{token}/availability.ics", h.availability).Methods("GET", "HEAD")
+
+	pr := r.PathPrefix("/v1/calendar/feed").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("", h.links).Methods("GET")
+	pr.HandleFunc("/rotate", h.rotate).Methods("POST")
+}
+
+func (h *FeedHandler) links(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	l, err := h.feedUC.Links(r.Context(), uid)
+	if err != nil {
+		writeFeedErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": l})
+}
+
+func (h *FeedHandler) rotate(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	l, err := h.feedUC.Rotate(r.Context(), uid)
+	if err != nil {
+		writeFeedErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": l})
+}
+
+func (h *FeedHandler) lessons(w http.ResponseWriter, r *http.Request) {
+	body, err := h.feedUC.Lessons(r.Context(), mux.Vars(r)["token"])
+	writeICS(w, r, "alem-lessons.ics", body, err)
+}
+
+func (h *FeedHandler) availability(w http.ResponseWriter, r *http.Request) {
+	body, err := h.feedUC.Availability(r.Context(), mux.Vars(r)["token"])
+	writeICS(w, r, "alem-availability.ics", body, err)
+}
+
+// writeICS отдаёт ленту с ETag: клиенты опрашивают подписку часто, а меняется она редко.
+func writeICS(w http.ResponseWriter, r *http.Request, filename string, body []byte, err error) {
+	if err != nil {
+		writeFeedErr(w, err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+func writeFeedErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrFeedNotFound), errors.Is(err, usecase.ErrFeedNotTutor):
+		writeErr(w, http.StatusNotFound, "FEED_NOT_FOUND", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "FEED_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"shared/flags"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type FlagHandler struct {
+	flags   flags.Client
+	tokenUC usecase.TokenUseCase
+}
+
+func NewFlagHandler(f flags.Client, tok usecase.TokenUseCase) *FlagHandler {
+	return &FlagHandler{flags: f, tokenUC: tok}
+}
+
+func (h *FlagHandler) RegisterRoutes(r *mux.Router) {
+	adm := r.PathPrefix("/v1/admin/flags").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.list).Methods("GET")
+	adm.HandleFunc("/{key}", h.get).Methods("GET")
+	adm.HandleFunc("/{key}", h.save).Methods("PUT")
+	adm.HandleFunc("/{key}", h.remove).Methods("DELETE")
+	adm.HandleFunc("/{key}/evaluate", h.evaluate).Methods("POST")
+}
+
+// ---------- DTOs ----------
+type flagDTO struct {
+	Enabled bool        `json:"enabled"`
+	Rules   flags.Rules `json:"rules"`
+}
+
+// ---------- handlers ----------
+func (h *FlagHandler) list(w http.ResponseWriter, r *http.Request) {
+	list, err := h.flags.List(r.Context())
+	if err != nil {
+		writeFlagErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *FlagHandler) get(w http.ResponseWriter, r *http.Request) {
+	f, err := h.flags.Get(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		writeFlagErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": f})
+}
+
+func (h *FlagHandler) save(w http.ResponseWriter, r *http.Request) {
+	var req flagDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	f, err := h.flags.Save(r.Context(), flags.Flag{Key: mux.Vars(r)["key"], Enabled: req.Enabled, Rules: req.Rules})
+	if err != nil {
+		writeFlagErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": f})
+}
+
+func (h *FlagHandler) remove(w http.ResponseWriter, r *http.Request) {
+	if err := h.flags.Delete(r.Context(), mux.Vars(r)["key"]); err != nil {
+		writeFlagErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+// evaluate — проверка правил на произвольном subject, без учёта кеша инстанса.
+func (h *FlagHandler) evaluate(w http.ResponseWriter, r *http.Request) {
+	var s flags.Subject
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	f, err := h.flags.Get(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		writeFlagErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"key": f.Key, "subject": s, "enabled": f.Evaluate(s),
+	}})
+}
+
+func writeFlagErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, flags.ErrFlagNotFound):
+		writeErr(w, http.StatusNotFound, "FLAG_NOT_FOUND", err.Error())
+	case errors.Is(err, flags.ErrInvalidKey), errors.Is(err, flags.ErrInvalidRules):
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "FLAGS_FAILED", err.Error())
+	}
+}
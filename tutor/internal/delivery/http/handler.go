@@ -3,13 +3,16 @@ package httpapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"shared/flags"
 	"tutor/internal/domain"
+	"tutor/internal/repository"
 	"tutor/internal/usecase"
 
 	"github.com/gorilla/mux"
@@ -18,35 +21,57 @@ import (
 type ctxKey string
 
 const (
-	userIDKey ctxKey = "userID"
+	userIDKey   ctxKey = "userID"
+	userRoleKey ctxKey = "userRole"
 )
 
 type TutorHandler struct {
-	tutorUC usecase.TutorUseCase
-	tokenUC usecase.TokenUseCase
+	tutorUC   usecase.TutorUseCase
+	pricingUC usecase.PricingUseCase
+	tokenUC   usecase.TokenUseCase
 }
 
-func NewTutorHandler(t usecase.TutorUseCase, tok usecase.TokenUseCase) *TutorHandler {
-	return &TutorHandler{tutorUC: t, tokenUC: tok}
+func NewTutorHandler(t usecase.TutorUseCase, p usecase.PricingUseCase, tok usecase.TokenUseCase) *TutorHandler {
+	return &TutorHandler{tutorUC: t, pricingUC: p, tokenUC: tok}
 }
 
 func (h *TutorHandler) RegisterRoutes(r *mux.Router) {
 
-	r.HandleFunc("/v1/tutors", h.listTutors).Methods("GET")
-	r.HandleFunc("/v1/tutors/{id}", h.tutorDetails).Methods("GET")
-
-	// protected wizard
+	// protected wizard (регистрируем до /v1/tutors/{id}, иначе "profile" уйдёт в {id})
 	pr := r.PathPrefix("/v1/tutors/profile").Subrouter()
 	pr.Use(h.jwt())
+	pr.HandleFunc("", h.getDraft).Methods("GET")
 	pr.HandleFunc("/about", h.upsertAbout).Methods("PUT")
 	pr.HandleFunc("/availability", h.replaceAvailability).Methods("PUT")
 	pr.HandleFunc("/education", h.upsertEducation).Methods("PUT")
 	pr.HandleFunc("/subjects", h.replaceSubjects).Methods("PUT")
 	pr.HandleFunc("/video", h.setVideo).Methods("PUT")
 	pr.HandleFunc("/complete", h.complete).Methods("POST")
+	pr.HandleFunc("/prices", h.myPrices).Methods("GET")
+	pr.HandleFunc("/prices", h.replacePrices).Methods("PUT")
+
+	r.HandleFunc("/v1/tutors", h.listTutors).Methods("GET")
+	r.HandleFunc("/v1/tutors/{id}", h.tutorDetails).Methods("GET")
+	r.HandleFunc("/v1/tutors/{id}/prices", h.tutorPrices).Methods("GET")
+
+	// курсы валют
+	r.HandleFunc("/v1/fx-rates", h.listFXRates).Methods("GET")
+	adm := r.PathPrefix("/v1/admin").Subrouter()
+	adm.Use(h.jwt(), h.adminOnly())
+	adm.HandleFunc("/fx-rates", h.setFXRate).Methods("PUT")
 }
 
 func (h *TutorHandler) jwt() mux.MiddlewareFunc {
+	return jwtMiddleware(h.tokenUC)
+}
+
+// adminOnly — ставится после jwt(): пропускает только role=admin.
+func (h *TutorHandler) adminOnly() mux.MiddlewareFunc {
+	return adminOnly()
+}
+
+// jwtMiddleware — общая проверка Bearer-токена для всех хендлеров сервиса.
+func jwtMiddleware(tokenUC usecase.TokenUseCase) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -74,7 +99,7 @@ func (h *TutorHandler) jwt() mux.MiddlewareFunc {
 			}
 
 			tokenString := parts[1]
-			claims, err := h.tokenUC.ParseToken(r.Context(), tokenString)
+			claims, err := tokenUC.ParseToken(r.Context(), tokenString)
 			if err != nil {
 				// Детализируем причину
 				if usecase.IsTokenExpired(err) {
@@ -101,18 +126,32 @@ func (h *TutorHandler) jwt() mux.MiddlewareFunc {
 			log.Printf("[JWT] ok user_id=%s role=%s", claims.UserID, claims.Role)
 
 			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, userRoleKey, claims.Role)
+			ctx = flags.WithUser(ctx, claims.UserID, claims.Role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+func adminOnly() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if role, _ := r.Context().Value(userRoleKey).(string); role != "admin" {
+				writeErr(w, http.StatusForbidden, "FORBIDDEN", "admin only")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // ---------- DTOs ----------
 type aboutDTO struct {
 	FirstName string                 `json:"firstName"`
 	LastName  string                 `json:"lastName"`
 	Phone     string                 `json:"phone"`
 	Gender    string                 `json:"gender"`
-	AvatarURL string                 `json:"avatarUrl"` // уже загруженный URL/ключ
+	AvatarURL string                 `json:"avatarUrl"` // url или key из POST /v1/media/uploads
 	Languages []domain.TutorLanguage `json:"languages"`
 }
 type availabilityDTO struct {
@@ -128,9 +167,10 @@ type subjectsDTO struct {
 	Items      []domain.TutorSubjectDTO `json:"items"`
 	RegularMap map[string]int64         `json:"regularPrices"` // slug -> minor
 	TrialMap   map[string]int64         `json:"trialPrices"`
+	Currencies map[string]string        `json:"currencies,omitempty"` // slug -> ISO 4217; по умолчанию — валюта предметов
 }
 type videoDTO struct {
-	VideoURL string `json:"videoUrl"`
+	VideoURL string `json:"videoUrl"` // url/key из POST /v1/media/uploads или ссылка на внешний хостинг
 }
 
 // ---------- handlers (wizard) ----------
@@ -147,7 +187,7 @@ func (h *TutorHandler) upsertAbout(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.tutorUC.UpsertAbout(r.Context(), uid, req.FirstName, req.LastName, req.Phone, req.Gender, req.AvatarURL, req.Languages); err != nil {
 		log.Printf("[about] upsert error user=%s: %v", uid, err)
-		writeErr(w, http.StatusInternalServerError, "UPsertAbout_FAILED", err.Error())
+		writeMediaErr(w, err, "UPsertAbout_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
@@ -178,6 +218,14 @@ func (h *TutorHandler) upsertEducation(w http.ResponseWriter, r *http.Request) {
 	}
 	uid := r.Context().Value(userIDKey).(string)
 	if err := h.tutorUC.UpsertEducation(r.Context(), uid, req.Description, req.Education, req.Certificates); err != nil {
+		if errors.Is(err, repository.ErrUniversityNotFound) {
+			writeErr(w, http.StatusBadRequest, "UNIVERSITY_NOT_FOUND", err.Error())
+			return
+		}
+		if errors.Is(err, repository.ErrCredentialNotFound) {
+			writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
 		writeErr(w, http.StatusInternalServerError, "EDU_FAILED", err.Error())
 		return
 	}
@@ -191,7 +239,11 @@ func (h *TutorHandler) replaceSubjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	uid := r.Context().Value(userIDKey).(string)
-	if err := h.tutorUC.ReplaceSubjects(r.Context(), uid, req.Items, req.RegularMap, req.TrialMap); err != nil {
+	if err := h.tutorUC.ReplaceSubjects(r.Context(), uid, req.Items, req.RegularMap, req.TrialMap, req.Currencies); err != nil {
+		if errors.Is(err, usecase.ErrInvalidCurrency) || errors.Is(err, usecase.ErrMixedCurrency) {
+			writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
 		writeErr(w, http.StatusInternalServerError, "SUBJECTS_FAILED", err.Error())
 		return
 	}
@@ -206,7 +258,7 @@ func (h *TutorHandler) setVideo(w http.ResponseWriter, r *http.Request) {
 	}
 	uid := r.Context().Value(userIDKey).(string)
 	if err := h.tutorUC.SetVideo(r.Context(), uid, req.VideoURL); err != nil {
-		writeErr(w, http.StatusInternalServerError, "VIDEO_FAILED", err.Error())
+		writeMediaErr(w, err, "VIDEO_FAILED")
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
@@ -215,12 +267,31 @@ func (h *TutorHandler) setVideo(w http.ResponseWriter, r *http.Request) {
 func (h *TutorHandler) complete(w http.ResponseWriter, r *http.Request) {
 	uid := r.Context().Value(userIDKey).(string)
 	if err := h.tutorUC.Complete(r.Context(), uid); err != nil {
+		var incomplete *usecase.ErrDraftIncomplete
+		if errors.As(err, &incomplete) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+				"success": false,
+				"error":   map[string]string{"code": "ONBOARDING_INCOMPLETE", "message": err.Error()},
+				"steps":   incomplete.Steps,
+			})
+			return
+		}
 		writeErr(w, http.StatusInternalServerError, "COMPLETE_FAILED", err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
 }
 
+func (h *TutorHandler) getDraft(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	d, err := h.tutorUC.GetDraft(r.Context(), uid)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "DRAFT_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": d})
+}
+
 // ---------- public list/details ----------
 func (h *TutorHandler) listTutors(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
@@ -234,9 +305,17 @@ func (h *TutorHandler) listTutors(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filters := map[string]string{
-		"search": q.Get("search"),
+		"search":   q.Get("search"),
+		"currency": q.Get("currency"),
+		// вуз (id или slug) и страна обучения
+		"university": q.Get("university"),
+		"country":    q.Get("country"),
 	}
 	list, p, err := h.tutorUC.List(r.Context(), filters, page, limit)
+	if errors.Is(err, usecase.ErrInvalidCurrency) {
+		writeErr(w, http.StatusBadRequest, "INVALID_CURRENCY", err.Error())
+		return
+	}
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
 		return
@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tutor/internal/domain"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type LedgerHandler struct {
+	ledgerUC usecase.LedgerUseCase
+	tokenUC  usecase.TokenUseCase
+}
+
+func NewLedgerHandler(l usecase.LedgerUseCase, tok usecase.TokenUseCase) *LedgerHandler {
+	return &LedgerHandler{ledgerUC: l, tokenUC: tok}
+}
+
+func (h *LedgerHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/wallet").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("", h.myWallets).Methods("GET")
+	pr.HandleFunc("/statement", h.myStatement).Methods("GET")
+
+	adm := r.PathPrefix("/v1/admin").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("/wallets/{ownerType}/{ownerId}", h.wallets).Methods("GET")
+	adm.HandleFunc("/wallets/{ownerType}/{ownerId}/statement", h.statement).Methods("GET")
+	adm.HandleFunc("/ledger/check", h.check).Methods("GET")
+}
+
+func (h *LedgerHandler) myWallets(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	list, err := h.ledgerUC.MyWallets(r.Context(), uid, role)
+	if err != nil {
+		writeLedgerErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *LedgerHandler) myStatement(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	page, limit := pageParams(r)
+	list, p, err := h.ledgerUC.MyStatement(r.Context(), uid, role, r.URL.Query().Get("currency"), page, limit)
+	if err != nil {
+		writeLedgerErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"entries": list, "pagination": p,
+	}})
+}
+
+func (h *LedgerHandler) wallets(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	list, err := h.ledgerUC.Wallets(r.Context(), vars["ownerType"], ownerID(vars))
+	if err != nil {
+		writeLedgerErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *LedgerHandler) statement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	page, limit := pageParams(r)
+	list, p, err := h.ledgerUC.Statement(r.Context(), vars["ownerType"], ownerID(vars), r.URL.Query().Get("currency"), page, limit)
+	if err != nil {
+		writeLedgerErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"entries": list, "pagination": p,
+	}})
+}
+
+func (h *LedgerHandler) check(w http.ResponseWriter, r *http.Request) {
+	issues, err := h.ledgerUC.Check(r.Context())
+	if err != nil {
+		writeLedgerErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"ok": len(issues) == 0, "issues": issues,
+	}})
+}
+
+// ownerID — для платформы допускаем короткие имена служебных кошельков.
+func ownerID(vars map[string]string) string {
+	if vars["ownerType"] == domain.OwnerPlatform {
+		switch vars["ownerId"] {
+		case "gateway":
+			return domain.PlatformGateway
+		case "escrow":
+			return domain.PlatformEscrow
+		case "revenue":
+			return domain.PlatformRevenue
+		case "payouts":
+			return domain.PlatformPayouts
+		}
+	}
+	return vars["ownerId"]
+}
+
+func pageParams(r *http.Request) (int, int) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
+
+func writeLedgerErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrForbidden):
+		writeErr(w, http.StatusForbidden, "FORBIDDEN", "wallets are available for tutors and students")
+	case errors.Is(err, usecase.ErrInvalidCurrency):
+		writeErr(w, http.StatusBadRequest, "INVALID_CURRENCY", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "LEDGER_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type LessonHandler struct {
+	lessonUC     usecase.LessonUseCase
+	commissionUC usecase.CommissionUseCase
+	tokenUC      usecase.TokenUseCase
+}
+
+func NewLessonHandler(l usecase.LessonUseCase, c usecase.CommissionUseCase, tok usecase.TokenUseCase) *LessonHandler {
+	return &LessonHandler{lessonUC: l, commissionUC: c, tokenUC: tok}
+}
+
+func (h *LessonHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/lessons").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("/{id}", h.getLesson).Methods("GET")
+	pr.HandleFunc("/{id}/complete", h.complete).Methods("POST")
+
+	adm := r.PathPrefix("/v1/admin/commission-rules").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.listRuleSets).Methods("GET")
+	adm.HandleFunc("", h.createRuleSet).Methods("POST")
+}
+
+func (h *LessonHandler) getLesson(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	l, err := h.lessonUC.Get(r.Context(), uid, role, mux.Vars(r)["id"])
+	if err != nil {
+		writeLessonErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": l})
+}
+
+func (h *LessonHandler) complete(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	c, err := h.lessonUC.Complete(r.Context(), uid, role, mux.Vars(r)["id"])
+	if err != nil {
+		writeLessonErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": c})
+}
+
+func (h *LessonHandler) listRuleSets(w http.ResponseWriter, r *http.Request) {
+	list, err := h.commissionUC.ListRuleSets(r.Context())
+	if err != nil {
+		writeLessonErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *LessonHandler) createRuleSet(w http.ResponseWriter, r *http.Request) {
+	var req domain.CommissionRuleSet
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	rs, err := h.commissionUC.CreateRuleSet(r.Context(), uid, req)
+	if err != nil {
+		writeLessonErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": rs})
+}
+
+func writeLessonErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLessonNotFound):
+		writeErr(w, http.StatusNotFound, "LESSON_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeErr(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, repository.ErrLessonTransition), errors.Is(err, usecase.ErrLessonNotStarted):
+		writeErr(w, http.StatusConflict, "INVALID_STATE", err.Error())
+	case errors.Is(err, domain.ErrInvalidCommissionRules):
+		writeErr(w, http.StatusBadRequest, "INVALID_RULES", err.Error())
+	case errors.Is(err, repository.ErrRuleSetExists):
+		writeErr(w, http.StatusConflict, "RULES_EXIST", err.Error())
+	case errors.Is(err, repository.ErrRuleSetNotFound):
+		writeErr(w, http.StatusInternalServerError, "NO_RULES", err.Error())
+	default:
+		writePaymentErr(w, err)
+	}
+}
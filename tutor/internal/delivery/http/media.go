@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// MediaPrefix — путь, под которым сервис сам раздаёт файлы и принимает загрузки при локальном хранилище.
+const MediaPrefix = "/media/"
+
+type MediaHandler struct {
+	mediaUC usecase.MediaUseCase
+	tokenUC usecase.TokenUseCase
+	files   http.Handler // storage.Local.Handler(); nil — файлы отдаёт внешнее хранилище
+}
+
+func NewMediaHandler(m usecase.MediaUseCase, tok usecase.TokenUseCase, files http.Handler) *MediaHandler {
+	return &MediaHandler{mediaUC: m, tokenUC: tok, files: files}
+}
+
+func (h *MediaHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/media").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("/uploads", h.presign).Methods("POST")
+
+	if h.files != nil {
+		r.PathPrefix(MediaPrefix).Handler(http.StripPrefix(MediaPrefix, h.files))
+	}
+}
+
+type mediaUploadDTO struct {
+	Kind        string `json:"kind"` // avatar | video
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// presign выдаёт ссылку для прямой загрузки; полученный url передаётся в avatarUrl / videoUrl анкеты.
+func (h *MediaHandler) presign(w http.ResponseWriter, r *http.Request) {
+	var req mediaUploadDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	up, err := h.mediaUC.PresignUpload(r.Context(), uid, req.Kind, req.ContentType, req.Size)
+	if err != nil {
+		writeMediaErr(w, err, "UPLOAD_URL_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": up})
+}
+
+// writeMediaErr — ошибки проверки загруженных файлов; остальное — 500 с кодом fallback.
+func writeMediaErr(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrMediaKind), errors.Is(err, usecase.ErrMediaURL):
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case errors.Is(err, usecase.ErrMediaNotFound):
+		writeErr(w, http.StatusBadRequest, "MEDIA_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrMediaType):
+		writeErr(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", err.Error())
+	case errors.Is(err, usecase.ErrMediaTooLarge):
+		writeErr(w, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, fallback, err.Error())
+	}
+}
@@ -0,0 +1,181 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type ModerationHandler struct {
+	moderationUC usecase.ModerationUseCase
+	tokenUC      usecase.TokenUseCase
+}
+
+func NewModerationHandler(m usecase.ModerationUseCase, tok usecase.TokenUseCase) *ModerationHandler {
+	return &ModerationHandler{moderationUC: m, tokenUC: tok}
+}
+
+func (h *ModerationHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/reports").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("", h.create).Methods("POST")
+	pr.HandleFunc("", h.mine).Methods("GET")
+
+	adm := r.PathPrefix("/v1/admin/reports").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.list).Methods("GET")
+	adm.HandleFunc("/{id}", h.get).Methods("GET")
+	adm.HandleFunc("/{id}/assign", h.assign).Methods("POST")
+	adm.HandleFunc("/{id}/status", h.setStatus).Methods("POST")
+	adm.HandleFunc("/{id}/resolve", h.resolve).Methods("POST")
+}
+
+// ---------- DTOs ----------
+type createReportDTO struct {
+	TargetType string `json:"targetType"` // tutor | user | lesson | message
+	TargetID   string `json:"targetId"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+}
+
+type assignReportDTO struct {
+	AssigneeID string `json:"assigneeId"` // "me" — на себя, "" — снять
+}
+
+type reportStatusDTO struct {
+	Status string `json:"status"`
+}
+
+// ---------- handlers ----------
+func (h *ModerationHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req createReportDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	rp, err := h.moderationUC.Report(r.Context(), uid, req.TargetType, req.TargetID, req.Reason, req.Details)
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": rp})
+}
+
+func (h *ModerationHandler) mine(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	page, limit := pageParams(r)
+	list, p, err := h.moderationUC.MyReports(r.Context(), uid, page, limit)
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"reports": list, "pagination": p,
+	}})
+}
+
+func (h *ModerationHandler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := domain.ReportFilter{
+		Status:     q.Get("status"),
+		TargetType: q.Get("targetType"),
+		TargetID:   q.Get("targetId"),
+		AssigneeID: q.Get("assigneeId"),
+		ReporterID: q.Get("reporterId"),
+		Unassigned: q.Get("unassigned") == "true",
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	page, limit := pageParams(r)
+	list, p, err := h.moderationUC.List(r.Context(), uid, f, page, limit)
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"reports": list, "pagination": p,
+	}})
+}
+
+func (h *ModerationHandler) get(w http.ResponseWriter, r *http.Request) {
+	rp, err := h.moderationUC.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rp})
+}
+
+func (h *ModerationHandler) assign(w http.ResponseWriter, r *http.Request) {
+	var req assignReportDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	rp, err := h.moderationUC.Assign(r.Context(), uid, mux.Vars(r)["id"], req.AssigneeID)
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rp})
+}
+
+func (h *ModerationHandler) setStatus(w http.ResponseWriter, r *http.Request) {
+	var req reportStatusDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	rp, err := h.moderationUC.SetStatus(r.Context(), uid, mux.Vars(r)["id"], req.Status)
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rp})
+}
+
+func (h *ModerationHandler) resolve(w http.ResponseWriter, r *http.Request) {
+	var req domain.ModerationDecision
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	rp, err := h.moderationUC.Resolve(r.Context(), uid, mux.Vars(r)["id"], req)
+	if err != nil {
+		writeModerationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rp})
+}
+
+func writeModerationErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrReportNotFound):
+		writeErr(w, http.StatusNotFound, "REPORT_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrReportTarget):
+		writeErr(w, http.StatusNotFound, "TARGET_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrUserNotFound):
+		writeErr(w, http.StatusNotFound, "USER_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeErr(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, usecase.ErrInvalidReport):
+		writeErr(w, http.StatusBadRequest, "INVALID_REPORT", err.Error())
+	case errors.Is(err, usecase.ErrInvalidDecision):
+		writeErr(w, http.StatusBadRequest, "INVALID_DECISION", err.Error())
+	case errors.Is(err, repository.ErrReportExists):
+		writeErr(w, http.StatusConflict, "REPORT_EXISTS", err.Error())
+	case errors.Is(err, repository.ErrReportTransition):
+		writeErr(w, http.StatusConflict, "INVALID_STATE", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "MODERATION_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/notify"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	notificationUC usecase.NotificationUseCase
+	tokenUC        usecase.TokenUseCase
+	fakes          []*notify.FakeChannel // пусто — fake-каналы выключены (prod)
+}
+
+func NewNotificationHandler(n usecase.NotificationUseCase, tok usecase.TokenUseCase, fakes ...*notify.FakeChannel) *NotificationHandler {
+	return &NotificationHandler{notificationUC: n, tokenUC: tok, fakes: fakes}
+}
+
+func (h *NotificationHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/notifications").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("/devices", h.registerDevice).Methods("POST")
+	pr.HandleFunc("/devices/{token}", h.removeDevice).Methods("DELETE")
+
+	adm := r.PathPrefix("/v1/admin/notifications").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.send).Methods("POST")
+	if len(h.fakes) > 0 {
+		adm.HandleFunc("/captured", h.captured).Methods("GET")
+		adm.HandleFunc("/captured", h.resetCaptured).Methods("DELETE")
+	}
+}
+
+// ---------- DTOs ----------
+type deviceDTO struct {
+	Platform string `json:"platform"` // fcm | apns
+	Token    string `json:"token"`
+}
+
+type sendNotificationDTO struct {
+	UserID      string         `json:"userId"`
+	TemplateKey string         `json:"templateKey"`
+	Payload     map[string]any `json:"payload"`
+	Channels    []string       `json:"channels"` // пусто — каналы шаблона
+}
+
+// ---------- handlers ----------
+func (h *NotificationHandler) registerDevice(w http.ResponseWriter, r *http.Request) {
+	var req deviceDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	if err := h.notificationUC.RegisterDevice(r.Context(), uid, req.Platform, req.Token); err != nil {
+		writeNotificationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func (h *NotificationHandler) removeDevice(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	if err := h.notificationUC.RemoveDevice(r.Context(), uid, mux.Vars(r)["token"]); err != nil {
+		writeNotificationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func (h *NotificationHandler) send(w http.ResponseWriter, r *http.Request) {
+	var req sendNotificationDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	if req.TemplateKey == "" {
+		req.TemplateKey = "admin.test"
+	}
+	if err := h.notificationUC.Notify(r.Context(), req.UserID, req.TemplateKey, req.Payload, req.Channels...); err != nil {
+		writeNotificationErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"saved": true})
+}
+
+// captured — что перехватили fake-каналы (dev).
+func (h *NotificationHandler) captured(w http.ResponseWriter, r *http.Request) {
+	out := map[string][]notify.Message{}
+	for _, f := range h.fakes {
+		out[f.Name()] = f.Sent()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": out})
+}
+
+func (h *NotificationHandler) resetCaptured(w http.ResponseWriter, r *http.Request) {
+	for _, f := range h.fakes {
+		f.Reset()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func writeNotificationErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrUnknownTemplate):
+		writeErr(w, http.StatusBadRequest, "UNKNOWN_TEMPLATE", err.Error())
+	case errors.Is(err, usecase.ErrInvalidChannel):
+		writeErr(w, http.StatusBadRequest, "INVALID_CHANNEL", err.Error())
+	case errors.Is(err, usecase.ErrInvalidDevice):
+		writeErr(w, http.StatusBadRequest, "INVALID_DEVICE", err.Error())
+	case errors.Is(err, repository.ErrRecipientNotFound):
+		writeErr(w, http.StatusNotFound, "USER_NOT_FOUND", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "NOTIFICATION_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type OutboxHandler struct {
+	outboxUC usecase.OutboxUseCase
+	tokenUC  usecase.TokenUseCase
+}
+
+func NewOutboxHandler(o usecase.OutboxUseCase, tok usecase.TokenUseCase) *OutboxHandler {
+	return &OutboxHandler{outboxUC: o, tokenUC: tok}
+}
+
+func (h *OutboxHandler) RegisterRoutes(r *mux.Router) {
+	adm := r.PathPrefix("/v1/admin/outbox").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("/dead", h.dead).Methods("GET")
+	adm.HandleFunc("/{id}/requeue", h.requeue).Methods("POST")
+}
+
+func (h *OutboxHandler) dead(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+	list, p, err := h.outboxUC.DeadLetters(r.Context(), r.URL.Query().Get("type"), page, limit)
+	if err != nil {
+		writeOutboxErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"events": list, "pagination": p,
+	}})
+}
+
+func (h *OutboxHandler) requeue(w http.ResponseWriter, r *http.Request) {
+	e, err := h.outboxUC.Requeue(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeOutboxErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": e})
+}
+
+func writeOutboxErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrOutboxEventNotFound):
+		writeErr(w, http.StatusNotFound, "EVENT_NOT_FOUND", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "OUTBOX_FAILED", err.Error())
+	}
+}
@@ -0,0 +1,184 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"tutor/internal/domain"
+	"tutor/internal/payment"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type PaymentHandler struct {
+	paymentUC usecase.PaymentUseCase
+	tokenUC   usecase.TokenUseCase
+	fake      *payment.FakeProvider // nil — симуляция оплаты выключена
+}
+
+func NewPaymentHandler(p usecase.PaymentUseCase, tok usecase.TokenUseCase, fake *payment.FakeProvider) *PaymentHandler {
+	return &PaymentHandler{paymentUC: p, tokenUC: tok, fake: fake}
+}
+
+func (h *PaymentHandler) RegisterRoutes(r *mux.Router) {
+	// вебхуки провайдеров — без JWT, аутентичность проверяется подписью
+	r.HandleFunc("/v1/payments/webhooks/{provider}", h.webhook).Methods("POST")
+
+	pr := r.NewRoute().Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	if h.fake != nil {
+		pr.HandleFunc("/v1/payments/fake/{intentId}/{outcome}", h.fakeComplete).Methods("POST")
+	}
+	pr.HandleFunc("/v1/bookings/{id}/payments", h.createPayment).Methods("POST")
+	pr.HandleFunc("/v1/payments/{id}", h.getPayment).Methods("GET")
+
+	adm := r.PathPrefix("/v1/admin/payments").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("/{id}/capture", h.capture).Methods("POST")
+	adm.HandleFunc("/{id}/void", h.void).Methods("POST")
+	adm.HandleFunc("/{id}/refund", h.refund).Methods("POST")
+}
+
+// ---------- DTOs ----------
+type refundDTO struct {
+	AmountMinor int64 `json:"amountMinor"` // 0 — полный возврат остатка
+}
+
+// ---------- handlers ----------
+func (h *PaymentHandler) createPayment(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	p, err := h.paymentUC.CreateForBooking(r.Context(), uid, mux.Vars(r)["id"], r.Header.Get("Idempotency-Key"))
+	if err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": p})
+}
+
+func (h *PaymentHandler) getPayment(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	p, err := h.paymentUC.Get(r.Context(), uid, role, mux.Vars(r)["id"])
+	if err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": p})
+}
+
+func (h *PaymentHandler) webhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "cannot read body")
+		return
+	}
+	provider := mux.Vars(r)["provider"]
+	if err := h.paymentUC.HandleWebhook(r.Context(), provider, payload, r.Header); err != nil {
+		switch {
+		case errors.Is(err, payment.ErrUnknownEvent):
+			// неинтересные нам типы событий подтверждаем, чтобы провайдер не ретраил
+			writeJSON(w, http.StatusOK, map[string]any{"received": true, "ignored": true})
+		case errors.Is(err, payment.ErrInvalidSignature):
+			log.Printf("[PAYMENT] webhook %s: invalid signature", provider)
+			writeErr(w, http.StatusUnauthorized, "INVALID_SIGNATURE", err.Error())
+		default:
+			log.Printf("[PAYMENT] webhook %s: %v", provider, err)
+			writePaymentErr(w, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"received": true})
+}
+
+// fakeComplete — "страница оплаты" fake-провайдера: outcome=succeed|fail|capture
+// отправляет подписанный вебхук по тому же пути, что и настоящий провайдер.
+// Завершить оплату может только студент брони.
+func (h *PaymentHandler) fakeComplete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uid := r.Context().Value(userIDKey).(string)
+	p, err := h.paymentUC.GetByIntent(r.Context(), uid, h.fake.Name(), vars["intentId"])
+	if err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	status := map[string]string{
+		"succeed": domain.PaymentAuthorized,
+		"capture": domain.PaymentCaptured,
+		"fail":    domain.PaymentFailed,
+	}[vars["outcome"]]
+	if status == "" {
+		writeErr(w, http.StatusBadRequest, "INVALID_OUTCOME", "outcome must be succeed, capture or fail")
+		return
+	}
+	payload, header := h.fake.SignedEvent(vars["intentId"], status, p.AmountMinor, p.Currency)
+	if err := h.paymentUC.HandleWebhook(r.Context(), h.fake.Name(), payload, header); err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{"status": status}})
+}
+
+func (h *PaymentHandler) capture(w http.ResponseWriter, r *http.Request) {
+	p, err := h.paymentUC.Capture(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": p})
+}
+
+func (h *PaymentHandler) void(w http.ResponseWriter, r *http.Request) {
+	p, err := h.paymentUC.Void(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": p})
+}
+
+func (h *PaymentHandler) refund(w http.ResponseWriter, r *http.Request) {
+	var req refundDTO
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+			return
+		}
+	}
+	rf, err := h.paymentUC.Refund(r.Context(), mux.Vars(r)["id"], req.AmountMinor)
+	if err != nil {
+		writePaymentErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rf})
+}
+
+func writePaymentErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrBookingNotFound):
+		writeErr(w, http.StatusNotFound, "BOOKING_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrPaymentNotFound):
+		writeErr(w, http.StatusNotFound, "PAYMENT_NOT_FOUND", err.Error())
+	case errors.Is(err, payment.ErrUnknownProvider):
+		writeErr(w, http.StatusNotFound, "UNKNOWN_PROVIDER", err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeErr(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, repository.ErrPriceNotFound):
+		writeErr(w, http.StatusUnprocessableEntity, "PRICE_NOT_FOUND", err.Error())
+	case errors.Is(err, repository.ErrDuplicateIdempotency):
+		writeErr(w, http.StatusConflict, "IDEMPOTENCY_CONFLICT", err.Error())
+	case errors.Is(err, usecase.ErrBookingClosed), errors.Is(err, usecase.ErrPaymentState),
+		errors.Is(err, repository.ErrPaymentTransition):
+		writeErr(w, http.StatusConflict, "INVALID_STATE", err.Error())
+	case errors.Is(err, usecase.ErrRefundAmount):
+		writeErr(w, http.StatusBadRequest, "INVALID_AMOUNT", err.Error())
+	case errors.Is(err, usecase.ErrWebhookMismatch):
+		writeErr(w, http.StatusUnprocessableEntity, "WEBHOOK_MISMATCH", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "PAYMENT_FAILED", err.Error())
+	}
+}
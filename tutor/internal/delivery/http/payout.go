@@ -0,0 +1,160 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type PayoutHandler struct {
+	payoutUC usecase.PayoutUseCase
+	tokenUC  usecase.TokenUseCase
+}
+
+func NewPayoutHandler(p usecase.PayoutUseCase, tok usecase.TokenUseCase) *PayoutHandler {
+	return &PayoutHandler{payoutUC: p, tokenUC: tok}
+}
+
+func (h *PayoutHandler) RegisterRoutes(r *mux.Router) {
+	pr := r.PathPrefix("/v1/payouts").Subrouter()
+	pr.Use(jwtMiddleware(h.tokenUC))
+	pr.HandleFunc("", h.listMine).Methods("GET")
+	pr.HandleFunc("", h.request).Methods("POST")
+	pr.HandleFunc("/balance", h.balance).Methods("GET")
+	pr.HandleFunc("/{id}/cancel", h.cancel).Methods("POST")
+
+	adm := r.PathPrefix("/v1/admin/payouts").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("", h.list).Methods("GET")
+	adm.HandleFunc("/approve", h.approve).Methods("POST")
+	adm.HandleFunc("/process", h.process).Methods("POST")
+	adm.HandleFunc("/{id}/cancel", h.cancel).Methods("POST")
+}
+
+// ---------- DTOs ----------
+type approvePayoutsDTO struct {
+	IDs []string `json:"ids"`
+}
+
+// ---------- handlers ----------
+func (h *PayoutHandler) balance(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	b, err := h.payoutUC.Balance(r.Context(), uid, role, r.URL.Query().Get("currency"))
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": b})
+}
+
+func (h *PayoutHandler) request(w http.ResponseWriter, r *http.Request) {
+	var req usecase.PayoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	p, err := h.payoutUC.Request(r.Context(), uid, role, req, r.Header.Get("Idempotency-Key"))
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": p})
+}
+
+func (h *PayoutHandler) listMine(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	page, limit := pageParams(r)
+	list, p, err := h.payoutUC.ListMine(r.Context(), uid, r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"payouts": list, "pagination": p,
+	}})
+}
+
+func (h *PayoutHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	role, _ := r.Context().Value(userRoleKey).(string)
+	p, err := h.payoutUC.Cancel(r.Context(), uid, role, mux.Vars(r)["id"])
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": p})
+}
+
+func (h *PayoutHandler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, limit := pageParams(r)
+	list, p, err := h.payoutUC.List(r.Context(), q.Get("status"), q.Get("ownerId"), page, limit)
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"payouts": list, "pagination": p,
+	}})
+}
+
+func (h *PayoutHandler) approve(w http.ResponseWriter, r *http.Request) {
+	var req approvePayoutsDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	ids, err := h.payoutUC.ApproveBatch(r.Context(), uid, req.IDs)
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"approved": ids, "skipped": len(req.IDs) - len(ids),
+	}})
+}
+
+func (h *PayoutHandler) process(w http.ResponseWriter, r *http.Request) {
+	sent, finished, err := h.payoutUC.Process(r.Context())
+	if err != nil {
+		writePayoutErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{
+		"sent": sent, "finished": finished,
+	}})
+}
+
+func writePayoutErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrPayoutNotFound):
+		writeErr(w, http.StatusNotFound, "PAYOUT_NOT_FOUND", err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeErr(w, http.StatusForbidden, "FORBIDDEN", "payouts are available for tutors only")
+	case errors.Is(err, usecase.ErrInvalidCurrency):
+		writeErr(w, http.StatusBadRequest, "INVALID_CURRENCY", err.Error())
+	case errors.Is(err, usecase.ErrInvalidPayout):
+		writeErr(w, http.StatusBadRequest, "INVALID_PAYOUT", err.Error())
+	case errors.Is(err, usecase.ErrBelowMinPayout):
+		writeErr(w, http.StatusUnprocessableEntity, "BELOW_MINIMUM", err.Error())
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		writeErr(w, http.StatusUnprocessableEntity, "INSUFFICIENT_FUNDS", err.Error())
+	case errors.Is(err, repository.ErrDuplicateIdempotency):
+		writeErr(w, http.StatusConflict, "IDEMPOTENCY_CONFLICT", err.Error())
+	case errors.Is(err, usecase.ErrNoPayoutProvider):
+		writeErr(w, http.StatusServiceUnavailable, "PAYOUTS_UNAVAILABLE", err.Error())
+	case errors.Is(err, repository.ErrPayoutTransition):
+		writeErr(w, http.StatusConflict, "INVALID_STATE", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "PAYOUT_FAILED", err.Error())
+	}
+}
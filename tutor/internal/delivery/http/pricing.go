@@ -0,0 +1,92 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// ---------- DTOs ----------
+type pricesDTO struct {
+	Items []domain.LessonPrice `json:"items"`
+}
+type fxRateDTO struct {
+	Base  string  `json:"base"`
+	Quote string  `json:"quote"`
+	Rate  float64 `json:"rate"`
+}
+
+// ---------- handlers (prices) ----------
+func (h *TutorHandler) replacePrices(w http.ResponseWriter, r *http.Request) {
+	var req pricesDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	if err := h.pricingUC.ReplacePrices(r.Context(), uid, req.Items); err != nil {
+		writePricingErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func (h *TutorHandler) myPrices(w http.ResponseWriter, r *http.Request) {
+	uid := r.Context().Value(userIDKey).(string)
+	h.writePrices(w, r, uid)
+}
+
+func (h *TutorHandler) tutorPrices(w http.ResponseWriter, r *http.Request) {
+	h.writePrices(w, r, mux.Vars(r)["id"])
+}
+
+func (h *TutorHandler) writePrices(w http.ResponseWriter, r *http.Request, tutorID string) {
+	list, err := h.pricingUC.ListPrices(r.Context(), tutorID, r.URL.Query().Get("currency"))
+	if err != nil {
+		writePricingErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+// ---------- handlers (fx) ----------
+func (h *TutorHandler) listFXRates(w http.ResponseWriter, r *http.Request) {
+	list, err := h.pricingUC.ListFXRates(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+func (h *TutorHandler) setFXRate(w http.ResponseWriter, r *http.Request) {
+	var req fxRateDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	if err := h.pricingUC.SetFXRate(r.Context(), req.Base, req.Quote, req.Rate); err != nil {
+		writePricingErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"saved": true})
+}
+
+func writePricingErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPrice):
+		writeErr(w, http.StatusBadRequest, "INVALID_PRICE", err.Error())
+	case errors.Is(err, usecase.ErrInvalidCurrency):
+		writeErr(w, http.StatusBadRequest, "INVALID_CURRENCY", err.Error())
+	case errors.Is(err, repository.ErrSubjectNotFound), errors.Is(err, repository.ErrSubdirectionNotFound):
+		writeErr(w, http.StatusBadRequest, "UNKNOWN_TARGET", err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, "PRICES_FAILED", err.Error())
+	}
+}
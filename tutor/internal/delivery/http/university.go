@@ -0,0 +1,67 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shared/flags"
+	"tutor/internal/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type UniversityHandler struct {
+	uniUC   usecase.UniversityUseCase
+	tokenUC usecase.TokenUseCase
+}
+
+func NewUniversityHandler(u usecase.UniversityUseCase, tok usecase.TokenUseCase) *UniversityHandler {
+	return &UniversityHandler{uniUC: u, tokenUC: tok}
+}
+
+func (h *UniversityHandler) RegisterRoutes(r *mux.Router) {
+	// автодополнение в анкете: ?q=каз&country=KZ&limit=10
+	r.HandleFunc("/v1/universities/suggest", h.suggest).Methods("GET")
+
+	adm := r.PathPrefix("/v1/admin/universities").Subrouter()
+	adm.Use(jwtMiddleware(h.tokenUC), adminOnly())
+	adm.HandleFunc("/match", h.match).Methods("POST")
+}
+
+func (h *UniversityHandler) suggest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	list, err := h.uniUC.Suggest(r.Context(), q.Get("q"), q.Get("country"), flags.FromContext(r.Context()).Locale, limit)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "SUGGEST_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": list})
+}
+
+type universityMatchDTO struct {
+	MinScore float64 `json:"minScore"` // 0 — порог по умолчанию
+	DryRun   bool    `json:"dryRun"`
+}
+
+// match сопоставляет свободный текст institution со справочником; dryRun — только показать пары.
+func (h *UniversityHandler) match(w http.ResponseWriter, r *http.Request) {
+	var req universityMatchDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "INVALID_BODY", "bad json")
+		return
+	}
+	uid := r.Context().Value(userIDKey).(string)
+	rep, err := h.uniUC.Match(r.Context(), uid, req.MinScore, req.DryRun)
+	if errors.Is(err, usecase.ErrMatchScore) {
+		writeErr(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "MATCH_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "data": rep})
+}
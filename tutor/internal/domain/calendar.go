@@ -0,0 +1,106 @@
+package domain
+
+import "time"
+
+// Статусы подключения календаря (calendar_accounts.status); revoked — доступ отозван, нужно переподключить
+const (
+	CalendarActive  = "active"
+	CalendarRevoked = "revoked"
+)
+
+// Статусы события урока во внешнем календаре (calendar_events.status)
+const (
+	CalendarEventSynced   = "synced"
+	CalendarEventToCreate = "to_create"
+	CalendarEventToUpdate = "to_update"
+	CalendarEventToDelete = "to_delete"
+	CalendarEventError    = "error"
+)
+
+type CalendarAccount struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"userId"`
+	Provider     string     `json:"provider"`
+	Email        string     `json:"email,omitempty"`
+	CalendarID   string     `json:"calendarId"`
+	Status       string     `json:"status"`
+	LastError    string     `json:"lastError,omitempty"`
+	BusySyncedAt *time.Time `json:"busySyncedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	// счётчики событий по статусам — для экрана настроек
+	Events map[string]int `json:"events,omitempty"`
+}
+
+// CalendarToken — OAuth-токены пользователя (oauth_tokens).
+type CalendarToken struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    *time.Time
+	Scope        string
+}
+
+// CalendarJob — взятое воркером событие урока вместе со всем, что нужно для вызова провайдера.
+type CalendarJob struct {
+	ID         string // calendar_events.id
+	LessonID   string
+	AccountID  string
+	UserID     string
+	Provider   string
+	CalendarID string
+	Status     string
+	EventID    string // "" — у провайдера ещё не создано
+	Revision   int
+	Attempts   int
+
+	Token CalendarToken
+
+	StartsAt    time.Time
+	Duration    time.Duration
+	Role        string // student | tutor — чей это календарь
+	PeerName    string
+	SubjectName string
+	Locale      string
+}
+
+// BusyInterval — занятое время пользователя: уроки и события внешнего календаря.
+type BusyInterval struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Source string    `json:"source"` // lesson | calendar
+}
+
+// FeedLinks — адреса ICS-подписки; availability — только у репетиторов.
+type FeedLinks struct {
+	Lessons            string `json:"lessons"`
+	LessonsWebcal      string `json:"lessonsWebcal"`
+	Availability       string `json:"availability,omitempty"`
+	AvailabilityWebcal string `json:"availabilityWebcal,omitempty"`
+}
+
+// FeedOwner — владелец ICS-ссылки.
+type FeedOwner struct {
+	UserID   string
+	Token    string
+	IsTutor  bool
+	Timezone string // IANA; "" — по умолчанию
+	Locale   string
+}
+
+// FeedLesson — урок в ICS-ленте участника.
+type FeedLesson struct {
+	ID          string
+	Status      string
+	StartsAt    time.Time
+	Duration    time.Duration
+	Role        string // student | tutor — роль владельца ленты
+	PeerName    string
+	SubjectName string
+	Sequence    int // секунды с создания до последнего изменения — монотонно растёт
+	UpdatedAt   time.Time
+}
+
+// WeeklySlot — еженедельный 30-минутный слот доступности из RRULE.
+type WeeklySlot struct {
+	Day    string // MO..SU
+	Minute int    // минута от начала дня в часовом поясе репетитора
+}
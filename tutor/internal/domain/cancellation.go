@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+var ErrInvalidCancellationRules = errors.New("invalid cancellation rules")
+
+// Кто отменяет урок (cancellations.initiator)
+const (
+	CancelByStudent = "student"
+	CancelByTutor   = "tutor"
+)
+
+// Что сделано с деньгами при отмене (outcome.paymentAction)
+const (
+	CancelPaymentNone   = "none"   // оплаты не было
+	CancelPaymentVoid   = "void"   // авторизация снята целиком
+	CancelPaymentRefund = "refund" // возврат (полный или частичный) по списанному платежу
+)
+
+// CancellationRules — содержимое cancellation_policies.rules. Для каждой стороны — ступени
+// "отмена не позже чем за MinHoursBefore часов до начала → RefundPercent". Подходит ступень
+// с наибольшим порогом, который выполнен; если ни одна не подошла — возврата нет.
+type CancellationRules struct {
+	Student []CancellationTier `json:"student"`
+	Tutor   []CancellationTier `json:"tutor"`
+}
+
+type CancellationTier struct {
+	MinHoursBefore float64 `json:"minHoursBefore"`
+	RefundPercent  float64 `json:"refundPercent"`
+}
+
+// CancellationPolicy неизменяема: правка создаёт новую версию, прежняя остаётся
+// за бронями, на которых она зафиксирована (SupersededBy — id следующей версии).
+type CancellationPolicy struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	IsDefault    bool              `json:"isDefault"`
+	Version      int               `json:"version"`
+	Rules        CancellationRules `json:"rules"`
+	SupersededBy string            `json:"supersededBy,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+}
+
+// CancellationOutcome — результат применения политики (cancellations.outcome).
+type CancellationOutcome struct {
+	Initiator     string    `json:"initiator"`
+	PolicyID      string    `json:"policyId"`
+	PolicyName    string    `json:"policyName"`
+	HoursBefore   float64   `json:"hoursBefore"`
+	RefundPercent float64   `json:"refundPercent"`
+	PaymentID     string    `json:"paymentId,omitempty"`
+	PaidMinor     int64     `json:"paidMinor"`
+	RefundMinor   int64     `json:"refundMinor"`
+	RetainedMinor int64     `json:"retainedMinor"` // остаётся репетитору как плата за позднюю отмену
+	Currency      string    `json:"currency,omitempty"`
+	PaymentAction string    `json:"paymentAction"`
+	RefundID      string    `json:"refundId,omitempty"`
+	Error         string    `json:"error,omitempty"` // деньги не удалось вернуть автоматически
+	EvaluatedAt   time.Time `json:"evaluatedAt"`
+}
+
+type Cancellation struct {
+	ID             string              `json:"id"`
+	BookingID      string              `json:"bookingId"`
+	LessonID       string              `json:"lessonId,omitempty"`
+	RequestedBy    string              `json:"requestedBy"`
+	Initiator      string              `json:"initiator"`
+	Reason         string              `json:"reason,omitempty"`
+	PolicyID       string              `json:"policyId,omitempty"`
+	Outcome        CancellationOutcome `json:"outcome"`
+	SettleAttempts int                 `json:"settleAttempts,omitempty"` // повторы неудавшегося возврата воркером
+	CreatedAt      time.Time           `json:"createdAt"`
+}
+
+func (r *CancellationRules) Validate() error {
+	for side, tiers := range map[string][]CancellationTier{CancelByStudent: r.Student, CancelByTutor: r.Tutor} {
+		for i, t := range tiers {
+			if t.MinHoursBefore < 0 || math.IsNaN(t.MinHoursBefore) || !validPercent(t.RefundPercent) {
+				return fmt.Errorf("%w: %s[%d] needs minHoursBefore >= 0 and refundPercent within 0..100",
+					ErrInvalidCancellationRules, side, i)
+			}
+		}
+	}
+	byThreshold := func(ts []CancellationTier) {
+		sort.Slice(ts, func(i, j int) bool { return ts[i].MinHoursBefore > ts[j].MinHoursBefore })
+	}
+	byThreshold(r.Student)
+	byThreshold(r.Tutor)
+	return nil
+}
+
+// RefundPercent — процент возврата для стороны-инициатора при отмене за hoursBefore часов.
+func (r CancellationRules) RefundPercent(initiator string, hoursBefore float64) float64 {
+	tiers := r.Student
+	if initiator == CancelByTutor {
+		tiers = r.Tutor
+	}
+	best, pct := -1.0, 0.0
+	for _, t := range tiers {
+		if hoursBefore >= t.MinHoursBefore && t.MinHoursBefore > best {
+			best, pct = t.MinHoursBefore, t.RefundPercent
+		}
+	}
+	return pct
+}
+
+// Evaluate применяет политику к брони; paidMinor — сколько студент фактически заплатил
+// (за вычетом уже оформленных возвратов).
+func (p CancellationPolicy) Evaluate(initiator string, startsAt, now time.Time, paidMinor int64) CancellationOutcome {
+	hours := startsAt.Sub(now).Hours()
+	pct := p.Rules.RefundPercent(initiator, hours)
+	refund := int64(math.Round(float64(paidMinor) * pct / 100))
+	return CancellationOutcome{
+		Initiator:     initiator,
+		PolicyID:      p.ID,
+		PolicyName:    p.Name,
+		HoursBefore:   math.Round(hours*100) / 100,
+		RefundPercent: pct,
+		PaidMinor:     paidMinor,
+		RefundMinor:   refund,
+		RetainedMinor: paidMinor - refund,
+		PaymentAction: CancelPaymentNone,
+		EvaluatedAt:   now,
+	}
+}
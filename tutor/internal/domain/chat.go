@@ -0,0 +1,90 @@
+package domain
+
+import "time"
+
+// Статусы диалога (conversations.status)
+const (
+	ConversationPending = "pending" // запрос студента, ждёт решения репетитора
+	ConversationActive  = "active"
+	ConversationBlocked = "blocked"
+)
+
+// Типы realtime-событий чата
+const (
+	ChatEventMessage      = "message"
+	ChatEventRead         = "read"
+	ChatEventTyping       = "typing"
+	ChatEventConversation = "conversation" // смена статуса диалога
+)
+
+type Conversation struct {
+	ID            string     `json:"id"`
+	StudentID     string     `json:"studentId"`
+	TutorID       string     `json:"tutorId"`
+	Status        string     `json:"status"`
+	InitiatorID   string     `json:"initiatorId,omitempty"`
+	ApprovedAt    *time.Time `json:"approvedAt,omitempty"`
+	BlockedBy     string     `json:"blockedBy,omitempty"`
+	LastMessageAt *time.Time `json:"lastMessageAt,omitempty"`
+	UnreadCount   int        `json:"unreadCount"`
+	PeerReadAt    *time.Time `json:"peerReadAt,omitempty"` // до какого момента прочитал собеседник
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// Participant — участвует ли пользователь в диалоге.
+func (c Conversation) Participant(userID string) bool {
+	return c.StudentID == userID || c.TutorID == userID
+}
+
+// Peer — собеседник пользователя в диалоге.
+func (c Conversation) Peer(userID string) string {
+	if c.StudentID == userID {
+		return c.TutorID
+	}
+	return c.StudentID
+}
+
+type Message struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationId"`
+	SenderID       string    `json:"senderId"`
+	Body           string    `json:"body"`               // то, что видят участники: body_redacted, если есть
+	Original       string    `json:"original,omitempty"` // исходный текст — только модераторам
+	Redacted       bool      `json:"redacted"`
+	Redactions     []string  `json:"redactions,omitempty"` // виды скрытых контактов (meta.redactions)
+	Hidden         bool      `json:"hidden"`               // скрыто модератором по жалобе
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Public — сообщение без исходного текста, для участников диалога.
+func (m Message) Public() Message {
+	m.Original = ""
+	if m.Hidden {
+		m.Body, m.Redactions = "", nil
+	}
+	return m
+}
+
+// ChatOffender — пользователь, систематически отправляющий контакты в чат.
+type ChatOffender struct {
+	UserID          string     `json:"userId"`
+	Violations      int        `json:"violations"`
+	LastViolationAt time.Time  `json:"lastViolationAt"`
+	FlaggedAt       *time.Time `json:"flaggedAt,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewedAt,omitempty"`
+	ReviewedBy      string     `json:"reviewedBy,omitempty"`
+	ReviewNote      string     `json:"reviewNote,omitempty"`
+}
+
+// ChatEvent — событие для подписчиков; между инстансами ходит через NOTIFY,
+// поэтому сообщение передаётся только идентификатором и дочитывается из БД.
+type ChatEvent struct {
+	Type           string    `json:"type"`
+	ConversationID string    `json:"conversationId"`
+	UserID         string    `json:"userId,omitempty"` // автор события
+	MessageID      string    `json:"messageId,omitempty"`
+	Status         string    `json:"status,omitempty"` // для conversation
+	At             time.Time `json:"at"`
+	Recipients     []string  `json:"recipients,omitempty"`
+	Message        *Message  `json:"message,omitempty"`
+}
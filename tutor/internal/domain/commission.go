@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCommissionRules = errors.New("invalid commission rules")
+
+// CommissionRules — содержимое commission_rules.rules. Проценты — от стоимости урока.
+// Порядок применения: тир по объёму → переопределение предмета → пробный урок → промо
+// (промо и пробный только снижают ставку).
+type CommissionRules struct {
+	Currency       string             `json:"currency"` // валюта порогов тиров
+	DefaultPercent float64            `json:"defaultPercent"`
+	Tiers          []CommissionTier   `json:"tiers,omitempty"`
+	TrialPercent   *float64           `json:"trialPercent,omitempty"`
+	Subjects       map[string]float64 `json:"subjects,omitempty"` // slug предмета/поднаправления → процент
+	Promos         []CommissionPromo  `json:"promos,omitempty"`
+}
+
+type CommissionTier struct {
+	MinVolumeMinor int64   `json:"minVolumeMinor"` // накопленный объём репетитора до урока
+	Percent        float64 `json:"percent"`
+}
+
+type CommissionPromo struct {
+	Name     string    `json:"name"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Percent  float64   `json:"percent"`
+	Subjects []string  `json:"subjects,omitempty"` // пусто — на все предметы
+}
+
+type CommissionRuleSet struct {
+	Version       string          `json:"version"`
+	EffectiveFrom time.Time       `json:"effectiveFrom"`
+	Rules         CommissionRules `json:"rules"`
+	CreatedBy     string          `json:"createdBy,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// CommissionInput — параметры урока для расчёта.
+type CommissionInput struct {
+	GrossMinor   int64
+	Currency     string
+	Trial        bool
+	SubjectSlugs []string // предмет и/или поднаправление урока
+	VolumeMinor  int64    // объём репетитора в Rules.Currency
+	At           time.Time
+}
+
+type Commission struct {
+	ID              string         `json:"id"`
+	LessonID        string         `json:"lessonId"`
+	CommissionMinor int64          `json:"commissionMinor"`
+	TutorEarnMinor  int64          `json:"tutorEarnMinor"`
+	Currency        string         `json:"currency"`
+	RuleVersion     string         `json:"ruleVersion"`
+	Details         map[string]any `json:"details"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+func validPercent(p float64) bool { return p >= 0 && p <= 100 && !math.IsNaN(p) }
+
+func (r *CommissionRules) Validate() error {
+	r.Currency = NormalizeCurrency(r.Currency)
+	if !IsValidCurrency(r.Currency) {
+		return fmt.Errorf("%w: currency %q", ErrInvalidCommissionRules, r.Currency)
+	}
+	if !validPercent(r.DefaultPercent) {
+		return fmt.Errorf("%w: defaultPercent must be within 0..100", ErrInvalidCommissionRules)
+	}
+	for i, t := range r.Tiers {
+		if t.MinVolumeMinor < 0 || !validPercent(t.Percent) {
+			return fmt.Errorf("%w: tiers[%d]", ErrInvalidCommissionRules, i)
+		}
+	}
+	sort.Slice(r.Tiers, func(i, j int) bool { return r.Tiers[i].MinVolumeMinor < r.Tiers[j].MinVolumeMinor })
+	if r.TrialPercent != nil && !validPercent(*r.TrialPercent) {
+		return fmt.Errorf("%w: trialPercent must be within 0..100", ErrInvalidCommissionRules)
+	}
+	subjects := make(map[string]float64, len(r.Subjects))
+	for slug, p := range r.Subjects {
+		if !validPercent(p) {
+			return fmt.Errorf("%w: subjects[%s]", ErrInvalidCommissionRules, slug)
+		}
+		subjects[strings.ToLower(strings.TrimSpace(slug))] = p
+	}
+	r.Subjects = subjects
+	for i, p := range r.Promos {
+		if p.From.IsZero() || p.To.IsZero() || !p.To.After(p.From) || !validPercent(p.Percent) {
+			return fmt.Errorf("%w: promos[%d] needs from < to and percent within 0..100", ErrInvalidCommissionRules, i)
+		}
+		for j := range p.Subjects {
+			r.Promos[i].Subjects[j] = strings.ToLower(strings.TrimSpace(p.Subjects[j]))
+		}
+	}
+	return nil
+}
+
+// Apply считает комиссию и возвращает расшифровку для commissions.details.
+func (r CommissionRules) Apply(in CommissionInput) (int64, map[string]any) {
+	pct := r.DefaultPercent
+	details := map[string]any{"grossMinor": in.GrossMinor, "volumeMinor": in.VolumeMinor, "basis": "default"}
+
+	for _, t := range r.Tiers {
+		if in.VolumeMinor >= t.MinVolumeMinor {
+			pct = t.Percent
+			details["basis"] = "tier"
+			details["tierMinVolumeMinor"] = t.MinVolumeMinor
+		}
+	}
+	for _, slug := range in.SubjectSlugs {
+		if p, ok := r.Subjects[slug]; ok {
+			pct = p
+			details["basis"] = "subject"
+			details["subject"] = slug
+			break
+		}
+	}
+	if in.Trial && r.TrialPercent != nil && *r.TrialPercent < pct {
+		pct = *r.TrialPercent
+		details["basis"] = "trial"
+	}
+	for _, p := range r.Promos {
+		if in.At.Before(p.From) || !in.At.Before(p.To) || p.Percent >= pct || !promoMatches(p, in.SubjectSlugs) {
+			continue
+		}
+		pct = p.Percent
+		details["basis"] = "promo"
+		details["promo"] = p.Name
+	}
+
+	commission := int64(math.Round(float64(in.GrossMinor) * pct / 100))
+	details["percent"] = pct
+	return commission, details
+}
+
+func promoMatches(p CommissionPromo, slugs []string) bool {
+	if len(p.Subjects) == 0 {
+		return true
+	}
+	for _, s := range p.Subjects {
+		for _, slug := range slugs {
+			if s == slug {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Виды записей анкеты, которые проверяются по отдельности.
+const (
+	CredentialEducation   = "education"
+	CredentialCertificate = "certificate"
+)
+
+// Статусы проверки записи (как у анкеты в целом).
+const (
+	CredentialPending  = "pending"
+	CredentialVerified = "verified"
+	CredentialRejected = "rejected"
+)
+
+// Document — скан диплома или сертификата. Файл лежит в хранилище под Key и отдаётся
+// только владельцу и админам.
+type Document struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+	Key         string    `json:"-"`
+}
+
+// CredentialReview — результат проверки записи админом; правка записи или новый документ
+// возвращают её в pending.
+type CredentialReview struct {
+	Verification     string     `json:"verification,omitempty"`
+	VerificationNote string     `json:"verificationNote,omitempty"`
+	VerifiedAt       *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// CredentialEntry — запись об образовании или сертификат в очереди проверки.
+type CredentialEntry struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	TutorID   string    `json:"tutorId"`
+	TutorName string    `json:"tutorName"`
+	Title     string    `json:"title"`    // вуз / название сертификата
+	Subtitle  string    `json:"subtitle"` // степень и специальность / кем выдан
+	Year      string    `json:"year"`
+	Document  *Document `json:"document,omitempty"`
+	CredentialReview
+	VerifiedBy string    `json:"verifiedBy,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type CredentialFilter struct {
+	Kind        string // пусто — оба вида
+	Status      string // пусто — pending
+	TutorID     string
+	HasDocument bool // только записи с приложенным документом
+}
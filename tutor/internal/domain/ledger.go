@@ -0,0 +1,225 @@
+package domain
+
+import "time"
+
+// Владельцы кошельков (wallets.owner_type)
+const (
+	OwnerTutor    = "tutor"
+	OwnerStudent  = "student"
+	OwnerPlatform = "platform"
+)
+
+// Служебные кошельки платформы (owner_type=platform, owner_id — фиксированный UUID).
+const (
+	PlatformGateway = "00000000-0000-0000-0000-000000000001" // деньги у платёжного провайдера (зеркало внешнего мира)
+	PlatformEscrow  = "00000000-0000-0000-0000-000000000002" // оплачено, урок ещё не проведён
+	PlatformRevenue = "00000000-0000-0000-0000-000000000003" // комиссия платформы
+	PlatformPayouts = "00000000-0000-0000-0000-000000000004" // зарезервировано под выплаты репетиторам
+)
+
+const (
+	EntryCredit = "credit"
+	EntryDebit  = "debit"
+)
+
+// Причины проводок (wallet_entries.reason)
+const (
+	ReasonPaymentCaptured = "payment_captured"
+	ReasonLessonHold      = "lesson_hold"
+	ReasonLessonEarning   = "lesson_earning"
+	ReasonCommission      = "commission"
+	ReasonRefund          = "refund"
+	ReasonRefundOut       = "refund_out"
+	ReasonCancellationFee = "cancellation_fee"
+	ReasonPayoutReserved  = "payout_reserved"
+	ReasonPayoutPaid      = "payout_paid"
+	ReasonPayoutReleased  = "payout_released"
+)
+
+type Wallet struct {
+	ID           string    `json:"id"`
+	OwnerType    string    `json:"ownerType"`
+	OwnerID      string    `json:"ownerId"`
+	Currency     string    `json:"currency"`
+	BalanceMinor int64     `json:"balanceMinor"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type WalletEntry struct {
+	ID           string    `json:"id"`
+	WalletID     string    `json:"walletId"`
+	TxnID        string    `json:"txnId,omitempty"`
+	TxnKind      string    `json:"txnKind,omitempty"`
+	LessonID     string    `json:"lessonId,omitempty"`
+	PaymentID    string    `json:"paymentId,omitempty"`
+	Type         string    `json:"type"`
+	Reason       string    `json:"reason"`
+	AmountMinor  int64     `json:"amountMinor"`
+	BalanceAfter int64     `json:"balanceAfter"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// LedgerPosting — одна нога проводки. Баланс кошелька = сумма credit − сумма debit.
+type LedgerPosting struct {
+	OwnerType   string
+	OwnerID     string
+	Type        string
+	Reason      string
+	AmountMinor int64
+}
+
+// LedgerTxn — сбалансированная проводка; Key делает повторную запись no-op.
+type LedgerTxn struct {
+	Key       string
+	Kind      string
+	Currency  string
+	PaymentID string
+	LessonID  string
+	PayoutID  string
+	Postings  []LedgerPosting
+}
+
+func (t LedgerTxn) Balanced() bool {
+	var credit, debit int64
+	for _, p := range t.Postings {
+		if p.AmountMinor <= 0 {
+			return false
+		}
+		switch p.Type {
+		case EntryCredit:
+			credit += p.AmountMinor
+		case EntryDebit:
+			debit += p.AmountMinor
+		default:
+			return false
+		}
+	}
+	return len(t.Postings) > 0 && credit == debit
+}
+
+// LedgerIssue — расхождение, найденное проверкой целостности.
+type LedgerIssue struct {
+	Kind     string `json:"kind"` // balance_after | wallet_balance | unbalanced_txn
+	WalletID string `json:"walletId,omitempty"`
+	TxnID    string `json:"txnId,omitempty"`
+	EntryID  string `json:"entryId,omitempty"`
+	Expected int64  `json:"expected"`
+	Actual   int64  `json:"actual"`
+}
+
+func move(from, to LedgerPosting, amount int64) []LedgerPosting {
+	from.Type, from.AmountMinor = EntryDebit, amount
+	to.Type, to.AmountMinor = EntryCredit, amount
+	return []LedgerPosting{from, to}
+}
+
+func platform(id, reason string) LedgerPosting {
+	return LedgerPosting{OwnerType: OwnerPlatform, OwnerID: id, Reason: reason}
+}
+
+// CaptureTxn: деньги пришли от провайдера на счёт студента и сразу зарезервированы под урок.
+func CaptureTxn(p Payment) LedgerTxn {
+	student := LedgerPosting{OwnerType: OwnerStudent, OwnerID: p.StudentID}
+	in := student
+	in.Reason = ReasonPaymentCaptured
+	hold := student
+	hold.Reason = ReasonLessonHold
+
+	postings := move(platform(PlatformGateway, ReasonPaymentCaptured), in, p.AmountMinor)
+	postings = append(postings, move(hold, platform(PlatformEscrow, ReasonLessonHold), p.AmountMinor)...)
+	return LedgerTxn{
+		Key: "payment:" + p.ID + ":capture", Kind: "payment_capture", Currency: p.Currency,
+		PaymentID: p.ID, LessonID: p.LessonID, Postings: postings,
+	}
+}
+
+// CommissionTxn: урок проведён — резерв распределяется между репетитором и платформой.
+// netMinor — то, что осталось в резерве: оплата за вычетом возвратов.
+func CommissionTxn(p Payment, tutorID string, netMinor, commissionMinor int64) LedgerTxn {
+	tutorEarn := netMinor - commissionMinor
+	var postings []LedgerPosting
+	if tutorEarn > 0 {
+		postings = append(postings, move(platform(PlatformEscrow, ReasonLessonEarning),
+			LedgerPosting{OwnerType: OwnerTutor, OwnerID: tutorID, Reason: ReasonLessonEarning}, tutorEarn)...)
+	}
+	if commissionMinor > 0 {
+		postings = append(postings, move(platform(PlatformEscrow, ReasonCommission),
+			platform(PlatformRevenue, ReasonCommission), commissionMinor)...)
+	}
+	return LedgerTxn{
+		Key: "lesson:" + p.LessonID + ":commission", Kind: "lesson_commission", Currency: p.Currency,
+		PaymentID: p.ID, LessonID: p.LessonID, Postings: postings,
+	}
+}
+
+// RefundSplit — откуда списывается возврат: из резерва (урок не проведён)
+// или обратно с репетитора и платформы (после распределения).
+type RefundSplit struct {
+	Escrow   int64
+	Tutor    int64
+	Platform int64
+	TutorID  string
+}
+
+func (s RefundSplit) Total() int64 { return s.Escrow + s.Tutor + s.Platform }
+
+// RefundTxn: сумма возвращается на счёт студента и уходит обратно провайдеру.
+func RefundTxn(key string, p Payment, s RefundSplit) LedgerTxn {
+	student := LedgerPosting{OwnerType: OwnerStudent, OwnerID: p.StudentID, Reason: ReasonRefund}
+	var postings []LedgerPosting
+	if s.Escrow > 0 {
+		postings = append(postings, move(platform(PlatformEscrow, ReasonRefund), student, s.Escrow)...)
+	}
+	if s.Tutor > 0 {
+		postings = append(postings, move(LedgerPosting{OwnerType: OwnerTutor, OwnerID: s.TutorID, Reason: ReasonRefund},
+			student, s.Tutor)...)
+	}
+	if s.Platform > 0 {
+		postings = append(postings, move(platform(PlatformRevenue, ReasonRefund), student, s.Platform)...)
+	}
+	out := student
+	out.Reason = ReasonRefundOut
+	postings = append(postings, move(out, platform(PlatformGateway, ReasonRefundOut), s.Total())...)
+	return LedgerTxn{
+		Key: key, Kind: "refund", Currency: p.Currency,
+		PaymentID: p.ID, LessonID: p.LessonID, Postings: postings,
+	}
+}
+
+// CancellationFeeTxn: поздняя отмена — невозвращённая часть резерва уходит репетитору.
+func CancellationFeeTxn(cancellationID string, p Payment, tutorID string, amountMinor int64) LedgerTxn {
+	return LedgerTxn{
+		Key: "cancellation:" + cancellationID + ":fee", Kind: "cancellation_fee", Currency: p.Currency,
+		PaymentID: p.ID, LessonID: p.LessonID,
+		Postings: move(platform(PlatformEscrow, ReasonCancellationFee),
+			LedgerPosting{OwnerType: OwnerTutor, OwnerID: tutorID, Reason: ReasonCancellationFee}, amountMinor),
+	}
+}
+
+func payoutOwner(p Payout, reason string) LedgerPosting {
+	return LedgerPosting{OwnerType: p.OwnerType, OwnerID: p.OwnerID, Reason: reason}
+}
+
+// PayoutReserveTxn: при запросе сумма сразу уходит с баланса владельца в резерв выплат.
+func PayoutReserveTxn(p Payout) LedgerTxn {
+	return LedgerTxn{
+		Key: "payout:" + p.ID + ":reserve", Kind: "payout_reserve", Currency: p.Currency, PayoutID: p.ID,
+		Postings: move(payoutOwner(p, ReasonPayoutReserved), platform(PlatformPayouts, ReasonPayoutReserved), p.AmountMinor),
+	}
+}
+
+// PayoutPaidTxn: провайдер перевёл деньги — резерв уходит во внешний мир.
+func PayoutPaidTxn(p Payout) LedgerTxn {
+	return LedgerTxn{
+		Key: "payout:" + p.ID + ":paid", Kind: "payout_paid", Currency: p.Currency, PayoutID: p.ID,
+		Postings: move(platform(PlatformPayouts, ReasonPayoutPaid), platform(PlatformGateway, ReasonPayoutPaid), p.AmountMinor),
+	}
+}
+
+// PayoutReleaseTxn: выплата отклонена/отменена — резерв возвращается владельцу.
+func PayoutReleaseTxn(p Payout) LedgerTxn {
+	return LedgerTxn{
+		Key: "payout:" + p.ID + ":release", Kind: "payout_release", Currency: p.Currency, PayoutID: p.ID,
+		Postings: move(platform(PlatformPayouts, ReasonPayoutReleased), payoutOwner(p, ReasonPayoutReleased), p.AmountMinor),
+	}
+}
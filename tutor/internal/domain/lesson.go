@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// Статусы урока (lessons.status)
+const (
+	LessonScheduled  = "scheduled"
+	LessonInProgress = "in_progress"
+	LessonCompleted  = "completed"
+	LessonNoShow     = "no_show"
+	LessonCancelled  = "cancelled"
+)
+
+type Lesson struct {
+	ID               string     `json:"id"`
+	BookingID        string     `json:"bookingId,omitempty"`
+	StudentID        string     `json:"studentId"`
+	TutorID          string     `json:"tutorId"`
+	SubjectID        string     `json:"subjectId,omitempty"`
+	SubjectSlug      string     `json:"subjectSlug,omitempty"`
+	SubdirectionID   string     `json:"subdirectionId,omitempty"`
+	SubdirectionSlug string     `json:"subdirectionSlug,omitempty"`
+	Status           string     `json:"status"`
+	Trial            bool       `json:"trial"`
+	StartedAt        *time.Time `json:"startedAt,omitempty"` // для scheduled — плановое начало
+	EndedAt          *time.Time `json:"endedAt,omitempty"`
+	DurationSeconds  int        `json:"durationSeconds"`
+	PaymentID        string     `json:"paymentId,omitempty"` // authorized/captured платёж урока
+}
+
+// Slugs — предмет/поднаправление урока для правил комиссии.
+func (l Lesson) Slugs() []string {
+	out := []string{}
+	for _, s := range []string{l.SubdirectionSlug, l.SubjectSlug} {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// LessonReminder — наступившее напоминание участнику урока.
+type LessonReminder struct {
+	LessonID string
+	UserID   string
+	Role     string // student | tutor — кому напоминаем
+	PeerName string // имя второго участника
+	StartsAt time.Time
+	Lead     time.Duration
+	Timezone string // IANA; "" — часовой пояс по умолчанию
+	Locale   string
+}
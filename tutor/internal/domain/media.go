@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Виды медиа анкеты, загружаемых в хранилище.
+const (
+	MediaAvatar = "avatar"
+	MediaVideo  = "video"
+)
+
+// MediaUpload — разрешение на прямую загрузку файла в хранилище. Клиент отправляет файл
+// методом Method на UploadURL с заголовками Headers, затем передаёт URL (или Key)
+// в avatarUrl / videoUrl соответствующего шага анкеты.
+type MediaUpload struct {
+	Kind      string            `json:"kind"`
+	Key       string            `json:"key"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	UploadURL string            `json:"uploadUrl"`
+	Headers   map[string]string `json:"headers"`
+	MaxSize   int64             `json:"maxSize"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// Объекты жалоб (reports.target_type); жалоба на репетитора — target_type=user.
+const (
+	ReportTargetUser    = "user"
+	ReportTargetLesson  = "lesson"
+	ReportTargetMessage = "message"
+)
+
+// Статусы жалобы (reports.status)
+const (
+	ReportOpen     = "open"
+	ReportInReview = "in_review"
+	ReportResolved = "resolved"
+	ReportRejected = "rejected"
+)
+
+// Действия модератора при решении (reports.resolution_action)
+const (
+	ModerationNone        = "none"
+	ModerationWarn        = "warn"
+	ModerationBlockUser   = "block_user"
+	ModerationHideMessage = "hide_message"
+)
+
+var ModerationActions = map[string]bool{
+	ModerationNone: true, ModerationWarn: true, ModerationBlockUser: true, ModerationHideMessage: true,
+}
+
+type Report struct {
+	ID               string     `json:"id"`
+	ReporterID       string     `json:"reporterId"`
+	TargetType       string     `json:"targetType"`
+	TargetID         string     `json:"targetId"`
+	Reason           string     `json:"reason"`
+	Details          string     `json:"details,omitempty"`
+	Status           string     `json:"status"`
+	AssigneeID       string     `json:"assigneeId,omitempty"`
+	ResolutionAction string     `json:"resolutionAction,omitempty"`
+	ResolutionNote   string     `json:"resolutionNote,omitempty"`
+	ResolvedBy       string     `json:"resolvedBy,omitempty"`
+	ResolvedAt       *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+type ReportFilter struct {
+	Status     string
+	TargetType string
+	TargetID   string
+	AssigneeID string // "me" разворачивается в id модератора на уровне usecase
+	ReporterID string
+	Unassigned bool
+}
+
+// ModerationDecision — решение по жалобе; UserID — кого предупредить/заблокировать
+// (для жалоб на урок — одна из сторон урока).
+type ModerationDecision struct {
+	Status string `json:"status"` // resolved | rejected
+	Action string `json:"action"`
+	UserID string `json:"userId,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// reportTransitions — допустимые ручные переходы статуса жалобы.
+var reportTransitions = map[string][]string{
+	ReportOpen:     {ReportInReview, ReportResolved, ReportRejected},
+	ReportInReview: {ReportOpen, ReportResolved, ReportRejected},
+}
+
+func CanTransitionReport(from, to string) bool {
+	for _, s := range reportTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// Статусы уведомления (notifications.status)
+const (
+	NotificationQueued  = "queued"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+	NotificationSkipped = "skipped" // пользователь отключил категорию
+)
+
+// NotificationPrefs — настройки пользователя из user-сервиса (users.notifications_*).
+type NotificationPrefs struct {
+	Lessons   bool `json:"lessons"`
+	Messages  bool `json:"messages"`
+	Reminders bool `json:"reminders"`
+}
+
+// Allows — разрешена ли категория шаблона; системные не отключаются.
+func (p NotificationPrefs) Allows(category string) bool {
+	switch category {
+	case "lessons":
+		return p.Lessons
+	case "messages":
+		return p.Messages
+	case "reminders":
+		return p.Reminders
+	}
+	return true
+}
+
+type Notification struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"userId"`
+	Channel     string         `json:"channel"`
+	TemplateKey string         `json:"templateKey"`
+	Payload     map[string]any `json:"payload"`
+	Status      string         `json:"status"`
+	Attempts    int            `json:"attempts"`
+	LastError   string         `json:"lastError,omitempty"`
+	Subject     string         `json:"subject,omitempty"`
+	Body        string         `json:"body,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	SentAt      *time.Time     `json:"sentAt,omitempty"`
+}
+
+// NotificationJob — взятое в работу уведомление вместе с адресами и настройками получателя.
+type NotificationJob struct {
+	Notification
+	Email   string
+	Phone   string
+	Locale  string
+	Deleted bool
+	Prefs   NotificationPrefs
+	Devices []PushDevice
+}
+
+type PushDevice struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"userId"`
+	Platform  string    `json:"platform"` // fcm | apns
+	CreatedAt time.Time `json:"createdAt"`
+}
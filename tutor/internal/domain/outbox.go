@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы события outbox (outbox_events.status); failed — dead letter
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// Доменные события
+const (
+	EventUserRegistered        = "user.registered"
+	EventTutorProfileCompleted = "tutor.profile_completed"
+	EventBookingConfirmed      = "booking.confirmed"
+)
+
+type OutboxEvent struct {
+	ID          string          `json:"id"`
+	Seq         int64           `json:"seq"`
+	Aggregate   string          `json:"aggregate"`
+	AggregateID string          `json:"aggregateId"`
+	EventType   string          `json:"eventType"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	PublishedAt *time.Time      `json:"publishedAt,omitempty"`
+}
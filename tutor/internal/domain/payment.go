@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+// Статусы бронирования (bookings.status)
+const (
+	BookingPending         = "pending"
+	BookingAwaitingPayment = "awaiting_payment"
+	BookingConfirmed       = "confirmed"
+	BookingCancelled       = "cancelled"
+	BookingExpired         = "expired"
+)
+
+// Статусы платежа (payments.status)
+const (
+	PaymentRequiresAction = "requires_action"
+	PaymentAuthorized     = "authorized"
+	PaymentCaptured       = "captured"
+	PaymentFailed         = "failed"
+	PaymentRefunded       = "refunded"
+	PaymentVoided         = "voided"
+)
+
+type Booking struct {
+	ID             string    `json:"id"`
+	StudentID      string    `json:"studentId"`
+	TutorID        string    `json:"tutorId"`
+	SubjectID      string    `json:"subjectId,omitempty"`
+	SubdirectionID string    `json:"subdirectionId,omitempty"`
+	StartsAt       time.Time `json:"startsAt"`
+	EndsAt         time.Time `json:"endsAt"`
+	Status         string    `json:"status"`
+	Trial          bool      `json:"trial"` // metadata.trial
+}
+
+func (b Booking) DurationMinutes() int {
+	return int(b.EndsAt.Sub(b.StartsAt).Minutes())
+}
+
+type Payment struct {
+	ID               string    `json:"id"`
+	BookingID        string    `json:"bookingId,omitempty"`
+	LessonID         string    `json:"lessonId,omitempty"`
+	StudentID        string    `json:"studentId"`
+	AmountMinor      int64     `json:"amountMinor"`
+	Currency         string    `json:"currency"`
+	Provider         string    `json:"provider"`
+	ProviderIntentID string    `json:"providerIntentId,omitempty"`
+	Status           string    `json:"status"`
+	ClientSecret     string    `json:"clientSecret,omitempty"` // provider_payload.client_secret
+	RedirectURL      string    `json:"redirectUrl,omitempty"`  // provider_payload.redirect_url
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type PaymentRefund struct {
+	ID          string    `json:"id"`
+	PaymentID   string    `json:"paymentId"`
+	AmountMinor int64     `json:"amountMinor"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// paymentTransitions — допустимые переходы payments.status
+var paymentTransitions = map[string][]string{
+	PaymentRequiresAction: {PaymentAuthorized, PaymentCaptured, PaymentFailed, PaymentVoided},
+	PaymentAuthorized:     {PaymentCaptured, PaymentVoided, PaymentFailed},
+	PaymentCaptured:       {PaymentRefunded},
+}
+
+func CanTransitionPayment(from, to string) bool {
+	for _, s := range paymentTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
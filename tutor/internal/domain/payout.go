@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// Статусы выплаты (payouts.status)
+const (
+	PayoutRequested  = "requested"
+	PayoutApproved   = "approved"
+	PayoutProcessing = "processing"
+	PayoutPaid       = "paid"
+	PayoutFailed     = "failed"
+	PayoutCancelled  = "cancelled"
+)
+
+// PayoutMethods — допустимые способы выплаты
+var PayoutMethods = map[string]bool{"bank_transfer": true, "card": true, "kaspi": true}
+
+type Payout struct {
+	ID            string         `json:"id"`
+	OwnerType     string         `json:"ownerType"`
+	OwnerID       string         `json:"ownerId"`
+	AmountMinor   int64          `json:"amountMinor"`
+	Currency      string         `json:"currency"`
+	Method        string         `json:"method"`
+	Destination   map[string]any `json:"destination"`
+	Status        string         `json:"status"`
+	RequestedBy   string         `json:"requestedBy"`
+	ApprovedBy    string         `json:"approvedBy,omitempty"`
+	Provider      string         `json:"provider,omitempty"`    // provider_payload.provider
+	ProviderRef   string         `json:"providerRef,omitempty"` // provider_payload.ref
+	FailureReason string         `json:"failureReason,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// PayoutBalance — сколько можно вывести: баланс минус заработок, ещё не прошедший hold.
+type PayoutBalance struct {
+	Currency       string `json:"currency"`
+	BalanceMinor   int64  `json:"balanceMinor"`
+	OnHoldMinor    int64  `json:"onHoldMinor"`
+	AvailableMinor int64  `json:"availableMinor"`
+	MinPayoutMinor int64  `json:"minPayoutMinor"`
+}
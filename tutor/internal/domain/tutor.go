@@ -4,26 +4,28 @@ import "time"
 
 // “Классы” домена
 type TutorProfile struct {
-	UserID       string            `json:"userId"`
-	FirstName    string            `json:"firstName"`
-	LastName     string            `json:"lastName"`
-	PhoneE164    string            `json:"phone"`
-	Gender       string            `json:"gender,omitempty"`
-	AvatarURL    string            `json:"avatar,omitempty"`
-	Languages    []TutorLanguage   `json:"languages"`
-	Bio          string            `json:"bio,omitempty"`
-	VideoURL     string            `json:"videoUrl,omitempty"`
-	Timezone     string            `json:"timezone,omitempty"`
-	Prices       map[string]int64  `json:"prices,omitempty"` // subdirection_slug -> regular price (minor)
-	TrialPrices  map[string]int64  `json:"trialPrices,omitempty"`
-	Education    []Education       `json:"education,omitempty"`
-	Certificates []Certification   `json:"certificates,omitempty"`
-	Subjects     []TutorSubjectDTO `json:"subjects,omitempty"`
-	RatingAvg    float32           `json:"ratingAvg"`
-	RatingCount  int               `json:"ratingCount"`
-	Verification string            `json:"verification"` // pending/verified/rejected
-	CreatedAt    time.Time         `json:"createdAt"`
-	UpdatedAt    time.Time         `json:"updatedAt"`
+	UserID            string            `json:"userId"`
+	FirstName         string            `json:"firstName"`
+	LastName          string            `json:"lastName"`
+	PhoneE164         string            `json:"phone"`
+	Gender            string            `json:"gender,omitempty"`
+	AvatarURL         string            `json:"avatar,omitempty"`
+	Languages         []TutorLanguage   `json:"languages"`
+	Bio               string            `json:"bio,omitempty"`
+	VideoURL          string            `json:"videoUrl,omitempty"`
+	Timezone          string            `json:"timezone,omitempty"`
+	Prices            map[string]int64  `json:"prices,omitempty"` // subdirection_slug -> regular price (minor)
+	TrialPrices       map[string]int64  `json:"trialPrices,omitempty"`
+	Education         []Education       `json:"education,omitempty"`
+	Certificates      []Certification   `json:"certificates,omitempty"`
+	Subjects          []TutorSubjectDTO `json:"subjects,omitempty"`
+	HourlyRate        *Money            `json:"hourlyRate,omitempty"`        // мин. цена разового 60-минутного занятия
+	HourlyRateDisplay *Money            `json:"hourlyRateDisplay,omitempty"` // то же в валюте студента
+	RatingAvg         float32           `json:"ratingAvg"`
+	RatingCount       int               `json:"ratingCount"`
+	Verification      string            `json:"verification"` // pending/verified/rejected
+	CreatedAt         time.Time         `json:"createdAt"`
+	UpdatedAt         time.Time         `json:"updatedAt"`
 }
 
 type TutorLanguage struct {
@@ -46,16 +48,57 @@ type AvailabilityDay struct {
 }
 
 type Education struct {
-	Degree      string `json:"degree"`
-	Institution string `json:"institution"`
-	Year        string `json:"year"`
-	Field       string `json:"field"`
+	ID           string `json:"id,omitempty"`
+	Degree       string `json:"degree"`
+	Institution  string `json:"institution"`
+	UniversityID string `json:"universityId,omitempty"` // universities.id; Institution — тогда его название
+	Year         string `json:"year"`
+	Field        string `json:"field"`
+	// University подставляется при чтении анкеты и не хранится
+	University *University `json:"university,omitempty"`
+	Document   *Document   `json:"document,omitempty"`
+	CredentialReview
 }
 
 type Certification struct {
-	Name   string `json:"name"`
-	Issuer string `json:"issuer"`
-	Year   string `json:"year"`
+	ID       string    `json:"id,omitempty"`
+	Name     string    `json:"name"`
+	Issuer   string    `json:"issuer"`
+	Year     string    `json:"year"`
+	Document *Document `json:"document,omitempty"`
+	CredentialReview
+}
+
+// Черновик анкеты для мастера онбординга
+type TutorDraft struct {
+	Profile      TutorProfile      `json:"profile"`
+	Availability []AvailabilityDay `json:"availability"`
+	CompletedAt  *time.Time        `json:"completedAt,omitempty"`
+	Steps        []WizardStep      `json:"steps"`
+	CanComplete  bool              `json:"canComplete"`
+}
+
+// Шаги мастера в порядке прохождения
+const (
+	StepAbout        = "about"
+	StepAvailability = "availability"
+	StepEducation    = "education"
+	StepSubjects     = "subjects"
+	StepVideo        = "video"
+	StepComplete     = "complete"
+)
+
+type WizardStep struct {
+	Key      string      `json:"key"`
+	Required bool        `json:"required"`
+	Valid    bool        `json:"valid"`
+	Errors   []StepError `json:"errors,omitempty"`
+}
+
+type StepError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"` // REQUIRED / INVALID / ...
+	Message string `json:"message"`
 }
 
 type Pagination struct {
@@ -0,0 +1,28 @@
+package domain
+
+// University — вуз из справочника universities; Label — название на языке запроса.
+type University struct {
+	ID          string         `json:"id"`
+	Slug        string         `json:"slug"`
+	Name        map[string]any `json:"name"`
+	Label       string         `json:"label,omitempty"`
+	CountryCode string         `json:"countryCode,omitempty"`
+	City        string         `json:"city,omitempty"`
+	Score       float64        `json:"score,omitempty"` // похожесть на запрос (pg_trgm), 0..1
+}
+
+// UniversityMatch — запись об образовании со свободным текстом и самый похожий вуз справочника.
+type UniversityMatch struct {
+	EducationID string     `json:"educationId"`
+	TutorID     string     `json:"tutorId"`
+	Institution string     `json:"institution"`
+	University  University `json:"university"`
+}
+
+// UniversityMatchReport — результат сопоставления; при dryRun Linked = 0.
+type UniversityMatchReport struct {
+	MinScore float64           `json:"minScore"`
+	DryRun   bool              `json:"dryRun"`
+	Matches  []UniversityMatch `json:"matches"`
+	Linked   int               `json:"linked"`
+}
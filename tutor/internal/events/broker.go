@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrUnknownBroker = errors.New("unknown event broker")
+
+// Event — доменное событие из outbox_events в том виде, в каком оно уходит в брокер.
+type Event struct {
+	ID          string          `json:"id"` // id строки outbox — ключ дедупликации у потребителей
+	Aggregate   string          `json:"aggregate"`
+	AggregateID string          `json:"aggregateId"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+}
+
+// Publisher — адаптер брокера. Publish возвращает nil только после подтверждения брокером;
+// доставка at-least-once, потребители дедуплицируют по Event.ID.
+type Publisher interface {
+	Name() string
+	Publish(ctx context.Context, ev Event) error
+	Close() error
+}
+
+// New создаёт адаптер по имени: inprocess | nats | redis | kafka.
+// prefix добавляется к subject/stream/topic: "alem." → "alem.booking".
+func New(broker, rawURL, prefix string, bus *Bus) (Publisher, error) {
+	switch strings.ToLower(broker) {
+	case "", "inprocess":
+		return bus, nil
+	case "nats":
+		return NewNATSPublisher(rawURL, prefix)
+	case "redis":
+		return NewRedisPublisher(rawURL, prefix)
+	case "kafka":
+		return NewKafkaRESTPublisher(rawURL, prefix), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownBroker, broker)
+}
+
+func parseURL(raw, defScheme, defPort string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = defScheme + "://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() == "" {
+		u.Host += ":" + defPort
+	}
+	return u, nil
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Handler обрабатывает событие; ошибка возвращает его в outbox на повтор.
+type Handler func(ctx context.Context, ev Event) error
+
+// Bus — брокер внутри процесса: подписчики вызываются синхронно из relay.
+// Годится для одного инстанса и dev; между сервисами — NATS/Redis/Kafka.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler // тип события или "*" → подписчики
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+func (b *Bus) Name() string { return "inprocess" }
+
+// Subscribe подписывает на тип события ("booking.confirmed") или на все ("*").
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+func (b *Bus) Publish(ctx context.Context, ev Event) error {
+	b.mu.RLock()
+	hs := append(append([]Handler(nil), b.handlers[ev.Type]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+	for _, h := range hs {
+		if err := h(ctx, ev); err != nil {
+			return fmt.Errorf("handler %s: %w", ev.Type, err)
+		}
+	}
+	return nil
+}
+
+func (b *Bus) Close() error { return nil }
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KafkaRESTPublisher — запись в Kafka через REST Proxy (Confluent API v2).
+// Topic — prefix + aggregate, ключ — aggregate id: события агрегата попадают
+// в одну партицию и читаются по порядку.
+type KafkaRESTPublisher struct {
+	baseURL string
+	prefix  string
+	client  *http.Client
+}
+
+func NewKafkaRESTPublisher(baseURL, prefix string) *KafkaRESTPublisher {
+	return &KafkaRESTPublisher{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		prefix:  prefix,
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *KafkaRESTPublisher) Name() string { return "kafka" }
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (p *KafkaRESTPublisher) Publish(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(map[string]any{
+		"records": []map[string]any{{"key": ev.AggregateID, "value": ev}},
+	})
+	if err != nil {
+		return err
+	}
+	u := p.baseURL + "/topics/" + url.PathEscape(p.prefix+ev.Aggregate)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("kafka rest: http %d: %s", resp.StatusCode, msg)
+	}
+	var out kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return err
+	}
+	for _, o := range out.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("kafka rest: partition %d: %s", o.Partition, o.Error)
+		}
+	}
+	return nil
+}
+
+func (p *KafkaRESTPublisher) Close() error { return nil }
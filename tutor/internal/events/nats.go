@@ -0,0 +1,146 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSPublisher — публикация по текстовому протоколу NATS (HPUB + PING/PONG как подтверждение).
+// Заголовок Nats-Msg-Id позволяет JetStream-стриму отбросить дубль при повторной отправке.
+// Subject: prefix + тип события, например "alem.booking.confirmed".
+type NATSPublisher struct {
+	addr   string
+	user   string
+	pass   string
+	token  string
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewNATSPublisher(rawURL, prefix string) (*NATSPublisher, error) {
+	u, err := parseURL(rawURL, "nats", "4222")
+	if err != nil {
+		return nil, err
+	}
+	p := &NATSPublisher{addr: u.Host, prefix: prefix}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			p.user, p.pass = u.User.Username(), pass
+		} else {
+			p.token = u.User.Username()
+		}
+	}
+	return p, nil
+}
+
+func (p *NATSPublisher) Name() string { return "nats" }
+
+func (p *NATSPublisher) Publish(ctx context.Context, ev Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.connect(ctx); err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = p.conn.SetDeadline(dl)
+	} else {
+		_ = p.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	hdr := "NATS/1.0\r\nNats-Msg-Id: " + ev.ID + "\r\n\r\n"
+	msg := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n", p.prefix+ev.Type, len(hdr), len(hdr)+len(body), hdr, body)
+	if _, err := p.conn.Write([]byte(msg)); err != nil {
+		p.reset()
+		return err
+	}
+	// PONG приходит после обработки всего, что было до PING: сообщение принято сервером
+	if err := p.waitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	p.conn, p.r = conn, bufio.NewReader(conn)
+
+	line, err := p.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		p.reset()
+		return fmt.Errorf("nats: unexpected greeting %q: %v", strings.TrimSpace(line), err)
+	}
+	opts := map[string]any{"verbose": false, "pedantic": false, "headers": true, "name": "tutor-outbox", "lang": "go"}
+	if p.user != "" {
+		opts["user"], opts["pass"] = p.user, p.pass
+	}
+	if p.token != "" {
+		opts["auth_token"] = p.token
+	}
+	connect, _ := json.Marshal(opts)
+	if _, err := fmt.Fprintf(p.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		p.reset()
+		return err
+	}
+	if err := p.waitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) waitPong() error {
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + line)
+		}
+		// +OK, INFO — пропускаем
+	}
+}
+
+func (p *NATSPublisher) reset() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn, p.r = nil, nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisPublisher — XADD в Redis Streams; стрим на агрегат (prefix + aggregate),
+// так что события одного агрегата лежат в одном стриме по порядку.
+type RedisPublisher struct {
+	addr   string
+	pass   string
+	db     int
+	prefix string
+	maxLen int // MAXLEN ~ — чтобы стрим не рос бесконечно
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewRedisPublisher(rawURL, prefix string) (*RedisPublisher, error) {
+	u, err := parseURL(rawURL, "redis", "6379")
+	if err != nil {
+		return nil, err
+	}
+	p := &RedisPublisher{addr: u.Host, prefix: prefix, maxLen: 1000000}
+	if u.User != nil {
+		p.pass, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if p.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis db %q: %w", db, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *RedisPublisher) Name() string { return "redis" }
+
+func (p *RedisPublisher) Publish(ctx context.Context, ev Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.connect(ctx); err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = p.conn.SetDeadline(dl)
+	} else {
+		_ = p.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+	_, err := p.do("XADD", p.prefix+ev.Aggregate, "MAXLEN", "~", strconv.Itoa(p.maxLen), "*",
+		"id", ev.ID, "type", ev.Type, "aggregateId", ev.AggregateID,
+		"occurredAt", ev.OccurredAt.UTC().Format(time.RFC3339Nano), "payload", string(ev.Payload))
+	if err != nil {
+		var re redisError
+		if !errors.As(err, &re) {
+			p.reset()
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *RedisPublisher) connect(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	p.conn, p.r = conn, bufio.NewReader(conn)
+	if p.pass != "" {
+		if _, err := p.do("AUTH", p.pass); err != nil {
+			p.reset()
+			return err
+		}
+	}
+	if p.db != 0 {
+		if _, err := p.do("SELECT", strconv.Itoa(p.db)); err != nil {
+			p.reset()
+			return err
+		}
+	}
+	return nil
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// do отправляет команду в RESP и читает простой ответ (строка, число, bulk или ошибка).
+func (p *RedisPublisher) do(args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := p.conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return "", err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(p.r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	}
+	return "", fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+func (p *RedisPublisher) reset() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn, p.r = nil, nil
+}
+
+func (p *RedisPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package ics — сборка iCalendar (RFC 5545) для подписок webcal:// в Apple Calendar,
+// Outlook и Google: события с часовыми поясами (VTIMEZONE), отмены, повторения.
+package ics
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+type Calendar struct {
+	Name     string
+	Location *time.Location // VTIMEZONE и время событий; nil — UTC
+	Refresh  time.Duration  // подсказка клиентам, как часто перечитывать подписку
+	Events   []Event
+}
+
+type Event struct {
+	UID          string
+	Start        time.Time
+	Duration     time.Duration
+	RRule        string      // без префикса "RRULE:", например FREQ=WEEKLY;BYDAY=MO
+	ExDates      []time.Time // выпавшие повторения RRULE — их начало, как у DTSTART
+	Summary      string
+	Description  string
+	Status       string // StatusConfirmed | StatusCancelled
+	Transparent  bool   // не занимает время (доступность, а не встреча)
+	Sequence     int    // растёт при каждом изменении — иначе клиенты не обновят событие
+	LastModified time.Time
+}
+
+// Encode — готовый текст с CRLF и свёрткой строк по 75 октетов.
+func (c Calendar) Encode() []byte {
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := time.Now().UTC()
+
+	var b bytes.Buffer
+	w := func(line string) { writeFolded(&b, line) }
+	w("BEGIN:VCALENDAR")
+	w("VERSION:2.0")
+	w("PRODID:-//Alem//Tutor Calendar//RU")
+	w("CALSCALE:GREGORIAN")
+	w("METHOD:PUBLISH")
+	if c.Name != "" {
+		w("X-WR-CALNAME:" + escape(c.Name))
+	}
+	w("X-WR-TIMEZONE:" + loc.String())
+	if c.Refresh > 0 {
+		w("REFRESH-INTERVAL;VALUE=DURATION:" + duration(c.Refresh))
+		// Outlook читает только этот вариант
+		w("X-PUBLISHED-TTL:" + duration(c.Refresh))
+	}
+	for _, line := range vtimezone(loc, now.AddDate(-1, 0, 0), now.AddDate(2, 0, 0)) {
+		w(line)
+	}
+
+	for _, e := range c.Events {
+		w("BEGIN:VEVENT")
+		w("UID:" + e.UID)
+		stamp := e.LastModified
+		if stamp.IsZero() {
+			stamp = now
+		}
+		w("DTSTAMP:" + utc(stamp))
+		w("LAST-MODIFIED:" + utc(stamp))
+		w("DTSTART;TZID=" + loc.String() + ":" + e.Start.In(loc).Format("20060102T150405"))
+		w("DURATION:" + duration(e.Duration))
+		if e.RRule != "" {
+			w("RRULE:" + e.RRule)
+		}
+		if len(e.ExDates) > 0 {
+			dates := make([]string, len(e.ExDates))
+			for i, d := range e.ExDates {
+				dates[i] = d.In(loc).Format("20060102T150405")
+			}
+			w("EXDATE;TZID=" + loc.String() + ":" + strings.Join(dates, ","))
+		}
+		w("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			w("DESCRIPTION:" + escape(e.Description))
+		}
+		status := e.Status
+		if status == "" {
+			status = StatusConfirmed
+		}
+		w("STATUS:" + status)
+		w(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		if e.Transparent {
+			w("TRANSP:TRANSPARENT")
+		} else {
+			w("TRANSP:OPAQUE")
+		}
+		w("END:VEVENT")
+	}
+	w("END:VCALENDAR")
+	return b.Bytes()
+}
+
+// vtimezone описывает все переходы пояса внутри окна явными STANDARD/DAYLIGHT —
+// без RRULE, зато точно совпадает с tzdata, по которой считается время событий.
+func vtimezone(loc *time.Location, from, to time.Time) []string {
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + loc.String()}
+	t := from.In(loc)
+	start, end := t.ZoneBounds()
+	_, prevOff := t.Zone()
+	if !start.IsZero() {
+		_, prevOff = start.Add(-time.Second).Zone()
+	}
+	for {
+		name, off := t.Zone()
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		dtstart := "19700101T000000"
+		if !start.IsZero() {
+			dtstart = start.In(time.FixedZone("", prevOff)).Format("20060102T150405")
+		}
+		lines = append(lines,
+			"BEGIN:"+kind,
+			"DTSTART:"+dtstart,
+			"TZOFFSETFROM:"+offset(prevOff),
+			"TZOFFSETTO:"+offset(off),
+		)
+		// сокращения вида "+05" клиенты показывают как есть — пропускаем
+		if name != "" && name[0] != '+' && name[0] != '-' {
+			lines = append(lines, "TZNAME:"+name)
+		}
+		lines = append(lines, "END:"+kind)
+
+		if end.IsZero() || end.After(to) {
+			break
+		}
+		prevOff = off
+		t = end.In(loc)
+		start, end = t.ZoneBounds()
+	}
+	return append(lines, "END:VTIMEZONE")
+}
+
+func offset(sec int) string {
+	sign := "+"
+	if sec < 0 {
+		sign, sec = "-", -sec
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, sec/3600, sec%3600/60)
+}
+
+func utc(t time.Time) string { return t.UTC().Format("20060102T150405Z") }
+
+// duration — PT1H30M; отрицательные и нулевые не бывают, минимум минута.
+func duration(d time.Duration) string {
+	if d < time.Minute {
+		d = time.Minute
+	}
+	s := "PT"
+	if h := int(d / time.Hour); h > 0 {
+		s += fmt.Sprintf("%dH", h)
+	}
+	if m := int(d % time.Hour / time.Minute); m > 0 {
+		s += fmt.Sprintf("%dM", m)
+	}
+	return s
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+func escape(s string) string { return escaper.Replace(s) }
+
+// writeFolded режет строку длиннее 75 октетов, не разрывая UTF-8 символы.
+func writeFolded(b *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // ведущий пробел продолжения тоже считается
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
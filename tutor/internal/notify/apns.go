@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APNsSender — Apple Push Notification service (HTTP/2, token-based auth ключом .p8).
+type APNsSender struct {
+	keyID  string
+	teamID string
+	topic  string // bundle id приложения
+	key    *ecdsa.PrivateKey
+	apiURL string
+	client *http.Client
+
+	mu       sync.Mutex
+	bearer   string
+	issuedAt time.Time
+}
+
+func NewAPNsSender(keyFile, keyID, teamID, topic string, sandbox bool) (*APNsSender, error) {
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("apns key: %w", err)
+	}
+	apiURL := "https://api.push.apple.com"
+	if sandbox {
+		apiURL = "https://api.sandbox.push.apple.com"
+	}
+	// http.Client сам договаривается об HTTP/2 по TLS — APNs иного не принимает
+	return &APNsSender{
+		keyID: keyID, teamID: teamID, topic: topic, key: key,
+		apiURL: apiURL,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (s *APNsSender) Send(ctx context.Context, token string, m Message) error {
+	bearer, err := s.token()
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{"title": m.Subject, "body": m.Body},
+			"sound": "default",
+		},
+		"data": pushData(m),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+bearer)
+	req.Header.Set("apns-topic", s.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-id", m.ID) // uuid — APNs дедуплицирует по нему
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		// 400 BadDeviceToken / 410 Unregistered — токен недействителен
+		return httpErr("apns", resp)
+	}
+	return nil
+}
+
+// token — JWT провайдера; Apple требует обновлять его не чаще раза в 20 минут и не реже раза в час.
+func (s *APNsSender) token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bearer != "" && time.Since(s.issuedAt) < 45*time.Minute {
+		return s.bearer, nil
+	}
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": s.teamID, "iat": now.Unix()})
+	t.Header["kid"] = s.keyID
+	signed, err := t.SignedString(s.key)
+	if err != nil {
+		return "", err
+	}
+	s.bearer, s.issuedAt = signed, now
+	return s.bearer, nil
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Каналы доставки — значения notifications.channel
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	ChannelInApp = "inapp"
+)
+
+// Платформы push-устройств
+const (
+	PlatformFCM  = "fcm"
+	PlatformAPNs = "apns"
+)
+
+var (
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	// ErrPermanent — повторять бессмысленно: нет адреса, провайдер отверг получателя и т.п.
+	ErrPermanent = errors.New("permanent delivery failure")
+)
+
+// Permanent помечает ошибку как неповторяемую.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %v", ErrPermanent, err)
+}
+
+type Device struct {
+	Platform string // fcm | apns
+	Token    string
+}
+
+// Message — уже отрендеренное уведомление с адресами получателя.
+type Message struct {
+	ID          string // notifications.id — ключ идемпотентности у провайдера
+	UserID      string
+	TemplateKey string
+	Locale      string
+	Email       string
+	Phone       string // E.164
+	Devices     []Device
+	Subject     string
+	Body        string
+	Data        map[string]any // исходный payload — для deep-link в push
+}
+
+// Channel — адаптер канала доставки. Ошибка, обёрнутая Permanent, не ретраится.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, m Message) error
+}
+
+// Registry — каналы по имени (имя хранится в notifications.channel).
+type Registry map[string]Channel
+
+func NewRegistry(channels ...Channel) Registry {
+	r := Registry{}
+	for _, c := range channels {
+		r[c.Name()] = c
+	}
+	return r
+}
+
+func (r Registry) Get(name string) (Channel, error) {
+	c, ok := r[name]
+	if !ok {
+		return nil, ErrUnknownChannel
+	}
+	return c, nil
+}
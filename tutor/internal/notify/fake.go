@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// FakeChannel — канал для dev и тестов: ничего не отправляет, складывает сообщения в память.
+// FailNext(n) заставляет следующие n отправок вернуть временную ошибку — для проверки ретраев.
+type FakeChannel struct {
+	name string
+	mu   sync.Mutex
+	sent []Message
+	fail int
+	max  int
+}
+
+func NewFakeChannel(name string) *FakeChannel {
+	return &FakeChannel{name: name, max: 500}
+}
+
+func (c *FakeChannel) Name() string { return c.name }
+
+func (c *FakeChannel) Send(_ context.Context, m Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail > 0 {
+		c.fail--
+		return errors.New("fake: simulated failure")
+	}
+	c.sent = append(c.sent, m)
+	if len(c.sent) > c.max {
+		c.sent = c.sent[len(c.sent)-c.max:]
+	}
+	log.Printf("[NOTIFY] fake %s → user=%s %q", c.name, m.UserID, m.Subject)
+	return nil
+}
+
+// Sent — копия перехваченных сообщений (последние 500).
+func (c *FakeChannel) Sent() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.sent...)
+}
+
+func (c *FakeChannel) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent, c.fail = nil, 0
+}
+
+func (c *FakeChannel) FailNext(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fail = n
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMSender — Firebase Cloud Messaging HTTP v1 с авторизацией сервисным аккаунтом.
+type FCMSender struct {
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	key         any // *rsa.PrivateKey
+	apiURL      string
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type fcmServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewFCMSender читает JSON сервисного аккаунта Firebase из файла.
+func NewFCMSender(credentialsFile string) (*FCMSender, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var sa fcmServiceAccount
+	if err := json.Unmarshal(raw, &sa); err != nil {
+		return nil, fmt.Errorf("fcm credentials: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sa.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("fcm private key: %w", err)
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &FCMSender{
+		projectID:   sa.ProjectID,
+		clientEmail: sa.ClientEmail,
+		tokenURI:    sa.TokenURI,
+		key:         key,
+		apiURL:      "https://fcm.googleapis.com",
+		client:      &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (s *FCMSender) Send(ctx context.Context, token string, m Message) error {
+	access, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(map[string]any{"message": map[string]any{
+		"token":        token,
+		"notification": map[string]string{"title": m.Subject, "body": m.Body},
+		"data":         pushData(m),
+	}})
+	u := fmt.Sprintf("%s/v1/projects/%s/messages:send", s.apiURL, s.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+access)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		s.mu.Lock()
+		s.accessToken = ""
+		s.mu.Unlock()
+		return errors.New("fcm: access token rejected")
+	}
+	if resp.StatusCode >= 300 {
+		// 404 UNREGISTERED / 400 INVALID_ARGUMENT — токен устройства больше не действителен
+		return httpErr("fcm", resp)
+	}
+	return nil
+}
+
+// token — OAuth2 access token по JWT-assertion сервисного аккаунта, кэшируется до истечения.
+func (s *FCMSender) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Now().Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.clientEmail,
+		"scope": fcmScope,
+		"aud":   s.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(s.key)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		// отказ в токене — проблема конфигурации, а не получателя: ретраим
+		return "", fmt.Errorf("fcm oauth: http %d", resp.StatusCode)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", err
+	}
+	s.accessToken = tok.AccessToken
+	s.expiresAt = now.Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return s.accessToken, nil
+}
@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+func tlsConfig(host string) *tls.Config {
+	return &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+}
+
+// httpErr: 4xx (кроме 408/429) — постоянный отказ, остальное — повторяем.
+func httpErr(provider string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	err := fmt.Errorf("%s: http %d: %s", provider, resp.StatusCode, body)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
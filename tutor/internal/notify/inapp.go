@@ -0,0 +1,13 @@
+package notify
+
+import "context"
+
+// InAppChannel — строка notifications с channel='inapp' сама и есть входящее уведомление,
+// внешней доставки нет: отправка лишь помечает её sent.
+type InAppChannel struct{}
+
+func NewInAppChannel() *InAppChannel { return &InAppChannel{} }
+
+func (c *InAppChannel) Name() string { return ChannelInApp }
+
+func (c *InAppChannel) Send(context.Context, Message) error { return nil }
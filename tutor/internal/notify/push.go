@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PushSender — провайдер push для одной платформы (FCM, APNs).
+type PushSender interface {
+	Send(ctx context.Context, token string, m Message) error
+}
+
+// PushChannel рассылает уведомление на все устройства пользователя.
+// Токены, отвергнутые провайдером, передаются в onInvalid — их нужно удалить.
+type PushChannel struct {
+	senders   map[string]PushSender // platform → sender
+	onInvalid func(ctx context.Context, token string)
+}
+
+func NewPushChannel(senders map[string]PushSender, onInvalid func(ctx context.Context, token string)) *PushChannel {
+	return &PushChannel{senders: senders, onInvalid: onInvalid}
+}
+
+func (c *PushChannel) Name() string { return ChannelPush }
+
+func (c *PushChannel) Send(ctx context.Context, m Message) error {
+	if len(m.Devices) == 0 {
+		return Permanent(errors.New("recipient has no push devices"))
+	}
+	delivered := 0
+	var lastErr error
+	for _, d := range m.Devices {
+		s, ok := c.senders[d.Platform]
+		if !ok {
+			continue
+		}
+		err := s.Send(ctx, d.Token, m)
+		switch {
+		case err == nil:
+			delivered++
+		case errors.Is(err, ErrPermanent):
+			if c.onInvalid != nil {
+				c.onInvalid(ctx, d.Token)
+			}
+		default:
+			lastErr = err
+		}
+	}
+	// хотя бы одно устройство получило — не ретраим, иначе остальные получат дубль
+	if delivered > 0 {
+		return nil
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return Permanent(fmt.Errorf("no deliverable push devices (%d rejected)", len(m.Devices)))
+}
+
+// pushData — payload в виде строк: FCM data принимает только string-значения.
+func pushData(m Message) map[string]string {
+	out := map[string]string{"notificationId": m.ID, "template": m.TemplateKey}
+	for k, v := range m.Data {
+		switch v := v.(type) {
+		case string:
+			out[k] = v
+		case nil:
+		default:
+			out[k] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SMSChannel — HTTP-шлюз SMS: POST {id, to, from, text} с Bearer-токеном.
+// Шлюз должен дедуплицировать по id — при ретрае тот же id уходит повторно.
+type SMSChannel struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+func NewSMSChannel(url, token, sender string) *SMSChannel {
+	return &SMSChannel{url: url, token: token, sender: sender, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (c *SMSChannel) Name() string { return ChannelSMS }
+
+func (c *SMSChannel) Send(ctx context.Context, m Message) error {
+	if m.Phone == "" {
+		return Permanent(errors.New("recipient has no phone"))
+	}
+	text := m.Body
+	if m.Subject != "" {
+		text = m.Subject + ": " + text
+	}
+	body, _ := json.Marshal(map[string]string{
+		"id": m.ID, "to": m.Phone, "from": c.sender, "text": strings.TrimSpace(text),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return httpErr("sms", resp)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPChannel — отправка писем через SMTP (STARTTLS, если сервер его поддерживает).
+type SMTPChannel struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string // "Alem <no-reply@alem.kz>"
+	timeout  time.Duration
+}
+
+func NewSMTPChannel(host string, port int, username, password, from string) *SMTPChannel {
+	if port == 0 {
+		port = 587
+	}
+	return &SMTPChannel{host: host, port: port, username: username, password: password, from: from, timeout: 15 * time.Second}
+}
+
+func (c *SMTPChannel) Name() string { return ChannelEmail }
+
+func (c *SMTPChannel) Send(ctx context.Context, m Message) error {
+	if m.Email == "" {
+		return Permanent(errors.New("recipient has no email"))
+	}
+	addr := net.JoinHostPort(c.host, strconv.Itoa(c.port))
+	d := net.Dialer{Timeout: c.timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	cl, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer cl.Close()
+
+	if ok, _ := cl.Extension("STARTTLS"); ok {
+		if err := cl.StartTLS(tlsConfig(c.host)); err != nil {
+			return err
+		}
+	}
+	if c.username != "" {
+		if err := cl.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return smtpErr(err)
+		}
+	}
+	if err := cl.Mail(envelope(c.from)); err != nil {
+		return smtpErr(err)
+	}
+	if err := cl.Rcpt(m.Email); err != nil {
+		return smtpErr(err)
+	}
+	w, err := cl.Data()
+	if err != nil {
+		return smtpErr(err)
+	}
+	if _, err := w.Write(c.compose(m)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpErr(err)
+	}
+	return cl.Quit()
+}
+
+func (c *SMTPChannel) compose(m Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", m.ID, c.host)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	enc := base64.StdEncoding.EncodeToString([]byte(m.Body))
+	for len(enc) > 76 {
+		b.WriteString(enc[:76] + "\r\n")
+		enc = enc[76:]
+	}
+	b.WriteString(enc + "\r\n")
+	return b.Bytes()
+}
+
+// envelope достаёт адрес из "Имя <addr>".
+func envelope(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
+
+// smtpErr: 5xx — постоянный отказ (нет ящика, отклонено), 4xx — временный.
+func smtpErr(err error) error {
+	var te *textproto.Error
+	if errors.As(err, &te) && te.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
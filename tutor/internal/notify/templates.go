@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"text/template"
+)
+
+// Категории — соответствуют настройкам пользователя Notifications{Lessons, Messages, Reminders}.
+// Системные уведомления (верификация, выплаты, модерация) отключить нельзя.
+const (
+	CategoryLessons   = "lessons"
+	CategoryMessages  = "messages"
+	CategoryReminders = "reminders"
+	CategorySystem    = "system"
+)
+
+const DefaultLocale = "ru"
+
+var ErrUnknownTemplate = errors.New("unknown notification template")
+
+// Locales — поддерживаемые языки шаблонов; первый — запасной.
+var Locales = []string{"ru", "kk", "en"}
+
+type Text struct {
+	Subject string // тема письма / заголовок push
+	Body    string
+}
+
+type Template struct {
+	Category string
+	Channels []string // каналы по умолчанию, если отправитель не указал свои
+	Texts    map[string]Text
+}
+
+// Templates — реестр шаблонов по template_key. Переменные — ключи payload.
+var Templates = map[string]Template{
+	"booking.confirmed": {
+		Category: CategoryLessons,
+		Channels: []string{ChannelInApp, ChannelEmail, ChannelPush},
+		Texts: map[string]Text{
+			"ru": {"Урок подтверждён", "Урок «{{.subject}}» с {{.peerName}} подтверждён на {{.startsAtLocal}}."},
+			"kk": {"Сабақ расталды", "{{.peerName}} бірге «{{.subject}}» сабағы {{.startsAtLocal}} уақытына расталды."},
+			"en": {"Lesson confirmed", "Your {{.subject}} lesson with {{.peerName}} is confirmed for {{.startsAtLocal}}."},
+		},
+	},
+	"lesson.cancelled": {
+		Category: CategoryLessons,
+		Channels: []string{ChannelInApp, ChannelEmail, ChannelPush},
+		Texts: map[string]Text{
+			"ru": {"Урок отменён", "Урок {{.startsAtLocal}} с {{.peerName}} отменён.{{if .reason}} Причина: {{.reason}}.{{end}}"},
+			"kk": {"Сабақ тоқтатылды", "{{.peerName}} бірге {{.startsAtLocal}} сабағы тоқтатылды.{{if .reason}} Себебі: {{.reason}}.{{end}}"},
+			"en": {"Lesson cancelled", "Your lesson with {{.peerName}} on {{.startsAtLocal}} was cancelled.{{if .reason}} Reason: {{.reason}}.{{end}}"},
+		},
+	},
+	"lesson.reminder": {
+		Category: CategoryReminders,
+		Channels: []string{ChannelInApp, ChannelPush, ChannelEmail},
+		Texts: map[string]Text{
+			"ru": {"Скоро урок", "Урок с {{.peerName}} начнётся {{.startsAtLocal}} (через {{.leadTime}})."},
+			"kk": {"Сабақ жақында", "{{.peerName}} бірге сабақ {{.startsAtLocal}} басталады ({{.leadTime}} кейін)."},
+			"en": {"Upcoming lesson", "Your lesson with {{.peerName}} starts at {{.startsAtLocal}} (in {{.leadTime}})."},
+		},
+	},
+	"chat.request": {
+		Category: CategoryMessages,
+		Channels: []string{ChannelInApp, ChannelPush},
+		Texts: map[string]Text{
+			"ru": {"Новый запрос на переписку", "{{.peerName}} хочет начать с вами переписку."},
+			"kk": {"Хат алмасуға жаңа сұраныс", "{{.peerName}} сізбен хат алмасуды бастағысы келеді."},
+			"en": {"New conversation request", "{{.peerName}} wants to start a conversation with you."},
+		},
+	},
+	"chat.message": {
+		Category: CategoryMessages,
+		Channels: []string{ChannelInApp, ChannelPush},
+		Texts: map[string]Text{
+			"ru": {"Новое сообщение от {{.peerName}}", "{{.preview}}"},
+			"kk": {"{{.peerName}} жаңа хабарлама жіберді", "{{.preview}}"},
+			"en": {"New message from {{.peerName}}", "{{.preview}}"},
+		},
+	},
+	"tutor.verification.verified": {
+		Category: CategorySystem,
+		Channels: []string{ChannelInApp, ChannelEmail},
+		Texts: map[string]Text{
+			"ru": {"Анкета подтверждена", "Ваша анкета репетитора прошла проверку и видна ученикам."},
+			"kk": {"Сауалнама расталды", "Сіздің репетитор сауалнамаңыз тексеруден өтті және оқушыларға көрінеді."},
+			"en": {"Profile verified", "Your tutor profile has been verified and is now visible to students."},
+		},
+	},
+	"tutor.verification.rejected": {
+		Category: CategorySystem,
+		Channels: []string{ChannelInApp, ChannelEmail},
+		Texts: map[string]Text{
+			"ru": {"Анкета отклонена", "Анкета не прошла проверку.{{if .reason}} Причина: {{.reason}}.{{end}}"},
+			"kk": {"Сауалнама қабылданбады", "Сауалнама тексеруден өтпеді.{{if .reason}} Себебі: {{.reason}}.{{end}}"},
+			"en": {"Profile rejected", "Your profile did not pass verification.{{if .reason}} Reason: {{.reason}}.{{end}}"},
+		},
+	},
+	"payout.paid": {
+		Category: CategorySystem,
+		Channels: []string{ChannelInApp, ChannelEmail},
+		Texts: map[string]Text{
+			"ru": {"Выплата отправлена", "Выплата {{.amount}} {{.currency}} отправлена на ваши реквизиты."},
+			"kk": {"Төлем жіберілді", "{{.amount}} {{.currency}} төлемі сіздің деректемелеріңізге жіберілді."},
+			"en": {"Payout sent", "Your payout of {{.amount}} {{.currency}} has been sent."},
+		},
+	},
+	"payout.failed": {
+		Category: CategorySystem,
+		Channels: []string{ChannelInApp, ChannelEmail},
+		Texts: map[string]Text{
+			"ru": {"Выплата не прошла", "Выплата {{.amount}} {{.currency}} не прошла, средства вернулись на баланс.{{if .reason}} Причина: {{.reason}}.{{end}}"},
+			"kk": {"Төлем өтпеді", "{{.amount}} {{.currency}} төлемі өтпеді, қаражат балансқа қайтарылды.{{if .reason}} Себебі: {{.reason}}.{{end}}"},
+			"en": {"Payout failed", "Your payout of {{.amount}} {{.currency}} failed and was returned to your balance.{{if .reason}} Reason: {{.reason}}.{{end}}"},
+		},
+	},
+	"report.resolved": {
+		Category: CategorySystem,
+		Channels: []string{ChannelInApp},
+		Texts: map[string]Text{
+			"ru": {"Жалоба рассмотрена", "Ваша жалоба рассмотрена модератором. Спасибо!"},
+			"kk": {"Шағым қаралды", "Сіздің шағымыңызды модератор қарады. Рақмет!"},
+			"en": {"Report reviewed", "A moderator has reviewed your report. Thank you!"},
+		},
+	},
+	"admin.test": {
+		Category: CategorySystem,
+		Channels: []string{ChannelInApp},
+		Texts: map[string]Text{
+			"ru": {"Тестовое уведомление", "{{or .text \"Проверка доставки\"}}"},
+			"kk": {"Сынақ хабарламасы", "{{or .text \"Жеткізуді тексеру\"}}"},
+			"en": {"Test notification", "{{or .text \"Delivery check\"}}"},
+		},
+	},
+}
+
+var parsed = map[string]*template.Template{}
+
+func init() {
+	for key, t := range Templates {
+		for loc, txt := range t.Texts {
+			name := key + "/" + loc
+			parsed[name+"/subject"] = template.Must(template.New(name).Option("missingkey=zero").Parse(txt.Subject))
+			parsed[name+"/body"] = template.Must(template.New(name).Option("missingkey=zero").Parse(txt.Body))
+		}
+	}
+}
+
+// Lookup возвращает шаблон по ключу.
+func Lookup(key string) (Template, bool) {
+	t, ok := Templates[key]
+	return t, ok
+}
+
+// NormalizeLocale: "kk-KZ" → "kk"; неподдерживаемые — DefaultLocale.
+func NormalizeLocale(loc string) string {
+	loc = strings.ToLower(strings.TrimSpace(loc))
+	if i := strings.IndexAny(loc, "-_"); i > 0 {
+		loc = loc[:i]
+	}
+	for _, l := range Locales {
+		if l == loc {
+			return l
+		}
+	}
+	return DefaultLocale
+}
+
+// Render подставляет payload в шаблон нужного языка; при отсутствии перевода — ru, затем en.
+func Render(key, locale string, data map[string]any) (subject, body string, err error) {
+	t, ok := Templates[key]
+	if !ok {
+		return "", "", ErrUnknownTemplate
+	}
+	loc := NormalizeLocale(locale)
+	for _, l := range []string{loc, DefaultLocale, "en"} {
+		if _, ok := t.Texts[l]; ok {
+			loc = l
+			break
+		}
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	var sb, bb bytes.Buffer
+	if err := parsed[key+"/"+loc+"/subject"].Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	if err := parsed[key+"/"+loc+"/body"].Execute(&bb, data); err != nil {
+		return "", "", err
+	}
+	// отсутствующий ключ map[string]any text/template печатает как "<no value>"
+	return strings.ReplaceAll(sb.String(), "<no value>", ""), strings.ReplaceAll(bb.String(), "<no value>", ""), nil
+}
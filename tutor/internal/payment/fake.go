@@ -0,0 +1,124 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider — локальный провайдер для dev/тестов: хранит интенты в памяти,
+// вебхуки подписывает HMAC-SHA256 (тот же секрет, что и при проверке).
+type FakeProvider struct {
+	secret  string
+	baseURL string
+
+	mu      sync.Mutex
+	intents map[string]*Intent
+}
+
+func NewFakeProvider(webhookSecret, baseURL string) *FakeProvider {
+	return &FakeProvider{secret: webhookSecret, baseURL: baseURL, intents: map[string]*Intent{}}
+}
+
+func (p *FakeProvider) Name() string { return "fake" }
+
+func (p *FakeProvider) CreateIntent(_ context.Context, req IntentRequest) (*Intent, error) {
+	id := "fake_pi_" + randomHex(12)
+	in := &Intent{
+		ID:     id,
+		Status: StatusRequiresAction,
+		// фронт отправляет сюда POST с JWT студента вместо перехода на страницу провайдера
+		RedirectURL: fmt.Sprintf("%s/v1/payments/fake/%s/succeed", p.baseURL, id),
+	}
+	in.Raw, _ = json.Marshal(map[string]any{"id": id, "amount": req.AmountMinor, "currency": req.Currency})
+	p.mu.Lock()
+	p.intents[id] = in
+	p.mu.Unlock()
+	return in, nil
+}
+
+func (p *FakeProvider) Capture(_ context.Context, intentID string, _ int64) (*Intent, error) {
+	return p.setStatus(intentID, StatusCaptured), nil
+}
+
+func (p *FakeProvider) Void(_ context.Context, intentID string) (*Intent, error) {
+	return p.setStatus(intentID, StatusVoided), nil
+}
+
+func (p *FakeProvider) Refund(_ context.Context, intentID string, amountMinor int64, _ string) (*Refund, error) {
+	id := "fake_re_" + randomHex(12)
+	raw, _ := json.Marshal(map[string]any{"id": id, "intent": intentID, "amount": amountMinor})
+	return &Refund{ID: id, Status: "succeeded", Raw: raw}, nil
+}
+
+type fakeEvent struct {
+	ID          string `json:"id"`
+	IntentID    string `json:"intentId"`
+	Status      string `json:"status"`
+	AmountMinor int64  `json:"amountMinor"`
+	Currency    string `json:"currency"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if !hmac.Equal([]byte(header.Get(FakeSignatureHeader)), []byte(p.sign(payload))) {
+		return nil, ErrInvalidSignature
+	}
+	var ev fakeEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, fmt.Errorf("decode fake event: %w", err)
+	}
+	switch ev.Status {
+	case StatusAuthorized, StatusCaptured, StatusFailed, StatusVoided, StatusRefunded:
+	default:
+		return nil, ErrUnknownEvent
+	}
+	return &WebhookEvent{ID: ev.ID, IntentID: ev.IntentID, Status: ev.Status, AmountMinor: ev.AmountMinor, Currency: ev.Currency, Raw: payload}, nil
+}
+
+// SignedEvent собирает подписанный вебхук — так "покупатель" завершает оплату в dev-окружении.
+func (p *FakeProvider) SignedEvent(intentID, status string, amountMinor int64, currency string) ([]byte, http.Header) {
+	p.setStatus(intentID, status)
+	payload, _ := json.Marshal(fakeEvent{
+		ID: "fake_evt_" + randomHex(12), IntentID: intentID, Status: status,
+		AmountMinor: amountMinor, Currency: currency, CreatedAt: time.Now().Unix(),
+	})
+	h := http.Header{}
+	h.Set(FakeSignatureHeader, p.sign(payload))
+	return payload, h
+}
+
+func (p *FakeProvider) setStatus(intentID, status string) *Intent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	in, ok := p.intents[intentID]
+	if !ok {
+		// после рестарта память пуста — ведём себя как провайдер, который "знает" интент
+		in = &Intent{ID: intentID}
+		p.intents[intentID] = in
+	}
+	in.Status = status
+	cp := *in
+	return &cp
+}
+
+func (p *FakeProvider) sign(payload []byte) string {
+	m := hmac.New(sha256.New, []byte(p.secret))
+	m.Write(payload)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,85 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Статусы интента в терминах нашей таблицы payments
+const (
+	StatusRequiresAction = "requires_action"
+	StatusAuthorized     = "authorized"
+	StatusCaptured       = "captured"
+	StatusFailed         = "failed"
+	StatusRefunded       = "refunded"
+	StatusVoided         = "voided"
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+	ErrUnknownEvent     = errors.New("webhook event not supported")
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+)
+
+// Provider — адаптер платёжного провайдера. Суммы — в minor units.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	Capture(ctx context.Context, intentID string, amountMinor int64) (*Intent, error)
+	Void(ctx context.Context, intentID string) (*Intent, error)
+	Refund(ctx context.Context, intentID string, amountMinor int64, idempotencyKey string) (*Refund, error)
+	// ParseWebhook проверяет подпись и приводит событие провайдера к общему виду.
+	ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+type IntentRequest struct {
+	PaymentID      string
+	BookingID      string
+	AmountMinor    int64
+	Currency       string
+	Description    string
+	IdempotencyKey string
+}
+
+type Intent struct {
+	ID           string
+	Status       string
+	ClientSecret string // для SDK на фронте (Stripe-like)
+	RedirectURL  string // для редирект-флоу (Kaspi-like / fake)
+	Raw          []byte
+}
+
+type Refund struct {
+	ID     string
+	Status string // pending/succeeded/failed
+	Raw    []byte
+}
+
+type WebhookEvent struct {
+	ID          string
+	IntentID    string
+	Status      string // целевой статус платежа
+	AmountMinor int64
+	Currency    string // ISO 4217, верхний регистр
+	Raw         []byte
+}
+
+// Registry — провайдеры по имени (имя же хранится в payments.provider и в URL вебхука).
+type Registry map[string]Provider
+
+func NewRegistry(providers ...Provider) Registry {
+	r := Registry{}
+	for _, p := range providers {
+		r[p.Name()] = p
+	}
+	return r
+}
+
+func (r Registry) Get(name string) (Provider, error) {
+	p, ok := r[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
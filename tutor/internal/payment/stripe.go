@@ -0,0 +1,251 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeSignatureHeader = "Stripe-Signature"
+
+// StripeProvider — адаптер Stripe PaymentIntents (capture_method=manual):
+// авторизация при бронировании, списание — отдельным Capture.
+type StripeProvider struct {
+	apiURL        string
+	secretKey     string
+	webhookSecret string
+	tolerance     time.Duration
+	client        *http.Client
+}
+
+func NewStripeProvider(apiURL, secretKey, webhookSecret string) *StripeProvider {
+	if apiURL == "" {
+		apiURL = "https://api.stripe.com"
+	}
+	return &StripeProvider{
+		apiURL:        strings.TrimRight(apiURL, "/"),
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		tolerance:     5 * time.Minute,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *StripeProvider) Name() string { return "stripe" }
+
+type stripeIntent struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	ClientSecret string `json:"client_secret"`
+	Amount       int64  `json:"amount"`
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *StripeProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.AmountMinor, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("capture_method", "manual")
+	form.Set("metadata[payment_id]", req.PaymentID)
+	form.Set("metadata[booking_id]", req.BookingID)
+	if req.Description != "" {
+		form.Set("description", req.Description)
+	}
+	var out stripeIntent
+	raw, err := p.post(ctx, "/v1/payment_intents", form, req.IdempotencyKey, &out)
+	if err != nil {
+		return nil, err
+	}
+	return &Intent{ID: out.ID, Status: mapStripeIntentStatus(out.Status), ClientSecret: out.ClientSecret, Raw: raw}, nil
+}
+
+func (p *StripeProvider) Capture(ctx context.Context, intentID string, amountMinor int64) (*Intent, error) {
+	form := url.Values{}
+	if amountMinor > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(amountMinor, 10))
+	}
+	var out stripeIntent
+	raw, err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(intentID)+"/capture", form, "capture-"+intentID, &out)
+	if err != nil {
+		return nil, err
+	}
+	return &Intent{ID: out.ID, Status: mapStripeIntentStatus(out.Status), Raw: raw}, nil
+}
+
+func (p *StripeProvider) Void(ctx context.Context, intentID string) (*Intent, error) {
+	var out stripeIntent
+	raw, err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(intentID)+"/cancel", url.Values{}, "cancel-"+intentID, &out)
+	if err != nil {
+		return nil, err
+	}
+	return &Intent{ID: out.ID, Status: mapStripeIntentStatus(out.Status), Raw: raw}, nil
+}
+
+func (p *StripeProvider) Refund(ctx context.Context, intentID string, amountMinor int64, idempotencyKey string) (*Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", intentID)
+	if amountMinor > 0 {
+		form.Set("amount", strconv.FormatInt(amountMinor, 10))
+	}
+	var out struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	raw, err := p.post(ctx, "/v1/refunds", form, idempotencyKey, &out)
+	if err != nil {
+		return nil, err
+	}
+	status := "pending"
+	switch out.Status {
+	case "succeeded":
+		status = "succeeded"
+	case "failed", "canceled":
+		status = "failed"
+	}
+	return &Refund{ID: out.ID, Status: status, Raw: raw}, nil
+}
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID             string `json:"id"`
+			Object         string `json:"object"`
+			Amount         int64  `json:"amount"`
+			AmountRefunded int64  `json:"amount_refunded"`
+			Currency       string `json:"currency"`
+			PaymentIntent  string `json:"payment_intent"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func (p *StripeProvider) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if err := p.verifySignature(payload, header.Get(stripeSignatureHeader), time.Now()); err != nil {
+		return nil, err
+	}
+	var ev stripeEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, fmt.Errorf("decode stripe event: %w", err)
+	}
+	obj := ev.Data.Object
+	out := &WebhookEvent{ID: ev.ID, IntentID: obj.ID, AmountMinor: obj.Amount, Currency: strings.ToUpper(obj.Currency), Raw: payload}
+	switch ev.Type {
+	case "payment_intent.amount_capturable_updated":
+		out.Status = StatusAuthorized
+	case "payment_intent.succeeded":
+		out.Status = StatusCaptured
+	case "payment_intent.payment_failed":
+		out.Status = StatusFailed
+	case "payment_intent.canceled":
+		out.Status = StatusVoided
+	case "charge.refunded":
+		// полный возврат → refunded; частичный обрабатываем через refunds
+		if obj.AmountRefunded < obj.Amount {
+			return nil, ErrUnknownEvent
+		}
+		out.IntentID = obj.PaymentIntent
+		out.AmountMinor = obj.AmountRefunded
+		out.Status = StatusRefunded
+	default:
+		return nil, ErrUnknownEvent
+	}
+	return out, nil
+}
+
+// verifySignature — схема Stripe: "t=<unix>,v1=<hex hmac_sha256(secret, t + "." + payload)>".
+func (p *StripeProvider) verifySignature(payload []byte, header string, now time.Time) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			sigs = append(sigs, kv[1])
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return ErrInvalidSignature
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > p.tolerance || d < -p.tolerance {
+		return ErrInvalidSignature
+	}
+	m := hmac.New(sha256.New, []byte(p.webhookSecret))
+	m.Write([]byte(ts + "."))
+	m.Write(payload)
+	expected := hex.EncodeToString(m.Sum(nil))
+	for _, s := range sigs {
+		if hmac.Equal([]byte(s), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out any) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("stripe %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		var se stripeError
+		_ = json.Unmarshal(raw, &se)
+		return nil, fmt.Errorf("stripe %s: status=%d type=%s code=%s: %s", path, resp.StatusCode, se.Error.Type, se.Error.Code, se.Error.Message)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return nil, fmt.Errorf("stripe %s: decode: %w", path, err)
+	}
+	return raw, nil
+}
+
+func mapStripeIntentStatus(s string) string {
+	switch s {
+	case "requires_capture":
+		return StatusAuthorized
+	case "succeeded":
+		return StatusCaptured
+	case "canceled":
+		return StatusVoided
+	default: // requires_payment_method / requires_confirmation / requires_action / processing
+		return StatusRequiresAction
+	}
+}
@@ -0,0 +1,54 @@
+package payout
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// FakeProvider — провайдер для dev: перевод сначала processing, на следующем опросе paid.
+// Назначение с "fail": true имитирует отказ банка.
+type FakeProvider struct {
+	mu        sync.Mutex
+	transfers map[string]*Result // ref → перевод
+	byPayout  map[string]string  // payoutID → ref
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{transfers: map[string]*Result{}, byPayout: map[string]string{}}
+}
+
+func (p *FakeProvider) Name() string { return "fake" }
+
+func (p *FakeProvider) Send(_ context.Context, req Request) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ref, ok := p.byPayout[req.PayoutID]; ok {
+		cp := *p.transfers[ref]
+		return &cp, nil
+	}
+	res := &Result{Ref: "fake_po_" + req.PayoutID, Status: StatusProcessing}
+	if fail, _ := req.Destination["fail"].(bool); fail {
+		res.Status, res.Reason = StatusFailed, "destination rejected"
+	}
+	res.Raw, _ = json.Marshal(map[string]any{"ref": res.Ref, "amount": req.AmountMinor, "currency": req.Currency})
+	p.transfers[res.Ref] = res
+	p.byPayout[req.PayoutID] = res.Ref
+	cp := *res
+	return &cp, nil
+}
+
+func (p *FakeProvider) Status(_ context.Context, ref string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res, ok := p.transfers[ref]
+	if !ok {
+		// после рестарта память пуста — о судьбе перевода ничего не известно
+		return nil, ErrUnknownTransfer
+	}
+	if res.Status == StatusProcessing {
+		res.Status = StatusPaid
+	}
+	cp := *res
+	return &cp, nil
+}
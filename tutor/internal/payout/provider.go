@@ -0,0 +1,38 @@
+package payout
+
+import (
+	"context"
+	"errors"
+)
+
+// Статусы выплаты у провайдера
+const (
+	StatusProcessing = "processing"
+	StatusPaid       = "paid"
+	StatusFailed     = "failed"
+)
+
+var ErrUnknownTransfer = errors.New("payout transfer not found at provider")
+
+// Provider — адаптер провайдера выплат. Send идемпотентен по Request.PayoutID:
+// повторная отправка той же выплаты возвращает уже созданный перевод.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, req Request) (*Result, error)
+	Status(ctx context.Context, ref string) (*Result, error)
+}
+
+type Request struct {
+	PayoutID    string
+	AmountMinor int64
+	Currency    string
+	Method      string
+	Destination map[string]any
+}
+
+type Result struct {
+	Ref    string // id перевода у провайдера
+	Status string
+	Reason string // причина отказа
+	Raw    []byte
+}
@@ -0,0 +1,334 @@
+// Package redact прячет контакты (телефоны, email, ссылки, ники в мессенджерах) в тексте
+// сообщений, включая обфускацию вида "8 7 0 1 …", "восемь семь ноль", "ivan at gmail dot com".
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Виды найденных контактов
+const (
+	KindPhone  = "phone"
+	KindEmail  = "email"
+	KindLink   = "link"
+	KindHandle = "handle"
+)
+
+// Placeholder подставляется вместо найденного контакта.
+const Placeholder = "[contact hidden]"
+
+// minPhoneDigits — столько цифр подряд (с разделителями) считаем телефоном; цены и даты короче.
+const minPhoneDigits = 9
+
+type Finding struct {
+	Kind  string `json:"kind"`
+	Start int    `json:"start"` // байтовые смещения в исходном тексте
+	End   int    `json:"end"`
+}
+
+// Result — текст с замаскированными контактами; Findings пуст, если менять нечего.
+type Result struct {
+	Text     string
+	Findings []Finding
+}
+
+func (r Result) Redacted() bool { return len(r.Findings) > 0 }
+
+// Kinds — уникальные виды найденного в порядке появления.
+func (r Result) Kinds() []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, f := range r.Findings {
+		if !seen[f.Kind] {
+			seen[f.Kind] = true
+			out = append(out, f.Kind)
+		}
+	}
+	return out
+}
+
+// Числа словами (ru/kk/en) — для телефонов, продиктованных словами.
+var digitWords = map[string]string{
+	"zero": "0", "oh": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"ноль": "0", "нуль": "0", "один": "1", "одна": "1", "два": "2", "две": "2", "три": "3",
+	"четыре": "4", "пять": "5", "шесть": "6", "семь": "7", "восемь": "8", "девять": "9",
+	"нөл": "0", "бір": "1", "екі": "2", "үш": "3", "төрт": "4", "бес": "5",
+	"алты": "6", "жеті": "7", "сегіз": "8", "тоғыз": "9",
+}
+
+// Слова-заменители "@" и "." в обфусцированных адресах.
+var (
+	atWords  = map[string]bool{"at": true, "собака": true, "собачка": true, "эт": true, "ат": true}
+	dotWords = map[string]bool{"dot": true, "точка": true, "тчк": true, "нүкте": true, "дот": true}
+)
+
+// Домены верхнего уровня, по которым узнаём адрес без протокола ("site.kz", "site dot kz").
+var tlds = map[string]bool{
+	"com": true, "ru": true, "kz": true, "net": true, "org": true, "io": true, "me": true,
+	"info": true, "app": true, "link": true, "site": true, "online": true, "pro": true,
+	"biz": true, "ua": true, "uz": true, "kg": true, "by": true, "co": true, "ly": true, "gl": true,
+	"рф": true, "қаз": true, "ком": true, "ру": true, "кз": true,
+}
+
+// Мессенджеры/соцсети: "тг: ivan_petrov", "insta - ivan.petrov".
+var messengerWords = map[string]bool{
+	"telegram": true, "tg": true, "телеграм": true, "телеграмм": true, "телега": true, "тг": true,
+	"whatsapp": true, "wa": true, "ватсап": true, "вацап": true, "ватсапп": true, "вотсап": true, "уатсап": true,
+	"instagram": true, "insta": true, "inst": true, "инстаграм": true, "инста": true, "инст": true,
+	"viber": true, "вайбер": true, "skype": true, "скайп": true, "discord": true, "дискорд": true,
+	"vk": true, "вк": true, "facebook": true, "fb": true, "фейсбук": true, "tiktok": true, "тикток": true,
+	"snapchat": true,
+}
+
+var (
+	urlRe    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+	shortRe  = regexp.MustCompile(`(?i)\b(?:t\.me|wa\.me|telegram\.me|vk\.com|instagram\.com|fb\.me|m\.me)/[^\s<>"']+`)
+	tokenRe  = regexp.MustCompile(`[\p{L}\p{N}_]+|[^\s\p{L}\p{N}_]`)
+	handleRe = regexp.MustCompile(`^[a-z0-9_.]{3,32}$`) // ники в мессенджерах — латиница
+)
+
+type token struct {
+	text       string // в нижнем регистре
+	start, end int
+}
+
+func (t token) word() bool {
+	r := []rune(t.text)
+	return len(r) > 0 && (unicode.IsLetter(r[0]) || unicode.IsDigit(r[0]) || r[0] == '_')
+}
+
+func (t token) digits() (string, bool) {
+	if d, ok := digitWords[t.text]; ok {
+		return d, true
+	}
+	for _, r := range t.text {
+		if !unicode.IsDigit(r) {
+			return "", false
+		}
+	}
+	return t.text, true
+}
+
+func (t token) isAt() bool  { return t.text == "@" || atWords[t.text] }
+func (t token) isDot() bool { return t.text == "." || dotWords[t.text] }
+
+// Text находит и маскирует контакты.
+func Text(s string) Result {
+	var found []Finding
+	for _, m := range urlRe.FindAllStringIndex(s, -1) {
+		found = append(found, Finding{Kind: KindLink, Start: m[0], End: m[1]})
+	}
+	for _, m := range shortRe.FindAllStringIndex(s, -1) {
+		found = append(found, Finding{Kind: KindHandle, Start: m[0], End: m[1]})
+	}
+
+	lower := strings.ToLower(s)
+	var toks []token
+	for _, m := range tokenRe.FindAllStringIndex(lower, -1) {
+		toks = append(toks, token{text: lower[m[0]:m[1]], start: m[0], end: m[1]})
+	}
+	found = append(found, phones(toks)...)
+	found = append(found, emails(toks)...)
+	found = append(found, domains(toks)...)
+	found = append(found, handles(toks)...)
+	if len(found) == 0 {
+		return Result{Text: s}
+	}
+	return apply(s, merge(found))
+}
+
+// phones — серии цифр (в т.ч. словами) через пробелы и разделители, не короче minPhoneDigits.
+// Запятая или точка с пробелом после неё серию обрывает.
+func phones(toks []token) []Finding {
+	var out []Finding
+	i := 0
+	for i < len(toks) {
+		if _, ok := toks[i].digits(); !ok && toks[i].text != "+" {
+			i++
+			continue
+		}
+		start, end, n := toks[i].start, toks[i].end, 0
+		j := i
+		for ; j < len(toks); j++ {
+			t := toks[j]
+			if d, ok := t.digits(); ok {
+				n += len(d)
+				end = t.end
+				continue
+			}
+			if strings.ContainsAny(t.text, "+-().,/") && len(t.text) == 1 {
+				// ", " и ". " — конец перечисления или фразы: списки цен и дат не склеиваются в номер
+				if (t.text == "," || t.text == ".") && (j+1 == len(toks) || toks[j+1].start > t.end) {
+					break
+				}
+				continue
+			}
+			break
+		}
+		if n >= minPhoneDigits {
+			out = append(out, Finding{Kind: KindPhone, Start: start, End: end})
+		}
+		if j == i {
+			j++
+		}
+		i = j
+	}
+	return out
+}
+
+// skipBrackets пропускает скобки вокруг "(at)", "[dot]".
+func skipBrackets(toks []token, i, step int) int {
+	for i >= 0 && i < len(toks) && strings.ContainsAny(toks[i].text, "()[]{}") && len(toks[i].text) == 1 {
+		i += step
+	}
+	return i
+}
+
+// emails — local AT domain (DOT part)+ , где AT/DOT могут быть словами.
+func emails(toks []token) []Finding {
+	var out []Finding
+	for i, t := range toks {
+		if !t.isAt() {
+			continue
+		}
+		l := skipBrackets(toks, i-1, -1)
+		if l < 0 || !toks[l].word() || toks[l].isAt() {
+			continue
+		}
+		// локальная часть: word(.|_|-)word…
+		for l-2 >= 0 && toks[l-2].word() && strings.ContainsAny(toks[l-1].text, ".-") && len(toks[l-1].text) == 1 &&
+			toks[l-1].end == toks[l].start && toks[l-2].end == toks[l-1].start {
+			l -= 2
+		}
+		if end, ok := domainAfter(toks, skipBrackets(toks, i+1, 1)); ok {
+			out = append(out, Finding{Kind: KindEmail, Start: toks[l].start, End: end})
+		}
+	}
+	return out
+}
+
+// domainAfter: word (DOT word)+ с известным доменом в конце; возвращает конец совпадения.
+func domainAfter(toks []token, i int) (int, bool) {
+	if i >= len(toks) || !toks[i].word() {
+		return 0, false
+	}
+	end, ok := 0, false
+	for j := i + 1; j+1 < len(toks); j += 2 {
+		d := skipBrackets(toks, j, 1)
+		w := skipBrackets(toks, d+1, 1)
+		if d >= len(toks) || w >= len(toks) || !toks[d].isDot() || !toks[w].word() {
+			break
+		}
+		// "school. Com" — конец предложения: символьная точка должна быть слитной
+		if toks[d].text == "." && (toks[d].start != toks[d-1].end || toks[w].start != toks[d].end) {
+			break
+		}
+		if tlds[toks[w].text] {
+			end, ok = toks[w].end, true
+		}
+		j = w - 1
+	}
+	return end, ok
+}
+
+// domains — "site.kz", "site dot kz", "my-school точка рф" без протокола.
+func domains(toks []token) []Finding {
+	var out []Finding
+	for i, t := range toks {
+		if !t.word() || t.isAt() || t.isDot() {
+			continue
+		}
+		if i > 0 && toks[i-1].isAt() {
+			continue // часть email
+		}
+		if end, ok := domainAfter(toks, i); ok {
+			out = append(out, Finding{Kind: KindLink, Start: t.start, End: end})
+		}
+	}
+	return out
+}
+
+// handles — "@ivan_petrov" и "<мессенджер>[:-] ivan_petrov".
+func handles(toks []token) []Finding {
+	var out []Finding
+	for i, t := range toks {
+		if t.text == "@" && i+1 < len(toks) && toks[i+1].start == t.end && handleRe.MatchString(toks[i+1].text) &&
+			(i == 0 || !toks[i-1].word() || toks[i-1].end != t.start) {
+			out = append(out, Finding{Kind: KindHandle, Start: t.start, End: handleEnd(toks, i+1)})
+			continue
+		}
+		if !messengerWords[t.text] {
+			continue
+		}
+		j := i + 1
+		for j < len(toks) && (toks[j].text == ":" || toks[j].text == "-" || toks[j].text == "@") {
+			j++
+		}
+		if j == i+1 || j >= len(toks) || !toks[j].word() || !handleRe.MatchString(toks[j].text) {
+			// без разделителя считаем ником только то, что не похоже на обычное слово
+			if j >= len(toks) || !toks[j].word() || !looksLikeHandle(toks[j].text) {
+				continue
+			}
+		}
+		if _, ok := toks[j].digits(); ok {
+			continue // номер поймает phones
+		}
+		out = append(out, Finding{Kind: KindHandle, Start: t.start, End: handleEnd(toks, j)})
+	}
+	return out
+}
+
+// handleEnd расширяет ник на слитные части через "." и "_": ivan.petrov_99
+func handleEnd(toks []token, i int) int {
+	end := toks[i].end
+	for j := i + 1; j+1 < len(toks); j += 2 {
+		if toks[j].text != "." || toks[j].start != end || !toks[j+1].word() || toks[j+1].start != toks[j].end {
+			break
+		}
+		end = toks[j+1].end
+	}
+	return end
+}
+
+func looksLikeHandle(s string) bool {
+	hasDigit, hasUnderscore := false, strings.Contains(s, "_")
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			hasDigit = true
+		}
+	}
+	return len(s) >= 4 && (hasDigit || hasUnderscore)
+}
+
+// merge сортирует и склеивает пересекающиеся находки (первый вид побеждает).
+func merge(fs []Finding) []Finding {
+	sort.SliceStable(fs, func(i, j int) bool { return fs[i].Start < fs[j].Start })
+	out := []Finding{fs[0]}
+	for _, f := range fs[1:] {
+		last := &out[len(out)-1]
+		if f.Start < last.End {
+			if f.End > last.End {
+				last.End = f.End
+			}
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func apply(s string, fs []Finding) Result {
+	var b strings.Builder
+	prev := 0
+	for _, f := range fs {
+		b.WriteString(s[prev:f.Start])
+		b.WriteString(Placeholder)
+		prev = f.End
+	}
+	b.WriteString(s[prev:])
+	return Result{Text: b.String(), Findings: fs}
+}
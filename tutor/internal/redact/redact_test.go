@@ -0,0 +1,96 @@
+package redact
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTextFindsContacts(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		kind string
+	}{
+		{"phone with plus", "звоните +7 701 234 56 78", KindPhone},
+		{"phone with dashes", "мой номер 8-701-234-56-78", KindPhone},
+		{"phone in brackets", "тел. 8 (701) 234-56-78", KindPhone},
+		{"spaced digits", "8 7 0 1 2 3 4 5 6 7", KindPhone},
+		{"digits in words ru", "восемь семь ноль один два три четыре пять шесть", KindPhone},
+		{"digits in words kk", "сегіз жеті нөл бір екі үш төрт бес алты", KindPhone},
+		{"digits in words en", "eight seven oh one two three four five six", KindPhone},
+		{"email", "пишите на ivan.petrov@gmail.com", KindEmail},
+		{"email with words", "ivan at gmail dot com", KindEmail},
+		{"email with brackets", "ivan (at) mail [dot] ru", KindEmail},
+		{"email in russian", "ivan собака mail точка ру", KindEmail},
+		{"url", "смотрите https://example.com/profile", KindLink},
+		{"www", "www.school.kz тоже подойдёт", KindLink},
+		{"bare domain", "сайт myschool.kz", KindLink},
+		{"domain with words", "myschool dot kz", KindLink},
+		{"short link", "t.me/ivan_petrov", KindHandle},
+		{"at handle", "пишите @ivan_petrov", KindHandle},
+		{"messenger with colon", "тг: ivan_petrov", KindHandle},
+		{"messenger with dash", "insta - ivan.petrov", KindHandle},
+		{"messenger without separator", "telegram ivan_99", KindHandle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Text(tt.in)
+			if !r.Redacted() {
+				t.Fatalf("Text(%q) found nothing, want %s", tt.in, tt.kind)
+			}
+			if !slices.Contains(r.Kinds(), tt.kind) {
+				t.Fatalf("Text(%q) kinds %v, want %s", tt.in, r.Kinds(), tt.kind)
+			}
+			if !strings.Contains(r.Text, Placeholder) {
+				t.Fatalf("Text(%q) = %q, want placeholder", tt.in, r.Text)
+			}
+		})
+	}
+}
+
+func TestTextKeepsOrdinaryMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"price", "занятие стоит 15 000 тенге, пакет — 120 000"},
+		{"price with decimals", "итого 1500.00 KZT"},
+		{"price list", "цены: 1 500, 2 000, 3 000 тенге"},
+		{"price list with decimals", "1500.00, 2000.00, 3000.00"},
+		{"date", "встретимся 10.01.2030"},
+		{"date list", "свободные дни: 10.01.2030, 12.01.2030, 15.01.2030"},
+		{"date and time", "урок 2030-01-10 в 09:00, потом 12:30"},
+		{"time range", "свободен с 14:00 до 18:00"},
+		{"at school", "I study at school"},
+		{"at school with dot", "I was at school. Com on, let's start"},
+		{"sentence end", "решим задачу. Ru и En варианты есть"},
+		{"messenger word", "в telegram напишу позже"},
+		{"email word", "почта не работает"},
+		{"lesson number", "урок 3 из 12, глава 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := Text(tt.in); r.Redacted() || r.Text != tt.in {
+				t.Fatalf("Text(%q) = %q (kinds %v), want unchanged", tt.in, r.Text, r.Kinds())
+			}
+		})
+	}
+}
+
+func TestTextMasksOnlyContacts(t *testing.T) {
+	in := "Урок в 18:00, звоните +7 701 234 56 78 или пишите ivan@mail.ru"
+	r := Text(in)
+	want := "Урок в 18:00, звоните " + Placeholder + " или пишите " + Placeholder
+	if r.Text != want {
+		t.Fatalf("Text(%q) = %q, want %q", in, r.Text, want)
+	}
+	if got := r.Kinds(); !slices.Equal(got, []string{KindPhone, KindEmail}) {
+		t.Fatalf("kinds %v, want [phone email]", got)
+	}
+	for _, f := range r.Findings {
+		if f.Start < 0 || f.End > len(in) || f.Start >= f.End {
+			t.Fatalf("finding %+v out of range", f)
+		}
+	}
+}
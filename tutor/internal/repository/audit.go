@@ -0,0 +1,29 @@
+package repository
+
+// Снимки анкеты репетитора для audit_log ($1 — user_id), по шагу мастера.
+const (
+	auditTutorAbout = `
+SELECT jsonb_build_object(
+         'firstName', u.first_name, 'lastName', u.last_name, 'phone', u.phone_e164,
+         'gender', tp.props->'gender', 'avatarUrl', tp.props->'avatar_url',
+         'languages', (SELECT COALESCE(jsonb_agg(jsonb_build_object('code', l.lang_code, 'proficiency', l.proficiency) ORDER BY l.lang_code), '[]'::jsonb)
+                       FROM public.tutor_languages l WHERE l.tutor_id = u.id))
+FROM public.users u LEFT JOIN public.tutor_profiles tp ON tp.user_id = u.id
+WHERE u.id = $1`
+	auditTutorEducation = `
+SELECT jsonb_build_object('bio', tp.bio,
+         'education', (SELECT COALESCE(jsonb_agg(jsonb_build_object('id', e.id, 'degree', e.degree,
+                         'institution', e.institution, 'universityId', e.university_id, 'year', e.year, 'field', e.field,
+                         'document', e.document_key, 'verification', e.verification) ORDER BY e.position, e.created_at), '[]'::jsonb)
+                       FROM public.tutor_education e WHERE e.tutor_id = tp.user_id),
+         'certificates', (SELECT COALESCE(jsonb_agg(jsonb_build_object('id', c.id, 'name', c.name, 'issuer', c.issuer,
+                         'year', c.year, 'document', c.document_key, 'verification', c.verification) ORDER BY c.position, c.created_at), '[]'::jsonb)
+                       FROM public.tutor_certificates c WHERE c.tutor_id = tp.user_id))
+FROM public.tutor_profiles tp WHERE tp.user_id = $1`
+	auditTutorVideo = `
+SELECT jsonb_build_object('videoUrl', video_url) FROM public.tutor_profiles WHERE user_id = $1`
+	auditTutorSubjects = `
+SELECT COALESCE(jsonb_agg(jsonb_build_object('subjectId', subject_id, 'level', level,
+         'priceMinor', price_minor, 'currency', currency) ORDER BY subject_id), '[]'::jsonb)
+FROM public.tutor_subjects WHERE tutor_id = $1`
+)
@@ -0,0 +1,459 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"tutor/internal/calendar"
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCalendarNotConnected = errors.New("calendar not connected")
+	// ErrCalendarEventGone — урок отменили, пока событие создавалось: строки уже нет.
+	ErrCalendarEventGone = errors.New("calendar event row gone")
+	ErrBusyNotTutor      = errors.New("user is not a tutor")
+)
+
+type CalendarRepository interface {
+	// SaveAccount подключает (или переподключает) календарь и ставит в очередь
+	// все предстоящие уроки пользователя.
+	SaveAccount(ctx context.Context, userID, provider, email, calendarID string, tok domain.CalendarToken) (*domain.CalendarAccount, error)
+	ListAccounts(ctx context.Context, userID string) ([]domain.CalendarAccount, error)
+	GetAccount(ctx context.Context, userID, provider string) (*domain.CalendarAccount, error)
+	// DeleteAccount удаляет подключение, токены, события и занятость; возвращает
+	// id событий у провайдера, которые стоит убрать из календаря.
+	DeleteAccount(ctx context.Context, userID, provider string) ([]string, error)
+	Token(ctx context.Context, userID, provider string) (*domain.CalendarToken, error)
+	SaveToken(ctx context.Context, userID, provider string, tok domain.CalendarToken) error
+	MarkRevoked(ctx context.Context, accountID, reason string) error
+
+	// Claim берёт события, ждущие синхронизации, у активных подключений и откладывает
+	// их на lease (SKIP LOCKED — безопасно для нескольких реплик).
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.CalendarJob, error)
+	// MarkSynced фиксирует событие у провайдера; если урок успел измениться после
+	// взятия (revision выросла), событие остаётся в очереди на обновление.
+	MarkSynced(ctx context.Context, id, eventID, link string, revision int) error
+	MarkDeleted(ctx context.Context, id string, revision int) error
+	Retry(ctx context.Context, id, reason string, at time.Time) error
+	MarkError(ctx context.Context, id, reason string) error
+
+	// ClaimBusy — подключения, у которых занятость не обновлялась дольше every.
+	ClaimBusy(ctx context.Context, every time.Duration, limit int) ([]domain.CalendarJob, error)
+	ReplaceBusy(ctx context.Context, accountID, userID string, busy []calendar.Interval) error
+	// Busy — своя занятость пользователя: уроки в любой роли и внешний календарь.
+	Busy(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error)
+	// TutorBusy — занятость репетитора для чужих глаз: только уроки, где он преподаёт,
+	// и его внешний календарь; ErrBusyNotTutor, если анкеты репетитора нет.
+	TutorBusy(ctx context.Context, tutorID string, from, to time.Time) ([]domain.BusyInterval, error)
+}
+
+type calendarRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCalendarRepository(db *pgxpool.Pool) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+// queueLessonEventsSQL — та же постановка в очередь, что и в триггере calendar_sync_lesson,
+// для уроков, запланированных до подключения календаря.
+const queueLessonEventsSQL = `
+INSERT INTO public.calendar_events (lesson_id, account_id, provider, status)
+SELECT l.id, $1, $3, 'to_create'
+FROM public.lessons l
+WHERE (l.student_id = $2 OR l.tutor_id = $2)
+  AND l.status = 'scheduled' AND l.started_at > now()
+ON CONFLICT (lesson_id, account_id) DO UPDATE
+   SET status = CASE WHEN calendar_events.event_id IS NULL THEN 'to_create' ELSE 'to_update' END,
+       revision = calendar_events.revision + 1, attempts = 0,
+       next_attempt_at = now(), error_msg = NULL, updated_at = now()`
+
+func (r *calendarRepository) SaveAccount(ctx context.Context, userID, provider, email, calendarID string, tok domain.CalendarToken) (*domain.CalendarAccount, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var accountID string
+	if err := tx.QueryRow(ctx, `
+INSERT INTO public.calendar_accounts (user_id, provider, email, calendar_id, status)
+VALUES ($1, $2, NULLIF($3,''), $4, 'active')
+ON CONFLICT (user_id, provider) DO UPDATE
+   SET email = EXCLUDED.email, calendar_id = EXCLUDED.calendar_id,
+       status = 'active', last_error = NULL, updated_at = now()
+RETURNING id::text`, userID, provider, email, calendarID).Scan(&accountID); err != nil {
+		return nil, err
+	}
+	if err := saveToken(ctx, tx, userID, provider, tok); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, queueLessonEventsSQL, accountID, userID, provider); err != nil {
+		return nil, err
+	}
+	// отложенные на время отзыва доступа — сразу в работу
+	if _, err := tx.Exec(ctx, `
+UPDATE public.calendar_events SET attempts = 0, next_attempt_at = now()
+WHERE account_id = $1 AND status IN ('to_create','to_update','to_delete')`, accountID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.GetAccount(ctx, userID, provider)
+}
+
+const calendarAccountColumns = `a.id::text, a.user_id::text, a.provider, COALESCE(a.email::text,''), a.calendar_id,
+       a.status, COALESCE(a.last_error,''), a.busy_synced_at, a.created_at,
+       COALESCE((SELECT jsonb_object_agg(s.status, s.n) FROM (
+           SELECT e.status, COUNT(*) AS n FROM public.calendar_events e WHERE e.account_id = a.id GROUP BY e.status
+       ) s), '{}'::jsonb)`
+
+func scanCalendarAccount(row pgx.Row) (*domain.CalendarAccount, error) {
+	var a domain.CalendarAccount
+	if err := row.Scan(&a.ID, &a.UserID, &a.Provider, &a.Email, &a.CalendarID,
+		&a.Status, &a.LastError, &a.BusySyncedAt, &a.CreatedAt, &a.Events); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *calendarRepository) ListAccounts(ctx context.Context, userID string) ([]domain.CalendarAccount, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT `+calendarAccountColumns+`
+FROM public.calendar_accounts a
+WHERE a.user_id = $1
+ORDER BY a.created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []domain.CalendarAccount{}
+	for rows.Next() {
+		a, err := scanCalendarAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+func (r *calendarRepository) GetAccount(ctx context.Context, userID, provider string) (*domain.CalendarAccount, error) {
+	a, err := scanCalendarAccount(r.db.QueryRow(ctx, `
+SELECT `+calendarAccountColumns+`
+FROM public.calendar_accounts a
+WHERE a.user_id = $1 AND a.provider = $2`, userID, provider))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCalendarNotConnected
+	}
+	return a, err
+}
+
+func (r *calendarRepository) DeleteAccount(ctx context.Context, userID, provider string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, `
+SELECT e.event_id FROM public.calendar_events e
+JOIN public.calendar_accounts a ON a.id = e.account_id
+WHERE a.user_id = $1 AND a.provider = $2 AND e.event_id IS NOT NULL`, userID, provider)
+	if err != nil {
+		return nil, err
+	}
+	var eventIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		eventIDs = append(eventIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// события и занятость удаляются каскадом
+	tag, err := tx.Exec(ctx, `DELETE FROM public.calendar_accounts WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrCalendarNotConnected
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM public.oauth_tokens WHERE user_id = $1 AND provider = $2`, userID, provider); err != nil {
+		return nil, err
+	}
+	return eventIDs, tx.Commit(ctx)
+}
+
+func (r *calendarRepository) Token(ctx context.Context, userID, provider string) (*domain.CalendarToken, error) {
+	var t domain.CalendarToken
+	err := r.db.QueryRow(ctx, `
+SELECT access_token, COALESCE(refresh_token,''), expires_at, COALESCE(scope,'')
+FROM public.oauth_tokens WHERE user_id = $1 AND provider = $2`, userID, provider).
+		Scan(&t.AccessToken, &t.RefreshToken, &t.ExpiresAt, &t.Scope)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCalendarNotConnected
+	}
+	return &t, err
+}
+
+func (r *calendarRepository) SaveToken(ctx context.Context, userID, provider string, tok domain.CalendarToken) error {
+	return saveToken(ctx, r.db, userID, provider, tok)
+}
+
+// saveToken: пустой refresh_token не затирает сохранённый — Google выдаёт его только при согласии.
+func saveToken(ctx context.Context, q execer, userID, provider string, tok domain.CalendarToken) error {
+	_, err := q.Exec(ctx, `
+INSERT INTO public.oauth_tokens (user_id, provider, access_token, refresh_token, expires_at, scope)
+VALUES ($1, $2, $3, NULLIF($4,''), $5, NULLIF($6,''))
+ON CONFLICT (user_id, provider) DO UPDATE
+   SET access_token = EXCLUDED.access_token,
+       refresh_token = COALESCE(EXCLUDED.refresh_token, oauth_tokens.refresh_token),
+       expires_at = EXCLUDED.expires_at,
+       scope = COALESCE(EXCLUDED.scope, oauth_tokens.scope),
+       updated_at = now()`,
+		userID, provider, tok.AccessToken, tok.RefreshToken, tok.ExpiresAt, tok.Scope)
+	return err
+}
+
+func (r *calendarRepository) MarkRevoked(ctx context.Context, accountID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.calendar_accounts SET status = 'revoked', last_error = $2, updated_at = now()
+WHERE id = $1`, accountID, reason)
+	return err
+}
+
+// calendarJobSQL дополняет взятые строки calendar_events данными урока, аккаунта и токена.
+const calendarJobSQL = `
+SELECT c.id::text, c.lesson_id::text, c.account_id::text, a.user_id::text, a.provider, a.calendar_id,
+       c.status, COALESCE(c.event_id,''), c.revision, c.attempts,
+       COALESCE(t.access_token,''), COALESCE(t.refresh_token,''), t.expires_at,
+       l.started_at,
+       COALESCE(l.duration_seconds, EXTRACT(EPOCH FROM b.ends_at - b.starts_at)::int, 3600),
+       CASE WHEN a.user_id = l.tutor_id THEN 'tutor' ELSE 'student' END,
+       COALESCE(NULLIF(trim(concat_ws(' ', pu.first_name, pu.last_name)), ''), ''),
+       COALESCE(s.name->>u.locale, s.name->>'ru', ''),
+       u.locale
+FROM claimed c
+JOIN public.calendar_accounts a ON a.id = c.account_id
+JOIN public.lessons l ON l.id = c.lesson_id
+JOIN public.users u ON u.id = a.user_id
+JOIN public.users pu ON pu.id = CASE WHEN a.user_id = l.tutor_id THEN l.student_id ELSE l.tutor_id END
+LEFT JOIN public.bookings b ON b.id = l.booking_id
+LEFT JOIN public.subjects s ON s.id = l.subject_id
+LEFT JOIN public.oauth_tokens t ON t.user_id = a.user_id AND t.provider = a.provider`
+
+func (r *calendarRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.CalendarJob, error) {
+	rows, err := r.db.Query(ctx, `
+WITH claimed AS (
+    UPDATE public.calendar_events SET attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+    WHERE id IN (
+        SELECT e.id FROM public.calendar_events e
+        JOIN public.calendar_accounts a ON a.id = e.account_id
+        WHERE e.status IN ('to_create','to_update','to_delete') AND e.next_attempt_at <= now()
+          AND a.status = 'active'
+        ORDER BY e.next_attempt_at
+        LIMIT $1
+        FOR UPDATE OF e SKIP LOCKED
+    )
+    RETURNING id, lesson_id, account_id, status, event_id, revision, attempts, next_attempt_at
+)`+calendarJobSQL, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.CalendarJob
+	for rows.Next() {
+		var j domain.CalendarJob
+		var startsAt *time.Time
+		var seconds int
+		if err := rows.Scan(&j.ID, &j.LessonID, &j.AccountID, &j.UserID, &j.Provider, &j.CalendarID,
+			&j.Status, &j.EventID, &j.Revision, &j.Attempts,
+			&j.Token.AccessToken, &j.Token.RefreshToken, &j.Token.ExpiresAt,
+			&startsAt, &seconds, &j.Role, &j.PeerName, &j.SubjectName, &j.Locale); err != nil {
+			return nil, err
+		}
+		if startsAt != nil {
+			j.StartsAt = *startsAt
+		}
+		j.Duration = time.Duration(seconds) * time.Second
+		list = append(list, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// удаления вперёд: отменённый урок не должен висеть в календаре дольше нужного
+	sort.SliceStable(list, func(i, k int) bool {
+		return list[i].Status == domain.CalendarEventToDelete && list[k].Status != domain.CalendarEventToDelete
+	})
+	return list, nil
+}
+
+func (r *calendarRepository) MarkSynced(ctx context.Context, id, eventID, link string, revision int) error {
+	tag, err := r.db.Exec(ctx, `
+UPDATE public.calendar_events
+   SET event_id = $2, html_link = NULLIF($3,''), synced_at = now(), error_msg = NULL, updated_at = now(),
+       status = CASE WHEN revision = $4 THEN 'synced'
+                     WHEN status = 'to_create' THEN 'to_update'
+                     ELSE status END,
+       attempts = CASE WHEN revision = $4 THEN attempts ELSE 0 END,
+       next_attempt_at = now()
+WHERE id = $1`, id, eventID, link, revision)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCalendarEventGone
+	}
+	return nil
+}
+
+func (r *calendarRepository) MarkDeleted(ctx context.Context, id string, revision int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM public.calendar_events WHERE id = $1 AND revision = $2`, id, revision)
+	if err != nil || tag.RowsAffected() > 0 {
+		return err
+	}
+	// урок снова запланирован, пока событие удалялось — создаём заново
+	_, err = r.db.Exec(ctx, `
+UPDATE public.calendar_events
+   SET event_id = NULL, html_link = NULL, next_attempt_at = now(), updated_at = now(),
+       status = CASE WHEN status = 'to_delete' THEN status ELSE 'to_create' END
+WHERE id = $1`, id)
+	return err
+}
+
+func (r *calendarRepository) Retry(ctx context.Context, id, reason string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.calendar_events SET next_attempt_at = $3, error_msg = $2, updated_at = now()
+WHERE id = $1`, id, reason, at)
+	return err
+}
+
+func (r *calendarRepository) MarkError(ctx context.Context, id, reason string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.calendar_events SET status = 'error', error_msg = $2, updated_at = now()
+WHERE id = $1`, id, reason)
+	return err
+}
+
+func (r *calendarRepository) ClaimBusy(ctx context.Context, every time.Duration, limit int) ([]domain.CalendarJob, error) {
+	// отметка busy_synced_at при взятии — она же lease: упавший опрос повторится через every
+	rows, err := r.db.Query(ctx, `
+UPDATE public.calendar_accounts a SET busy_synced_at = now()
+FROM (
+    SELECT id FROM public.calendar_accounts
+    WHERE status = 'active' AND (busy_synced_at IS NULL OR busy_synced_at <= now() - make_interval(secs => $1))
+    ORDER BY busy_synced_at NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+) due, public.oauth_tokens t
+WHERE a.id = due.id AND t.user_id = a.user_id AND t.provider = a.provider
+RETURNING a.id::text, a.user_id::text, a.provider, a.calendar_id,
+          t.access_token, COALESCE(t.refresh_token,''), t.expires_at`, every.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []domain.CalendarJob
+	for rows.Next() {
+		var j domain.CalendarJob
+		if err := rows.Scan(&j.AccountID, &j.UserID, &j.Provider, &j.CalendarID,
+			&j.Token.AccessToken, &j.Token.RefreshToken, &j.Token.ExpiresAt); err != nil {
+			return nil, err
+		}
+		list = append(list, j)
+	}
+	return list, rows.Err()
+}
+
+func (r *calendarRepository) ReplaceBusy(ctx context.Context, accountID, userID string, busy []calendar.Interval) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM public.calendar_busy WHERE account_id = $1`, accountID); err != nil {
+		return err
+	}
+	for _, b := range busy {
+		if !b.End.After(b.Start) {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO public.calendar_busy (account_id, user_id, starts_at, ends_at) VALUES ($1, $2, $3, $4)`,
+			accountID, userID, b.Start, b.End); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.calendar_accounts SET last_error = NULL, updated_at = now() WHERE id = $1`, accountID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *calendarRepository) Busy(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error) {
+	return r.busy(ctx, `(l.student_id = $1 OR l.tutor_id = $1)`, userID, from, to)
+}
+
+func (r *calendarRepository) TutorBusy(ctx context.Context, tutorID string, from, to time.Time) ([]domain.BusyInterval, error) {
+	var ok bool
+	if err := r.db.QueryRow(ctx, `
+SELECT EXISTS (SELECT 1 FROM public.tutor_profiles WHERE user_id = $1 AND deleted_at IS NULL)`,
+		tutorID).Scan(&ok); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBusyNotTutor
+	}
+	return r.busy(ctx, `l.tutor_id = $1`, tutorID, from, to)
+}
+
+// busy — уроки по условию lessonsWhere (параметр $1 — пользователь) плюс внешний календарь.
+func (r *calendarRepository) busy(ctx context.Context, lessonsWhere, userID string, from, to time.Time) ([]domain.BusyInterval, error) {
+	// свои уроки, выгруженные в календарь, возвращаются из free/busy ещё раз — их отбрасываем
+	rows, err := r.db.Query(ctx, `
+WITH booked AS (
+    SELECT l.started_at AS starts_at,
+           l.started_at + make_interval(secs => COALESCE(l.duration_seconds, EXTRACT(EPOCH FROM b.ends_at - b.starts_at)::int, 3600)) AS ends_at
+    FROM public.lessons l
+    LEFT JOIN public.bookings b ON b.id = l.booking_id
+    WHERE `+lessonsWhere+`
+      AND l.status IN ('scheduled','in_progress') AND l.started_at IS NOT NULL
+)
+SELECT starts_at, ends_at, 'lesson' FROM booked
+WHERE ends_at > $2 AND starts_at < $3
+UNION ALL
+SELECT c.starts_at, c.ends_at, 'calendar' FROM public.calendar_busy c
+WHERE c.user_id = $1 AND c.ends_at > $2 AND c.starts_at < $3
+  AND NOT EXISTS (SELECT 1 FROM booked l WHERE l.starts_at = c.starts_at AND l.ends_at = c.ends_at)
+ORDER BY 1`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []domain.BusyInterval{}
+	for rows.Next() {
+		var b domain.BusyInterval
+		if err := rows.Scan(&b.Start, &b.End, &b.Source); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPolicyNotFound       = errors.New("cancellation policy not found")
+	ErrPolicySuperseded     = errors.New("cancellation policy has a newer version")
+	ErrCancellationNotFound = errors.New("cancellation not found")
+	ErrAlreadyCancelled     = errors.New("booking is already cancelled")
+	ErrBookingNotCancelable = errors.New("booking cannot be cancelled in its current status")
+	ErrTutorProfileNotFound = errors.New("tutor profile not found")
+)
+
+// policyForBookingSQL — политика брони: зафиксированная на брони → выбранная репетитором → по умолчанию.
+const policyForBookingSQL = `COALESCE(
+    b.cancellation_policy_id,
+    (SELECT tp.cancellation_policy_id FROM public.tutor_profiles tp WHERE tp.user_id = b.tutor_id),
+    (SELECT cp.id FROM public.cancellation_policies cp WHERE cp.is_default))`
+
+type CancellationRepository interface {
+	// ListPolicies — актуальные версии политик.
+	ListPolicies(ctx context.Context) ([]domain.CancellationPolicy, error)
+	FindPolicy(ctx context.Context, policyID string) (*domain.CancellationPolicy, error)
+	// CreatePolicy; isDefault снимает флаг с прежней политики по умолчанию.
+	CreatePolicy(ctx context.Context, p *domain.CancellationPolicy) error
+	// UpdatePolicy создаёт следующую версию политики p.ID и переводит на неё репетиторов;
+	// брони остаются на зафиксированной версии. p.ID заменяется на id новой версии.
+	UpdatePolicy(ctx context.Context, p *domain.CancellationPolicy) error
+	// SetTutorPolicy — политика для новых броней репетитора; "" — вернуться к политике по умолчанию.
+	SetTutorPolicy(ctx context.Context, tutorID, policyID string) error
+	PolicyForBooking(ctx context.Context, bookingID string) (*domain.CancellationPolicy, error)
+
+	FindByBooking(ctx context.Context, bookingID string) (*domain.Cancellation, error)
+	// Create под блокировкой брони пишет cancellations и переводит бронь и урок в cancelled.
+	Create(ctx context.Context, c *domain.Cancellation) error
+	// Finish сохраняет итоговый outcome и (опц.) проводку платы за позднюю отмену.
+	Finish(ctx context.Context, cancellationID string, outcome domain.CancellationOutcome, fee *domain.LedgerTxn) error
+	// ClaimUnsettled забирает отмены, по которым деньги не удалось вернуть автоматически,
+	// с попытками меньше maxAttempts: счётчик растёт, а строка не видна другим репликам lease.
+	ClaimUnsettled(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]domain.Cancellation, error)
+}
+
+type cancellationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCancellationRepository(db *pgxpool.Pool) CancellationRepository {
+	return &cancellationRepository{db: db}
+}
+
+const policyColumns = `id, name, is_default, version, rules, COALESCE(superseded_by::text,''), created_at`
+
+func scanPolicy(row pgx.Row) (*domain.CancellationPolicy, error) {
+	var p domain.CancellationPolicy
+	var raw []byte
+	if err := row.Scan(&p.ID, &p.Name, &p.IsDefault, &p.Version, &raw, &p.SupersededBy, &p.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPolicyNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &p.Rules); err != nil {
+		return nil, fmt.Errorf("decode cancellation policy %s: %w", p.ID, err)
+	}
+	return &p, nil
+}
+
+func (r *cancellationRepository) ListPolicies(ctx context.Context) ([]domain.CancellationPolicy, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+policyColumns+` FROM public.cancellation_policies
+WHERE superseded_by IS NULL ORDER BY is_default DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.CancellationPolicy{}
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func (r *cancellationRepository) FindPolicy(ctx context.Context, policyID string) (*domain.CancellationPolicy, error) {
+	return scanPolicy(r.db.QueryRow(ctx, `SELECT `+policyColumns+` FROM public.cancellation_policies WHERE id = $1`, policyID))
+}
+
+func (r *cancellationRepository) CreatePolicy(ctx context.Context, p *domain.CancellationPolicy) error {
+	raw, err := json.Marshal(p.Rules)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if p.IsDefault {
+		if _, err := tx.Exec(ctx, `UPDATE public.cancellation_policies SET is_default = false WHERE is_default`); err != nil {
+			return err
+		}
+	}
+	if err := tx.QueryRow(ctx, `
+INSERT INTO public.cancellation_policies (name, is_default, rules)
+VALUES ($1, $2, $3::jsonb)
+RETURNING id, version, created_at`, p.Name, p.IsDefault, raw).Scan(&p.ID, &p.Version, &p.CreatedAt); err != nil {
+		return fmt.Errorf("insert cancellation policy: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *cancellationRepository) UpdatePolicy(ctx context.Context, p *domain.CancellationPolicy) error {
+	raw, err := json.Marshal(p.Rules)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var version int
+	var wasDefault, superseded bool
+	if err := tx.QueryRow(ctx, `
+SELECT version, is_default, superseded_by IS NOT NULL FROM public.cancellation_policies
+WHERE id = $1 FOR UPDATE`, p.ID).Scan(&version, &wasDefault, &superseded); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPolicyNotFound
+		}
+		return err
+	}
+	if superseded {
+		return ErrPolicySuperseded
+	}
+	if p.IsDefault || wasDefault {
+		if _, err := tx.Exec(ctx, `UPDATE public.cancellation_policies SET is_default = false WHERE is_default`); err != nil {
+			return err
+		}
+	}
+	prevID := p.ID
+	if err := tx.QueryRow(ctx, `
+INSERT INTO public.cancellation_policies (name, is_default, version, rules)
+VALUES ($1, $2, $3, $4::jsonb)
+RETURNING id, version, created_at`, p.Name, p.IsDefault, version+1, raw).Scan(&p.ID, &p.Version, &p.CreatedAt); err != nil {
+		return fmt.Errorf("insert cancellation policy version: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.cancellation_policies SET superseded_by = $2, superseded_at = now() WHERE id = $1`, prevID, p.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.tutor_profiles SET cancellation_policy_id = $2, updated_at = now()
+WHERE cancellation_policy_id = $1`, prevID, p.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *cancellationRepository) SetTutorPolicy(ctx context.Context, tutorID, policyID string) error {
+	if policyID != "" {
+		var superseded bool
+		err := r.db.QueryRow(ctx, `
+SELECT superseded_by IS NOT NULL FROM public.cancellation_policies WHERE id = $1`, policyID).Scan(&superseded)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrPolicyNotFound
+		case err != nil:
+			return err
+		case superseded:
+			return ErrPolicySuperseded
+		}
+	}
+	tag, err := r.db.Exec(ctx, `
+UPDATE public.tutor_profiles SET cancellation_policy_id = NULLIF($2,'')::uuid, updated_at = now()
+WHERE user_id = $1`, tutorID, policyID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrPolicyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTutorProfileNotFound
+	}
+	return nil
+}
+
+func (r *cancellationRepository) PolicyForBooking(ctx context.Context, bookingID string) (*domain.CancellationPolicy, error) {
+	return scanPolicy(r.db.QueryRow(ctx, `
+SELECT `+policyColumns+` FROM public.cancellation_policies
+WHERE id = (SELECT `+policyForBookingSQL+` FROM public.bookings b WHERE b.id = $1)`, bookingID))
+}
+
+const cancellationColumns = `id, booking_id, COALESCE(lesson_id::text,''), requested_by, COALESCE(initiator,''),
+       COALESCE(reason,''), COALESCE(policy_id::text,''), COALESCE(outcome, '{}'::jsonb), settle_attempts, created_at`
+
+func scanCancellation(row pgx.Row) (*domain.Cancellation, error) {
+	var c domain.Cancellation
+	var raw []byte
+	err := row.Scan(&c.ID, &c.BookingID, &c.LessonID, &c.RequestedBy, &c.Initiator, &c.Reason, &c.PolicyID, &raw, &c.SettleAttempts, &c.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCancellationNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &c.Outcome); err != nil {
+		return nil, fmt.Errorf("decode cancellation outcome %s: %w", c.ID, err)
+	}
+	return &c, nil
+}
+
+func (r *cancellationRepository) FindByBooking(ctx context.Context, bookingID string) (*domain.Cancellation, error) {
+	return scanCancellation(r.db.QueryRow(ctx, `
+SELECT `+cancellationColumns+` FROM public.cancellations WHERE booking_id = $1`, bookingID))
+}
+
+func (r *cancellationRepository) ClaimUnsettled(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]domain.Cancellation, error) {
+	rows, err := r.db.Query(ctx, `
+WITH picked AS (
+    SELECT id FROM public.cancellations
+    WHERE outcome->>'error' IS NOT NULL AND settle_attempts < $2
+      AND COALESCE(settle_next_at, created_at) <= now()
+    ORDER BY created_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE public.cancellations
+SET settle_attempts = settle_attempts + 1, settle_next_at = now() + make_interval(secs => $3)
+WHERE id IN (SELECT id FROM picked)
+RETURNING `+cancellationColumns, limit, maxAttempts, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Cancellation
+	for rows.Next() {
+		c, err := scanCancellation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *cancellationRepository) Create(ctx context.Context, c *domain.Cancellation) error {
+	outcome, err := json.Marshal(c.Outcome)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM public.bookings WHERE id = $1 FOR UPDATE`, c.BookingID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBookingNotFound
+		}
+		return err
+	}
+	switch status {
+	case domain.BookingPending, domain.BookingAwaitingPayment, domain.BookingConfirmed:
+	case domain.BookingCancelled:
+		return ErrAlreadyCancelled
+	default:
+		return fmt.Errorf("%w: booking is %s", ErrBookingNotCancelable, status)
+	}
+
+	err = tx.QueryRow(ctx, `
+INSERT INTO public.cancellations (booking_id, lesson_id, requested_by, initiator, reason, policy_id, outcome)
+VALUES ($1, (SELECT id FROM public.lessons WHERE booking_id = $1), $2, $3, NULLIF($4,''), NULLIF($5,'')::uuid, $6::jsonb)
+ON CONFLICT (booking_id) DO NOTHING
+RETURNING id, COALESCE(lesson_id::text,''), created_at`,
+		c.BookingID, c.RequestedBy, c.Initiator, c.Reason, c.PolicyID, outcome).Scan(&c.ID, &c.LessonID, &c.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAlreadyCancelled
+		}
+		return fmt.Errorf("insert cancellation: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE public.bookings SET status = 'cancelled', updated_at = now() WHERE id = $1`, c.BookingID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.lessons SET status = 'cancelled', updated_at = now()
+WHERE booking_id = $1 AND status = 'scheduled'`, c.BookingID); err != nil {
+		return err
+	}
+	// об отмене подтверждённого урока сообщаем второй стороне (при отмене администратором — обеим)
+	if status == domain.BookingConfirmed {
+		if err := notifyBookingParties(ctx, tx, c.BookingID, "lesson.cancelled", c.RequestedBy,
+			map[string]any{"lessonId": c.LessonID, "reason": c.Reason}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *cancellationRepository) Finish(ctx context.Context, cancellationID string, outcome domain.CancellationOutcome, fee *domain.LedgerTxn) error {
+	raw, err := json.Marshal(outcome)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `UPDATE public.cancellations SET outcome = $2::jsonb WHERE id = $1`, cancellationID, raw); err != nil {
+		return err
+	}
+	if fee != nil {
+		if err := postLedger(ctx, tx, *fee); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
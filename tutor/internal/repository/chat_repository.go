@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"shared/audit"
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrChatParticipant      = errors.New("student or tutor profile not found")
+	ErrOffenderNotFound     = errors.New("chat offender not found")
+)
+
+// chatChannel — канал LISTEN/NOTIFY для событий чата между инстансами.
+const chatChannel = "chat_events"
+
+type ChatRepository interface {
+	// StartConversation создаёт диалог-запрос или возвращает существующий для пары (created=false).
+	StartConversation(ctx context.Context, studentID, tutorID, initiatorID string) (*domain.Conversation, bool, error)
+	FindConversation(ctx context.Context, conversationID, viewerID string) (*domain.Conversation, error)
+	ListConversations(ctx context.Context, userID, status string, page, limit int) ([]domain.Conversation, int, error)
+	SetStatus(ctx context.Context, conversationID, status, actorID string) (*domain.Conversation, error)
+
+	// InsertMessage пишет сообщение (Original → body, Body → body_redacted, если Redacted),
+	// двигает last_message_at и отметку прочтения автора.
+	InsertMessage(ctx context.Context, m *domain.Message) error
+	// HasBooking — есть ли у пары подтверждённая бронь (после неё контакты не скрываем).
+	HasBooking(ctx context.Context, studentID, tutorID string) (bool, error)
+	CountMessages(ctx context.Context, conversationID, senderID string) (int, error)
+	FindMessage(ctx context.Context, messageID string) (*domain.Message, error)
+	// History — сообщения от новых к старым; before — id сообщения-курсора (не включительно).
+	History(ctx context.Context, conversationID, before string, limit int) ([]domain.Message, error)
+	MarkRead(ctx context.Context, conversationID, userID string, at time.Time) error
+
+	// RecordViolation пересчитывает нарушения автора за окно (после последней проверки)
+	// и ставит флаг при достижении порога; flagged — пользователь ждёт модерации.
+	RecordViolation(ctx context.Context, userID string, window time.Duration, threshold int) (flagged bool, err error)
+	ListOffenders(ctx context.Context, onlyFlagged bool, page, limit int) ([]domain.ChatOffender, int, error)
+	ReviewOffender(ctx context.Context, userID, reviewerID, note string) (*domain.ChatOffender, error)
+
+	// Notify публикует событие всем инстансам; Listen блокируется и отдаёт события в fn.
+	Notify(ctx context.Context, ev domain.ChatEvent) error
+	Listen(ctx context.Context, fn func(domain.ChatEvent)) error
+}
+
+type chatRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewChatRepository(db *pgxpool.Pool) ChatRepository {
+	return &chatRepository{db: db}
+}
+
+// conversationColumns; $2 — пользователь, для которого считаются непрочитанные.
+const conversationColumns = `c.id, c.student_id, c.tutor_id, c.status, COALESCE(c.initiator_id::text,''), c.approved_at,
+       COALESCE(c.blocked_by::text,''), c.last_message_at, c.created_at,
+       (SELECT count(*) FROM public.messages m
+        WHERE m.conversation_id = c.id AND m.sender_id <> $2
+          AND m.created_at > COALESCE((SELECT r.last_read_at FROM public.conversation_reads r
+                                       WHERE r.conversation_id = c.id AND r.user_id = $2), '-infinity')),
+       (SELECT max(r.last_read_at) FROM public.conversation_reads r
+        WHERE r.conversation_id = c.id AND r.user_id <> $2)`
+
+func scanConversation(row pgx.Row) (*domain.Conversation, error) {
+	var c domain.Conversation
+	if err := row.Scan(&c.ID, &c.StudentID, &c.TutorID, &c.Status, &c.InitiatorID, &c.ApprovedAt,
+		&c.BlockedBy, &c.LastMessageAt, &c.CreatedAt, &c.UnreadCount, &c.PeerReadAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *chatRepository) StartConversation(ctx context.Context, studentID, tutorID, initiatorID string) (*domain.Conversation, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var id string
+	err = tx.QueryRow(ctx, `
+INSERT INTO public.conversations (student_id, tutor_id, initiator_id, status)
+VALUES ($1, $2, $3, 'pending')
+ON CONFLICT (student_id, tutor_id) DO NOTHING
+RETURNING id`, studentID, tutorID, initiatorID).Scan(&id)
+	created := err == nil
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23503":
+		return nil, false, ErrChatParticipant
+	case errors.Is(err, pgx.ErrNoRows):
+		if err := tx.QueryRow(ctx, `
+SELECT id FROM public.conversations WHERE student_id = $1 AND tutor_id = $2`, studentID, tutorID).Scan(&id); err != nil {
+			return nil, false, err
+		}
+	case err != nil:
+		return nil, false, fmt.Errorf("insert conversation: %w", err)
+	default:
+		if err := notifyPeer(ctx, tx, id, initiatorID, "chat.request", nil); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	c, err := r.FindConversation(ctx, id, initiatorID)
+	return c, created, err
+}
+
+func (r *chatRepository) FindConversation(ctx context.Context, conversationID, viewerID string) (*domain.Conversation, error) {
+	return scanConversation(r.db.QueryRow(ctx, `
+SELECT `+conversationColumns+` FROM public.conversations c WHERE c.id = $1`, conversationID, viewerID))
+}
+
+func (r *chatRepository) ListConversations(ctx context.Context, userID, status string, page, limit int) ([]domain.Conversation, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `
+SELECT count(*) FROM public.conversations c
+WHERE (c.student_id = $1 OR c.tutor_id = $1) AND ($2 = '' OR c.status = $2)`, userID, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(ctx, `
+SELECT `+conversationColumns+` FROM public.conversations c
+WHERE (c.student_id = $2 OR c.tutor_id = $2) AND ($1 = '' OR c.status = $1)
+ORDER BY COALESCE(c.last_message_at, c.created_at) DESC
+LIMIT $3 OFFSET $4`, status, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []domain.Conversation{}
+	for rows.Next() {
+		c, err := scanConversation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *c)
+	}
+	return out, total, rows.Err()
+}
+
+func (r *chatRepository) SetStatus(ctx context.Context, conversationID, status, actorID string) (*domain.Conversation, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var prev string
+	err = tx.QueryRow(ctx, `SELECT status FROM public.conversations WHERE id = $1 FOR UPDATE`, conversationID).Scan(&prev)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.conversations
+SET status = $2,
+    approved_at = CASE WHEN $2 = 'active' THEN COALESCE(approved_at, now()) ELSE approved_at END,
+    blocked_by = CASE WHEN $2 = 'blocked' THEN $3::uuid ELSE NULL END
+WHERE id = $1`, conversationID, status, actorID); err != nil {
+		return nil, err
+	}
+	if err := audit.Write(ctx, tx, actorID, "conversation", conversationID, "conversation.status",
+		map[string]any{"status": prev}, map[string]any{"status": status}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindConversation(ctx, conversationID, actorID)
+}
+
+func (r *chatRepository) InsertMessage(ctx context.Context, m *domain.Message) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var redacted *string
+	meta := map[string]any{}
+	if m.Redacted {
+		redacted = &m.Body
+		meta["redactions"] = m.Redactions
+	}
+	rawMeta, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := tx.QueryRow(ctx, `
+INSERT INTO public.messages (conversation_id, sender_id, body, body_redacted, meta)
+VALUES ($1, $2, $3, $4, $5::jsonb)
+RETURNING id, created_at`, m.ConversationID, m.SenderID, m.Original, redacted, rawMeta).Scan(&m.ID, &m.CreatedAt); err != nil {
+		return fmt.Errorf("insert message: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.conversations SET last_message_at = $2 WHERE id = $1`, m.ConversationID, m.CreatedAt); err != nil {
+		return err
+	}
+	if err := markRead(ctx, tx, m.ConversationID, m.SenderID, m.CreatedAt); err != nil {
+		return err
+	}
+	if err := notifyPeer(ctx, tx, m.ConversationID, m.SenderID, "chat.message", map[string]any{
+		"conversationId": m.ConversationID,
+		"messageId":      m.ID,
+		"preview":        messagePreview(m.Body),
+	}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// notifyPeer ставит уведомление второму участнику диалога; peerName — имя отправителя.
+func notifyPeer(ctx context.Context, tx pgx.Tx, conversationID, senderID, templateKey string, extra map[string]any) error {
+	var recipientID, role, sender string
+	if err := tx.QueryRow(ctx, `
+SELECT CASE WHEN c.student_id = $2 THEN c.tutor_id ELSE c.student_id END::text,
+       CASE WHEN c.student_id = $2 THEN 'tutor' ELSE 'student' END,
+       COALESCE(NULLIF(trim(concat_ws(' ', u.first_name, u.last_name)), ''), '')
+FROM public.conversations c JOIN public.users u ON u.id = $2
+WHERE c.id = $1`, conversationID, senderID).Scan(&recipientID, &role, &sender); err != nil {
+		return err
+	}
+	payload := map[string]any{"conversationId": conversationID, "peerName": sender, "role": role}
+	maps.Copy(payload, extra)
+	ns, err := templateNotifications(recipientID, templateKey, payload)
+	if err != nil {
+		return err
+	}
+	return enqueueNotifications(ctx, tx, ns)
+}
+
+// messagePreview — начало сообщения для push и in-app (контакты в нём уже скрыты).
+func messagePreview(body string) string {
+	const limit = 140
+	r := []rune(strings.TrimSpace(body))
+	if len(r) <= limit {
+		return string(r)
+	}
+	return string(r[:limit-1]) + "…"
+}
+
+func (r *chatRepository) CountMessages(ctx context.Context, conversationID, senderID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `
+SELECT count(*) FROM public.messages WHERE conversation_id = $1 AND sender_id = $2`, conversationID, senderID).Scan(&n)
+	return n, err
+}
+
+const messageColumns = `id, conversation_id, sender_id, COALESCE(body_redacted, body, ''), COALESCE(body,''),
+       body_redacted IS NOT NULL, COALESCE(meta->'redactions', '[]'::jsonb), hidden_at IS NOT NULL, created_at`
+
+func scanMessage(row pgx.Row) (*domain.Message, error) {
+	var m domain.Message
+	var kinds []byte
+	if err := row.Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.Body, &m.Original, &m.Redacted, &kinds, &m.Hidden, &m.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(kinds, &m.Redactions); err != nil {
+		return nil, fmt.Errorf("decode message meta %s: %w", m.ID, err)
+	}
+	return &m, nil
+}
+
+func (r *chatRepository) HasBooking(ctx context.Context, studentID, tutorID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `
+SELECT EXISTS (SELECT 1 FROM public.bookings
+               WHERE student_id = $1 AND tutor_id = $2 AND status = 'confirmed')`, studentID, tutorID).Scan(&ok)
+	return ok, err
+}
+
+func (r *chatRepository) FindMessage(ctx context.Context, messageID string) (*domain.Message, error) {
+	return scanMessage(r.db.QueryRow(ctx, `SELECT `+messageColumns+` FROM public.messages WHERE id = $1`, messageID))
+}
+
+func (r *chatRepository) History(ctx context.Context, conversationID, before string, limit int) ([]domain.Message, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT `+messageColumns+` FROM public.messages
+WHERE conversation_id = $1
+  AND ($2 = '' OR (created_at, id) < (SELECT created_at, id FROM public.messages WHERE id = NULLIF($2,'')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3`, conversationID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.Message{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
+
+func (r *chatRepository) MarkRead(ctx context.Context, conversationID, userID string, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := markRead(ctx, tx, conversationID, userID, at); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// markRead не сдвигает отметку назад (параллельные вкладки, запоздавшие запросы).
+func markRead(ctx context.Context, tx pgx.Tx, conversationID, userID string, at time.Time) error {
+	_, err := tx.Exec(ctx, `
+INSERT INTO public.conversation_reads (conversation_id, user_id, last_read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (conversation_id, user_id)
+DO UPDATE SET last_read_at = GREATEST(conversation_reads.last_read_at, EXCLUDED.last_read_at)`,
+		conversationID, userID, at)
+	return err
+}
+
+func (r *chatRepository) RecordViolation(ctx context.Context, userID string, window time.Duration, threshold int) (bool, error) {
+	var flagged bool
+	err := r.db.QueryRow(ctx, `
+WITH c AS (
+    SELECT count(*) AS n FROM public.messages
+    WHERE sender_id = $1 AND body_redacted IS NOT NULL
+      AND created_at > GREATEST(now() - make_interval(secs => $2),
+                                COALESCE((SELECT reviewed_at FROM public.chat_offenders WHERE user_id = $1), '-infinity'))
+)
+INSERT INTO public.chat_offenders (user_id, violations, last_violation_at, flagged_at)
+SELECT $1, c.n, now(), CASE WHEN c.n >= $3 THEN now() END FROM c
+ON CONFLICT (user_id) DO UPDATE SET
+    violations = EXCLUDED.violations,
+    last_violation_at = EXCLUDED.last_violation_at,
+    flagged_at = CASE WHEN chat_offenders.flagged_at IS NOT NULL AND chat_offenders.reviewed_at IS NULL
+                      THEN chat_offenders.flagged_at ELSE EXCLUDED.flagged_at END,
+    reviewed_at = CASE WHEN EXCLUDED.flagged_at IS NOT NULL THEN NULL ELSE chat_offenders.reviewed_at END
+RETURNING flagged_at IS NOT NULL AND reviewed_at IS NULL`, userID, window.Seconds(), threshold).Scan(&flagged)
+	return flagged, err
+}
+
+const offenderColumns = `user_id, violations, last_violation_at, flagged_at, reviewed_at,
+       COALESCE(reviewed_by::text,''), COALESCE(review_note,'')`
+
+func scanOffender(row pgx.Row) (*domain.ChatOffender, error) {
+	var o domain.ChatOffender
+	if err := row.Scan(&o.UserID, &o.Violations, &o.LastViolationAt, &o.FlaggedAt, &o.ReviewedAt,
+		&o.ReviewedBy, &o.ReviewNote); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOffenderNotFound
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *chatRepository) ListOffenders(ctx context.Context, onlyFlagged bool, page, limit int) ([]domain.ChatOffender, int, error) {
+	const where = `WHERE NOT $1 OR (flagged_at IS NOT NULL AND reviewed_at IS NULL)`
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT count(*) FROM public.chat_offenders `+where, onlyFlagged).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(ctx, `
+SELECT `+offenderColumns+` FROM public.chat_offenders `+where+`
+ORDER BY flagged_at DESC NULLS LAST, last_violation_at DESC
+LIMIT $2 OFFSET $3`, onlyFlagged, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []domain.ChatOffender{}
+	for rows.Next() {
+		o, err := scanOffender(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *o)
+	}
+	return out, total, rows.Err()
+}
+
+func (r *chatRepository) ReviewOffender(ctx context.Context, userID, reviewerID, note string) (*domain.ChatOffender, error) {
+	return scanOffender(r.db.QueryRow(ctx, `
+UPDATE public.chat_offenders
+SET reviewed_at = now(), reviewed_by = $2, review_note = NULLIF($3,''), violations = 0
+WHERE user_id = $1
+RETURNING `+offenderColumns, userID, reviewerID, note))
+}
+
+func (r *chatRepository) Notify(ctx context.Context, ev domain.ChatEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `SELECT pg_notify($1, $2)`, chatChannel, string(payload))
+	return err
+}
+
+func (r *chatRepository) Listen(ctx context.Context, fn func(domain.ChatEvent)) error {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	// при отмене ctx pgx закрывает соединение; иначе снимаем подписку перед возвратом в пул
+	defer func() { _, _ = conn.Exec(context.Background(), `UNLISTEN `+chatChannel) }()
+	if _, err := conn.Exec(ctx, `LISTEN `+chatChannel); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var ev domain.ChatEvent
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			continue
+		}
+		fn(ev)
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRuleSetNotFound = errors.New("no commission rules in effect")
+	ErrRuleSetExists   = errors.New("commission rules version or effective date already exists")
+)
+
+type CommissionRepository interface {
+	ListRuleSets(ctx context.Context) ([]domain.CommissionRuleSet, error)
+	// RuleSetAt — версия, действующая на момент at (последняя с effective_from <= at).
+	RuleSetAt(ctx context.Context, at time.Time) (*domain.CommissionRuleSet, error)
+	CreateRuleSet(ctx context.Context, rs domain.CommissionRuleSet) error
+	// TutorVolume — накопленный объём проведённых уроков репетитора по валютам.
+	TutorVolume(ctx context.Context, tutorID string) (map[string]int64, error)
+	FindByLesson(ctx context.Context, lessonID string) (*domain.Commission, error)
+}
+
+type commissionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCommissionRepository(db *pgxpool.Pool) CommissionRepository {
+	return &commissionRepository{db: db}
+}
+
+func scanRuleSet(row pgx.Row) (*domain.CommissionRuleSet, error) {
+	var rs domain.CommissionRuleSet
+	var raw []byte
+	if err := row.Scan(&rs.Version, &raw, &rs.EffectiveFrom, &rs.CreatedBy, &rs.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &rs.Rules); err != nil {
+		return nil, fmt.Errorf("decode commission rules %s: %w", rs.Version, err)
+	}
+	return &rs, nil
+}
+
+func (r *commissionRepository) ListRuleSets(ctx context.Context) ([]domain.CommissionRuleSet, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT version, rules, effective_from, COALESCE(created_by::text,''), created_at
+FROM public.commission_rules ORDER BY effective_from DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.CommissionRuleSet{}
+	for rows.Next() {
+		rs, err := scanRuleSet(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rs)
+	}
+	return out, rows.Err()
+}
+
+func (r *commissionRepository) RuleSetAt(ctx context.Context, at time.Time) (*domain.CommissionRuleSet, error) {
+	rs, err := scanRuleSet(r.db.QueryRow(ctx, `
+SELECT version, rules, effective_from, COALESCE(created_by::text,''), created_at
+FROM public.commission_rules WHERE effective_from <= $1
+ORDER BY effective_from DESC LIMIT 1`, at))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRuleSetNotFound
+	}
+	return rs, err
+}
+
+func (r *commissionRepository) CreateRuleSet(ctx context.Context, rs domain.CommissionRuleSet) error {
+	raw, err := json.Marshal(rs.Rules)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `
+INSERT INTO public.commission_rules (version, rules, effective_from, created_by)
+VALUES ($1, $2::jsonb, $3, NULLIF($4,'')::uuid)`, rs.Version, raw, rs.EffectiveFrom, rs.CreatedBy)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrRuleSetExists
+	}
+	return err
+}
+
+func (r *commissionRepository) TutorVolume(ctx context.Context, tutorID string) (map[string]int64, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT c.currency, SUM(c.commission_minor + c.tutor_earn_minor)::bigint
+FROM public.commissions c
+JOIN public.lessons l ON l.id = c.lesson_id
+WHERE l.tutor_id = $1 AND l.status = 'completed'
+GROUP BY c.currency`, tutorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int64{}
+	for rows.Next() {
+		var cur string
+		var sum int64
+		if err := rows.Scan(&cur, &sum); err != nil {
+			return nil, err
+		}
+		out[cur] = sum
+	}
+	return out, rows.Err()
+}
+
+func (r *commissionRepository) FindByLesson(ctx context.Context, lessonID string) (*domain.Commission, error) {
+	var c domain.Commission
+	var raw []byte
+	err := r.db.QueryRow(ctx, `
+SELECT id, lesson_id, commission_minor, tutor_earn_minor, currency, rule_version, details, created_at
+FROM public.commissions WHERE lesson_id = $1`, lessonID).
+		Scan(&c.ID, &c.LessonID, &c.CommissionMinor, &c.TutorEarnMinor, &c.Currency, &c.RuleVersion, &raw, &c.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLessonNotFound
+		}
+		return nil, err
+	}
+	_ = json.Unmarshal(raw, &c.Details)
+	return &c, nil
+}
@@ -0,0 +1,565 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"shared/audit"
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCredentialNotFound = errors.New("education or certificate entry not found")
+	ErrUnknownCredential  = errors.New("unknown credential kind")
+)
+
+// CredentialRepository — записи об образовании и сертификаты анкеты по одной: правка,
+// документы и проверка админом. Шаг мастера целиком пишет TutorRepository.UpsertEducation.
+type CredentialRepository interface {
+	List(ctx context.Context, tutorID string) ([]domain.Education, []domain.Certification, error)
+	// SaveEducation/SaveCertificate — без ID создают запись в конце списка, с ID — обновляют свою.
+	SaveEducation(ctx context.Context, tutorID string, e domain.Education) (*domain.Education, error)
+	SaveCertificate(ctx context.Context, tutorID string, c domain.Certification) (*domain.Certification, error)
+	// Delete возвращает ключ документа удалённой записи (пусто, если его не было).
+	Delete(ctx context.Context, tutorID, kind, id string) (string, error)
+
+	// Document — владелец записи и её документ (nil, если не загружен).
+	Document(ctx context.Context, kind, id string) (string, *domain.Document, error)
+	// SetDocument прикладывает документ (nil — убирает) и возвращает ключ прежнего.
+	SetDocument(ctx context.Context, tutorID, kind, id string, doc *domain.Document) (string, error)
+
+	Review(ctx context.Context, actorID, kind, id, status, note string) (*domain.CredentialEntry, error)
+	ListForReview(ctx context.Context, f domain.CredentialFilter, page, limit int) ([]domain.CredentialEntry, int, error)
+}
+
+type credentialRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCredentialRepository(db *pgxpool.Pool) CredentialRepository {
+	return &credentialRepository{db: db}
+}
+
+// credentialTables — вид записи → таблица.
+var credentialTables = map[string]string{
+	domain.CredentialEducation:   "tutor_education",
+	domain.CredentialCertificate: "tutor_certificates",
+}
+
+func credentialTable(kind string) (string, error) {
+	t, ok := credentialTables[kind]
+	if !ok {
+		return "", ErrUnknownCredential
+	}
+	return "public." + t, nil
+}
+
+// queryer — общее у pgxpool.Pool и pgx.Tx для чтения.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// credentialMetaColumns — документ и проверка; одинаковы в обеих таблицах.
+const credentialMetaColumns = `document_key, COALESCE(document_name,''), COALESCE(document_type,''),
+       COALESCE(document_size,0), document_uploaded_at,
+       verification, COALESCE(verification_note,''), verified_at`
+
+// credentialMeta — приёмник credentialMetaColumns для Scan.
+type credentialMeta struct {
+	key        *string
+	doc        domain.Document
+	uploadedAt *time.Time
+	review     domain.CredentialReview
+}
+
+func (m *credentialMeta) dest() []any {
+	return []any{&m.key, &m.doc.Name, &m.doc.ContentType, &m.doc.Size, &m.uploadedAt,
+		&m.review.Verification, &m.review.VerificationNote, &m.review.VerifiedAt}
+}
+
+func (m *credentialMeta) document() *domain.Document {
+	if m.key == nil {
+		return nil
+	}
+	d := m.doc
+	d.Key = *m.key
+	if m.uploadedAt != nil {
+		d.UploadedAt = *m.uploadedAt
+	}
+	return &d
+}
+
+// loadEducation — записи об образовании репетитора по порядку, с данными вуза; id — только одна.
+func loadEducation(ctx context.Context, q queryer, tutorID, id string) ([]domain.Education, error) {
+	rows, err := q.Query(ctx, `
+SELECT e.id::text, e.degree, e.institution, COALESCE(e.university_id::text,''), e.year, e.field,
+       COALESCE(un.slug,''), un.name, COALESCE(un.country_code,''), COALESCE(un.city,''),
+       `+credentialMetaColumns+`
+FROM public.tutor_education e
+LEFT JOIN public.universities un ON un.id = e.university_id
+WHERE e.tutor_id = $1 AND ($2 = '' OR e.id::text = $2)
+ORDER BY e.position, e.created_at`, tutorID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.Education{}
+	for rows.Next() {
+		var e domain.Education
+		var u domain.University
+		var uname []byte
+		var m credentialMeta
+		dest := append([]any{&e.ID, &e.Degree, &e.Institution, &e.UniversityID, &e.Year, &e.Field,
+			&u.Slug, &uname, &u.CountryCode, &u.City}, m.dest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if e.UniversityID != "" {
+			u.ID = e.UniversityID
+			_ = json.Unmarshal(uname, &u.Name)
+			e.University = &u
+		}
+		e.Document, e.CredentialReview = m.document(), m.review
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func loadCertificates(ctx context.Context, q queryer, tutorID, id string) ([]domain.Certification, error) {
+	rows, err := q.Query(ctx, `
+SELECT id::text, name, issuer, year, `+credentialMetaColumns+`
+FROM public.tutor_certificates
+WHERE tutor_id = $1 AND ($2 = '' OR id::text = $2)
+ORDER BY position, created_at`, tutorID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.Certification{}
+	for rows.Next() {
+		var c domain.Certification
+		var m credentialMeta
+		if err := rows.Scan(append([]any{&c.ID, &c.Name, &c.Issuer, &c.Year}, m.dest()...)...); err != nil {
+			return nil, err
+		}
+		c.Document, c.CredentialReview = m.document(), m.review
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// resetReview — при изменении содержимого проверка начинается заново; changed — SQL-условие изменения.
+func resetReview(changed string) string {
+	return fmt.Sprintf(`
+    verification      = CASE WHEN %[1]s THEN 'pending' ELSE verification END,
+    verification_note = CASE WHEN %[1]s THEN NULL ELSE verification_note END,
+    verified_by       = CASE WHEN %[1]s THEN NULL ELSE verified_by END,
+    verified_at       = CASE WHEN %[1]s THEN NULL ELSE verified_at END`, changed)
+}
+
+// saveEducation вставляет (без ID) или обновляет запись; position < 0 — в конец списка / не двигать.
+func saveEducation(ctx context.Context, tx pgx.Tx, tutorID string, e *domain.Education, position int) error {
+	e.Degree, e.Institution = strings.TrimSpace(e.Degree), strings.TrimSpace(e.Institution)
+	e.Year, e.Field = strings.TrimSpace(e.Year), strings.TrimSpace(e.Field)
+	if err := linkUniversity(ctx, tx, e); err != nil {
+		return err
+	}
+	if e.ID == "" {
+		return tx.QueryRow(ctx, `
+INSERT INTO public.tutor_education (tutor_id, position, degree, institution, university_id, year, field)
+VALUES ($1, CASE WHEN $2 < 0 THEN (SELECT COALESCE(max(position)+1, 0) FROM public.tutor_education WHERE tutor_id = $1) ELSE $2 END,
+        $3, $4, NULLIF($5,'')::uuid, $6, $7)
+RETURNING id::text`, tutorID, position, e.Degree, e.Institution, e.UniversityID, e.Year, e.Field).Scan(&e.ID)
+	}
+	tag, err := tx.Exec(ctx, `
+UPDATE public.tutor_education SET`+resetReview(`(degree, institution, university_id, year, field) IS DISTINCT FROM ($3, $4, NULLIF($5,'')::uuid, $6, $7)`)+`,
+    degree = $3, institution = $4, university_id = NULLIF($5,'')::uuid, year = $6, field = $7,
+    position = CASE WHEN $8 < 0 THEN position ELSE $8 END,
+    updated_at = now()
+WHERE id::text = $1 AND tutor_id = $2`, e.ID, tutorID, e.Degree, e.Institution, e.UniversityID, e.Year, e.Field, position)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+func saveCertificate(ctx context.Context, tx pgx.Tx, tutorID string, c *domain.Certification, position int) error {
+	c.Name, c.Issuer, c.Year = strings.TrimSpace(c.Name), strings.TrimSpace(c.Issuer), strings.TrimSpace(c.Year)
+	if c.ID == "" {
+		return tx.QueryRow(ctx, `
+INSERT INTO public.tutor_certificates (tutor_id, position, name, issuer, year)
+VALUES ($1, CASE WHEN $2 < 0 THEN (SELECT COALESCE(max(position)+1, 0) FROM public.tutor_certificates WHERE tutor_id = $1) ELSE $2 END,
+        $3, $4, $5)
+RETURNING id::text`, tutorID, position, c.Name, c.Issuer, c.Year).Scan(&c.ID)
+	}
+	tag, err := tx.Exec(ctx, `
+UPDATE public.tutor_certificates SET`+resetReview(`(name, issuer, year) IS DISTINCT FROM ($3, $4, $5)`)+`,
+    name = $3, issuer = $4, year = $5,
+    position = CASE WHEN $6 < 0 THEN position ELSE $6 END,
+    updated_at = now()
+WHERE id::text = $1 AND tutor_id = $2`, c.ID, tutorID, c.Name, c.Issuer, c.Year, position)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+// replaceEducation приводит записи репетитора к списку шага мастера: с ID обновляются, без ID —
+// добавляются, остальные удаляются. Запись без ID с тем же содержимым, что у существующей,
+// считается ею (старый клиент не знает про ID — документы и проверка не должны пропадать).
+// Возвращает ключи документов удалённых записей.
+func replaceEducation(ctx context.Context, tx pgx.Tx, tutorID string, list []domain.Education) ([]string, error) {
+	existing, err := loadEducation(ctx, tx, tutorID, "")
+	if err != nil {
+		return nil, err
+	}
+	claimed := map[string]bool{}
+	for _, e := range list {
+		claimed[e.ID] = true
+	}
+	keep := make([]string, 0, len(list))
+	for i := range list {
+		e := &list[i]
+		if e.ID == "" {
+			for _, x := range existing {
+				if !claimed[x.ID] && sameEducation(x, *e) {
+					e.ID, claimed[x.ID] = x.ID, true
+					break
+				}
+			}
+		}
+		if err := saveEducation(ctx, tx, tutorID, e, i); err != nil {
+			return nil, fmt.Errorf("education[%d]: %w", i, err)
+		}
+		keep = append(keep, e.ID)
+	}
+	return deleteCredentialsExcept(ctx, tx, "public.tutor_education", tutorID, keep)
+}
+
+func replaceCertificates(ctx context.Context, tx pgx.Tx, tutorID string, list []domain.Certification) ([]string, error) {
+	existing, err := loadCertificates(ctx, tx, tutorID, "")
+	if err != nil {
+		return nil, err
+	}
+	claimed := map[string]bool{}
+	for _, c := range list {
+		claimed[c.ID] = true
+	}
+	keep := make([]string, 0, len(list))
+	for i := range list {
+		c := &list[i]
+		if c.ID == "" {
+			for _, x := range existing {
+				if !claimed[x.ID] && strings.TrimSpace(c.Name) == x.Name &&
+					strings.TrimSpace(c.Issuer) == x.Issuer && strings.TrimSpace(c.Year) == x.Year {
+					c.ID, claimed[x.ID] = x.ID, true
+					break
+				}
+			}
+		}
+		if err := saveCertificate(ctx, tx, tutorID, c, i); err != nil {
+			return nil, fmt.Errorf("certificates[%d]: %w", i, err)
+		}
+		keep = append(keep, c.ID)
+	}
+	return deleteCredentialsExcept(ctx, tx, "public.tutor_certificates", tutorID, keep)
+}
+
+func sameEducation(x, e domain.Education) bool {
+	return x.Degree == strings.TrimSpace(e.Degree) && x.Year == strings.TrimSpace(e.Year) &&
+		x.Field == strings.TrimSpace(e.Field) && x.UniversityID == strings.TrimSpace(e.UniversityID) &&
+		(x.Institution == strings.TrimSpace(e.Institution) || strings.TrimSpace(e.Institution) == "" && e.UniversityID != "")
+}
+
+func deleteCredentialsExcept(ctx context.Context, tx pgx.Tx, table, tutorID string, keep []string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+DELETE FROM `+table+` WHERE tutor_id = $1 AND NOT (id::text = ANY($2::text[]))
+RETURNING document_key`, tutorID, keep)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k *string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		if k != nil {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// credentialTx — изменение записей анкеты одной транзакцией с аудитом шага education от имени репетитора.
+func (r *credentialRepository) credentialTx(ctx context.Context, tutorID string, fn func(tx pgx.Tx) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	before, err := audit.Snapshot(ctx, tx, auditTutorEducation, tutorID)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	after, err := audit.Snapshot(ctx, tx, auditTutorEducation, tutorID)
+	if err != nil {
+		return err
+	}
+	if err := audit.Change(ctx, tx, tutorID, "tutor", tutorID, "tutor.profile.education", before, after); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *credentialRepository) List(ctx context.Context, tutorID string) ([]domain.Education, []domain.Certification, error) {
+	edu, err := loadEducation(ctx, r.db, tutorID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	certs, err := loadCertificates(ctx, r.db, tutorID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return edu, certs, nil
+}
+
+func (r *credentialRepository) SaveEducation(ctx context.Context, tutorID string, e domain.Education) (*domain.Education, error) {
+	var out []domain.Education
+	err := r.credentialTx(ctx, tutorID, func(tx pgx.Tx) error {
+		if err := ensureTutorProfile(ctx, tx, tutorID); err != nil {
+			return err
+		}
+		if err := saveEducation(ctx, tx, tutorID, &e, -1); err != nil {
+			return err
+		}
+		var err error
+		out, err = loadEducation(ctx, tx, tutorID, e.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrCredentialNotFound
+	}
+	return &out[0], nil
+}
+
+func (r *credentialRepository) SaveCertificate(ctx context.Context, tutorID string, c domain.Certification) (*domain.Certification, error) {
+	var out []domain.Certification
+	err := r.credentialTx(ctx, tutorID, func(tx pgx.Tx) error {
+		if err := ensureTutorProfile(ctx, tx, tutorID); err != nil {
+			return err
+		}
+		if err := saveCertificate(ctx, tx, tutorID, &c, -1); err != nil {
+			return err
+		}
+		var err error
+		out, err = loadCertificates(ctx, tx, tutorID, c.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrCredentialNotFound
+	}
+	return &out[0], nil
+}
+
+func (r *credentialRepository) Delete(ctx context.Context, tutorID, kind, id string) (string, error) {
+	table, err := credentialTable(kind)
+	if err != nil {
+		return "", err
+	}
+	var key *string
+	err = r.credentialTx(ctx, tutorID, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `DELETE FROM `+table+` WHERE id::text = $1 AND tutor_id = $2 RETURNING document_key`,
+			id, tutorID).Scan(&key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCredentialNotFound
+		}
+		return err
+	})
+	if err != nil || key == nil {
+		return "", err
+	}
+	return *key, nil
+}
+
+func (r *credentialRepository) Document(ctx context.Context, kind, id string) (string, *domain.Document, error) {
+	table, err := credentialTable(kind)
+	if err != nil {
+		return "", nil, err
+	}
+	var tutorID string
+	var m credentialMeta
+	err = r.db.QueryRow(ctx, `SELECT tutor_id::text, `+credentialMetaColumns+` FROM `+table+` WHERE id::text = $1`, id).
+		Scan(append([]any{&tutorID}, m.dest()...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return tutorID, m.document(), nil
+}
+
+func (r *credentialRepository) SetDocument(ctx context.Context, tutorID, kind, id string, doc *domain.Document) (string, error) {
+	table, err := credentialTable(kind)
+	if err != nil {
+		return "", err
+	}
+	if doc == nil {
+		doc = &domain.Document{}
+	}
+	var old *string
+	err = r.credentialTx(ctx, tutorID, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `SELECT document_key FROM `+table+` WHERE id::text = $1 AND tutor_id = $2 FOR UPDATE`,
+			id, tutorID).Scan(&old)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCredentialNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// новый (или убранный) документ — повод проверить запись заново
+		_, err = tx.Exec(ctx, `
+UPDATE `+table+` SET`+resetReview("true")+`,
+    document_key = NULLIF($3,''), document_name = NULLIF($4,''), document_type = NULLIF($5,''),
+    document_size = NULLIF($6,0), document_uploaded_at = CASE WHEN $3 = '' THEN NULL ELSE now() END,
+    updated_at = now()
+WHERE id::text = $1 AND tutor_id = $2`, id, tutorID, doc.Key, doc.Name, doc.ContentType, doc.Size)
+		return err
+	})
+	if err != nil || old == nil {
+		return "", err
+	}
+	return *old, nil
+}
+
+// credentialEntries — обе таблицы в общем виде для очереди проверки.
+const credentialEntries = `
+WITH entries AS (
+  SELECT 'education' AS kind, id, tutor_id,
+         institution AS title, concat_ws(', ', NULLIF(degree,''), NULLIF(field,'')) AS subtitle, year,
+         document_key, document_name, document_type, document_size, document_uploaded_at,
+         verification, verification_note, verified_at, verified_by, updated_at
+  FROM public.tutor_education
+  UNION ALL
+  SELECT 'certificate', id, tutor_id, name, issuer, year,
+         document_key, document_name, document_type, document_size, document_uploaded_at,
+         verification, verification_note, verified_at, verified_by, updated_at
+  FROM public.tutor_certificates
+)`
+
+const credentialEntryColumns = `kind, id::text, tutor_id::text,
+       COALESCE((SELECT btrim(COALESCE(u.first_name,'') || ' ' || COALESCE(u.last_name,''))
+                 FROM public.users u WHERE u.id = x.tutor_id), ''),
+       title, subtitle, year, ` + credentialMetaColumns + `, COALESCE(verified_by::text,''), updated_at`
+
+func scanCredentialEntry(row pgx.Row) (*domain.CredentialEntry, error) {
+	var e domain.CredentialEntry
+	var m credentialMeta
+	dest := append([]any{&e.Kind, &e.ID, &e.TutorID, &e.TutorName, &e.Title, &e.Subtitle, &e.Year}, m.dest()...)
+	if err := row.Scan(append(dest, &e.VerifiedBy, &e.UpdatedAt)...); err != nil {
+		return nil, err
+	}
+	e.Document, e.CredentialReview = m.document(), m.review
+	return &e, nil
+}
+
+func (r *credentialRepository) Review(ctx context.Context, actorID, kind, id, status, note string) (*domain.CredentialEntry, error) {
+	table, err := credentialTable(kind)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var tutorID, prevStatus, prevNote string
+	err = tx.QueryRow(ctx, `SELECT tutor_id::text, verification, COALESCE(verification_note,'') FROM `+table+`
+WHERE id::text = $1 FOR UPDATE`, id).Scan(&tutorID, &prevStatus, &prevNote)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE `+table+`
+SET verification = $2, verification_note = NULLIF($3,''),
+    verified_by = CASE WHEN $2 = 'pending' THEN NULL ELSE NULLIF($4,'')::uuid END,
+    verified_at = CASE WHEN $2 = 'pending' THEN NULL ELSE now() END
+WHERE id::text = $1`, id, status, note, actorID); err != nil {
+		return nil, err
+	}
+	if err := audit.Write(ctx, tx, actorID, "tutor", tutorID, "tutor.credential.review",
+		map[string]any{"kind": kind, "id": id, "verification": prevStatus, "note": prevNote},
+		map[string]any{"kind": kind, "id": id, "verification": status, "note": note}); err != nil {
+		return nil, err
+	}
+	e, err := scanCredentialEntry(tx.QueryRow(ctx, credentialEntries+`
+SELECT `+credentialEntryColumns+`
+FROM entries x
+WHERE x.kind = $1 AND x.id::text = $2`, kind, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *credentialRepository) ListForReview(ctx context.Context, f domain.CredentialFilter, page, limit int) ([]domain.CredentialEntry, int, error) {
+	const cond = `
+FROM entries x
+WHERE ($1 = '' OR x.kind = $1) AND x.verification = $2
+  AND ($3 = '' OR x.tutor_id::text = $3) AND (NOT $4 OR x.document_key IS NOT NULL)`
+	args := []any{f.Kind, f.Status, f.TutorID, f.HasDocument}
+
+	var total int
+	if err := r.db.QueryRow(ctx, credentialEntries+`SELECT count(*)`+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(ctx, credentialEntries+`
+SELECT `+credentialEntryColumns+cond+`
+ORDER BY x.updated_at ASC, x.id
+LIMIT $5 OFFSET $6`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []domain.CredentialEntry{}
+	for rows.Next() {
+		e, err := scanCredentialEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *e)
+	}
+	return out, total, rows.Err()
+}
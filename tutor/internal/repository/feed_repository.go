@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+type FeedRepository interface {
+	// Owner — владелец ленты по userID (для выдачи ссылок); Token пуст, если ссылки ещё нет.
+	Owner(ctx context.Context, userID string) (*domain.FeedOwner, error)
+	OwnerByToken(ctx context.Context, token string) (*domain.FeedOwner, error)
+	// EnsureToken сохраняет token, если у пользователя ещё нет ссылки, и возвращает действующий.
+	EnsureToken(ctx context.Context, userID, token string) (string, error)
+	RotateToken(ctx context.Context, userID, token string) error
+
+	// Lessons — уроки участника, начавшиеся не раньше since, включая отменённые.
+	Lessons(ctx context.Context, userID string, since time.Time, limit int) ([]domain.FeedLesson, error)
+	WeeklySlots(ctx context.Context, tutorID string) ([]domain.WeeklySlot, time.Time, error)
+	// CalendarBusy — занятость из внешнего календаря пользователя в окне [from, to).
+	CalendarBusy(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error)
+}
+
+type feedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeedRepository(db *pgxpool.Pool) FeedRepository {
+	return &feedRepository{db: db}
+}
+
+const feedOwnerSQL = `
+SELECT u.id::text, COALESCE(f.token,''), tp.user_id IS NOT NULL,
+       COALESCE(NULLIF(tp.props->>'timezone',''), NULLIF(sp.prefs->>'timezone',''), ''),
+       u.locale
+FROM public.users u
+LEFT JOIN public.calendar_feeds f ON f.user_id = u.id
+LEFT JOIN public.tutor_profiles tp ON tp.user_id = u.id
+LEFT JOIN public.student_profiles sp ON sp.user_id = u.id`
+
+func scanFeedOwner(row pgx.Row) (*domain.FeedOwner, error) {
+	var o domain.FeedOwner
+	err := row.Scan(&o.UserID, &o.Token, &o.IsTutor, &o.Timezone, &o.Locale)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *feedRepository) Owner(ctx context.Context, userID string) (*domain.FeedOwner, error) {
+	return scanFeedOwner(r.db.QueryRow(ctx, feedOwnerSQL+` WHERE u.id = $1`, userID))
+}
+
+func (r *feedRepository) OwnerByToken(ctx context.Context, token string) (*domain.FeedOwner, error) {
+	return scanFeedOwner(r.db.QueryRow(ctx, feedOwnerSQL+` WHERE f.token = $1`, token))
+}
+
+func (r *feedRepository) EnsureToken(ctx context.Context, userID, token string) (string, error) {
+	var saved string
+	err := r.db.QueryRow(ctx, `
+INSERT INTO public.calendar_feeds (user_id, token) VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET token = calendar_feeds.token
+RETURNING token`, userID, token).Scan(&saved)
+	return saved, err
+}
+
+func (r *feedRepository) RotateToken(ctx context.Context, userID, token string) error {
+	_, err := r.db.Exec(ctx, `
+INSERT INTO public.calendar_feeds (user_id, token) VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, rotated_at = now()`, userID, token)
+	return err
+}
+
+func (r *feedRepository) Lessons(ctx context.Context, userID string, since time.Time, limit int) ([]domain.FeedLesson, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT l.id::text, l.status, l.started_at,
+       COALESCE(l.duration_seconds, EXTRACT(EPOCH FROM b.ends_at - b.starts_at)::int, 3600),
+       CASE WHEN l.tutor_id = $1 THEN 'tutor' ELSE 'student' END,
+       COALESCE(NULLIF(trim(concat_ws(' ', pu.first_name, pu.last_name)), ''), ''),
+       COALESCE(s.name->>u.locale, s.name->>'ru', ''),
+       EXTRACT(EPOCH FROM l.updated_at - l.created_at)::int,
+       l.updated_at
+FROM public.lessons l
+JOIN public.users u ON u.id = $1
+JOIN public.users pu ON pu.id = CASE WHEN l.tutor_id = $1 THEN l.student_id ELSE l.tutor_id END
+LEFT JOIN public.bookings b ON b.id = l.booking_id
+LEFT JOIN public.subjects s ON s.id = l.subject_id
+WHERE (l.student_id = $1 OR l.tutor_id = $1)
+  AND l.started_at IS NOT NULL AND l.started_at >= $2
+ORDER BY l.started_at
+LIMIT $3`, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []domain.FeedLesson{}
+	for rows.Next() {
+		var l domain.FeedLesson
+		var seconds int
+		if err := rows.Scan(&l.ID, &l.Status, &l.StartsAt, &seconds, &l.Role, &l.PeerName,
+			&l.SubjectName, &l.Sequence, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		l.Duration = time.Duration(seconds) * time.Second
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// WeeklySlots — рекуррентные слоты репетитора и время последнего изменения расписания.
+func (r *feedRepository) WeeklySlots(ctx context.Context, tutorID string) ([]domain.WeeklySlot, time.Time, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT COALESCE(recurrence,''), updated_at FROM public.availability_slots
+WHERE tutor_id = $1 AND is_recurring`, tutorID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+	var list []domain.WeeklySlot
+	var modified time.Time
+	for rows.Next() {
+		var rrule string
+		var updated time.Time
+		if err := rows.Scan(&rrule, &updated); err != nil {
+			return nil, time.Time{}, err
+		}
+		if updated.After(modified) {
+			modified = updated
+		}
+		day, slot, ok := parseWeeklyRRule(rrule)
+		if !ok {
+			continue
+		}
+		hh, mm := splitHHMM(slot)
+		list = append(list, domain.WeeklySlot{Day: day, Minute: hh*60 + mm})
+	}
+	return list, modified, rows.Err()
+}
+
+func (r *feedRepository) CalendarBusy(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT starts_at, ends_at, 'calendar' FROM public.calendar_busy
+WHERE user_id = $1 AND ends_at > $2 AND starts_at < $3
+ORDER BY starts_at`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []domain.BusyInterval
+	for rows.Next() {
+		var b domain.BusyInterval
+		if err := rows.Scan(&b.Start, &b.End, &b.Source); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
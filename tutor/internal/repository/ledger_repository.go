@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrUnbalancedTxn     = errors.New("ledger transaction is not balanced")
+	ErrInsufficientFunds = errors.New("not enough funds on ledger for this operation")
+)
+
+type LedgerRepository interface {
+	// Post — запись проводки в отдельной транзакции БД (для проводок вне платёжного потока).
+	Post(ctx context.Context, t domain.LedgerTxn) error
+	Wallets(ctx context.Context, ownerType, ownerID string) ([]domain.Wallet, error)
+	Statement(ctx context.Context, ownerType, ownerID, currency string, page, limit int) ([]domain.WalletEntry, int, error)
+	// Check сверяет цепочки balance_after, балансы кошельков и баланс каждой проводки.
+	Check(ctx context.Context) ([]domain.LedgerIssue, error)
+}
+
+type ledgerRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLedgerRepository(db *pgxpool.Pool) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) Post(ctx context.Context, t domain.LedgerTxn) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := postLedger(ctx, tx, t); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+type walletKey struct{ ownerType, ownerID string }
+
+// postLedger пишет проводку в рамках переданной транзакции. Кошельки блокируются
+// FOR UPDATE в порядке id (без дедлоков между параллельными проводками),
+// balance_after считается от заблокированного wallets.balance_minor.
+// Повтор с тем же Key — no-op.
+func postLedger(ctx context.Context, tx pgx.Tx, t domain.LedgerTxn) error {
+	if !t.Balanced() {
+		return fmt.Errorf("%w: %s", ErrUnbalancedTxn, t.Key)
+	}
+
+	var txnID string
+	err := tx.QueryRow(ctx, `
+INSERT INTO public.ledger_transactions (key, kind, currency, payment_id, lesson_id, payout_id)
+VALUES ($1, $2, $3, NULLIF($4,'')::uuid, NULLIF($5,'')::uuid, NULLIF($6,'')::uuid)
+ON CONFLICT (key) DO NOTHING
+RETURNING id`, t.Key, t.Kind, t.Currency, t.PaymentID, t.LessonID, t.PayoutID).Scan(&txnID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("insert ledger_transactions: %w", err)
+	}
+
+	ids := map[walletKey]string{}
+	for _, p := range t.Postings {
+		k := walletKey{p.OwnerType, p.OwnerID}
+		if _, ok := ids[k]; ok {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO public.wallets (owner_type, owner_id, currency) VALUES ($1, $2, $3)
+ON CONFLICT (owner_type, owner_id, currency) DO NOTHING`, p.OwnerType, p.OwnerID, t.Currency); err != nil {
+			return fmt.Errorf("ensure wallet: %w", err)
+		}
+		var id string
+		if err := tx.QueryRow(ctx, `
+SELECT id FROM public.wallets WHERE owner_type = $1 AND owner_id = $2 AND currency = $3`,
+			p.OwnerType, p.OwnerID, t.Currency).Scan(&id); err != nil {
+			return err
+		}
+		ids[k] = id
+	}
+
+	locked := make([]string, 0, len(ids))
+	for _, id := range ids {
+		locked = append(locked, id)
+	}
+	sort.Strings(locked)
+	balances := map[string]int64{}
+	for _, id := range locked {
+		var b int64
+		if err := tx.QueryRow(ctx, `SELECT balance_minor FROM public.wallets WHERE id = $1 FOR UPDATE`, id).Scan(&b); err != nil {
+			return err
+		}
+		balances[id] = b
+	}
+
+	for _, p := range t.Postings {
+		id := ids[walletKey{p.OwnerType, p.OwnerID}]
+		if p.Type == domain.EntryCredit {
+			balances[id] += p.AmountMinor
+		} else {
+			balances[id] -= p.AmountMinor
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO public.wallet_entries (wallet_id, txn_id, lesson_id, payment_id, type, reason, amount_minor, balance_after)
+VALUES ($1, $2, NULLIF($3,'')::uuid, NULLIF($4,'')::uuid, $5, $6, $7, $8)`,
+			id, txnID, t.LessonID, t.PaymentID, p.Type, p.Reason, p.AmountMinor, balances[id]); err != nil {
+			return fmt.Errorf("insert wallet_entries: %w", err)
+		}
+	}
+	for _, id := range locked {
+		if _, err := tx.Exec(ctx, `UPDATE public.wallets SET balance_minor = $2 WHERE id = $1`, id, balances[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refundSplit раскладывает возврат по платежу: сначала из резерва,
+// остаток — пропорционально с репетитора и выручки платформы по этому платежу.
+func refundSplit(ctx context.Context, tx pgx.Tx, paymentID string, amount int64) (domain.RefundSplit, error) {
+	var s domain.RefundSplit
+	var escrow, tutor, platform int64
+	if err := tx.QueryRow(ctx, `
+SELECT
+  COALESCE(SUM(CASE WHEN e.type='credit' THEN e.amount_minor ELSE -e.amount_minor END)
+           FILTER (WHERE w.owner_type='platform' AND w.owner_id=$2::uuid), 0),
+  COALESCE(SUM(CASE WHEN e.type='credit' THEN e.amount_minor ELSE -e.amount_minor END)
+           FILTER (WHERE w.owner_type='tutor'), 0),
+  COALESCE(SUM(CASE WHEN e.type='credit' THEN e.amount_minor ELSE -e.amount_minor END)
+           FILTER (WHERE w.owner_type='platform' AND w.owner_id=$3::uuid), 0),
+  COALESCE(MAX(w.owner_id::text) FILTER (WHERE w.owner_type='tutor'), '')
+FROM public.wallet_entries e
+JOIN public.wallets w ON w.id = e.wallet_id
+WHERE e.payment_id = $1`, paymentID, domain.PlatformEscrow, domain.PlatformRevenue).
+		Scan(&escrow, &tutor, &platform, &s.TutorID); err != nil {
+		return s, err
+	}
+
+	s.Escrow = min(amount, max(escrow, 0))
+	rest := amount - s.Escrow
+	if rest == 0 {
+		return s, nil
+	}
+	tutor, platform = max(tutor, 0), max(platform, 0)
+	if rest > tutor+platform {
+		return s, fmt.Errorf("%w: refund %d exceeds held %d", ErrInsufficientFunds, amount, escrow+tutor+platform)
+	}
+	s.Platform = rest * platform / (tutor + platform)
+	s.Tutor = rest - s.Platform
+	if s.Tutor > tutor {
+		s.Platform += s.Tutor - tutor
+		s.Tutor = tutor
+	}
+	return s, nil
+}
+
+func (r *ledgerRepository) Wallets(ctx context.Context, ownerType, ownerID string) ([]domain.Wallet, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT id, owner_type, owner_id, currency, balance_minor, created_at
+FROM public.wallets WHERE owner_type = $1 AND owner_id = $2
+ORDER BY currency`, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.Wallet{}
+	for rows.Next() {
+		var w domain.Wallet
+		if err := rows.Scan(&w.ID, &w.OwnerType, &w.OwnerID, &w.Currency, &w.BalanceMinor, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (r *ledgerRepository) Statement(ctx context.Context, ownerType, ownerID, currency string, page, limit int) ([]domain.WalletEntry, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `
+SELECT COUNT(*) FROM public.wallet_entries e
+JOIN public.wallets w ON w.id = e.wallet_id
+WHERE w.owner_type = $1 AND w.owner_id = $2 AND w.currency = $3`, ownerType, ownerID, currency).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+SELECT e.id, e.wallet_id, COALESCE(e.txn_id::text,''), COALESCE(t.kind,''),
+       COALESCE(e.lesson_id::text,''), COALESCE(e.payment_id::text,''),
+       e.type, e.reason, e.amount_minor, e.balance_after, e.created_at
+FROM public.wallet_entries e
+JOIN public.wallets w ON w.id = e.wallet_id
+LEFT JOIN public.ledger_transactions t ON t.id = e.txn_id
+WHERE w.owner_type = $1 AND w.owner_id = $2 AND w.currency = $3
+ORDER BY e.seq DESC
+LIMIT $4 OFFSET $5`, ownerType, ownerID, currency, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []domain.WalletEntry{}
+	for rows.Next() {
+		var e domain.WalletEntry
+		if err := rows.Scan(&e.ID, &e.WalletID, &e.TxnID, &e.TxnKind, &e.LessonID, &e.PaymentID,
+			&e.Type, &e.Reason, &e.AmountMinor, &e.BalanceAfter, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, e)
+	}
+	return out, total, rows.Err()
+}
+
+func (r *ledgerRepository) Check(ctx context.Context) ([]domain.LedgerIssue, error) {
+	issues := []domain.LedgerIssue{}
+	collect := func(sql string, scan func(pgx.Rows) (domain.LedgerIssue, error)) error {
+		rows, err := r.db.Query(ctx, sql)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			is, err := scan(rows)
+			if err != nil {
+				return err
+			}
+			issues = append(issues, is)
+		}
+		return rows.Err()
+	}
+
+	// 1) balance_after каждой записи = нарастающая сумма по кошельку
+	if err := collect(`
+SELECT id, wallet_id, running, balance_after FROM (
+  SELECT e.id, e.wallet_id, e.balance_after,
+         SUM(CASE WHEN e.type='credit' THEN e.amount_minor ELSE -e.amount_minor END)
+           OVER (PARTITION BY e.wallet_id ORDER BY e.seq) AS running
+  FROM public.wallet_entries e
+) x WHERE running <> balance_after`, func(rows pgx.Rows) (domain.LedgerIssue, error) {
+		is := domain.LedgerIssue{Kind: "balance_after"}
+		return is, rows.Scan(&is.EntryID, &is.WalletID, &is.Expected, &is.Actual)
+	}); err != nil {
+		return nil, err
+	}
+
+	// 2) wallets.balance_minor = сумма записей кошелька
+	if err := collect(`
+SELECT w.id, COALESCE(SUM(CASE WHEN e.type='credit' THEN e.amount_minor ELSE -e.amount_minor END), 0)::bigint, w.balance_minor
+FROM public.wallets w
+LEFT JOIN public.wallet_entries e ON e.wallet_id = w.id
+GROUP BY w.id, w.balance_minor
+HAVING COALESCE(SUM(CASE WHEN e.type='credit' THEN e.amount_minor ELSE -e.amount_minor END), 0) <> w.balance_minor`,
+		func(rows pgx.Rows) (domain.LedgerIssue, error) {
+			is := domain.LedgerIssue{Kind: "wallet_balance"}
+			return is, rows.Scan(&is.WalletID, &is.Expected, &is.Actual)
+		}); err != nil {
+		return nil, err
+	}
+
+	// 3) в каждой проводке debit = credit
+	if err := collect(`
+SELECT txn_id::text,
+       COALESCE(SUM(amount_minor) FILTER (WHERE type='debit'), 0)::bigint,
+       COALESCE(SUM(amount_minor) FILTER (WHERE type='credit'), 0)::bigint
+FROM public.wallet_entries
+WHERE txn_id IS NOT NULL
+GROUP BY txn_id
+HAVING COALESCE(SUM(amount_minor) FILTER (WHERE type='debit'), 0) <> COALESCE(SUM(amount_minor) FILTER (WHERE type='credit'), 0)`,
+		func(rows pgx.Rows) (domain.LedgerIssue, error) {
+			is := domain.LedgerIssue{Kind: "unbalanced_txn"}
+			return is, rows.Scan(&is.TxnID, &is.Expected, &is.Actual)
+		}); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// bookingPartiesSQL — оба участника брони с тем, что нужно шаблонам уроков:
+// роль, имя второй стороны, часовой пояс, язык и название предмета на этом языке.
+const bookingPartiesSQL = `
+WITH parties AS (
+    SELECT b.student_id AS user_id, b.tutor_id AS peer_id, 'student' AS role FROM public.bookings b WHERE b.id = $1
+    UNION ALL
+    SELECT b.tutor_id, b.student_id, 'tutor' FROM public.bookings b WHERE b.id = $1
+)
+SELECT p.user_id::text, p.role, b.starts_at,
+       COALESCE(NULLIF(trim(concat_ws(' ', pu.first_name, pu.last_name)), ''), ''),
+       COALESCE(NULLIF(tp.props->>'timezone', ''), NULLIF(sp.prefs->>'timezone', ''), ''),
+       COALESCE(s.name->>u.locale, s.name->>'ru', s.slug, '')
+FROM parties p
+JOIN public.bookings b ON b.id = $1
+JOIN public.users u ON u.id = p.user_id
+JOIN public.users pu ON pu.id = p.peer_id
+LEFT JOIN public.subjects s ON s.id = b.subject_id
+LEFT JOIN public.tutor_profiles tp ON tp.user_id = p.user_id AND p.role = 'tutor'
+LEFT JOIN public.student_profiles sp ON sp.user_id = p.user_id AND p.role = 'student'`
+
+// notifyBookingParties ставит уведомление templateKey участникам брони в транзакции
+// изменения, по каналам шаблона. skipUserID — инициатор, которому сообщать не нужно.
+// Время урока в поясе получателя подставляет диспетчер при отправке.
+func notifyBookingParties(ctx context.Context, tx pgx.Tx, bookingID, templateKey, skipUserID string, extra map[string]any) error {
+	rows, err := tx.Query(ctx, bookingPartiesSQL, bookingID)
+	if err != nil {
+		return err
+	}
+	var ns []domain.Notification
+	for rows.Next() {
+		var userID, role, peer, tz, subject string
+		var startsAt time.Time
+		if err := rows.Scan(&userID, &role, &startsAt, &peer, &tz, &subject); err != nil {
+			rows.Close()
+			return err
+		}
+		if userID == skipUserID {
+			continue
+		}
+		payload := map[string]any{
+			"bookingId": bookingID,
+			"startsAt":  startsAt.UTC().Format(time.RFC3339),
+			"timezone":  tz,
+			"peerName":  peer,
+			"role":      role,
+			"subject":   subject,
+		}
+		maps.Copy(payload, extra)
+		batch, err := templateNotifications(userID, templateKey, payload)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		ns = append(ns, batch...)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return enqueueNotifications(ctx, tx, ns)
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrLessonNotFound   = errors.New("lesson not found")
+	ErrLessonTransition = errors.New("lesson status transition not allowed")
+)
+
+type LessonRepository interface {
+	FindLesson(ctx context.Context, lessonID string) (*domain.Lesson, error)
+	// CompleteLesson переводит урок в completed, пишет commissions и проводку распределения
+	// (если урок оплачен) в одной транзакции. Повторный вызов вернёт ErrLessonTransition.
+	// Распределяется оплата за вычетом возвратов; c должна быть посчитана от неё же.
+	CompleteLesson(ctx context.Context, lessonID string, c domain.Commission, p *domain.Payment) error
+}
+
+type lessonRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLessonRepository(db *pgxpool.Pool) LessonRepository {
+	return &lessonRepository{db: db}
+}
+
+const lessonColumns = `l.id, COALESCE(l.booking_id::text,''), l.student_id, l.tutor_id,
+       COALESCE(l.subject_id::text,''), COALESCE(s.slug,''), COALESCE(l.subdirection_id::text,''), COALESCE(sd.slug,''),
+       l.status, l.is_trial, l.started_at, l.ended_at, COALESCE(l.duration_seconds, 0),
+       COALESCE((SELECT p.id::text FROM public.payments p
+                 WHERE p.lesson_id = l.id AND p.status IN ('authorized','captured')
+                 ORDER BY p.created_at DESC LIMIT 1), '')`
+
+const lessonFrom = `FROM public.lessons l
+LEFT JOIN public.subjects s ON s.id = l.subject_id
+LEFT JOIN public.subdirections sd ON sd.id = l.subdirection_id`
+
+func scanLesson(row pgx.Row) (*domain.Lesson, error) {
+	var l domain.Lesson
+	if err := row.Scan(&l.ID, &l.BookingID, &l.StudentID, &l.TutorID, &l.SubjectID, &l.SubjectSlug,
+		&l.SubdirectionID, &l.SubdirectionSlug, &l.Status, &l.Trial, &l.StartedAt, &l.EndedAt,
+		&l.DurationSeconds, &l.PaymentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLessonNotFound
+		}
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *lessonRepository) FindLesson(ctx context.Context, lessonID string) (*domain.Lesson, error) {
+	return scanLesson(r.db.QueryRow(ctx, `SELECT `+lessonColumns+` `+lessonFrom+` WHERE l.id = $1`, lessonID))
+}
+
+func (r *lessonRepository) CompleteLesson(ctx context.Context, lessonID string, c domain.Commission, p *domain.Payment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status, tutorID string
+	if err := tx.QueryRow(ctx, `SELECT status, tutor_id FROM public.lessons WHERE id = $1 FOR UPDATE`, lessonID).Scan(&status, &tutorID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrLessonNotFound
+		}
+		return err
+	}
+	if status != domain.LessonScheduled && status != domain.LessonInProgress {
+		return fmt.Errorf("%w: lesson is %s", ErrLessonTransition, status)
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE public.lessons
+SET status = 'completed', ended_at = COALESCE(ended_at, now()), updated_at = now()
+WHERE id = $1`, lessonID); err != nil {
+		return err
+	}
+
+	details, err := json.Marshal(c.Details)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+INSERT INTO public.commissions (lesson_id, commission_minor, tutor_earn_minor, currency, rule_version, details)
+VALUES ($1, $2, $3, $4, $5, $6::jsonb)`,
+		lessonID, c.CommissionMinor, c.TutorEarnMinor, c.Currency, c.RuleVersion, details); err != nil {
+		return fmt.Errorf("insert commission: %w", err)
+	}
+
+	if p != nil {
+		// возврат мог пройти после расчёта комиссии — сверяем под блокировкой платежа
+		var status string
+		var net int64
+		if err := tx.QueryRow(ctx, `
+SELECT p.status, p.amount_minor - COALESCE((SELECT SUM(r.amount_minor) FROM public.refunds r
+                                            WHERE r.payment_id = p.id AND r.status IN ('pending','succeeded')), 0)
+FROM public.payments p WHERE p.id = $1 FOR UPDATE`, p.ID).Scan(&status, &net); err != nil {
+			return err
+		}
+		if status != domain.PaymentCaptured {
+			net = 0 // возвращён или отменён — в резерве ничего нет
+		}
+		if net != c.CommissionMinor+c.TutorEarnMinor {
+			return fmt.Errorf("%w: payment changed during lesson completion", ErrPaymentTransition)
+		}
+		if net > 0 {
+			pay := *p
+			pay.LessonID = lessonID
+			if err := postLedger(ctx, tx, domain.CommissionTxn(pay, tutorID, net, c.CommissionMinor)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MediaRepository interface {
+	// CurrentMedia — текущие аватар и видео анкеты (пустые, если анкеты ещё нет).
+	CurrentMedia(ctx context.Context, userID string) (avatarURL, videoURL string, err error)
+	// Referenced — какие из объектов хранилища ещё нужны. Сравниваются ключи: у сканов это
+	// document_key, у аватара и видео — ключ, выделенный из сохранённого адреса (avatar_key, video_key).
+	Referenced(ctx context.Context, keys []string) (map[string]bool, error)
+}
+
+type mediaRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMediaRepository(db *pgxpool.Pool) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+func (r *mediaRepository) CurrentMedia(ctx context.Context, userID string) (string, string, error) {
+	var avatar, video string
+	err := r.db.QueryRow(ctx, `
+SELECT COALESCE(props->>'avatar_url',''), COALESCE(video_url,'')
+FROM public.tutor_profiles WHERE user_id = $1`, userID).Scan(&avatar, &video)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", "", err
+	}
+	return avatar, video, nil
+}
+
+func (r *mediaRepository) Referenced(ctx context.Context, keys []string) (map[string]bool, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT m.key
+FROM unnest($1::text[]) AS m(key)
+WHERE EXISTS (SELECT 1 FROM public.tutor_profiles WHERE avatar_key = m.key)
+   OR EXISTS (SELECT 1 FROM public.tutor_profiles WHERE video_key = m.key)
+   OR EXISTS (SELECT 1 FROM public.tutor_education WHERE document_key = m.key)
+   OR EXISTS (SELECT 1 FROM public.tutor_certificates WHERE document_key = m.key)`, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	used := map[string]bool{}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		used[k] = true
+	}
+	return used, rows.Err()
+}
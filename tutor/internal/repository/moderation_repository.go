@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"shared/audit"
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrReportNotFound   = errors.New("report not found")
+	ErrReportTarget     = errors.New("report target not found")
+	ErrReportExists     = errors.New("you already have an open report for this target")
+	ErrReportTransition = errors.New("report status transition not allowed")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+type ModerationRepository interface {
+	// TargetParties — пользователи, связанные с объектом жалобы: для user — он сам,
+	// для lesson — студент и репетитор, для message — автор и участники диалога (автор первым).
+	TargetParties(ctx context.Context, targetType, targetID string) ([]string, error)
+	CreateReport(ctx context.Context, rp *domain.Report) error
+	FindReport(ctx context.Context, reportID string) (*domain.Report, error)
+	ListReports(ctx context.Context, f domain.ReportFilter, page, limit int) ([]domain.Report, int, error)
+	// Assign назначает модератора ("" — снять); открытая жалоба уходит в in_review.
+	Assign(ctx context.Context, reportID, assigneeID, actorID string) (*domain.Report, error)
+	SetStatus(ctx context.Context, reportID, status, actorID string) (*domain.Report, error)
+	// Resolve закрывает жалобу и применяет действие к userID/сообщению в одной транзакции.
+	Resolve(ctx context.Context, reportID string, d domain.ModerationDecision, actorID string) (*domain.Report, error)
+}
+
+type moderationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewModerationRepository(db *pgxpool.Pool) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+func (r *moderationRepository) TargetParties(ctx context.Context, targetType, targetID string) ([]string, error) {
+	var q string
+	switch targetType {
+	case domain.ReportTargetUser:
+		q = `SELECT ARRAY[id::text] FROM public.users WHERE id = $1 AND deleted_at IS NULL`
+	case domain.ReportTargetLesson:
+		q = `SELECT ARRAY[tutor_id::text, student_id::text] FROM public.lessons WHERE id = $1`
+	case domain.ReportTargetMessage:
+		q = `
+SELECT ARRAY[m.sender_id::text, c.student_id::text, c.tutor_id::text]
+FROM public.messages m JOIN public.conversations c ON c.id = m.conversation_id
+WHERE m.id = $1`
+	default:
+		return nil, ErrReportTarget
+	}
+	var parties []string
+	if err := r.db.QueryRow(ctx, q, targetID).Scan(&parties); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, ErrReportTarget
+		}
+		return nil, err
+	}
+	return parties, nil
+}
+
+const reportColumns = `id, reporter_id, target_type, target_id, reason, COALESCE(details,''), status,
+       COALESCE(assignee_id::text,''), COALESCE(resolution_action,''), COALESCE(resolution_note,''),
+       COALESCE(resolved_by::text,''), resolved_at, created_at, updated_at`
+
+func scanReport(row pgx.Row) (*domain.Report, error) {
+	var rp domain.Report
+	if err := row.Scan(&rp.ID, &rp.ReporterID, &rp.TargetType, &rp.TargetID, &rp.Reason, &rp.Details, &rp.Status,
+		&rp.AssigneeID, &rp.ResolutionAction, &rp.ResolutionNote, &rp.ResolvedBy, &rp.ResolvedAt,
+		&rp.CreatedAt, &rp.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+	return &rp, nil
+}
+
+func (r *moderationRepository) CreateReport(ctx context.Context, rp *domain.Report) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	created, err := scanReport(tx.QueryRow(ctx, `
+INSERT INTO public.reports (reporter_id, target_type, target_id, reason, details)
+VALUES ($1, $2, $3, $4, NULLIF($5,''))
+RETURNING `+reportColumns, rp.ReporterID, rp.TargetType, rp.TargetID, rp.Reason, rp.Details))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrReportExists
+		}
+		return fmt.Errorf("insert report: %w", err)
+	}
+	*rp = *created
+	if err := audit.Write(ctx, tx, rp.ReporterID, "report", rp.ID, "report.create", nil, rp); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *moderationRepository) FindReport(ctx context.Context, reportID string) (*domain.Report, error) {
+	return scanReport(r.db.QueryRow(ctx, `SELECT `+reportColumns+` FROM public.reports WHERE id = $1`, reportID))
+}
+
+func (r *moderationRepository) ListReports(ctx context.Context, f domain.ReportFilter, page, limit int) ([]domain.Report, int, error) {
+	var where []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id::text = $%d", f.TargetID)
+	}
+	if f.AssigneeID != "" {
+		add("assignee_id::text = $%d", f.AssigneeID)
+	}
+	if f.ReporterID != "" {
+		add("reporter_id::text = $%d", f.ReporterID)
+	}
+	if f.Unassigned {
+		where = append(where, "assignee_id IS NULL")
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT count(*) FROM public.reports `+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, limit, (page-1)*limit)
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+SELECT `+reportColumns+` FROM public.reports `+cond+`
+ORDER BY created_at ASC
+LIMIT $%d OFFSET $%d`, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []domain.Report{}
+	for rows.Next() {
+		rp, err := scanReport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *rp)
+	}
+	return out, total, rows.Err()
+}
+
+// lockReport — жалоба под FOR UPDATE для изменения в транзакции.
+func lockReport(ctx context.Context, tx pgx.Tx, reportID string) (*domain.Report, error) {
+	return scanReport(tx.QueryRow(ctx, `SELECT `+reportColumns+` FROM public.reports WHERE id = $1 FOR UPDATE`, reportID))
+}
+
+func (r *moderationRepository) Assign(ctx context.Context, reportID, assigneeID, actorID string) (*domain.Report, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	before, err := lockReport(ctx, tx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if before.Status != domain.ReportOpen && before.Status != domain.ReportInReview {
+		return nil, fmt.Errorf("%w: report is %s", ErrReportTransition, before.Status)
+	}
+	after, err := scanReport(tx.QueryRow(ctx, `
+UPDATE public.reports
+SET assignee_id = NULLIF($2,'')::uuid,
+    status = CASE WHEN $2 <> '' AND status = 'open' THEN 'in_review' ELSE status END,
+    updated_at = now()
+WHERE id = $1
+RETURNING `+reportColumns, reportID, assigneeID))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := audit.Write(ctx, tx, actorID, "report", reportID, "report.assign", before, after); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (r *moderationRepository) SetStatus(ctx context.Context, reportID, status, actorID string) (*domain.Report, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	before, err := lockReport(ctx, tx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.CanTransitionReport(before.Status, status) {
+		return nil, fmt.Errorf("%w: %s → %s", ErrReportTransition, before.Status, status)
+	}
+	after, err := scanReport(tx.QueryRow(ctx, `
+UPDATE public.reports SET status = $2, updated_at = now() WHERE id = $1
+RETURNING `+reportColumns, reportID, status))
+	if err != nil {
+		return nil, err
+	}
+	if err := audit.Write(ctx, tx, actorID, "report", reportID, "report.status", before, after); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (r *moderationRepository) Resolve(ctx context.Context, reportID string, d domain.ModerationDecision, actorID string) (*domain.Report, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	before, err := lockReport(ctx, tx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.CanTransitionReport(before.Status, d.Status) {
+		return nil, fmt.Errorf("%w: %s → %s", ErrReportTransition, before.Status, d.Status)
+	}
+
+	switch d.Action {
+	case domain.ModerationWarn:
+		if _, err := tx.Exec(ctx, `
+INSERT INTO public.user_warnings (user_id, report_id, issued_by, reason)
+VALUES ($1, $2, $3, NULLIF($4,''))`, d.UserID, reportID, actorID, d.Note); err != nil {
+			return nil, fmt.Errorf("insert warning: %w", err)
+		}
+		if err := audit.Write(ctx, tx, actorID, "user", d.UserID, "user.warn", nil,
+			map[string]any{"reportId": reportID, "note": d.Note}); err != nil {
+			return nil, err
+		}
+	case domain.ModerationBlockUser:
+		if err := blockUser(ctx, tx, d.UserID, actorID, reportID); err != nil {
+			return nil, err
+		}
+	case domain.ModerationHideMessage:
+		tag, err := tx.Exec(ctx, `
+UPDATE public.messages SET hidden_at = now(), hidden_by = $2
+WHERE id = $1 AND hidden_at IS NULL`, before.TargetID, actorID)
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() > 0 {
+			if err := audit.Write(ctx, tx, actorID, "message", before.TargetID, "message.hide",
+				map[string]any{"hidden": false}, map[string]any{"hidden": true, "reportId": reportID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	after, err := scanReport(tx.QueryRow(ctx, `
+UPDATE public.reports
+SET status = $2, resolution_action = $3, resolution_note = NULLIF($4,''),
+    resolved_by = $5, resolved_at = now(), updated_at = now()
+WHERE id = $1
+RETURNING `+reportColumns, reportID, d.Status, d.Action, d.Note, actorID))
+	if err != nil {
+		return nil, err
+	}
+	if err := audit.Write(ctx, tx, actorID, "report", reportID, "report."+d.Status, before, after); err != nil {
+		return nil, err
+	}
+	// автор жалобы узнаёт, что её рассмотрели; решение и заметка модератора остаются внутренними
+	ns, err := templateNotifications(after.ReporterID, "report.resolved", map[string]any{"reportId": reportID})
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueNotifications(ctx, tx, ns); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// blockUser блокирует аккаунт и отзывает refresh-сессии, чтобы нельзя было продлить вход.
+func blockUser(ctx context.Context, tx pgx.Tx, userID, actorID, reportID string) error {
+	var prev string
+	if err := tx.QueryRow(ctx, `SELECT status FROM public.users WHERE id = $1 FOR UPDATE`, userID).Scan(&prev); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if prev == "blocked" {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE public.users SET status = 'blocked', updated_at = now() WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.auth_sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return err
+	}
+	return audit.Write(ctx, tx, actorID, "user", userID, "user.block",
+		map[string]any{"status": prev}, map[string]any{"status": "blocked", "reportId": reportID})
+}
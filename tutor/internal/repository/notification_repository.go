@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/notify"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrRecipientNotFound = errors.New("notification recipient not found")
+
+type NotificationRepository interface {
+	Enqueue(ctx context.Context, ns []domain.Notification) error
+	// Claim берёт готовые к отправке уведомления (SKIP LOCKED — безопасно для нескольких реплик)
+	// и откладывает их на lease: если воркер упадёт, уведомление вернётся в работу.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.NotificationJob, error)
+	MarkSent(ctx context.Context, id, subject, body string) error
+	MarkSkipped(ctx context.Context, id, reason string) error
+	MarkFailed(ctx context.Context, id, reason string) error
+	Retry(ctx context.Context, id, reason string, at time.Time) error
+
+	UpsertDevice(ctx context.Context, d domain.PushDevice) error
+	// DeleteDevice удаляет токен; userID == "" — независимо от владельца (токен отвергнут провайдером).
+	DeleteDevice(ctx context.Context, userID, token string) error
+}
+
+type notificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// execer — общее у pgxpool.Pool и pgx.Tx: уведомления можно ставить в транзакции вызывающего.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// enqueueNotifications вставляет уведомления в очередь; для событий, которые ставятся
+// вместе с изменением данных, вызывается с транзакцией этого изменения.
+func enqueueNotifications(ctx context.Context, q execer, ns []domain.Notification) error {
+	for _, n := range ns {
+		if n.Payload == nil {
+			n.Payload = map[string]any{}
+		}
+		payload, err := json.Marshal(n.Payload)
+		if err != nil {
+			return err
+		}
+		if _, err := q.Exec(ctx, `
+INSERT INTO public.notifications (user_id, channel, template_key, payload, status)
+VALUES ($1, $2, $3, $4::jsonb, 'queued')`, n.UserID, n.Channel, n.TemplateKey, payload); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return ErrRecipientNotFound
+			}
+			return fmt.Errorf("enqueue %s: %w", n.TemplateKey, err)
+		}
+	}
+	return nil
+}
+
+// templateNotifications — уведомление userID по каналам шаблона templateKey по умолчанию.
+func templateNotifications(userID, templateKey string, payload map[string]any) ([]domain.Notification, error) {
+	tpl, ok := notify.Lookup(templateKey)
+	if !ok {
+		return nil, notify.ErrUnknownTemplate
+	}
+	ns := make([]domain.Notification, 0, len(tpl.Channels))
+	for _, ch := range tpl.Channels {
+		ns = append(ns, domain.Notification{UserID: userID, Channel: ch, TemplateKey: templateKey, Payload: payload})
+	}
+	return ns, nil
+}
+
+func (r *notificationRepository) Enqueue(ctx context.Context, ns []domain.Notification) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := enqueueNotifications(ctx, tx, ns); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *notificationRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.NotificationJob, error) {
+	rows, err := r.db.Query(ctx, `
+WITH picked AS (
+    SELECT id FROM public.notifications
+    WHERE status = 'queued' AND next_attempt_at <= now()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE public.notifications n
+SET attempts = n.attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+FROM picked, public.users u
+WHERE n.id = picked.id AND u.id = n.user_id
+RETURNING n.id::text, n.user_id::text, n.channel, n.template_key, n.payload, n.status, n.attempts, n.created_at,
+          u.email::text, COALESCE(u.phone_e164,''), u.locale, u.deleted_at IS NOT NULL,
+          u.notifications_lessons, u.notifications_messages, u.notifications_reminders`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.NotificationJob
+	var pushUsers []string
+	for rows.Next() {
+		var j domain.NotificationJob
+		var payload []byte
+		if err := rows.Scan(&j.ID, &j.UserID, &j.Channel, &j.TemplateKey, &payload, &j.Status, &j.Attempts, &j.CreatedAt,
+			&j.Email, &j.Phone, &j.Locale, &j.Deleted,
+			&j.Prefs.Lessons, &j.Prefs.Messages, &j.Prefs.Reminders); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(payload, &j.Payload)
+		if j.Channel == "push" {
+			pushUsers = append(pushUsers, j.UserID)
+		}
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(pushUsers) == 0 {
+		return jobs, nil
+	}
+
+	devices, err := r.devices(ctx, pushUsers)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].Channel == "push" {
+			jobs[i].Devices = devices[jobs[i].UserID]
+		}
+	}
+	return jobs, nil
+}
+
+func (r *notificationRepository) devices(ctx context.Context, userIDs []string) (map[string][]domain.PushDevice, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT token, user_id::text, platform, created_at FROM public.push_devices
+WHERE user_id = ANY($1::uuid[])`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string][]domain.PushDevice{}
+	for rows.Next() {
+		var d domain.PushDevice
+		if err := rows.Scan(&d.Token, &d.UserID, &d.Platform, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		out[d.UserID] = append(out[d.UserID], d)
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationRepository) MarkSent(ctx context.Context, id, subject, body string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.notifications SET status = 'sent', subject = $2, body = $3, sent_at = now(), last_error = NULL
+WHERE id = $1 AND status = 'queued'`, id, subject, body)
+	return err
+}
+
+func (r *notificationRepository) MarkSkipped(ctx context.Context, id, reason string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.notifications SET status = 'skipped', last_error = $2
+WHERE id = $1 AND status = 'queued'`, id, reason)
+	return err
+}
+
+func (r *notificationRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.notifications SET status = 'failed', last_error = $2
+WHERE id = $1 AND status = 'queued'`, id, reason)
+	return err
+}
+
+func (r *notificationRepository) Retry(ctx context.Context, id, reason string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.notifications SET next_attempt_at = $3, last_error = $2
+WHERE id = $1 AND status = 'queued'`, id, reason, at)
+	return err
+}
+
+func (r *notificationRepository) UpsertDevice(ctx context.Context, d domain.PushDevice) error {
+	// токен переезжает к новому владельцу: на устройстве залогинился другой пользователь
+	_, err := r.db.Exec(ctx, `
+INSERT INTO public.push_devices (token, user_id, platform)
+VALUES ($1, $2, $3)
+ON CONFLICT (token) DO UPDATE SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, updated_at = now()`,
+		d.Token, d.UserID, d.Platform)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrRecipientNotFound
+	}
+	return err
+}
+
+func (r *notificationRepository) DeleteDevice(ctx context.Context, userID, token string) error {
+	_, err := r.db.Exec(ctx, `
+DELETE FROM public.push_devices WHERE token = $1 AND ($2::text = '' OR user_id::text = $2)`, token, userID)
+	return err
+}
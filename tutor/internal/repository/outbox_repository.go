@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrOutboxEventNotFound = errors.New("outbox event not found")
+
+// writeOutbox пишет доменное событие в той же транзакции, что и изменение состояния:
+// событие уходит в брокер тогда и только тогда, когда изменение закоммичено.
+func writeOutbox(ctx context.Context, q execer, aggregate, aggregateID, eventType string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := q.Exec(ctx, `
+INSERT INTO public.outbox_events (aggregate, aggregate_id, event_type, payload)
+VALUES ($1, $2, $3, $4::jsonb)`, aggregate, aggregateID, eventType, b); err != nil {
+		return fmt.Errorf("outbox %s: %w", eventType, err)
+	}
+	return nil
+}
+
+type OutboxRepository interface {
+	// Claim берёт первые по порядку pending-события своих агрегатов: следующее событие
+	// агрегата не уходит, пока предыдущее не отправлено или не ушло в dead letter.
+	// Взятые откладываются на lease (SKIP LOCKED — безопасно для нескольких реплик).
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error)
+	MarkSent(ctx context.Context, id string) error
+	Retry(ctx context.Context, id, reason string, at time.Time) error
+	DeadLetter(ctx context.Context, id, reason string) error
+
+	ListDead(ctx context.Context, eventType string, page, limit int) ([]domain.OutboxEvent, int, error)
+	// Requeue возвращает событие из dead letter в очередь с обнулёнными попытками.
+	Requeue(ctx context.Context, id string) (*domain.OutboxEvent, error)
+}
+
+type outboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+const outboxColumns = `id::text, seq, aggregate, aggregate_id::text, event_type, payload, status, attempts,
+       COALESCE(last_error,''), created_at, published_at`
+
+func scanOutbox(row pgx.Row) (*domain.OutboxEvent, error) {
+	var e domain.OutboxEvent
+	if err := row.Scan(&e.ID, &e.Seq, &e.Aggregate, &e.AggregateID, &e.EventType, &e.Payload, &e.Status,
+		&e.Attempts, &e.LastError, &e.CreatedAt, &e.PublishedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func collectOutbox(rows pgx.Rows) ([]domain.OutboxEvent, error) {
+	defer rows.Close()
+	var out []domain.OutboxEvent
+	for rows.Next() {
+		e, err := scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+func (r *outboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	rows, err := r.db.Query(ctx, `
+UPDATE public.outbox_events SET attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+WHERE id IN (
+    SELECT e.id FROM public.outbox_events e
+    WHERE e.status = 'pending' AND e.next_attempt_at <= now()
+      AND NOT EXISTS (
+          SELECT 1 FROM public.outbox_events p
+          WHERE p.aggregate = e.aggregate AND p.aggregate_id = e.aggregate_id
+            AND p.status = 'pending' AND p.seq < e.seq
+      )
+    ORDER BY e.seq
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING `+outboxColumns, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	list, err := collectOutbox(rows)
+	if err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING не сохраняет порядок подзапроса
+	sort.Slice(list, func(i, j int) bool { return list[i].Seq < list[j].Seq })
+	return list, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.outbox_events SET status = 'sent', published_at = now(), last_error = NULL
+WHERE id = $1 AND status = 'pending'`, id)
+	return err
+}
+
+func (r *outboxRepository) Retry(ctx context.Context, id, reason string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.outbox_events SET next_attempt_at = $3, last_error = $2
+WHERE id = $1 AND status = 'pending'`, id, reason, at)
+	return err
+}
+
+func (r *outboxRepository) DeadLetter(ctx context.Context, id, reason string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE public.outbox_events SET status = 'failed', last_error = $2
+WHERE id = $1 AND status = 'pending'`, id, reason)
+	return err
+}
+
+func (r *outboxRepository) ListDead(ctx context.Context, eventType string, page, limit int) ([]domain.OutboxEvent, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `
+SELECT COUNT(*) FROM public.outbox_events WHERE status = 'failed' AND ($1 = '' OR event_type = $1)`,
+		eventType).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(ctx, `
+SELECT `+outboxColumns+` FROM public.outbox_events
+WHERE status = 'failed' AND ($1 = '' OR event_type = $1)
+ORDER BY created_at DESC LIMIT $2 OFFSET $3`, eventType, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := collectOutbox(rows)
+	return list, total, err
+}
+
+func (r *outboxRepository) Requeue(ctx context.Context, id string) (*domain.OutboxEvent, error) {
+	e, err := scanOutbox(r.db.QueryRow(ctx, `
+UPDATE public.outbox_events SET status = 'pending', attempts = 0, next_attempt_at = now()
+WHERE id = $1 AND status = 'failed'
+RETURNING `+outboxColumns, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrOutboxEventNotFound
+	}
+	return e, err
+}
@@ -0,0 +1,480 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBookingNotFound      = errors.New("booking not found")
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPriceNotFound        = errors.New("no price for booking")
+	ErrPaymentTransition    = errors.New("payment status transition not allowed")
+	ErrDuplicateIdempotency = errors.New("idempotency key already used")
+	ErrActivePayment        = errors.New("booking already has an active payment")
+	ErrRefundExceeds        = errors.New("refund exceeds the amount left on the payment")
+)
+
+type PaymentRepository interface {
+	FindBooking(ctx context.Context, bookingID string) (*domain.Booking, error)
+	// BookingPrice — цена разового занятия из tutor_prices под предмет/длительность брони.
+	BookingPrice(ctx context.Context, b *domain.Booking) (domain.Money, error)
+
+	FindPayment(ctx context.Context, paymentID string) (*domain.Payment, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*domain.Payment, error)
+	FindActiveForBooking(ctx context.Context, bookingID string) (*domain.Payment, error)
+	FindByIntent(ctx context.Context, provider, intentID string) (*domain.Payment, error)
+
+	// CreatePayment создаёт платёж requires_action, переводит бронь в awaiting_payment
+	// и фиксирует на ней действующую политику отмены. Если у брони уже есть активный
+	// платёж, p заполняется им и возвращается ErrActivePayment.
+	CreatePayment(ctx context.Context, p *domain.Payment, idempotencyKey string) error
+	AttachIntent(ctx context.Context, paymentID, intentID, status string, payload map[string]any) error
+	// ApplyStatus — переход статуса платежа под блокировкой строки с побочными эффектами
+	// на бронь/урок. eventID (опц.) — дедупликация повторных вебхуков.
+	ApplyStatus(ctx context.Context, paymentID, status, eventID string, raw []byte) (*domain.Payment, error)
+	// RefundedMinor — сумма уже оформленных (pending/succeeded) возвратов по платежу.
+	RefundedMinor(ctx context.Context, paymentID string) (int64, error)
+	// ReserveRefund под блокировкой платежа проверяет остаток и пишет возврат pending,
+	// чтобы параллельный возврат уже видел его в сумме; amountMinor=0 — весь остаток.
+	// full — возврат закрывает платёж целиком.
+	ReserveRefund(ctx context.Context, paymentID string, amountMinor int64) (rf *domain.PaymentRefund, full bool, err error)
+	// FinishRefund фиксирует ответ провайдера по pending-возврату; succeeded — со сторнирующей проводкой.
+	FinishRefund(ctx context.Context, refundID, status string, raw []byte) (*domain.PaymentRefund, error)
+}
+
+type paymentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRepository(db *pgxpool.Pool) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) FindBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
+	var b domain.Booking
+	err := r.db.QueryRow(ctx, `
+SELECT id, student_id, tutor_id, COALESCE(subject_id::text,''), COALESCE(subdirection_id::text,''),
+       starts_at, ends_at, status, COALESCE((metadata->>'trial')::boolean, false)
+FROM public.bookings WHERE id = $1`, bookingID).
+		Scan(&b.ID, &b.StudentID, &b.TutorID, &b.SubjectID, &b.SubdirectionID, &b.StartsAt, &b.EndsAt, &b.Status, &b.Trial)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookingNotFound
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *paymentRepository) BookingPrice(ctx context.Context, b *domain.Booking) (domain.Money, error) {
+	var m domain.Money
+	err := r.db.QueryRow(ctx, `
+SELECT price_minor, currency FROM public.tutor_prices
+WHERE tutor_id = $1
+  AND (($2 <> '' AND subject_id::text = $2) OR ($3 <> '' AND subdirection_id::text = $3))
+  AND duration_minutes = $4 AND lessons_count = 1 AND is_trial = $5
+ORDER BY price_minor
+LIMIT 1`, b.TutorID, b.SubjectID, b.SubdirectionID, b.DurationMinutes(), b.Trial).Scan(&m.AmountMinor, &m.Currency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return m, ErrPriceNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+const paymentColumns = `id, COALESCE(booking_id::text,''), COALESCE(lesson_id::text,''), student_id, amount_minor, currency,
+       provider, COALESCE(provider_intent_id,''), status,
+       COALESCE(provider_payload->>'client_secret',''), COALESCE(provider_payload->>'redirect_url',''),
+       created_at, updated_at`
+
+func scanPayment(row pgx.Row) (*domain.Payment, error) {
+	var p domain.Payment
+	if err := row.Scan(&p.ID, &p.BookingID, &p.LessonID, &p.StudentID, &p.AmountMinor, &p.Currency,
+		&p.Provider, &p.ProviderIntentID, &p.Status, &p.ClientSecret, &p.RedirectURL,
+		&p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *paymentRepository) FindPayment(ctx context.Context, paymentID string) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow(ctx, `SELECT `+paymentColumns+` FROM public.payments WHERE id = $1`, paymentID))
+}
+
+func (r *paymentRepository) FindByIdempotencyKey(ctx context.Context, key string) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow(ctx, `SELECT `+paymentColumns+` FROM public.payments WHERE idempotency_key = $1`, key))
+}
+
+// activePaymentSQL — последний незавершённый или прошедший платёж брони ($1).
+const activePaymentSQL = `
+SELECT ` + paymentColumns + ` FROM public.payments
+WHERE booking_id = $1 AND status IN ('requires_action','authorized','captured')
+ORDER BY created_at DESC LIMIT 1`
+
+func (r *paymentRepository) FindActiveForBooking(ctx context.Context, bookingID string) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow(ctx, activePaymentSQL, bookingID))
+}
+
+func (r *paymentRepository) FindByIntent(ctx context.Context, provider, intentID string) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow(ctx, `
+SELECT `+paymentColumns+` FROM public.payments WHERE provider = $1 AND provider_intent_id = $2`, provider, intentID))
+}
+
+func (r *paymentRepository) CreatePayment(ctx context.Context, p *domain.Payment, idempotencyKey string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM public.bookings WHERE id = $1 FOR UPDATE`, p.BookingID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBookingNotFound
+		}
+		return err
+	}
+	if status != domain.BookingPending && status != domain.BookingAwaitingPayment {
+		return fmt.Errorf("%w: booking is %s", ErrPaymentTransition, status)
+	}
+	// под блокировкой брони: параллельный запрос без ключа мог создать платёж первым
+	active, err := scanPayment(tx.QueryRow(ctx, activePaymentSQL, p.BookingID))
+	switch {
+	case err == nil:
+		*p = *active
+		return ErrActivePayment
+	case !errors.Is(err, ErrPaymentNotFound):
+		return err
+	}
+
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+	}
+	err = tx.QueryRow(ctx, `
+INSERT INTO public.payments (booking_id, student_id, amount_minor, currency, provider, status, idempotency_key)
+VALUES ($1, $2, $3, $4, $5, 'requires_action', $6)
+ON CONFLICT (idempotency_key) DO NOTHING
+RETURNING id, status, created_at, updated_at`,
+		p.BookingID, p.StudentID, p.AmountMinor, p.Currency, p.Provider, key).
+		Scan(&p.ID, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDuplicateIdempotency
+		}
+		return fmt.Errorf("insert payment: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE public.bookings b SET status = 'awaiting_payment', updated_at = now(),
+    cancellation_policy_id = `+policyForBookingSQL+`
+WHERE b.id = $1 AND b.status = 'pending'`, p.BookingID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *paymentRepository) AttachIntent(ctx context.Context, paymentID, intentID, status string, payload map[string]any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `
+UPDATE public.payments
+SET provider_intent_id = NULLIF($2,''), status = $3,
+    provider_payload = provider_payload || $4::jsonb, updated_at = now()
+WHERE id = $1`, paymentID, intentID, status, b)
+	return err
+}
+
+func (r *paymentRepository) ApplyStatus(ctx context.Context, paymentID, status, eventID string, raw []byte) (*domain.Payment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	p, err := scanPayment(tx.QueryRow(ctx, `SELECT `+paymentColumns+` FROM public.payments WHERE id = $1 FOR UPDATE`, paymentID))
+	if err != nil {
+		return nil, err
+	}
+	if eventID != "" {
+		var seen bool
+		if err := tx.QueryRow(ctx, `
+SELECT COALESCE(provider_payload->'event_ids', '[]'::jsonb) ? $2 FROM public.payments WHERE id = $1`,
+			paymentID, eventID).Scan(&seen); err != nil {
+			return nil, err
+		}
+		if seen {
+			return p, nil
+		}
+	}
+	if p.Status == status {
+		return p, nil
+	}
+	// вебхуки могут прийти не по порядку: authorized после captured просто игнорируем
+	if !domain.CanTransitionPayment(p.Status, status) {
+		if eventID != "" {
+			return p, nil
+		}
+		return nil, fmt.Errorf("%w: %s → %s", ErrPaymentTransition, p.Status, status)
+	}
+
+	event := json.RawMessage("null")
+	if json.Valid(raw) {
+		event = raw
+	}
+	if err := tx.QueryRow(ctx, `
+UPDATE public.payments
+SET status = $2,
+    provider_payload = provider_payload
+        || jsonb_build_object('last_event', $4::jsonb)
+        || CASE WHEN $3 = '' THEN '{}'::jsonb
+                ELSE jsonb_build_object('event_ids', COALESCE(provider_payload->'event_ids','[]'::jsonb) || to_jsonb($3::text)) END,
+    updated_at = now()
+WHERE id = $1
+RETURNING status, updated_at`, paymentID, status, eventID, []byte(event)).Scan(&p.Status, &p.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("update payment: %w", err)
+	}
+
+	if p.BookingID != "" {
+		if err := applyBookingEffects(ctx, tx, p); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyLedgerEffects(ctx, tx, p, event); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// applyLedgerEffects: списание → проводка захвата; полный возврат со стороны провайдера
+// (например, из его кабинета) → возврат остатка, ещё не оформленного через refunds.
+func applyLedgerEffects(ctx context.Context, tx pgx.Tx, p *domain.Payment, raw []byte) error {
+	switch p.Status {
+	case domain.PaymentCaptured:
+		return postLedger(ctx, tx, domain.CaptureTxn(*p))
+	case domain.PaymentRefunded:
+		var refunded int64
+		if err := tx.QueryRow(ctx, `
+SELECT COALESCE(SUM(amount_minor), 0) FROM public.refunds
+WHERE payment_id = $1 AND status = 'succeeded'`, p.ID).Scan(&refunded); err != nil {
+			return err
+		}
+		if rest := p.AmountMinor - refunded; rest > 0 {
+			_, err := insertRefund(ctx, tx, p, rest, "succeeded", raw)
+			return err
+		}
+	}
+	return nil
+}
+
+// applyBookingEffects: деньги авторизованы/списаны → бронь confirmed и урок scheduled;
+// отказ → бронь снова pending (можно оплатить заново); отмена авторизации → бронь cancelled,
+// а участникам уже запланированного урока — уведомление lesson.cancelled.
+func applyBookingEffects(ctx context.Context, tx pgx.Tx, p *domain.Payment) error {
+	switch p.Status {
+	case domain.PaymentAuthorized, domain.PaymentCaptured:
+		tag, err := tx.Exec(ctx, `
+UPDATE public.bookings SET status = 'confirmed', updated_at = now()
+WHERE id = $1 AND status = 'awaiting_payment'`, p.BookingID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		if err := tx.QueryRow(ctx, `
+INSERT INTO public.lessons (booking_id, student_id, tutor_id, subject_id, subdirection_id, status, is_trial, started_at, duration_seconds)
+SELECT b.id, b.student_id, b.tutor_id, b.subject_id, b.subdirection_id, 'scheduled',
+       COALESCE((b.metadata->>'trial')::boolean, false), b.starts_at,
+       EXTRACT(EPOCH FROM (b.ends_at - b.starts_at))::int
+FROM public.bookings b WHERE b.id = $1
+ON CONFLICT (booking_id) DO UPDATE SET updated_at = now()
+RETURNING id`, p.BookingID).Scan(&p.LessonID); err != nil {
+			return fmt.Errorf("create lesson: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE public.payments SET lesson_id = $2 WHERE id = $1`, p.ID, p.LessonID); err != nil {
+			return err
+		}
+		return writeBookingConfirmed(ctx, tx, p)
+	case domain.PaymentFailed:
+		_, err := tx.Exec(ctx, `
+UPDATE public.bookings SET status = 'pending', updated_at = now()
+WHERE id = $1 AND status = 'awaiting_payment'`, p.BookingID)
+		return err
+	case domain.PaymentVoided:
+		if _, err := tx.Exec(ctx, `
+UPDATE public.bookings SET status = 'cancelled', updated_at = now()
+WHERE id = $1 AND status IN ('awaiting_payment','confirmed')`, p.BookingID); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `
+UPDATE public.lessons SET status = 'cancelled', updated_at = now()
+WHERE booking_id = $1 AND status = 'scheduled'`, p.BookingID)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		return notifyBookingParties(ctx, tx, p.BookingID, "lesson.cancelled", "", map[string]any{"lessonId": p.LessonID})
+	}
+	return nil
+}
+
+// writeBookingConfirmed — событие booking.confirmed и уведомления обоим участникам
+// в транзакции подтверждения брони.
+func writeBookingConfirmed(ctx context.Context, tx pgx.Tx, p *domain.Payment) error {
+	var studentID, tutorID string
+	var startsAt, endsAt time.Time
+	if err := tx.QueryRow(ctx, `
+SELECT student_id::text, tutor_id::text, starts_at, ends_at FROM public.bookings WHERE id = $1`,
+		p.BookingID).Scan(&studentID, &tutorID, &startsAt, &endsAt); err != nil {
+		return err
+	}
+	if err := notifyBookingParties(ctx, tx, p.BookingID, "booking.confirmed", "", map[string]any{"lessonId": p.LessonID}); err != nil {
+		return err
+	}
+	return writeOutbox(ctx, tx, "booking", p.BookingID, domain.EventBookingConfirmed, map[string]any{
+		"bookingId":   p.BookingID,
+		"lessonId":    p.LessonID,
+		"paymentId":   p.ID,
+		"studentId":   studentID,
+		"tutorId":     tutorID,
+		"startsAt":    startsAt,
+		"endsAt":      endsAt,
+		"amountMinor": p.AmountMinor,
+		"currency":    p.Currency,
+	})
+}
+
+func (r *paymentRepository) RefundedMinor(ctx context.Context, paymentID string) (int64, error) {
+	var sum int64
+	err := r.db.QueryRow(ctx, `
+SELECT COALESCE(SUM(amount_minor), 0) FROM public.refunds
+WHERE payment_id = $1 AND status IN ('pending','succeeded')`, paymentID).Scan(&sum)
+	return sum, err
+}
+
+func (r *paymentRepository) ReserveRefund(ctx context.Context, paymentID string, amountMinor int64) (*domain.PaymentRefund, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	p, err := scanPayment(tx.QueryRow(ctx, `SELECT `+paymentColumns+` FROM public.payments WHERE id = $1 FOR UPDATE`, paymentID))
+	if err != nil {
+		return nil, false, err
+	}
+	if p.Status != domain.PaymentCaptured {
+		return nil, false, fmt.Errorf("%w: payment is %s", ErrPaymentTransition, p.Status)
+	}
+	var refunded int64
+	if err := tx.QueryRow(ctx, `
+SELECT COALESCE(SUM(amount_minor), 0) FROM public.refunds
+WHERE payment_id = $1 AND status IN ('pending','succeeded')`, p.ID).Scan(&refunded); err != nil {
+		return nil, false, err
+	}
+	left := p.AmountMinor - refunded
+	if amountMinor == 0 {
+		amountMinor = left
+	}
+	if amountMinor <= 0 || amountMinor > left {
+		return nil, false, fmt.Errorf("%w: %d of %d", ErrRefundExceeds, amountMinor, left)
+	}
+	rf, err := insertRefund(ctx, tx, p, amountMinor, "pending", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return rf, amountMinor == left, nil
+}
+
+func (r *paymentRepository) FinishRefund(ctx context.Context, refundID, status string, raw []byte) (*domain.PaymentRefund, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rf := domain.PaymentRefund{ID: refundID}
+	if err := tx.QueryRow(ctx, `
+SELECT payment_id, amount_minor, currency, status, created_at FROM public.refunds WHERE id = $1 FOR UPDATE`, refundID).
+		Scan(&rf.PaymentID, &rf.AmountMinor, &rf.Currency, &rf.Status, &rf.CreatedAt); err != nil {
+		return nil, err
+	}
+	if rf.Status != "pending" || status == "pending" {
+		return &rf, nil
+	}
+	payload := json.RawMessage("{}")
+	if json.Valid(raw) {
+		payload = raw
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.refunds SET status = $2, provider_payload = $3::jsonb WHERE id = $1`, refundID, status, []byte(payload)); err != nil {
+		return nil, err
+	}
+	rf.Status = status
+	if status == "succeeded" {
+		p, err := scanPayment(tx.QueryRow(ctx, `SELECT `+paymentColumns+` FROM public.payments WHERE id = $1 FOR UPDATE`, rf.PaymentID))
+		if err != nil {
+			return nil, err
+		}
+		if err := postRefund(ctx, tx, p, rf); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &rf, nil
+}
+
+// insertRefund пишет refunds и, если возврат прошёл, сторнирующую проводку в леджере.
+func insertRefund(ctx context.Context, tx pgx.Tx, p *domain.Payment, amountMinor int64, status string, raw []byte) (*domain.PaymentRefund, error) {
+	payload := json.RawMessage("{}")
+	if json.Valid(raw) {
+		payload = raw
+	}
+	rf := domain.PaymentRefund{PaymentID: p.ID, AmountMinor: amountMinor, Currency: p.Currency, Status: status}
+	if err := tx.QueryRow(ctx, `
+INSERT INTO public.refunds (payment_id, amount_minor, currency, status, provider_payload)
+VALUES ($1, $2, $3, $4, $5::jsonb)
+RETURNING id, created_at`, p.ID, amountMinor, p.Currency, status, []byte(payload)).Scan(&rf.ID, &rf.CreatedAt); err != nil {
+		return nil, fmt.Errorf("insert refund: %w", err)
+	}
+	if status != "succeeded" {
+		return &rf, nil
+	}
+	if err := postRefund(ctx, tx, p, rf); err != nil {
+		return nil, err
+	}
+	return &rf, nil
+}
+
+// postRefund — сторнирующая проводка прошедшего возврата.
+func postRefund(ctx context.Context, tx pgx.Tx, p *domain.Payment, rf domain.PaymentRefund) error {
+	split, err := refundSplit(ctx, tx, p.ID, rf.AmountMinor)
+	if err != nil {
+		return err
+	}
+	return postLedger(ctx, tx, domain.RefundTxn("refund:"+rf.ID, *p, split))
+}
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/audit"
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPayoutNotFound   = errors.New("payout not found")
+	ErrPayoutTransition = errors.New("payout status transition not allowed")
+)
+
+type PayoutRepository interface {
+	// Balance — баланс владельца и часть, ещё находящаяся в hold (заработок моложе hold).
+	Balance(ctx context.Context, ownerType, ownerID, currency string, hold time.Duration) (domain.PayoutBalance, error)
+	// Create под блокировкой кошелька проверяет доступный остаток, создаёт выплату
+	// requested и резервирует сумму в леджере.
+	Create(ctx context.Context, p *domain.Payout, idempotencyKey string, hold time.Duration) error
+	FindPayout(ctx context.Context, id string) (*domain.Payout, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*domain.Payout, error)
+	List(ctx context.Context, ownerType, ownerID, status string, page, limit int) ([]domain.Payout, int, error)
+
+	// Approve переводит пачку requested → approved; возвращает одобренные id.
+	Approve(ctx context.Context, ids []string, adminID string) ([]string, error)
+	// Cancel — requested/approved → cancelled с возвратом резерва.
+	Cancel(ctx context.Context, id, actorID string) (*domain.Payout, error)
+	// ClaimApproved забирает одобренные выплаты в processing (SKIP LOCKED — безопасно для нескольких реплик).
+	ClaimApproved(ctx context.Context, provider string, limit int) ([]domain.Payout, error)
+	// ListProcessing — выплаты в processing, не обновлявшиеся дольше olderThan.
+	ListProcessing(ctx context.Context, olderThan time.Duration, limit int) ([]domain.Payout, error)
+	SetProviderRef(ctx context.Context, id, ref string, raw []byte) error
+	// Finish — processing → paid/failed с проводкой выплаты или возвратом резерва.
+	Finish(ctx context.Context, id, status, reason string, raw []byte) (*domain.Payout, error)
+}
+
+type payoutRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPayoutRepository(db *pgxpool.Pool) PayoutRepository {
+	return &payoutRepository{db: db}
+}
+
+const payoutColumns = `id, COALESCE(owner_type,'tutor'), COALESCE(owner_id, tutor_id)::text, amount_minor, currency, method,
+       destination, status, requested_by, COALESCE(approved_by::text,''),
+       COALESCE(provider_payload->>'provider',''), COALESCE(provider_payload->>'ref',''),
+       COALESCE(provider_payload->>'reason',''), created_at, updated_at`
+
+func scanPayout(row pgx.Row) (*domain.Payout, error) {
+	var p domain.Payout
+	var dest []byte
+	if err := row.Scan(&p.ID, &p.OwnerType, &p.OwnerID, &p.AmountMinor, &p.Currency, &p.Method,
+		&dest, &p.Status, &p.RequestedBy, &p.ApprovedBy, &p.Provider, &p.ProviderRef,
+		&p.FailureReason, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPayoutNotFound
+		}
+		return nil, err
+	}
+	_ = json.Unmarshal(dest, &p.Destination)
+	return &p, nil
+}
+
+func collectPayouts(rows pgx.Rows) ([]domain.Payout, error) {
+	defer rows.Close()
+	out := []domain.Payout{}
+	for rows.Next() {
+		p, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+type balanceQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func payoutBalance(ctx context.Context, q balanceQuerier, ownerType, ownerID, currency string, hold time.Duration, lock bool) (domain.PayoutBalance, error) {
+	b := domain.PayoutBalance{Currency: currency}
+	sql := `SELECT id, balance_minor FROM public.wallets WHERE owner_type = $1 AND owner_id = $2 AND currency = $3`
+	if lock {
+		sql += ` FOR UPDATE`
+	}
+	var walletID string
+	if err := q.QueryRow(ctx, sql, ownerType, ownerID, currency).Scan(&walletID, &b.BalanceMinor); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return b, nil
+		}
+		return b, err
+	}
+	if err := q.QueryRow(ctx, `
+SELECT COALESCE(SUM(amount_minor), 0)::bigint FROM public.wallet_entries
+WHERE wallet_id = $1 AND type = 'credit' AND reason = $2 AND created_at > now() - make_interval(secs => $3)`,
+		walletID, domain.ReasonLessonEarning, hold.Seconds()).Scan(&b.OnHoldMinor); err != nil {
+		return b, err
+	}
+	b.AvailableMinor = max(b.BalanceMinor-b.OnHoldMinor, 0)
+	return b, nil
+}
+
+func (r *payoutRepository) Balance(ctx context.Context, ownerType, ownerID, currency string, hold time.Duration) (domain.PayoutBalance, error) {
+	return payoutBalance(ctx, r.db, ownerType, ownerID, currency, hold, false)
+}
+
+func (r *payoutRepository) Create(ctx context.Context, p *domain.Payout, idempotencyKey string, hold time.Duration) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	b, err := payoutBalance(ctx, tx, p.OwnerType, p.OwnerID, p.Currency, hold, true)
+	if err != nil {
+		return err
+	}
+	if p.AmountMinor > b.AvailableMinor {
+		return fmt.Errorf("%w: available %d %s", ErrInsufficientFunds, b.AvailableMinor, p.Currency)
+	}
+
+	dest, err := json.Marshal(p.Destination)
+	if err != nil {
+		return err
+	}
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+	}
+	// tutor_id обязателен в исходной схеме — для репетиторов дублируем owner_id
+	err = tx.QueryRow(ctx, `
+INSERT INTO public.payouts (tutor_id, owner_type, owner_id, amount_minor, currency, method, destination, status, requested_by, idempotency_key)
+VALUES ($1, $2, $1, $3, $4, $5, $6::jsonb, 'requested', $7, $8)
+ON CONFLICT (idempotency_key) DO NOTHING
+RETURNING id, status, created_at, updated_at`,
+		p.OwnerID, p.OwnerType, p.AmountMinor, p.Currency, p.Method, dest, p.RequestedBy, key).
+		Scan(&p.ID, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDuplicateIdempotency
+		}
+		return fmt.Errorf("insert payout: %w", err)
+	}
+	if err := postLedger(ctx, tx, domain.PayoutReserveTxn(*p)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *payoutRepository) FindPayout(ctx context.Context, id string) (*domain.Payout, error) {
+	return scanPayout(r.db.QueryRow(ctx, `SELECT `+payoutColumns+` FROM public.payouts WHERE id = $1`, id))
+}
+
+func (r *payoutRepository) FindByIdempotencyKey(ctx context.Context, key string) (*domain.Payout, error) {
+	return scanPayout(r.db.QueryRow(ctx, `SELECT `+payoutColumns+` FROM public.payouts WHERE idempotency_key = $1`, key))
+}
+
+func (r *payoutRepository) List(ctx context.Context, ownerType, ownerID, status string, page, limit int) ([]domain.Payout, int, error) {
+	where := `($1 = '' OR owner_type = $1) AND ($2 = '' OR owner_id::text = $2) AND ($3 = '' OR status = $3)`
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM public.payouts WHERE `+where, ownerType, ownerID, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(ctx, `
+SELECT `+payoutColumns+` FROM public.payouts WHERE `+where+`
+ORDER BY created_at DESC LIMIT $4 OFFSET $5`, ownerType, ownerID, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := collectPayouts(rows)
+	return list, total, err
+}
+
+func (r *payoutRepository) Approve(ctx context.Context, ids []string, adminID string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, `
+UPDATE public.payouts SET status = 'approved', approved_by = $2, updated_at = now()
+WHERE id::text = ANY($1) AND status = 'requested'
+RETURNING id::text`, ids, adminID)
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range out {
+		if err := audit.Write(ctx, tx, adminID, "payout", id, "payout.approve",
+			map[string]any{"status": domain.PayoutRequested}, map[string]any{"status": domain.PayoutApproved}); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *payoutRepository) Cancel(ctx context.Context, id, actorID string) (*domain.Payout, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	p, err := scanPayout(tx.QueryRow(ctx, `SELECT `+payoutColumns+` FROM public.payouts WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.PayoutRequested && p.Status != domain.PayoutApproved {
+		return nil, fmt.Errorf("%w: payout is %s", ErrPayoutTransition, p.Status)
+	}
+	prev := p.Status
+	if err := tx.QueryRow(ctx, `
+UPDATE public.payouts SET status = 'cancelled', updated_at = now() WHERE id = $1
+RETURNING status, updated_at`, id).Scan(&p.Status, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := audit.Write(ctx, tx, actorID, "payout", p.ID, "payout.cancel",
+		map[string]any{"status": prev}, map[string]any{"status": p.Status}); err != nil {
+		return nil, err
+	}
+	if err := postLedger(ctx, tx, domain.PayoutReleaseTxn(*p)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *payoutRepository) ClaimApproved(ctx context.Context, provider string, limit int) ([]domain.Payout, error) {
+	rows, err := r.db.Query(ctx, `
+UPDATE public.payouts SET status = 'processing',
+       provider_payload = provider_payload || jsonb_build_object('provider', $1::text),
+       updated_at = now()
+WHERE id IN (
+    SELECT id FROM public.payouts WHERE status = 'approved'
+    ORDER BY created_at LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING `+payoutColumns, provider, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectPayouts(rows)
+}
+
+func (r *payoutRepository) ListProcessing(ctx context.Context, olderThan time.Duration, limit int) ([]domain.Payout, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT `+payoutColumns+` FROM public.payouts
+WHERE status = 'processing' AND updated_at < now() - make_interval(secs => $1)
+ORDER BY updated_at LIMIT $2`, olderThan.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectPayouts(rows)
+}
+
+func (r *payoutRepository) SetProviderRef(ctx context.Context, id, ref string, raw []byte) error {
+	payload := map[string]any{"ref": ref}
+	if json.Valid(raw) {
+		payload["response"] = json.RawMessage(raw)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `
+UPDATE public.payouts SET provider_payload = provider_payload || $2::jsonb, updated_at = now()
+WHERE id = $1 AND status = 'processing'`, id, b)
+	return err
+}
+
+func (r *payoutRepository) Finish(ctx context.Context, id, status, reason string, raw []byte) (*domain.Payout, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	p, err := scanPayout(tx.QueryRow(ctx, `SELECT `+payoutColumns+` FROM public.payouts WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == status {
+		return p, nil
+	}
+	if p.Status != domain.PayoutProcessing || (status != domain.PayoutPaid && status != domain.PayoutFailed) {
+		return nil, fmt.Errorf("%w: %s → %s", ErrPayoutTransition, p.Status, status)
+	}
+
+	payload := map[string]any{}
+	if reason != "" {
+		payload["reason"] = reason
+	}
+	if json.Valid(raw) {
+		payload["result"] = json.RawMessage(raw)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(ctx, `
+UPDATE public.payouts SET status = $2, provider_payload = provider_payload || $3::jsonb, updated_at = now()
+WHERE id = $1 RETURNING status, updated_at`, id, status, b).Scan(&p.Status, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.FailureReason = reason
+
+	txn := domain.PayoutPaidTxn(*p)
+	if status == domain.PayoutFailed {
+		txn = domain.PayoutReleaseTxn(*p)
+	}
+	if err := postLedger(ctx, tx, txn); err != nil {
+		return nil, err
+	}
+	if err := notifyPayout(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// notifyPayout — payout.paid / payout.failed владельцу выплаты; выплаты платформы без уведомлений.
+func notifyPayout(ctx context.Context, tx pgx.Tx, p *domain.Payout) error {
+	if p.OwnerType == domain.OwnerPlatform {
+		return nil
+	}
+	ns, err := templateNotifications(p.OwnerID, "payout."+p.Status, map[string]any{
+		"payoutId": p.ID,
+		"amount":   domain.FormatMinor(p.AmountMinor, p.Currency),
+		"currency": p.Currency,
+		"reason":   p.FailureReason,
+	})
+	if err != nil {
+		return err
+	}
+	return enqueueNotifications(ctx, tx, ns)
+}
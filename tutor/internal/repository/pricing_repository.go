@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrSubjectNotFound      = errors.New("subject not found")
+	ErrSubdirectionNotFound = errors.New("subdirection not found")
+)
+
+type PricingRepository interface {
+	ReplacePrices(ctx context.Context, tutorID string, items []domain.LessonPrice) error
+	ListPrices(ctx context.Context, tutorID string) ([]domain.LessonPrice, error)
+
+	UpsertFXRate(ctx context.Context, base, quote string, rate float64) error
+	ListFXRates(ctx context.Context) ([]domain.FXRate, error)
+}
+
+type pricingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPricingRepository(db *pgxpool.Pool) PricingRepository {
+	return &pricingRepository{db: db}
+}
+
+// ReplacePrices — полная замена прайса репетитора; legacy-поля (tutor_subjects.price_minor,
+// tutor_subdirections.price_minor, hourly_rate_minor, props.prices) пересчитываются из tutor_prices.
+func (r *pricingRepository) ReplacePrices(ctx context.Context, tutorID string, items []domain.LessonPrice) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO public.tutor_profiles (user_id, hourly_rate_minor, currency, verification, props, created_at, updated_at)
+VALUES ($1, 0, 'KZT', 'pending', '{}'::jsonb, now(), now())
+ON CONFLICT (user_id) DO NOTHING`, tutorID); err != nil {
+		return fmt.Errorf("ensure tutor_profiles: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM public.tutor_prices WHERE tutor_id = $1`, tutorID); err != nil {
+		return err
+	}
+
+	for _, it := range items {
+		subjectID, subdirID, err := resolvePriceTarget(ctx, tx, it)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO public.tutor_prices (tutor_id, subject_id, subdirection_id, duration_minutes, lessons_count, is_trial, price_minor, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			tutorID, subjectID, subdirID, it.DurationMinutes, it.LessonsCount, it.Trial, it.PriceMinor, it.Currency); err != nil {
+			return fmt.Errorf("insert tutor_prices: %w", err)
+		}
+	}
+
+	if err := syncLegacyPrices(ctx, tx, tutorID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *pricingRepository) ListPrices(ctx context.Context, tutorID string) ([]domain.LessonPrice, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT p.id, COALESCE(p.subject_id::text,''), COALESCE(s.slug,''),
+       COALESCE(p.subdirection_id::text,''), COALESCE(sd.slug,''),
+       p.duration_minutes, p.lessons_count, p.is_trial, p.price_minor, p.currency
+FROM public.tutor_prices p
+LEFT JOIN public.subjects s ON s.id = p.subject_id
+LEFT JOIN public.subdirections sd ON sd.id = p.subdirection_id
+WHERE p.tutor_id = $1
+ORDER BY COALESCE(s.slug, sd.slug), p.duration_minutes, p.is_trial DESC, p.lessons_count`, tutorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.LessonPrice{}
+	for rows.Next() {
+		var p domain.LessonPrice
+		if err := rows.Scan(&p.ID, &p.SubjectID, &p.SubjectSlug, &p.SubdirectionID, &p.SubdirectionSlug,
+			&p.DurationMinutes, &p.LessonsCount, &p.Trial, &p.PriceMinor, &p.Currency); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *pricingRepository) UpsertFXRate(ctx context.Context, base, quote string, rate float64) error {
+	_, err := r.db.Exec(ctx, `
+INSERT INTO public.fx_rates (base, quote, rate, updated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (base, quote) DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()`, base, quote, rate)
+	return err
+}
+
+func (r *pricingRepository) ListFXRates(ctx context.Context) ([]domain.FXRate, error) {
+	rows, err := r.db.Query(ctx, `SELECT base, quote, rate::float8, updated_at FROM public.fx_rates ORDER BY base, quote`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.FXRate{}
+	for rows.Next() {
+		var x domain.FXRate
+		if err := rows.Scan(&x.Base, &x.Quote, &x.Rate, &x.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+// ------------------ helpers ------------------
+
+// resolvePriceTarget — id предмета/поднаправления по id или slug (ровно одно из двух).
+func resolvePriceTarget(ctx context.Context, tx pgx.Tx, it domain.LessonPrice) (*string, *string, error) {
+	switch {
+	case it.SubjectID != "":
+		return &it.SubjectID, nil, nil
+	case it.SubjectSlug != "":
+		var id string
+		if err := tx.QueryRow(ctx, `SELECT id FROM public.subjects WHERE slug = $1`, strings.ToLower(it.SubjectSlug)).Scan(&id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, nil, fmt.Errorf("%w: %s", ErrSubjectNotFound, it.SubjectSlug)
+			}
+			return nil, nil, err
+		}
+		return &id, nil, nil
+	case it.SubdirectionID != "":
+		return nil, &it.SubdirectionID, nil
+	default:
+		var id string
+		if err := tx.QueryRow(ctx, `SELECT id FROM public.subdirections WHERE slug = $1`, strings.ToLower(it.SubdirectionSlug)).Scan(&id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, nil, fmt.Errorf("%w: %s", ErrSubdirectionNotFound, it.SubdirectionSlug)
+			}
+			return nil, nil, err
+		}
+		return nil, &id, nil
+	}
+}
+
+// syncLegacyPrices пересчитывает старые места хранения цен из tutor_prices:
+// привязки tutor_subjects/tutor_subdirections (цена за 60 минут), hourly_rate_minor
+// (минимальная цена разового 60-минутного занятия) и props.prices (slug → regular/trial)
+// в валюте анкеты.
+func syncLegacyPrices(ctx context.Context, tx pgx.Tx, tutorID string) error {
+	if _, err := tx.Exec(ctx, `
+INSERT INTO public.tutor_subjects (tutor_id, subject_id, price_minor, currency)
+SELECT DISTINCT ON (subject_id) tutor_id, subject_id,
+       CASE WHEN duration_minutes = 60 THEN price_minor END, currency
+FROM public.tutor_prices
+WHERE tutor_id = $1 AND subject_id IS NOT NULL AND lessons_count = 1 AND NOT is_trial
+ORDER BY subject_id, (duration_minutes = 60) DESC
+ON CONFLICT (tutor_id, subject_id) DO UPDATE
+SET price_minor = COALESCE(EXCLUDED.price_minor, public.tutor_subjects.price_minor), currency = EXCLUDED.currency`, tutorID); err != nil {
+		return fmt.Errorf("sync tutor_subjects: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO public.tutor_subdirections (tutor_id, subdirection_id, price_minor, currency)
+SELECT DISTINCT ON (subdirection_id) tutor_id, subdirection_id,
+       CASE WHEN duration_minutes = 60 THEN price_minor END, currency
+FROM public.tutor_prices
+WHERE tutor_id = $1 AND subdirection_id IS NOT NULL AND lessons_count = 1 AND NOT is_trial
+ORDER BY subdirection_id, (duration_minutes = 60) DESC
+ON CONFLICT (tutor_id, subdirection_id) DO UPDATE
+SET price_minor = COALESCE(EXCLUDED.price_minor, public.tutor_subdirections.price_minor), currency = EXCLUDED.currency`, tutorID); err != nil {
+		return fmt.Errorf("sync tutor_subdirections: %w", err)
+	}
+
+	// суммы в разных валютах не сравниваются: hourly_rate_minor и props.prices считаются
+	// только по ценам в валюте анкеты. Она остаётся прежней, пока в ней есть разовая
+	// 60-минутная цена, иначе переходит на самую частую валюту таких цен.
+	if _, err := tx.Exec(ctx, `
+WITH hourly AS (
+    SELECT p.*, sd.slug FROM public.tutor_prices p
+    LEFT JOIN public.subdirections sd ON sd.id = p.subdirection_id
+    WHERE p.tutor_id = $1 AND p.duration_minutes = 60 AND p.lessons_count = 1
+), cur AS (
+    SELECT COALESCE(
+      (SELECT tp.currency FROM public.tutor_profiles tp
+       WHERE tp.user_id = $1 AND EXISTS (SELECT 1 FROM hourly s WHERE NOT s.is_trial AND s.currency = tp.currency)),
+      (SELECT s.currency FROM hourly s WHERE NOT s.is_trial
+       GROUP BY s.currency ORDER BY count(*) DESC, s.currency LIMIT 1)
+    ) AS currency
+)
+UPDATE public.tutor_profiles tp
+SET hourly_rate_minor = COALESCE((SELECT MIN(s.price_minor) FROM hourly s
+                                  WHERE NOT s.is_trial AND s.currency = cur.currency), 0),
+    currency          = COALESCE(cur.currency, tp.currency),
+    props = COALESCE(tp.props,'{}'::jsonb) || jsonb_build_object('prices', COALESCE((
+      SELECT jsonb_object_agg(x.slug, x.obj) FROM (
+        SELECT s.slug, jsonb_strip_nulls(jsonb_build_object(
+                 'regular_minor', MAX(s.price_minor) FILTER (WHERE NOT s.is_trial),
+                 'trial_minor',   MAX(s.price_minor) FILTER (WHERE s.is_trial))) AS obj
+        FROM hourly s
+        WHERE s.slug IS NOT NULL AND s.currency = cur.currency
+        GROUP BY s.slug
+      ) x), '{}'::jsonb)),
+    updated_at = now()
+FROM cur
+WHERE tp.user_id = $1`, tutorID); err != nil {
+		return fmt.Errorf("sync tutor_profiles prices: %w", err)
+	}
+	return nil
+}
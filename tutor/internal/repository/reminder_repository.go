@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReminderRepository interface {
+	// Schedule в одной транзакции отмечает наступившие напоминания с интервалом lead
+	// и ставит уведомления, которые вернул build. Уже отмеченные (другой репликой или
+	// прошлым проходом) пропускаются — повторно напоминание не уйдёт.
+	Schedule(ctx context.Context, lead time.Duration, limit int, build func(domain.LessonReminder) []domain.Notification) (int, error)
+}
+
+type reminderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReminderRepository(db *pgxpool.Pool) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+// dueRemindersSQL: запланированные уроки, до начала которых осталось не больше lead
+// (partial-индекс lessons_scheduled_starts_idx). Уроки, забронированные уже внутри
+// окна, пропускаем — о них сообщило уведомление booking.confirmed, которое ставится
+// в транзакции подтверждения брони.
+const dueRemindersSQL = `
+WITH due AS (
+    SELECT l.id, l.student_id, l.tutor_id, l.started_at
+    FROM public.lessons l
+    WHERE l.status = 'scheduled'
+      AND l.started_at > now()
+      AND l.started_at <= now() + make_interval(secs => $1)
+      AND l.created_at < l.started_at - make_interval(secs => $1)
+      AND NOT EXISTS (SELECT 1 FROM public.lesson_reminders r WHERE r.lesson_id = l.id AND r.lead_seconds = $1::int)
+    ORDER BY l.started_at
+    LIMIT $2
+), parties AS (
+    SELECT id AS lesson_id, student_id AS user_id, tutor_id AS peer_id, started_at, 'student' AS role FROM due
+    UNION ALL
+    SELECT id, tutor_id, student_id, started_at, 'tutor' FROM due
+), ins AS (
+    INSERT INTO public.lesson_reminders (lesson_id, user_id, lead_seconds)
+    SELECT lesson_id, user_id, $1::int FROM parties
+    ON CONFLICT DO NOTHING
+    RETURNING lesson_id, user_id
+)
+SELECT p.lesson_id::text, p.user_id::text, p.role, p.started_at,
+       COALESCE(NULLIF(trim(concat_ws(' ', pu.first_name, pu.last_name)), ''), ''),
+       COALESCE(NULLIF(tp.props->>'timezone', ''), NULLIF(sp.prefs->>'timezone', ''), ''),
+       u.locale
+FROM ins
+JOIN parties p ON p.lesson_id = ins.lesson_id AND p.user_id = ins.user_id
+JOIN public.users u ON u.id = p.user_id
+JOIN public.users pu ON pu.id = p.peer_id
+LEFT JOIN public.tutor_profiles tp ON tp.user_id = p.user_id AND p.role = 'tutor'
+LEFT JOIN public.student_profiles sp ON sp.user_id = p.user_id AND p.role = 'student'`
+
+func (r *reminderRepository) Schedule(ctx context.Context, lead time.Duration, limit int, build func(domain.LessonReminder) []domain.Notification) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// конкурирующая реплика ждёт на PK lesson_reminders и после нашего commit вставит 0 строк
+	rows, err := tx.Query(ctx, dueRemindersSQL, lead.Seconds(), limit)
+	if err != nil {
+		return 0, err
+	}
+	var due []domain.LessonReminder
+	for rows.Next() {
+		rm := domain.LessonReminder{Lead: lead}
+		if err := rows.Scan(&rm.LessonID, &rm.UserID, &rm.Role, &rm.StartsAt, &rm.PeerName, &rm.Timezone, &rm.Locale); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, rm)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, rm := range due {
+		if err := enqueueNotifications(ctx, tx, build(rm)); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), tx.Commit(ctx)
+}
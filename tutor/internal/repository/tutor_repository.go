@@ -3,12 +3,17 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"shared/audit"
 	"tutor/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,10 +22,12 @@ type TutorRepository interface {
 	UpsertAbout(ctx context.Context, userID string, first, last, phone, gender, avatarURL string, langs []domain.TutorLanguage) error
 	// Availability
 	ReplaceWeeklyAvailability(ctx context.Context, userID, timezone string, days []domain.AvailabilityDay) error
-	// Education
-	UpsertEducation(ctx context.Context, userID string, bio string, education []domain.Education, certs []domain.Certification) error
+	// Education: записи с ID обновляются, без ID — добавляются, остальные удаляются;
+	// возвращает ключи документов удалённых записей (файлы удаляет вызывающий после коммита)
+	UpsertEducation(ctx context.Context, userID string, bio string, education []domain.Education, certs []domain.Certification) ([]string, error)
 	// Subjects & prices
-	ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular map[string]int64, trial map[string]int64) error
+	// ReplaceSubjects: currencies — валюта цен поднаправления по тому же slug, что в regular.
+	ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular, trial map[string]int64, currencies map[string]string) error
 	// Video
 	SetVideo(ctx context.Context, userID string, videoURL string) error
 	// Complete onboarding
@@ -29,6 +36,8 @@ type TutorRepository interface {
 	// Queries
 	FindTutorCardList(ctx context.Context, filters map[string]string, page, limit int) ([]domain.TutorProfile, int, error)
 	FindTutorDetails(ctx context.Context, tutorID string) (*domain.TutorProfile, error)
+	// Draft (анкета целиком, включая незаполненную)
+	FindDraft(ctx context.Context, userID string) (*domain.TutorDraft, error)
 }
 
 type tutorRepository struct {
@@ -48,6 +57,11 @@ func (r *tutorRepository) UpsertAbout(ctx context.Context, userID, first, last,
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
+	before, err := audit.Snapshot(ctx, tx, auditTutorAbout, userID)
+	if err != nil {
+		return err
+	}
+
 	// users (first/last/phone)
 	if _, err := tx.Exec(ctx, `
 UPDATE public.users
@@ -57,11 +71,8 @@ WHERE id = $1`, userID, first, last, nullIfEmpty(phone)); err != nil {
 	}
 
 	// tutor_profiles (ensure row)
-	if _, err := tx.Exec(ctx, `
-INSERT INTO public.tutor_profiles (user_id, hourly_rate_minor, currency, verification, props, created_at, updated_at)
-VALUES ($1, 0, 'KZT', 'pending', '{}'::jsonb, now(), now())
-ON CONFLICT (user_id) DO UPDATE SET updated_at = now()`, userID); err != nil {
-		return fmt.Errorf("upsert tutor_profiles: %w", err)
+	if err := ensureTutorProfile(ctx, tx, userID); err != nil {
+		return err
 	}
 
 	// props: gender, avatar_url
@@ -108,6 +119,13 @@ ON CONFLICT (tutor_id, lang_code) DO UPDATE SET proficiency = EXCLUDED.proficien
 		}
 	}
 
+	after, err := audit.Snapshot(ctx, tx, auditTutorAbout, userID)
+	if err != nil {
+		return err
+	}
+	if err := audit.Change(ctx, tx, userID, "tutor", userID, "tutor.profile.about", before, after); err != nil {
+		return err
+	}
 	return tx.Commit(ctx)
 }
 
@@ -158,86 +176,268 @@ VALUES (gen_random_uuid(), $1, $2, $3, $4, true, now())`, userID, start, end, rr
 	return tx.Commit(ctx)
 }
 
-func (r *tutorRepository) UpsertEducation(ctx context.Context, userID string, bio string, education []domain.Education, certs []domain.Certification) error {
+func (r *tutorRepository) UpsertEducation(ctx context.Context, userID string, bio string, education []domain.Education, certs []domain.Certification) ([]string, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	eduBytes, _ := json.Marshal(education)
-	certBytes, _ := json.Marshal(certs)
+	before, err := audit.Snapshot(ctx, tx, auditTutorEducation, userID)
+	if err != nil {
+		return nil, err
+	}
 
-	if _, err := tx.Exec(ctx, `
-UPDATE public.tutor_profiles
-SET bio = $2,
-    props = COALESCE(props,'{}'::jsonb) || jsonb_build_object('education', $3::jsonb, 'certificates', $4::jsonb),
-    updated_at = now()
-WHERE user_id = $1`, userID, bio, string(eduBytes), string(certBytes)); err != nil {
-		return err
+	var bioChanged bool
+	err = tx.QueryRow(ctx, `
+UPDATE public.tutor_profiles tp SET bio = $2, updated_at = now()
+FROM (SELECT bio FROM public.tutor_profiles WHERE user_id = $1 FOR UPDATE) old
+WHERE tp.user_id = $1
+RETURNING old.bio IS DISTINCT FROM $2`, userID, bio).Scan(&bioChanged)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// анкеты ещё нет — записям не к чему привязаться
+		return nil, tx.Commit(ctx)
 	}
-	return tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bioChanged {
+		if err := requeueVerification(ctx, tx, userID, "verified"); err != nil {
+			return nil, err
+		}
+	}
+	removed, err := replaceEducation(ctx, tx, userID, education)
+	if err != nil {
+		return nil, err
+	}
+	removedCerts, err := replaceCertificates(ctx, tx, userID, certs)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := audit.Snapshot(ctx, tx, auditTutorEducation, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := audit.Change(ctx, tx, userID, "tutor", userID, "tutor.profile.education", before, after); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return append(removed, removedCerts...), nil
 }
 
-func (r *tutorRepository) ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular map[string]int64, trial map[string]int64) error {
+func (r *tutorRepository) ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular, trial map[string]int64, currencies map[string]string) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	// чистим предметы
+	before, err := audit.Snapshot(ctx, tx, auditTutorSubjects, userID)
+	if err != nil {
+		return err
+	}
+
+	// чистим предметы (и их цены, иначе синхронизация вернёт привязки обратно)
+	subjectIDs := make([]string, 0, len(items))
+	for _, it := range items {
+		subjectIDs = append(subjectIDs, it.SubjectID)
+	}
 	if _, err := tx.Exec(ctx, `DELETE FROM public.tutor_subjects WHERE tutor_id = $1`, userID); err != nil {
 		return err
 	}
+	if _, err := tx.Exec(ctx, `
+DELETE FROM public.tutor_prices
+WHERE tutor_id = $1 AND subject_id IS NOT NULL AND NOT (subject_id = ANY($2::uuid[]))`, userID, subjectIDs); err != nil {
+		return err
+	}
 	// вставляем новые
 	for _, it := range items {
+		cur := domain.NormalizeCurrency(it.Currency)
 		if _, err := tx.Exec(ctx, `
 INSERT INTO public.tutor_subjects (tutor_id, subject_id, level, price_minor, currency)
 VALUES ($1, $2, $3, $4, $5)
 ON CONFLICT (tutor_id, subject_id) DO UPDATE
 SET level = EXCLUDED.level, price_minor = EXCLUDED.price_minor, currency = EXCLUDED.currency`,
-			userID, it.SubjectID, nullIfEmpty(it.Level), it.PriceMinor, strings.ToUpper(it.Currency)); err != nil {
+			userID, it.SubjectID, nullIfEmpty(it.Level), it.PriceMinor, cur); err != nil {
 			return fmt.Errorf("upsert tutor_subjects: %w", err)
 		}
+		if it.PriceMinor > 0 {
+			if err := upsertHourPrice(ctx, tx, userID, &it.SubjectID, nil, false, it.PriceMinor, cur); err != nil {
+				return err
+			}
+		}
 	}
 
-	// сохраняем цены в props (trial)
-	props := map[string]interface{}{"prices": map[string]interface{}{}}
+	// цены по поднаправлениям (slug → regular/trial): шаг мастера владеет разовыми 60-минутными ценами
+	keep := []string{}
+	for slug := range regular {
+		keep = append(keep, strings.ToLower(slug))
+	}
+	if _, err := tx.Exec(ctx, `
+DELETE FROM public.tutor_prices p
+USING public.subdirections sd
+WHERE sd.id = p.subdirection_id AND p.tutor_id = $1
+  AND p.duration_minutes = 60 AND p.lessons_count = 1
+  AND NOT (sd.slug = ANY($2::text[]))`, userID, keep); err != nil {
+		return err
+	}
 	for slug, p := range regular {
-		ensureNestedMap(props, "prices")[slug] = map[string]interface{}{
-			"regular_minor": p,
-			"trial_minor":   trial[slug],
+		subjectID, subdirID, ok, err := resolveSlug(ctx, tx, slug)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		currency := domain.NormalizeCurrency(currencies[slug])
+		if p > 0 {
+			if err := upsertHourPrice(ctx, tx, userID, subjectID, subdirID, false, p, currency); err != nil {
+				return err
+			}
+		}
+		if t := trial[slug]; t > 0 {
+			if err := upsertHourPrice(ctx, tx, userID, subjectID, subdirID, true, t, currency); err != nil {
+				return err
+			}
 		}
 	}
-	propsJSON, _ := json.Marshal(props)
 
-	if _, err := tx.Exec(ctx, `
-UPDATE public.tutor_profiles
-SET props = COALESCE(props,'{}'::jsonb) || $2::jsonb,
-    updated_at = now()
-WHERE user_id = $1`, userID, string(propsJSON)); err != nil {
+	if err := syncLegacyPrices(ctx, tx, userID); err != nil {
+		return err
+	}
+	after, err := audit.Snapshot(ctx, tx, auditTutorSubjects, userID)
+	if err != nil {
+		return err
+	}
+	if err := audit.Change(ctx, tx, userID, "tutor", userID, "tutor.subjects", before, after); err != nil {
 		return err
 	}
-
 	return tx.Commit(ctx)
 }
 
 func (r *tutorRepository) SetVideo(ctx context.Context, userID string, videoURL string) error {
-	_, err := r.db.Exec(ctx, `
-UPDATE public.tutor_profiles
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	before, err := audit.Snapshot(ctx, tx, auditTutorVideo, userID)
+	if err != nil {
+		return err
+	}
+	var videoChanged bool
+	err = tx.QueryRow(ctx, `
+UPDATE public.tutor_profiles tp
 SET video_url = $2, updated_at = now()
-WHERE user_id = $1`, userID, videoURL)
-	return err
+FROM (SELECT video_url FROM public.tutor_profiles WHERE user_id = $1 FOR UPDATE) old
+WHERE tp.user_id = $1
+RETURNING old.video_url IS DISTINCT FROM $2`, userID, videoURL).Scan(&videoChanged)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if videoChanged {
+		if err := requeueVerification(ctx, tx, userID, "verified"); err != nil {
+			return err
+		}
+	}
+	after, err := audit.Snapshot(ctx, tx, auditTutorVideo, userID)
+	if err != nil {
+		return err
+	}
+	if err := audit.Change(ctx, tx, userID, "tutor", userID, "tutor.profile.video", before, after); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
+// MarkCompleted отмечает завершение онбординга; при первом завершении в той же
+// транзакции пишет событие tutor.profile_completed, после отклонения — возвращает
+// анкету на проверку.
 func (r *tutorRepository) MarkCompleted(ctx context.Context, userID string) error {
-	_, err := r.db.Exec(ctx, `
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var wasCompleted bool
+	err = tx.QueryRow(ctx, `
+SELECT COALESCE(props,'{}'::jsonb) ? 'onboarding_completed_at'
+FROM public.tutor_profiles WHERE user_id = $1
+FOR UPDATE`, userID).Scan(&wasCompleted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var completedAt time.Time
+	if err := tx.QueryRow(ctx, `
 UPDATE public.tutor_profiles
-SET props = COALESCE(props,'{}'::jsonb) || jsonb_build_object('onboarding_completed_at', to_char(now(),'YYYY-MM-DD"T"HH24:MI:SSZ')),
+SET props = COALESCE(props,'{}'::jsonb) || jsonb_build_object('onboarding_completed_at', to_char(now() AT TIME ZONE 'UTC','YYYY-MM-DD"T"HH24:MI:SS"Z"')),
     updated_at = now()
-WHERE user_id = $1`, userID)
-	return err
+WHERE user_id = $1
+RETURNING now()`, userID).Scan(&completedAt); err != nil {
+		return err
+	}
+	// исправленная после отклонения анкета снова попадает в очередь проверки
+	if err := requeueVerification(ctx, tx, userID, "rejected"); err != nil {
+		return err
+	}
+	if !wasCompleted {
+		if err := writeOutbox(ctx, tx, "tutor", userID, domain.EventTutorProfileCompleted, map[string]any{
+			"tutorId":     userID,
+			"completedAt": completedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// requeueVerification возвращает анкету в pending, если её статус проверки — один из from
+// (отклонённую — после исправления, одобренную — после смены био или видео).
+// Причина прошлого отклонения снимается; дата и автор проверки остаются для истории.
+func requeueVerification(ctx context.Context, tx pgx.Tx, userID string, from ...string) error {
+	var status string
+	var before []byte
+	err := tx.QueryRow(ctx, `
+SELECT verification, jsonb_build_object('verification', verification, 'reason', props->'verification_reason')
+FROM public.tutor_profiles WHERE user_id = $1
+FOR UPDATE`, userID).Scan(&status, &before)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(from, status) {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE public.tutor_profiles
+SET verification = 'pending', props = COALESCE(props,'{}'::jsonb) - 'verification_reason', updated_at = now()
+WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("requeue verification: %w", err)
+	}
+	after := map[string]any{"verification": "pending", "reason": nil}
+	return audit.Write(ctx, tx, userID, "tutor", userID, "tutor.verification", before, after)
+}
+
+// ensureTutorProfile создаёт пустую анкету, если её ещё нет.
+func ensureTutorProfile(ctx context.Context, tx pgx.Tx, userID string) error {
+	if _, err := tx.Exec(ctx, `
+INSERT INTO public.tutor_profiles (user_id, hourly_rate_minor, currency, verification, props, created_at, updated_at)
+VALUES ($1, 0, 'KZT', 'pending', '{}'::jsonb, now(), now())
+ON CONFLICT (user_id) DO UPDATE SET updated_at = now()`, userID); err != nil {
+		return fmt.Errorf("upsert tutor_profiles: %w", err)
+	}
+	return nil
 }
 
 // ------------------ READ ------------------
@@ -247,15 +447,21 @@ func (r *tutorRepository) FindTutorCardList(ctx context.Context, filters map[str
 	offset := (page - 1) * limit
 	// фильтры search по био/фио
 	search := strings.TrimSpace(filters["search"])
+	// "verified" — только одобренные анкеты; пусто — pending + verified
+	verification := strings.TrimSpace(filters["verification"])
+	// вуз (id или slug) и страна обучения — по образованию, привязанному к справочнику
+	university := strings.ToLower(strings.TrimSpace(filters["university"]))
+	country := strings.TrimSpace(filters["country"])
 
 	var rowsCount int
 	if err := r.db.QueryRow(ctx, `
 SELECT COUNT(*) FROM public.tutor_profiles tp
 JOIN public.users u ON u.id = tp.user_id
 WHERE tp.deleted_at IS NULL AND u.deleted_at IS NULL
-  AND tp.verification IN ('pending','verified')
-  AND ($1 = '' OR (tp.bio ILIKE '%'||$1||'%' OR u.first_name ILIKE '%'||$1||'%' OR u.last_name ILIKE '%'||$1||'%'))`,
-		search).Scan(&rowsCount); err != nil {
+  AND (($2 = '' AND tp.verification IN ('pending','verified')) OR tp.verification = $2)
+  AND ($1 = '' OR (tp.bio ILIKE '%'||$1||'%' OR u.first_name ILIKE '%'||$1||'%' OR u.last_name ILIKE '%'||$1||'%'))`+
+		universityFilter("$3", "$4"),
+		search, verification, university, country).Scan(&rowsCount); err != nil {
 		return nil, 0, err
 	}
 
@@ -263,14 +469,16 @@ WHERE tp.deleted_at IS NULL AND u.deleted_at IS NULL
 SELECT tp.user_id, COALESCE(u.first_name,''), COALESCE(u.last_name,''), COALESCE(u.phone_e164,''),
        COALESCE(tp.props->>'gender',''), COALESCE(tp.props->>'avatar_url',''),
        COALESCE(tp.bio,''), COALESCE(tp.video_url,''), COALESCE(tp.props->>'timezone',''),
-       COALESCE(tp.rating_avg,0), COALESCE(tp.rating_count,0), tp.verification, tp.created_at, tp.updated_at
+       COALESCE(tp.rating_avg,0), COALESCE(tp.rating_count,0), tp.verification, tp.created_at, tp.updated_at,
+       tp.hourly_rate_minor, tp.currency
 FROM public.tutor_profiles tp
 JOIN public.users u ON u.id = tp.user_id
 WHERE tp.deleted_at IS NULL AND u.deleted_at IS NULL
-  AND tp.verification IN ('pending','verified')
-  AND ($1 = '' OR (tp.bio ILIKE '%'||$1||'%' OR u.first_name ILIKE '%'||$1||'%' OR u.last_name ILIKE '%'||$1||'%'))
+  AND (($4 = '' AND tp.verification IN ('pending','verified')) OR tp.verification = $4)
+  AND ($1 = '' OR (tp.bio ILIKE '%'||$1||'%' OR u.first_name ILIKE '%'||$1||'%' OR u.last_name ILIKE '%'||$1||'%'))`+
+		universityFilter("$5", "$6")+`
 ORDER BY tp.rating_avg DESC NULLS LAST, tp.updated_at DESC
-LIMIT $2 OFFSET $3`, search, limit, offset)
+LIMIT $2 OFFSET $3`, search, limit, offset, verification, university, country)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -279,13 +487,18 @@ LIMIT $2 OFFSET $3`, search, limit, offset)
 	list := make([]domain.TutorProfile, 0, limit)
 	for rows.Next() {
 		var p domain.TutorProfile
+		var rate domain.Money
 		if err := rows.Scan(
 			&p.UserID, &p.FirstName, &p.LastName, &p.PhoneE164,
 			&p.Gender, &p.AvatarURL, &p.Bio, &p.VideoURL, &p.Timezone,
 			&p.RatingAvg, &p.RatingCount, &p.Verification, &p.CreatedAt, &p.UpdatedAt,
+			&rate.AmountMinor, &rate.Currency,
 		); err != nil {
 			return nil, 0, err
 		}
+		if rate.AmountMinor > 0 {
+			p.HourlyRate = &rate
+		}
 		list = append(list, p)
 	}
 	return list, rowsCount, nil
@@ -296,27 +509,36 @@ func (r *tutorRepository) FindTutorDetails(ctx context.Context, tutorID string)
 SELECT tp.user_id, COALESCE(u.first_name,''), COALESCE(u.last_name,''), COALESCE(u.phone_e164,''),
        COALESCE(tp.props->>'gender',''), COALESCE(tp.props->>'avatar_url',''),
        COALESCE(tp.bio,''), COALESCE(tp.video_url,''), COALESCE(tp.props->>'timezone',''),
-       COALESCE(tp.props->'education','[]'::jsonb),
-       COALESCE(tp.props->'certificates','[]'::jsonb),
        COALESCE(tp.props->'prices','{}'::jsonb),
-       COALESCE(tp.rating_avg,0), COALESCE(tp.rating_count,0), tp.verification, tp.created_at, tp.updated_at
+       COALESCE(tp.rating_avg,0), COALESCE(tp.rating_count,0), tp.verification, tp.created_at, tp.updated_at,
+       tp.hourly_rate_minor, tp.currency
 FROM public.tutor_profiles tp
 JOIN public.users u ON u.id = tp.user_id
 WHERE tp.user_id = $1 AND tp.deleted_at IS NULL AND u.deleted_at IS NULL
 `, tutorID)
 
 	var p domain.TutorProfile
-	var eduJSON, certJSON, pricesJSON []byte
+	var pricesJSON []byte
+	var rate domain.Money
 	if err := row.Scan(
 		&p.UserID, &p.FirstName, &p.LastName, &p.PhoneE164,
 		&p.Gender, &p.AvatarURL, &p.Bio, &p.VideoURL, &p.Timezone,
-		&eduJSON, &certJSON, &pricesJSON,
+		&pricesJSON,
 		&p.RatingAvg, &p.RatingCount, &p.Verification, &p.CreatedAt, &p.UpdatedAt,
+		&rate.AmountMinor, &rate.Currency,
 	); err != nil {
 		return nil, err
 	}
-	_ = json.Unmarshal(eduJSON, &p.Education)
-	_ = json.Unmarshal(certJSON, &p.Certificates)
+	if rate.AmountMinor > 0 {
+		p.HourlyRate = &rate
+	}
+	var err error
+	if p.Education, err = loadEducation(ctx, r.db, tutorID, ""); err != nil {
+		return nil, err
+	}
+	if p.Certificates, err = loadCertificates(ctx, r.db, tutorID, ""); err != nil {
+		return nil, err
+	}
 	prices := map[string]map[string]int64{}
 	_ = json.Unmarshal(pricesJSON, &prices)
 	p.Prices, p.TrialPrices = map[string]int64{}, map[string]int64{}
@@ -367,8 +589,122 @@ WHERE ts.tutor_id = $1`, tutorID)
 	return &p, nil
 }
 
+func (r *tutorRepository) FindDraft(ctx context.Context, userID string) (*domain.TutorDraft, error) {
+	d := &domain.TutorDraft{Availability: []domain.AvailabilityDay{}}
+
+	p, err := r.FindTutorDetails(ctx, userID)
+	switch {
+	case err == nil:
+		d.Profile = *p
+	case errors.Is(err, pgx.ErrNoRows):
+		// анкеты ещё нет — отдаём то, что знаем о пользователе
+		if err := r.db.QueryRow(ctx, `
+SELECT id, COALESCE(first_name,''), COALESCE(last_name,''), COALESCE(phone_e164,''), created_at, updated_at
+FROM public.users WHERE id = $1 AND deleted_at IS NULL`, userID).Scan(
+			&d.Profile.UserID, &d.Profile.FirstName, &d.Profile.LastName, &d.Profile.PhoneE164,
+			&d.Profile.CreatedAt, &d.Profile.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		d.Profile.Verification = "pending"
+		return d, nil
+	default:
+		return nil, err
+	}
+
+	var completedAt *string
+	if err := r.db.QueryRow(ctx, `
+SELECT props->>'onboarding_completed_at' FROM public.tutor_profiles WHERE user_id = $1`, userID).Scan(&completedAt); err != nil {
+		return nil, err
+	}
+	if completedAt != nil {
+		if t, err := time.Parse(time.RFC3339, *completedAt); err == nil {
+			d.CompletedAt = &t
+		}
+	}
+
+	rows, err := r.db.Query(ctx, `
+SELECT COALESCE(recurrence,'') FROM public.availability_slots
+WHERE tutor_id = $1 AND is_recurring`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byDay := map[string][]string{}
+	for rows.Next() {
+		var rrule string
+		if err := rows.Scan(&rrule); err != nil {
+			return nil, err
+		}
+		day, slot, ok := parseWeeklyRRule(rrule)
+		if !ok {
+			continue
+		}
+		byDay[day] = append(byDay[day], slot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, code := range weekICal {
+		slots, ok := byDay[code]
+		if !ok {
+			continue
+		}
+		sort.Strings(slots)
+		d.Availability = append(d.Availability, domain.AvailabilityDay{Day: mapDayICalToRu(code), Slots: slots})
+	}
+	return d, nil
+}
+
 // ------------------ helpers ------------------
 
+var weekICal = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+func mapDayICalToRu(code string) string {
+	switch code {
+	case "MO":
+		return "Понедельник"
+	case "TU":
+		return "Вторник"
+	case "WE":
+		return "Среда"
+	case "TH":
+		return "Четверг"
+	case "FR":
+		return "Пятница"
+	case "SA":
+		return "Суббота"
+	case "SU":
+		return "Воскресенье"
+	default:
+		return ""
+	}
+}
+
+// parseWeeklyRRule — обратное преобразование "FREQ=WEEKLY;BYDAY=MO;BYHOUR=18;BYMINUTE=0" → ("MO", "18:00")
+func parseWeeklyRRule(rrule string) (string, string, bool) {
+	var day string
+	hh, mm := -1, 0
+	for _, part := range strings.Split(rrule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.ToUpper(kv[0]) {
+		case "BYDAY":
+			day = strings.ToUpper(kv[1])
+		case "BYHOUR":
+			fmt.Sscanf(kv[1], "%d", &hh)
+		case "BYMINUTE":
+			fmt.Sscanf(kv[1], "%d", &mm)
+		}
+	}
+	if day == "" || hh < 0 {
+		return "", "", false
+	}
+	return day, fmt.Sprintf("%02d:%02d", hh, mm), true
+}
+
 func mapDayRuToICal(day string) string {
 	switch strings.ToLower(strings.TrimSpace(day)) {
 	case "понедельник":
@@ -401,11 +737,48 @@ func nullIfEmpty(s string) *string {
 	v := strings.TrimSpace(s)
 	return &v
 }
-func ensureNestedMap(m map[string]interface{}, key string) map[string]interface{} {
-	if _, ok := m[key]; !ok {
-		m[key] = map[string]interface{}{}
+
+// upsertHourPrice — разовое 60-минутное занятие (цена из шага мастера "subjects").
+func upsertHourPrice(ctx context.Context, tx pgx.Tx, tutorID string, subjectID, subdirID *string, trial bool, price int64, currency string) error {
+	var err error
+	if subjectID != nil {
+		_, err = tx.Exec(ctx, `
+INSERT INTO public.tutor_prices (tutor_id, subject_id, duration_minutes, lessons_count, is_trial, price_minor, currency)
+VALUES ($1, $2, 60, 1, $3, $4, $5)
+ON CONFLICT (tutor_id, subject_id, duration_minutes, lessons_count, is_trial) WHERE subject_id IS NOT NULL
+DO UPDATE SET price_minor = EXCLUDED.price_minor, currency = EXCLUDED.currency`, tutorID, *subjectID, trial, price, currency)
+	} else {
+		_, err = tx.Exec(ctx, `
+INSERT INTO public.tutor_prices (tutor_id, subdirection_id, duration_minutes, lessons_count, is_trial, price_minor, currency)
+VALUES ($1, $2, 60, 1, $3, $4, $5)
+ON CONFLICT (tutor_id, subdirection_id, duration_minutes, lessons_count, is_trial) WHERE subdirection_id IS NOT NULL
+DO UPDATE SET price_minor = EXCLUDED.price_minor, currency = EXCLUDED.currency`, tutorID, *subdirID, trial, price, currency)
+	}
+	if err != nil {
+		return fmt.Errorf("upsert tutor_prices: %w", err)
+	}
+	return nil
+}
+
+// resolveSlug — slug из карты цен: сначала поднаправление, затем предмет.
+func resolveSlug(ctx context.Context, tx pgx.Tx, slug string) (subjectID, subdirID *string, ok bool, err error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	var id string
+	err = tx.QueryRow(ctx, `SELECT id FROM public.subdirections WHERE slug = $1`, slug).Scan(&id)
+	if err == nil {
+		return nil, &id, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, false, err
+	}
+	err = tx.QueryRow(ctx, `SELECT id FROM public.subjects WHERE slug = $1`, slug).Scan(&id)
+	if err == nil {
+		return &id, nil, true, nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, false, nil
 	}
-	return m[key].(map[string]interface{})
+	return nil, nil, false, err
 }
 
 func normalizeProficiency(p string) string {
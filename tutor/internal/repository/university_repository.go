@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"shared/audit"
+	"tutor/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrUniversityNotFound = errors.New("university not found")
+
+type UniversityRepository interface {
+	// Suggest — автодополнение: сначала совпадения по началу названия, затем по похожести.
+	Suggest(ctx context.Context, q, country string, limit int) ([]domain.University, error)
+	// MatchCandidates — записи об образовании без ссылки на вуз и лучший кандидат для каждой.
+	MatchCandidates(ctx context.Context, minScore float64, limit int) ([]domain.UniversityMatch, error)
+	// Link проставляет university_id найденным записям; пропускает те, что изменились с момента поиска.
+	Link(ctx context.Context, actorID string, matches []domain.UniversityMatch) (int, error)
+}
+
+type universityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUniversityRepository(db *pgxpool.Pool) UniversityRepository {
+	return &universityRepository{db: db}
+}
+
+// universityFilter — условие каталога по вузу (id или slug) и стране обучения; пустые параметры не фильтруют.
+func universityFilter(universityArg, countryArg string) string {
+	return fmt.Sprintf(`
+  AND (%[1]s = '' OR EXISTS (SELECT 1 FROM public.tutor_education te
+        JOIN public.universities un ON un.id = te.university_id
+        WHERE te.tutor_id = tp.user_id AND (un.id::text = %[1]s OR un.slug = %[1]s)))
+  AND (%[2]s = '' OR EXISTS (SELECT 1 FROM public.tutor_education te
+        JOIN public.universities un ON un.id = te.university_id
+        WHERE te.tutor_id = tp.user_id AND lower(un.country_code) = lower(%[2]s)))`,
+		universityArg, countryArg)
+}
+
+// linkUniversity проверяет ссылку на справочник и подставляет название вуза,
+// если репетитор выбрал его из списка, не заполнив institution.
+func linkUniversity(ctx context.Context, tx pgx.Tx, e *domain.Education) error {
+	e.University = nil
+	e.UniversityID = strings.TrimSpace(e.UniversityID)
+	if e.UniversityID == "" {
+		return nil
+	}
+	var name string
+	err := tx.QueryRow(ctx, `
+SELECT COALESCE(NULLIF(name->>'ru',''), NULLIF(name->>'en',''), NULLIF(name->>'kk',''), slug)
+FROM public.universities WHERE id::text = $1`, e.UniversityID).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: universityId", ErrUniversityNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(e.Institution) == "" {
+		e.Institution = name
+	}
+	return nil
+}
+
+// withTrgmThreshold — порог операторов pg_trgm только для этой транзакции.
+func withTrgmThreshold(ctx context.Context, tx pgx.Tx, threshold float64) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`,
+		strconv.FormatFloat(threshold, 'f', 2, 64))
+	return err
+}
+
+func (r *universityRepository) Suggest(ctx context.Context, q, country string, limit int) ([]domain.University, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	// опечатки в 3-4 буквах запроса дают низкую похожесть — порог ниже стандартного 0.6
+	if err := withTrgmThreshold(ctx, tx, 0.3); err != nil {
+		return nil, err
+	}
+	q = strings.ToLower(strings.TrimSpace(q))
+	rows, err := tx.Query(ctx, `
+SELECT id::text, slug, name, COALESCE(country_code,''), COALESCE(city,''), word_similarity($1, search_text)
+FROM public.universities
+WHERE ($1 <% search_text OR search_text LIKE '%' || $1 || '%')
+  AND ($2 = '' OR lower(country_code) = lower($2))
+ORDER BY (search_text LIKE $1 || '%' OR search_text LIKE '% ' || $1 || '%') DESC,
+         word_similarity($1, search_text) DESC, slug
+LIMIT $3`, q, strings.TrimSpace(country), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []domain.University{}
+	for rows.Next() {
+		var u domain.University
+		var name []byte
+		if err := rows.Scan(&u.ID, &u.Slug, &name, &u.CountryCode, &u.City, &u.Score); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(name, &u.Name)
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+func (r *universityRepository) MatchCandidates(ctx context.Context, minScore float64, limit int) ([]domain.UniversityMatch, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := withTrgmThreshold(ctx, tx, minScore); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, `
+SELECT e.id::text, e.tutor_id::text, e.institution,
+       m.id::text, m.slug, m.name, COALESCE(m.country_code,''), COALESCE(m.city,''), m.score
+FROM public.tutor_education e
+JOIN public.tutor_profiles tp ON tp.user_id = e.tutor_id AND tp.deleted_at IS NULL
+CROSS JOIN LATERAL (
+  SELECT un.id, un.slug, un.name, un.country_code, un.city,
+         word_similarity(lower(e.institution), un.search_text) AS score
+  FROM public.universities un
+  WHERE lower(e.institution) <% un.search_text
+  ORDER BY score DESC, un.slug
+  LIMIT 1
+) m
+WHERE e.university_id IS NULL AND e.institution <> ''
+ORDER BY m.score DESC, e.tutor_id, e.position
+LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []domain.UniversityMatch{}
+	for rows.Next() {
+		var m domain.UniversityMatch
+		var name []byte
+		if err := rows.Scan(&m.EducationID, &m.TutorID, &m.Institution,
+			&m.University.ID, &m.University.Slug, &name, &m.University.CountryCode, &m.University.City,
+			&m.University.Score); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(name, &m.University.Name)
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+func (r *universityRepository) Link(ctx context.Context, actorID string, matches []domain.UniversityMatch) (int, error) {
+	byTutor := map[string][]domain.UniversityMatch{}
+	var order []string
+	for _, m := range matches {
+		if _, ok := byTutor[m.TutorID]; !ok {
+			order = append(order, m.TutorID)
+		}
+		byTutor[m.TutorID] = append(byTutor[m.TutorID], m)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	linked := 0
+	for _, tutorID := range order {
+		before, err := audit.Snapshot(ctx, tx, auditTutorEducation, tutorID)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range byTutor[tutorID] {
+			// запись могли отредактировать после поиска — проверяем, что это всё ещё тот же текст без ссылки
+			tag, err := tx.Exec(ctx, `
+UPDATE public.tutor_education
+SET university_id = $3::uuid, updated_at = now()
+WHERE id::text = $1 AND university_id IS NULL AND institution = $2`,
+				m.EducationID, m.Institution, m.University.ID)
+			if err != nil {
+				return 0, err
+			}
+			linked += int(tag.RowsAffected())
+		}
+		after, err := audit.Snapshot(ctx, tx, auditTutorEducation, tutorID)
+		if err != nil {
+			return 0, err
+		}
+		if err := audit.Change(ctx, tx, actorID, "tutor", tutorID, "tutor.profile.education.link", before, after); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return linked, nil
+}
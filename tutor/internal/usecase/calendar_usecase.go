@@ -0,0 +1,415 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"tutor/internal/calendar"
+	"tutor/internal/domain"
+	"tutor/internal/notify"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrCalendarState = errors.New("invalid or expired oauth state")
+	ErrBusyRange     = errors.New("invalid busy range")
+)
+
+// maxBusyRange — сколько занятости можно запросить за раз.
+const maxBusyRange = 62 * 24 * time.Hour
+
+type CalendarOptions struct {
+	StateSecret  string        // подпись state в OAuth-редиректе (JWT-секрет сервиса)
+	StateTTL     time.Duration // сколько живёт ссылка на подключение
+	CalendarID   string        // календарь, куда пишем события; primary — основной
+	MaxAttempts  int           // после стольких неудач событие получает status=error
+	Backoff      time.Duration // пауза перед 2-й попыткой, дальше удваивается (до часа)
+	Lease        time.Duration
+	BatchSize    int
+	BusyInterval time.Duration // как часто перечитывать занятость подключения
+	BusyWindow   time.Duration // на сколько вперёд читать занятость
+}
+
+type CalendarUseCase interface {
+	// ConnectURL — адрес согласия у провайдера; после него провайдер вернёт пользователя в Callback.
+	ConnectURL(ctx context.Context, userID, provider string) (string, error)
+	Callback(ctx context.Context, provider, state, code string) (*domain.CalendarAccount, error)
+	Accounts(ctx context.Context, userID string) ([]domain.CalendarAccount, error)
+	// Disconnect удаляет подключение и по возможности убирает выгруженные уроки из календаря.
+	Disconnect(ctx context.Context, userID, provider string) error
+	Busy(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error)
+	// TutorBusy — занятость репетитора для выбора времени брони, без уроков, где он учится сам.
+	TutorBusy(ctx context.Context, tutorID string, from, to time.Time) ([]domain.BusyInterval, error)
+
+	// Sync — один проход воркера: создаёт/обновляет/удаляет события по calendar_events.status.
+	Sync(ctx context.Context) (synced, failed int, err error)
+	// PullBusy — один проход: перечитывает занятость подключений, у которых она устарела.
+	PullBusy(ctx context.Context) (int, error)
+}
+
+type calendarUseCase struct {
+	repo      repository.CalendarRepository
+	providers calendar.Registry
+	opts      CalendarOptions
+}
+
+func NewCalendarUseCase(repo repository.CalendarRepository, providers calendar.Registry, opts CalendarOptions) CalendarUseCase {
+	if opts.StateTTL <= 0 {
+		opts.StateTTL = 15 * time.Minute
+	}
+	if opts.CalendarID == "" {
+		opts.CalendarID = "primary"
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 30 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 2 * time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.BusyInterval <= 0 {
+		opts.BusyInterval = 10 * time.Minute
+	}
+	if opts.BusyWindow <= 0 {
+		opts.BusyWindow = 30 * 24 * time.Hour
+	}
+	return &calendarUseCase{repo: repo, providers: providers, opts: opts}
+}
+
+// ---------- OAuth ----------
+
+func (uc *calendarUseCase) ConnectURL(_ context.Context, userID, provider string) (string, error) {
+	p, err := uc.providers.Get(provider)
+	if err != nil {
+		return "", err
+	}
+	return p.AuthCodeURL(uc.signState(userID, provider, time.Now().Add(uc.opts.StateTTL))), nil
+}
+
+// state = base64(userID|provider|exp).base64(hmac) — callback приходит без JWT,
+// пользователя узнаём только из подписанного state.
+func (uc *calendarUseCase) signState(userID, provider string, exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID + "|" + provider + "|" + strconv.FormatInt(exp.Unix(), 10)))
+	return payload + "." + uc.stateMAC(payload)
+}
+
+func (uc *calendarUseCase) stateMAC(payload string) string {
+	m := hmac.New(sha256.New, []byte(uc.opts.StateSecret))
+	m.Write([]byte("calendar-state:" + payload))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func (uc *calendarUseCase) parseState(state, provider string) (string, error) {
+	payload, mac, ok := strings.Cut(state, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(uc.stateMAC(payload))) {
+		return "", ErrCalendarState
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrCalendarState
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[1] != provider {
+		return "", ErrCalendarState
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return "", ErrCalendarState
+	}
+	return parts[0], nil
+}
+
+func (uc *calendarUseCase) Callback(ctx context.Context, provider, state, code string) (*domain.CalendarAccount, error) {
+	p, err := uc.providers.Get(provider)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := uc.parseState(state, provider)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, ErrCalendarState
+	}
+	tok, err := p.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("calendar exchange: %w", err)
+	}
+	email, err := p.Email(ctx, tok.AccessToken)
+	if err != nil {
+		// адрес только для отображения — подключение без него рабочее
+		log.Printf("[CALENDAR] %s email for %s: %v", provider, userID, err)
+	}
+	return uc.repo.SaveAccount(ctx, userID, provider, email, uc.opts.CalendarID, toDomainToken(tok))
+}
+
+func (uc *calendarUseCase) Accounts(ctx context.Context, userID string) ([]domain.CalendarAccount, error) {
+	return uc.repo.ListAccounts(ctx, userID)
+}
+
+func (uc *calendarUseCase) Disconnect(ctx context.Context, userID, provider string) error {
+	acc, err := uc.repo.GetAccount(ctx, userID, provider)
+	if err != nil {
+		return err
+	}
+	tok, tokErr := uc.repo.Token(ctx, userID, provider)
+	eventIDs, err := uc.repo.DeleteAccount(ctx, userID, provider)
+	if err != nil {
+		return err
+	}
+	p, err := uc.providers.Get(provider)
+	if err != nil || tokErr != nil || acc.Status != domain.CalendarActive || len(eventIDs) == 0 {
+		return nil
+	}
+	// уборка best effort: подключение уже удалено, ошибки провайдера только логируем
+	job := domain.CalendarJob{UserID: userID, Provider: provider, Token: *tok}
+	access, err := uc.accessToken(ctx, p, &job)
+	if err != nil {
+		log.Printf("[CALENDAR] disconnect %s: token: %v", userID, err)
+		return nil
+	}
+	for _, id := range eventIDs {
+		if err := p.Delete(ctx, access, acc.CalendarID, id); err != nil {
+			log.Printf("[CALENDAR] disconnect %s: delete event %s: %v", userID, id, err)
+		}
+	}
+	return nil
+}
+
+func (uc *calendarUseCase) Busy(ctx context.Context, userID string, from, to time.Time) ([]domain.BusyInterval, error) {
+	if !to.After(from) || to.Sub(from) > maxBusyRange {
+		return nil, ErrBusyRange
+	}
+	return uc.repo.Busy(ctx, userID, from, to)
+}
+
+func (uc *calendarUseCase) TutorBusy(ctx context.Context, tutorID string, from, to time.Time) ([]domain.BusyInterval, error) {
+	if !to.After(from) || to.Sub(from) > maxBusyRange {
+		return nil, ErrBusyRange
+	}
+	return uc.repo.TutorBusy(ctx, tutorID, from, to)
+}
+
+// ---------- workers ----------
+
+func (uc *calendarUseCase) Sync(ctx context.Context) (int, int, error) {
+	synced, failed := 0, 0
+	for {
+		jobs, err := uc.repo.Claim(ctx, uc.opts.BatchSize, uc.opts.Lease)
+		if err != nil {
+			return synced, failed, err
+		}
+		for _, j := range jobs {
+			if uc.syncEvent(ctx, j) {
+				synced++
+			} else {
+				failed++
+			}
+		}
+		if len(jobs) < uc.opts.BatchSize || ctx.Err() != nil {
+			return synced, failed, nil
+		}
+	}
+}
+
+func (uc *calendarUseCase) syncEvent(ctx context.Context, j domain.CalendarJob) bool {
+	err := uc.push(ctx, j)
+	if err == nil {
+		return true
+	}
+	switch {
+	case errors.Is(err, calendar.ErrUnauthorized):
+		// доступ отозван: события ждут переподключения (SaveAccount вернёт их в работу)
+		log.Printf("[CALENDAR] account %s revoked: %v", j.AccountID, err)
+		if err := uc.repo.MarkRevoked(ctx, j.AccountID, err.Error()); err != nil {
+			log.Printf("[CALENDAR] mark revoked %s: %v", j.AccountID, err)
+		}
+		if err := uc.repo.Retry(ctx, j.ID, err.Error(), time.Now()); err != nil {
+			log.Printf("[CALENDAR] retry %s: %v", j.ID, err)
+		}
+	case errors.Is(err, calendar.ErrPermanent) || j.Attempts >= uc.opts.MaxAttempts:
+		log.Printf("[CALENDAR] %s %s lesson %s failed after %d attempts: %v", j.Status, j.ID, j.LessonID, j.Attempts, err)
+		if err := uc.repo.MarkError(ctx, j.ID, err.Error()); err != nil {
+			log.Printf("[CALENDAR] mark error %s: %v", j.ID, err)
+		}
+	default:
+		d := uc.opts.Backoff
+		for i := 1; i < j.Attempts && d < time.Hour; i++ {
+			d *= 2
+		}
+		if d > time.Hour {
+			d = time.Hour
+		}
+		if err := uc.repo.Retry(ctx, j.ID, err.Error(), time.Now().Add(d)); err != nil {
+			log.Printf("[CALENDAR] retry %s: %v", j.ID, err)
+		}
+	}
+	return false
+}
+
+func (uc *calendarUseCase) push(ctx context.Context, j domain.CalendarJob) error {
+	p, err := uc.providers.Get(j.Provider)
+	if err != nil {
+		return calendar.Permanent(err)
+	}
+	if j.Status == domain.CalendarEventToDelete && j.EventID == "" {
+		return uc.repo.MarkDeleted(ctx, j.ID, j.Revision)
+	}
+	access, err := uc.accessToken(ctx, p, &j)
+	if err != nil {
+		return err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	switch j.Status {
+	case domain.CalendarEventToDelete:
+		if err := p.Delete(callCtx, access, j.CalendarID, j.EventID); err != nil {
+			return err
+		}
+		return uc.repo.MarkDeleted(ctx, j.ID, j.Revision)
+	case domain.CalendarEventToUpdate:
+		ref, err := p.Update(callCtx, access, j.CalendarID, j.EventID, uc.event(j))
+		if errors.Is(err, calendar.ErrNotFound) {
+			// пользователь удалил событие у себя — создаём заново
+			ref, err = p.Insert(callCtx, access, j.CalendarID, uc.event(j))
+		}
+		if err != nil {
+			return err
+		}
+		return uc.markSynced(ctx, p, access, j, ref)
+	default:
+		ref, err := p.Insert(callCtx, access, j.CalendarID, uc.event(j))
+		if err != nil {
+			return err
+		}
+		return uc.markSynced(ctx, p, access, j, ref)
+	}
+}
+
+func (uc *calendarUseCase) markSynced(ctx context.Context, p calendar.Provider, access string, j domain.CalendarJob, ref *calendar.Ref) error {
+	err := uc.repo.MarkSynced(ctx, j.ID, ref.ID, ref.HTMLLink, j.Revision)
+	if !errors.Is(err, repository.ErrCalendarEventGone) {
+		return err
+	}
+	// урок отменили, пока событие создавалось: строки нет — убираем созданное
+	if err := p.Delete(ctx, access, j.CalendarID, ref.ID); err != nil {
+		log.Printf("[CALENDAR] drop orphan event %s: %v", ref.ID, err)
+	}
+	return nil
+}
+
+// accessToken обновляет истёкший токен и сохраняет новый.
+func (uc *calendarUseCase) accessToken(ctx context.Context, p calendar.Provider, j *domain.CalendarJob) (string, error) {
+	tok := calendar.Token{AccessToken: j.Token.AccessToken, RefreshToken: j.Token.RefreshToken}
+	if j.Token.ExpiresAt != nil {
+		tok.Expiry = *j.Token.ExpiresAt
+	}
+	if tok.AccessToken != "" && !tok.Expired() {
+		return tok.AccessToken, nil
+	}
+	fresh, err := p.Refresh(ctx, tok.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+	j.Token = toDomainToken(fresh)
+	if err := uc.repo.SaveToken(ctx, j.UserID, j.Provider, j.Token); err != nil {
+		log.Printf("[CALENDAR] save token %s: %v", j.UserID, err)
+	}
+	return fresh.AccessToken, nil
+}
+
+func toDomainToken(t *calendar.Token) domain.CalendarToken {
+	out := domain.CalendarToken{AccessToken: t.AccessToken, RefreshToken: t.RefreshToken, Scope: t.Scope}
+	if !t.Expiry.IsZero() {
+		exp := t.Expiry
+		out.ExpiresAt = &exp
+	}
+	return out
+}
+
+// event — урок глазами владельца календаря: на языке пользователя и с именем второго участника.
+func (uc *calendarUseCase) event(j domain.CalendarJob) calendar.Event {
+	return calendar.Event{
+		LessonID:    j.LessonID,
+		Summary:     lessonTitle(j.Locale, j.Role, j.PeerName, j.SubjectName),
+		Description: "Alem lesson " + j.LessonID,
+		Start:       j.StartsAt,
+		End:         j.StartsAt.Add(j.Duration),
+	}
+}
+
+// lessonTitle — название урока в календаре участника (Google и ICS-подписка).
+func lessonTitle(locale, role, peer, subject string) string {
+	locale = notify.NormalizeLocale(locale)
+	if peer == "" {
+		peer = peerFallback(locale, role)
+	}
+	var title string
+	switch locale {
+	case "kk":
+		title = "Alem сабағы — " + peer
+	case "en":
+		title = "Alem lesson with " + peer
+	default:
+		title = "Урок Alem с " + peer
+	}
+	if subject != "" {
+		title += ": " + subject
+	}
+	return title
+}
+
+func (uc *calendarUseCase) PullBusy(ctx context.Context) (int, error) {
+	accounts, err := uc.repo.ClaimBusy(ctx, uc.opts.BusyInterval, uc.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	from := time.Now()
+	to := from.Add(uc.opts.BusyWindow)
+	for _, a := range accounts {
+		if err := uc.pullAccount(ctx, a, from, to); err != nil {
+			if errors.Is(err, calendar.ErrUnauthorized) {
+				if err := uc.repo.MarkRevoked(ctx, a.AccountID, err.Error()); err != nil {
+					log.Printf("[CALENDAR] mark revoked %s: %v", a.AccountID, err)
+				}
+			}
+			log.Printf("[CALENDAR] busy %s: %v", a.AccountID, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (uc *calendarUseCase) pullAccount(ctx context.Context, a domain.CalendarJob, from, to time.Time) error {
+	p, err := uc.providers.Get(a.Provider)
+	if err != nil {
+		return err
+	}
+	access, err := uc.accessToken(ctx, p, &a)
+	if err != nil {
+		return err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	busy, err := p.Busy(callCtx, access, a.CalendarID, from, to)
+	if err != nil {
+		return err
+	}
+	return uc.repo.ReplaceBusy(ctx, a.AccountID, a.UserID, busy)
+}
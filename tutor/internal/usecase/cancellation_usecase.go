@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrCancellationClosed = errors.New("lesson has already started and cannot be cancelled")
+	ErrInvalidInitiator   = errors.New("initiator must be student or tutor")
+	ErrInvalidPolicy      = errors.New("invalid cancellation policy")
+)
+
+type CancellationUseCase interface {
+	Policies(ctx context.Context) ([]domain.CancellationPolicy, error)
+	CreatePolicy(ctx context.Context, p domain.CancellationPolicy) (*domain.CancellationPolicy, error)
+	UpdatePolicy(ctx context.Context, p domain.CancellationPolicy) (*domain.CancellationPolicy, error)
+	SetTutorPolicy(ctx context.Context, tutorID, role, policyID string) error
+
+	// Quote — что получит студент, если отменить бронь сейчас (без изменений).
+	Quote(ctx context.Context, userID, role, bookingID, initiator string) (*domain.CancellationOutcome, error)
+	// Cancel отменяет бронь по политике и автоматически оформляет возврат/снятие авторизации.
+	// initiator учитывается только для админа; студент и репетитор — всегда сами инициаторы.
+	Cancel(ctx context.Context, userID, role, bookingID, reason, initiator string) (*domain.Cancellation, error)
+	Get(ctx context.Context, userID, role, bookingID string) (*domain.Cancellation, error)
+	// RetrySettlements повторяет возвраты по отменам, где они не прошли (outcome.error).
+	RetrySettlements(ctx context.Context) (settled, failed int, err error)
+}
+
+type CancellationOptions struct {
+	RetryBatch       int           // сколько неурегулированных отмен разбираем за проход
+	RetryLease       time.Duration // пауза между попытками; на это время строка закреплена за репликой
+	RetryMaxAttempts int           // после стольких попыток отмена остаётся для ручного разбора
+}
+
+type cancellationUseCase struct {
+	repo      repository.CancellationRepository
+	payments  repository.PaymentRepository
+	paymentUC PaymentUseCase
+	opts      CancellationOptions
+}
+
+func NewCancellationUseCase(repo repository.CancellationRepository, payments repository.PaymentRepository, paymentUC PaymentUseCase, opts CancellationOptions) CancellationUseCase {
+	if opts.RetryBatch <= 0 {
+		opts.RetryBatch = 50
+	}
+	if opts.RetryLease <= 0 {
+		opts.RetryLease = 5 * time.Minute
+	}
+	if opts.RetryMaxAttempts <= 0 {
+		opts.RetryMaxAttempts = 10
+	}
+	return &cancellationUseCase{repo: repo, payments: payments, paymentUC: paymentUC, opts: opts}
+}
+
+func (uc *cancellationUseCase) Policies(ctx context.Context) ([]domain.CancellationPolicy, error) {
+	return uc.repo.ListPolicies(ctx)
+}
+
+func (uc *cancellationUseCase) CreatePolicy(ctx context.Context, p domain.CancellationPolicy) (*domain.CancellationPolicy, error) {
+	if err := validatePolicy(&p); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.CreatePolicy(ctx, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (uc *cancellationUseCase) UpdatePolicy(ctx context.Context, p domain.CancellationPolicy) (*domain.CancellationPolicy, error) {
+	if err := validatePolicy(&p); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.UpdatePolicy(ctx, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func validatePolicy(p *domain.CancellationPolicy) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPolicy)
+	}
+	return p.Rules.Validate()
+}
+
+func (uc *cancellationUseCase) SetTutorPolicy(ctx context.Context, tutorID, role, policyID string) error {
+	if role != "tutor" {
+		return ErrForbidden
+	}
+	return uc.repo.SetTutorPolicy(ctx, tutorID, strings.TrimSpace(policyID))
+}
+
+func (uc *cancellationUseCase) Quote(ctx context.Context, userID, role, bookingID, initiator string) (*domain.CancellationOutcome, error) {
+	b, initiator, err := uc.authorize(ctx, userID, role, bookingID, initiator)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := uc.evaluate(ctx, b, initiator, time.Now().UTC())
+	return out, err
+}
+
+func (uc *cancellationUseCase) Get(ctx context.Context, userID, role, bookingID string) (*domain.Cancellation, error) {
+	if _, _, err := uc.authorize(ctx, userID, role, bookingID, ""); err != nil {
+		return nil, err
+	}
+	return uc.repo.FindByBooking(ctx, bookingID)
+}
+
+func (uc *cancellationUseCase) Cancel(ctx context.Context, userID, role, bookingID, reason, initiator string) (*domain.Cancellation, error) {
+	b, initiator, err := uc.authorize(ctx, userID, role, bookingID, initiator)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	outcome, p, err := uc.evaluate(ctx, b, initiator, now)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &domain.Cancellation{
+		BookingID:   b.ID,
+		RequestedBy: userID,
+		Initiator:   initiator,
+		Reason:      strings.TrimSpace(reason),
+		PolicyID:    outcome.PolicyID,
+		Outcome:     *outcome,
+	}
+	if err := uc.repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+
+	// бронь уже отменена; деньги двигаем после коммита — провайдера нельзя держать в транзакции
+	var fee *domain.LedgerTxn
+	if p != nil {
+		fee, err = uc.settle(ctx, c, b.TutorID, p)
+		if err != nil {
+			log.Printf("[CANCEL] booking %s payment %s: %v", b.ID, p.ID, err)
+			c.Outcome.Error = err.Error()
+		}
+	}
+	if err := uc.repo.Finish(ctx, c.ID, c.Outcome, fee); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (uc *cancellationUseCase) RetrySettlements(ctx context.Context) (int, int, error) {
+	pending, err := uc.repo.ClaimUnsettled(ctx, uc.opts.RetryBatch, uc.opts.RetryMaxAttempts, uc.opts.RetryLease)
+	if err != nil {
+		return 0, 0, err
+	}
+	settled, failed := 0, 0
+	for _, c := range pending {
+		if err := uc.retrySettlement(ctx, &c); err != nil {
+			failed++
+			if c.SettleAttempts >= uc.opts.RetryMaxAttempts {
+				log.Printf("[CANCEL] retry %s: giving up after %d attempts: %v", c.ID, c.SettleAttempts, err)
+			} else {
+				log.Printf("[CANCEL] retry %s (attempt %d): %v", c.ID, c.SettleAttempts, err)
+			}
+			continue
+		}
+		settled++
+	}
+	return settled, failed, nil
+}
+
+// retrySettlement повторяет settle по одной отмене и сохраняет итог; ошибка остаётся в outcome.
+func (uc *cancellationUseCase) retrySettlement(ctx context.Context, c *domain.Cancellation) error {
+	b, err := uc.payments.FindBooking(ctx, c.BookingID)
+	if err != nil {
+		return err
+	}
+	p, err := uc.payments.FindPayment(ctx, c.Outcome.PaymentID)
+	if err != nil {
+		return err
+	}
+	c.Outcome.Error = ""
+	fee, settleErr := uc.settle(ctx, c, b.TutorID, p)
+	if settleErr != nil {
+		c.Outcome.Error = settleErr.Error()
+	}
+	if err := uc.repo.Finish(ctx, c.ID, c.Outcome, fee); err != nil {
+		return err
+	}
+	return settleErr
+}
+
+// settle возвращает студенту его долю: авторизацию при полном возврате снимаем,
+// иначе списываем и возвращаем часть; удержанное уходит репетитору проводкой fee.
+// Повторный вызов после сбоя продолжает с текущего статуса платежа и не возвращает дважды.
+func (uc *cancellationUseCase) settle(ctx context.Context, c *domain.Cancellation, tutorID string, p *domain.Payment) (*domain.LedgerTxn, error) {
+	o := &c.Outcome
+	switch p.Status {
+	case domain.PaymentVoided:
+		o.PaymentAction = domain.CancelPaymentVoid
+		return nil, nil
+	case domain.PaymentFailed:
+		return nil, nil
+	case domain.PaymentRequiresAction:
+		o.PaymentAction = domain.CancelPaymentVoid
+		if p.ProviderIntentID == "" {
+			_, err := uc.payments.ApplyStatus(ctx, p.ID, domain.PaymentVoided, "", nil)
+			return nil, err
+		}
+		_, err := uc.paymentUC.Void(ctx, p.ID)
+		return nil, err
+	case domain.PaymentAuthorized:
+		if o.RetainedMinor == 0 {
+			o.PaymentAction = domain.CancelPaymentVoid
+			_, err := uc.paymentUC.Void(ctx, p.ID)
+			return nil, err
+		}
+		captured, err := uc.paymentUC.Capture(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		p = captured
+	}
+
+	if o.RefundMinor > 0 {
+		o.PaymentAction = domain.CancelPaymentRefund
+		// возвраты до отмены уже вычтены из PaidMinor; остальное оформлено прошлой попыткой
+		refunded, err := uc.payments.RefundedMinor(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if due := o.RefundMinor - (refunded - (p.AmountMinor - o.PaidMinor)); due > 0 {
+			rf, err := uc.paymentUC.Refund(ctx, p.ID, due)
+			if err != nil {
+				return nil, err
+			}
+			o.RefundID = rf.ID
+		}
+	}
+	if o.RetainedMinor > 0 {
+		fee := domain.CancellationFeeTxn(c.ID, *p, tutorID, o.RetainedMinor)
+		return &fee, nil
+	}
+	return nil, nil
+}
+
+// authorize: отменять может студент или репетитор брони и админ (от имени любой стороны).
+func (uc *cancellationUseCase) authorize(ctx context.Context, userID, role, bookingID, initiator string) (*domain.Booking, string, error) {
+	b, err := uc.payments.FindBooking(ctx, bookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case role == "admin":
+		if initiator == "" {
+			initiator = domain.CancelByTutor
+		}
+		if initiator != domain.CancelByStudent && initiator != domain.CancelByTutor {
+			return nil, "", ErrInvalidInitiator
+		}
+	case b.StudentID == userID:
+		initiator = domain.CancelByStudent
+	case b.TutorID == userID:
+		initiator = domain.CancelByTutor
+	default:
+		return nil, "", ErrForbidden
+	}
+	return b, initiator, nil
+}
+
+// evaluate применяет политику брони к оплаченной (за вычетом возвратов) сумме.
+func (uc *cancellationUseCase) evaluate(ctx context.Context, b *domain.Booking, initiator string, now time.Time) (*domain.CancellationOutcome, *domain.Payment, error) {
+	if !now.Before(b.StartsAt) {
+		return nil, nil, ErrCancellationClosed
+	}
+	policy, err := uc.repo.PolicyForBooking(ctx, b.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var paid int64
+	p, err := uc.payments.FindActiveForBooking(ctx, b.ID)
+	switch {
+	case errors.Is(err, repository.ErrPaymentNotFound):
+		p = nil
+	case err != nil:
+		return nil, nil, err
+	case p.Status == domain.PaymentAuthorized:
+		paid = p.AmountMinor
+	case p.Status == domain.PaymentCaptured:
+		refunded, err := uc.payments.RefundedMinor(ctx, p.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		paid = p.AmountMinor - refunded
+	}
+
+	out := policy.Evaluate(initiator, b.StartsAt, now, paid)
+	if p != nil {
+		out.PaymentID, out.Currency = p.ID, p.Currency
+	}
+	return &out, p, nil
+}
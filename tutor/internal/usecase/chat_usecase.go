@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tutor/internal/chat"
+	"tutor/internal/domain"
+	"tutor/internal/redact"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrConversationBlocked = errors.New("conversation is blocked")
+	ErrConversationPending = errors.New("conversation is waiting for the tutor's approval")
+	ErrInvalidMessage      = errors.New("message body must be 1..4000 characters")
+)
+
+const (
+	maxMessageRunes = 4000
+	// pendingMessageLimit — сколько сообщений студент может написать до одобрения запроса.
+	pendingMessageLimit = 3
+)
+
+type ChatUseCase interface {
+	// Start — студент пишет репетитору: создаётся запрос (pending), firstMessage опционален.
+	Start(ctx context.Context, userID, role, tutorID, firstMessage string) (*domain.Conversation, *domain.Message, error)
+	List(ctx context.Context, userID, status string, page, limit int) ([]domain.Conversation, *domain.Pagination, error)
+	Get(ctx context.Context, userID, role, conversationID string) (*domain.Conversation, error)
+	// Approve/Block — решение репетитора по диалогу.
+	Approve(ctx context.Context, userID, conversationID string) (*domain.Conversation, error)
+	Block(ctx context.Context, userID, conversationID string) (*domain.Conversation, error)
+
+	Send(ctx context.Context, userID, conversationID, body string) (*domain.Message, error)
+	// History — страница сообщений от новых к старым; next — курсор следующей страницы.
+	History(ctx context.Context, userID, role, conversationID, before string, limit int) (msgs []domain.Message, next string, err error)
+	MarkRead(ctx context.Context, userID, conversationID string) error
+	Typing(ctx context.Context, userID, conversationID string) error
+
+	// Offenders/ReviewOffender — очередь модерации пользователей, пишущих контакты.
+	Offenders(ctx context.Context, onlyFlagged bool, page, limit int) ([]domain.ChatOffender, *domain.Pagination, error)
+	ReviewOffender(ctx context.Context, adminID, userID, note string) (*domain.ChatOffender, error)
+
+	Subscribe(userID string) (<-chan domain.ChatEvent, func())
+	// Run слушает NOTIFY и раздаёт события локальным подписчикам до отмены ctx.
+	Run(ctx context.Context)
+}
+
+// ChatOptions — порог и окно, после которых автор контактов попадает на модерацию.
+type ChatOptions struct {
+	OffenderThreshold int
+	OffenderWindow    time.Duration
+}
+
+type chatUseCase struct {
+	repo repository.ChatRepository
+	hub  *chat.Hub
+	opts ChatOptions
+}
+
+func NewChatUseCase(repo repository.ChatRepository, hub *chat.Hub, opts ChatOptions) ChatUseCase {
+	if opts.OffenderThreshold <= 0 {
+		opts.OffenderThreshold = 3
+	}
+	if opts.OffenderWindow <= 0 {
+		opts.OffenderWindow = 30 * 24 * time.Hour
+	}
+	return &chatUseCase{repo: repo, hub: hub, opts: opts}
+}
+
+// isModerator — кто видит исходный текст сообщений со скрытыми контактами.
+func isModerator(role string) bool { return role == "admin" }
+
+func (uc *chatUseCase) Start(ctx context.Context, userID, role, tutorID, firstMessage string) (*domain.Conversation, *domain.Message, error) {
+	if role != "student" || tutorID == userID {
+		return nil, nil, ErrForbidden
+	}
+	c, created, err := uc.repo.StartConversation(ctx, userID, tutorID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Status == domain.ConversationBlocked {
+		return nil, nil, ErrConversationBlocked
+	}
+	if created {
+		uc.publish(ctx, domain.ChatEvent{
+			Type: domain.ChatEventConversation, ConversationID: c.ID, UserID: userID,
+			Status: c.Status, Recipients: []string{c.StudentID, c.TutorID},
+		})
+	}
+	if strings.TrimSpace(firstMessage) == "" {
+		return c, nil, nil
+	}
+	m, err := uc.send(ctx, c, userID, firstMessage)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, m, nil
+}
+
+func (uc *chatUseCase) List(ctx context.Context, userID, status string, page, limit int) ([]domain.Conversation, *domain.Pagination, error) {
+	list, total, err := uc.repo.ListConversations(ctx, userID, status, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *chatUseCase) Get(ctx context.Context, userID, role, conversationID string) (*domain.Conversation, error) {
+	c, err := uc.repo.FindConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && !c.Participant(userID) {
+		return nil, ErrForbidden
+	}
+	return c, nil
+}
+
+func (uc *chatUseCase) Approve(ctx context.Context, userID, conversationID string) (*domain.Conversation, error) {
+	return uc.decide(ctx, userID, conversationID, domain.ConversationActive)
+}
+
+func (uc *chatUseCase) Block(ctx context.Context, userID, conversationID string) (*domain.Conversation, error) {
+	return uc.decide(ctx, userID, conversationID, domain.ConversationBlocked)
+}
+
+func (uc *chatUseCase) decide(ctx context.Context, userID, conversationID, status string) (*domain.Conversation, error) {
+	c, err := uc.repo.FindConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if c.TutorID != userID {
+		return nil, ErrForbidden
+	}
+	if c.Status == status {
+		return c, nil
+	}
+	c, err = uc.repo.SetStatus(ctx, conversationID, status, userID)
+	if err != nil {
+		return nil, err
+	}
+	uc.publish(ctx, domain.ChatEvent{
+		Type: domain.ChatEventConversation, ConversationID: c.ID, UserID: userID,
+		Status: c.Status, Recipients: []string{c.StudentID, c.TutorID},
+	})
+	return c, nil
+}
+
+func (uc *chatUseCase) Send(ctx context.Context, userID, conversationID, body string) (*domain.Message, error) {
+	c, err := uc.repo.FindConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Participant(userID) {
+		return nil, ErrForbidden
+	}
+	return uc.send(ctx, c, userID, body)
+}
+
+func (uc *chatUseCase) send(ctx context.Context, c *domain.Conversation, userID, body string) (*domain.Message, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxMessageRunes {
+		return nil, ErrInvalidMessage
+	}
+	switch c.Status {
+	case domain.ConversationBlocked:
+		return nil, ErrConversationBlocked
+	case domain.ConversationPending:
+		// до одобрения пишет только инициатор и не больше лимита
+		if userID != c.InitiatorID {
+			return nil, ErrConversationPending
+		}
+		n, err := uc.repo.CountMessages(ctx, c.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if n >= pendingMessageLimit {
+			return nil, ErrConversationPending
+		}
+	}
+
+	m := &domain.Message{ConversationID: c.ID, SenderID: userID, Body: body, Original: body}
+	// до первой подтверждённой брони контакты скрываем, чтобы не уводили сделку с платформы
+	booked, err := uc.repo.HasBooking(ctx, c.StudentID, c.TutorID)
+	if err != nil {
+		return nil, err
+	}
+	if !booked {
+		if res := redact.Text(body); res.Redacted() {
+			m.Body, m.Redacted, m.Redactions = res.Text, true, res.Kinds()
+		}
+	}
+	if err := uc.repo.InsertMessage(ctx, m); err != nil {
+		return nil, err
+	}
+	if m.Redacted {
+		flagged, err := uc.repo.RecordViolation(ctx, userID, uc.opts.OffenderWindow, uc.opts.OffenderThreshold)
+		if err != nil {
+			log.Printf("[CHAT] record violation %s: %v", userID, err)
+		} else if flagged {
+			log.Printf("[CHAT] user %s flagged for sharing contacts", userID)
+		}
+	}
+	uc.publish(ctx, domain.ChatEvent{
+		Type: domain.ChatEventMessage, ConversationID: c.ID, UserID: userID, MessageID: m.ID,
+		At: m.CreatedAt, Recipients: []string{c.StudentID, c.TutorID},
+	})
+	pub := m.Public()
+	return &pub, nil
+}
+
+func (uc *chatUseCase) History(ctx context.Context, userID, role, conversationID, before string, limit int) ([]domain.Message, string, error) {
+	if _, err := uc.Get(ctx, userID, role, conversationID); err != nil {
+		return nil, "", err
+	}
+	msgs, err := uc.repo.History(ctx, conversationID, before, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if !isModerator(role) {
+		for i := range msgs {
+			msgs[i] = msgs[i].Public()
+		}
+	}
+	next := ""
+	if len(msgs) == limit {
+		next = msgs[len(msgs)-1].ID
+	}
+	return msgs, next, nil
+}
+
+func (uc *chatUseCase) MarkRead(ctx context.Context, userID, conversationID string) error {
+	c, err := uc.repo.FindConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if !c.Participant(userID) {
+		return ErrForbidden
+	}
+	now := time.Now().UTC()
+	if err := uc.repo.MarkRead(ctx, c.ID, userID, now); err != nil {
+		return err
+	}
+	uc.publish(ctx, domain.ChatEvent{
+		Type: domain.ChatEventRead, ConversationID: c.ID, UserID: userID,
+		At: now, Recipients: []string{c.Peer(userID)},
+	})
+	return nil
+}
+
+// Typing ничего не пишет в БД — только эфемерное событие собеседнику.
+func (uc *chatUseCase) Typing(ctx context.Context, userID, conversationID string) error {
+	c, err := uc.repo.FindConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if !c.Participant(userID) {
+		return ErrForbidden
+	}
+	if c.Status != domain.ConversationActive {
+		return fmt.Errorf("%w: %s", ErrConversationPending, c.Status)
+	}
+	uc.publish(ctx, domain.ChatEvent{
+		Type: domain.ChatEventTyping, ConversationID: c.ID, UserID: userID,
+		At: time.Now().UTC(), Recipients: []string{c.Peer(userID)},
+	})
+	return nil
+}
+
+func (uc *chatUseCase) Offenders(ctx context.Context, onlyFlagged bool, page, limit int) ([]domain.ChatOffender, *domain.Pagination, error) {
+	list, total, err := uc.repo.ListOffenders(ctx, onlyFlagged, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *chatUseCase) ReviewOffender(ctx context.Context, adminID, userID, note string) (*domain.ChatOffender, error) {
+	return uc.repo.ReviewOffender(ctx, userID, adminID, strings.TrimSpace(note))
+}
+
+func (uc *chatUseCase) Subscribe(userID string) (<-chan domain.ChatEvent, func()) {
+	return uc.hub.Subscribe(userID)
+}
+
+// publish: ошибка доставки realtime не роняет запрос — клиент дочитает историю по REST.
+func (uc *chatUseCase) publish(ctx context.Context, ev domain.ChatEvent) {
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+	if err := uc.repo.Notify(ctx, ev); err != nil {
+		log.Printf("[CHAT] notify %s %s: %v", ev.Type, ev.ConversationID, err)
+	}
+}
+
+func (uc *chatUseCase) Run(ctx context.Context) {
+	for {
+		err := uc.repo.Listen(ctx, func(ev domain.ChatEvent) {
+			if ev.Type == domain.ChatEventMessage && ev.MessageID != "" {
+				m, err := uc.repo.FindMessage(ctx, ev.MessageID)
+				if err != nil {
+					log.Printf("[CHAT] load message %s: %v", ev.MessageID, err)
+					return
+				}
+				pub := m.Public()
+				ev.Message = &pub
+			}
+			uc.hub.Broadcast(ev)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("[CHAT] listener stopped: %v; reconnecting", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
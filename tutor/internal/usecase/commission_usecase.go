@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+type CommissionUseCase interface {
+	ListRuleSets(ctx context.Context) ([]domain.CommissionRuleSet, error)
+	// CreateRuleSet добавляет новую версию; существующие версии не редактируются,
+	// поэтому уже посчитанные уроки сохраняют свой rule_version.
+	CreateRuleSet(ctx context.Context, adminID string, rs domain.CommissionRuleSet) (*domain.CommissionRuleSet, error)
+	// Calculate — комиссия по правилам, действующим на момент at.
+	Calculate(ctx context.Context, l *domain.Lesson, gross domain.Money, at time.Time) (*domain.Commission, error)
+}
+
+type commissionUseCase struct {
+	repo    repository.CommissionRepository
+	pricing PricingUseCase
+}
+
+func NewCommissionUseCase(repo repository.CommissionRepository, pricing PricingUseCase) CommissionUseCase {
+	return &commissionUseCase{repo: repo, pricing: pricing}
+}
+
+func (uc *commissionUseCase) ListRuleSets(ctx context.Context) ([]domain.CommissionRuleSet, error) {
+	return uc.repo.ListRuleSets(ctx)
+}
+
+func (uc *commissionUseCase) CreateRuleSet(ctx context.Context, adminID string, rs domain.CommissionRuleSet) (*domain.CommissionRuleSet, error) {
+	rs.Version = strings.TrimSpace(rs.Version)
+	if rs.Version == "" {
+		return nil, fmt.Errorf("%w: version is required", domain.ErrInvalidCommissionRules)
+	}
+	if rs.EffectiveFrom.IsZero() {
+		rs.EffectiveFrom = time.Now().UTC()
+	}
+	// задним числом правила не вводим — иначе "действующая" версия прошлых уроков поменялась бы
+	if rs.EffectiveFrom.Before(time.Now().Add(-time.Minute)) {
+		return nil, fmt.Errorf("%w: effectiveFrom cannot be in the past", domain.ErrInvalidCommissionRules)
+	}
+	if err := rs.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	rs.CreatedBy = adminID
+	if err := uc.repo.CreateRuleSet(ctx, rs); err != nil {
+		return nil, err
+	}
+	rs.CreatedAt = time.Now().UTC()
+	return &rs, nil
+}
+
+func (uc *commissionUseCase) Calculate(ctx context.Context, l *domain.Lesson, gross domain.Money, at time.Time) (*domain.Commission, error) {
+	rs, err := uc.repo.RuleSetAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	volume, err := uc.tutorVolume(ctx, l.TutorID, rs.Rules.Currency)
+	if err != nil {
+		return nil, err
+	}
+	commission, details := rs.Rules.Apply(domain.CommissionInput{
+		GrossMinor:   gross.AmountMinor,
+		Currency:     gross.Currency,
+		Trial:        l.Trial,
+		SubjectSlugs: l.Slugs(),
+		VolumeMinor:  volume,
+		At:           at,
+	})
+	return &domain.Commission{
+		LessonID:        l.ID,
+		CommissionMinor: commission,
+		TutorEarnMinor:  gross.AmountMinor - commission,
+		Currency:        gross.Currency,
+		RuleVersion:     rs.Version,
+		Details:         details,
+	}, nil
+}
+
+// tutorVolume сводит объём по всем валютам в валюту порогов; пары без курса пропускаются.
+func (uc *commissionUseCase) tutorVolume(ctx context.Context, tutorID, currency string) (int64, error) {
+	byCurrency, err := uc.repo.TutorVolume(ctx, tutorID)
+	if err != nil {
+		return 0, err
+	}
+	var conv *FXConverter
+	var total int64
+	for cur, sum := range byCurrency {
+		if cur == currency {
+			total += sum
+			continue
+		}
+		if conv == nil {
+			if conv, err = uc.pricing.Converter(ctx); err != nil {
+				return 0, err
+			}
+		}
+		if m, err := conv.Convert(domain.Money{AmountMinor: sum, Currency: cur}, currency); err == nil {
+			total += m.AmountMinor
+		}
+	}
+	return total, nil
+}
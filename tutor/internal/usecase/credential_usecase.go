@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shared/storage"
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrInvalidCredential = errors.New("invalid education or certificate entry")
+	ErrDocumentType      = errors.New("document must be a PDF, JPEG, PNG or WebP file")
+	ErrDocumentTooLarge  = errors.New("document is too large")
+	ErrNoDocument        = errors.New("no document attached")
+	ErrReviewStatus      = errors.New("status must be pending, verified or rejected; rejection needs a note")
+)
+
+// DefaultMaxDocumentSize — предел скана по умолчанию.
+const DefaultMaxDocumentSize = 10 << 20
+
+// documentTypes — допустимые типы сканов → расширение ключа. Тип определяется по содержимому,
+// Content-Type клиента не учитывается.
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// CredentialUseCase — образование и сертификаты анкеты по одной записи, сканы документов и их проверка.
+type CredentialUseCase interface {
+	List(ctx context.Context, tutorID string) ([]domain.Education, []domain.Certification, error)
+	SaveEducation(ctx context.Context, tutorID string, e domain.Education) (*domain.Education, error)
+	SaveCertificate(ctx context.Context, tutorID string, c domain.Certification) (*domain.Certification, error)
+	Delete(ctx context.Context, tutorID, kind, id string) error
+
+	// UploadDocument заменяет скан записи; size — размер файла из запроса.
+	UploadDocument(ctx context.Context, tutorID, kind, id, name string, size int64, r io.Reader) (*domain.Document, error)
+	DeleteDocument(ctx context.Context, tutorID, kind, id string) error
+	// OpenDocument — файл скана; доступен владельцу записи и админам.
+	OpenDocument(ctx context.Context, userID, role, kind, id string) (io.ReadCloser, *domain.Document, error)
+
+	Review(ctx context.Context, actorID, kind, id, status, note string) (*domain.CredentialEntry, error)
+	ListForReview(ctx context.Context, f domain.CredentialFilter, page, limit int) ([]domain.CredentialEntry, *domain.Pagination, error)
+}
+
+type CredentialOptions struct {
+	MaxDocumentSize int64 // 0 — DefaultMaxDocumentSize
+}
+
+type credentialUseCase struct {
+	repo    repository.CredentialRepository
+	store   storage.Storage
+	maxSize int64
+}
+
+func NewCredentialUseCase(repo repository.CredentialRepository, store storage.Storage, opts CredentialOptions) CredentialUseCase {
+	if opts.MaxDocumentSize <= 0 {
+		opts.MaxDocumentSize = DefaultMaxDocumentSize
+	}
+	return &credentialUseCase{repo: repo, store: store, maxSize: opts.MaxDocumentSize}
+}
+
+func (uc *credentialUseCase) List(ctx context.Context, tutorID string) ([]domain.Education, []domain.Certification, error) {
+	return uc.repo.List(ctx, tutorID)
+}
+
+func (uc *credentialUseCase) SaveEducation(ctx context.Context, tutorID string, e domain.Education) (*domain.Education, error) {
+	if strings.TrimSpace(e.Institution) == "" && strings.TrimSpace(e.UniversityID) == "" {
+		return nil, fmt.Errorf("%w: institution or universityId is required", ErrInvalidCredential)
+	}
+	if strings.TrimSpace(e.Degree) == "" {
+		return nil, fmt.Errorf("%w: degree is required", ErrInvalidCredential)
+	}
+	return uc.repo.SaveEducation(ctx, tutorID, e)
+}
+
+func (uc *credentialUseCase) SaveCertificate(ctx context.Context, tutorID string, c domain.Certification) (*domain.Certification, error) {
+	if strings.TrimSpace(c.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCredential)
+	}
+	return uc.repo.SaveCertificate(ctx, tutorID, c)
+}
+
+func (uc *credentialUseCase) Delete(ctx context.Context, tutorID, kind, id string) error {
+	key, err := uc.repo.Delete(ctx, tutorID, kind, id)
+	if err != nil {
+		return err
+	}
+	dropDocuments(ctx, uc.store, key)
+	return nil
+}
+
+func (uc *credentialUseCase) UploadDocument(ctx context.Context, tutorID, kind, id, name string, size int64, r io.Reader) (*domain.Document, error) {
+	if size > uc.maxSize {
+		return nil, fmt.Errorf("%w: max %d bytes", ErrDocumentTooLarge, uc.maxSize)
+	}
+	// запись проверяем до загрузки, чтобы не оставлять в хранилище файлы без владельца
+	owner, _, err := uc.repo.Document(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	if owner != tutorID {
+		return nil, repository.ErrCredentialNotFound
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	ct, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	ext, ok := documentTypes[ct]
+	if !ok || n == 0 {
+		return nil, ErrDocumentType
+	}
+
+	doc := &domain.Document{
+		Key:         storage.NewKey(path.Join("credentials", tutorID, kind, id), ext),
+		Name:        documentName(name, ext),
+		ContentType: ct,
+		Size:        size,
+	}
+	if err := uc.store.Put(ctx, doc.Key, ct, io.MultiReader(bytes.NewReader(head[:n]), r), size); err != nil {
+		return nil, err
+	}
+	old, err := uc.repo.SetDocument(ctx, tutorID, kind, id, doc)
+	if err != nil {
+		dropDocuments(ctx, uc.store, doc.Key)
+		return nil, err
+	}
+	dropDocuments(ctx, uc.store, old)
+	doc.UploadedAt = time.Now().UTC()
+	return doc, nil
+}
+
+func (uc *credentialUseCase) DeleteDocument(ctx context.Context, tutorID, kind, id string) error {
+	old, err := uc.repo.SetDocument(ctx, tutorID, kind, id, nil)
+	if err != nil {
+		return err
+	}
+	dropDocuments(ctx, uc.store, old)
+	return nil
+}
+
+func (uc *credentialUseCase) OpenDocument(ctx context.Context, userID, role, kind, id string) (io.ReadCloser, *domain.Document, error) {
+	owner, doc, err := uc.repo.Document(ctx, kind, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if owner != userID && role != "admin" {
+		// чужие записи не отличаем от несуществующих
+		return nil, nil, repository.ErrCredentialNotFound
+	}
+	if doc == nil {
+		return nil, nil, ErrNoDocument
+	}
+	rc, _, err := uc.store.Get(ctx, doc.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrNoDocument
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, doc, nil
+}
+
+func (uc *credentialUseCase) Review(ctx context.Context, actorID, kind, id, status, note string) (*domain.CredentialEntry, error) {
+	note = strings.TrimSpace(note)
+	switch status {
+	case domain.CredentialPending, domain.CredentialVerified:
+	case domain.CredentialRejected:
+		if note == "" {
+			return nil, ErrReviewStatus
+		}
+	default:
+		return nil, ErrReviewStatus
+	}
+	return uc.repo.Review(ctx, actorID, kind, id, status, note)
+}
+
+func (uc *credentialUseCase) ListForReview(ctx context.Context, f domain.CredentialFilter, page, limit int) ([]domain.CredentialEntry, *domain.Pagination, error) {
+	if f.Status == "" {
+		f.Status = domain.CredentialPending
+	}
+	list, total, err := uc.repo.ListForReview(ctx, f, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+// documentName — имя файла для скачивания: без пути, не длиннее 120 символов, с расширением по типу.
+func documentName(name, ext string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" {
+		name = ""
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if r := []rune(name); len(r) > 120 {
+		name = string(r[:120])
+	}
+	if name == "" {
+		name = "document"
+	}
+	return name + ext
+}
+
+// dropDocuments удаляет файлы, на которые больше нет ссылок; ошибка не откатывает изменение —
+// оставшийся файл только занимает место.
+func dropDocuments(ctx context.Context, store storage.Storage, keys ...string) {
+	for _, k := range keys {
+		if k == "" || store == nil {
+			continue
+		}
+		if err := store.Delete(ctx, k); err != nil {
+			log.Printf("[STORAGE] delete %s: %v", k, err)
+		}
+	}
+}
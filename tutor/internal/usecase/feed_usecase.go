@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/ics"
+	"tutor/internal/notify"
+	"tutor/internal/repository"
+)
+
+var ErrFeedNotTutor = errors.New("availability feed is only for tutors")
+
+const (
+	slotLength = 30 * time.Minute // шаг слотов доступности в мастере анкеты
+	feedLimit  = 500
+)
+
+// seqEpoch — отсчёт SEQUENCE для слотов доступности: они пересоздаются при каждом
+// сохранении расписания, так что порядковый номер берём от времени изменения.
+var seqEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type FeedOptions struct {
+	BaseURL         string        // публичный адрес сервиса для ссылок
+	DefaultTimezone string        // если пользователь не указал свой
+	History         time.Duration // сколько прошедших уроков оставлять в ленте
+	Refresh         time.Duration // REFRESH-INTERVAL для клиентов
+	BusyWindow      time.Duration // на сколько вперёд вычитать занятость внешнего календаря
+}
+
+// FeedUseCase — ICS-подписка: секретная ссылка на уроки участника и доступность репетитора.
+type FeedUseCase interface {
+	Links(ctx context.Context, userID string) (*domain.FeedLinks, error)
+	// Rotate выдаёт новую ссылку; старая сразу перестаёт работать.
+	Rotate(ctx context.Context, userID string) (*domain.FeedLinks, error)
+	Lessons(ctx context.Context, token string) ([]byte, error)
+	Availability(ctx context.Context, token string) ([]byte, error)
+}
+
+type feedUseCase struct {
+	repo repository.FeedRepository
+	opts FeedOptions
+}
+
+func NewFeedUseCase(repo repository.FeedRepository, opts FeedOptions) FeedUseCase {
+	if opts.History <= 0 {
+		opts.History = 30 * 24 * time.Hour
+	}
+	if opts.Refresh <= 0 {
+		opts.Refresh = time.Hour
+	}
+	if opts.BusyWindow <= 0 {
+		opts.BusyWindow = 30 * 24 * time.Hour
+	}
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	return &feedUseCase{repo: repo, opts: opts}
+}
+
+func (uc *feedUseCase) Links(ctx context.Context, userID string) (*domain.FeedLinks, error) {
+	o, err := uc.repo.Owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Token == "" {
+		if o.Token, err = uc.repo.EnsureToken(ctx, userID, newFeedToken()); err != nil {
+			return nil, err
+		}
+	}
+	return uc.links(o), nil
+}
+
+func (uc *feedUseCase) Rotate(ctx context.Context, userID string) (*domain.FeedLinks, error) {
+	o, err := uc.repo.Owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	o.Token = newFeedToken()
+	if err := uc.repo.RotateToken(ctx, userID, o.Token); err != nil {
+		return nil, err
+	}
+	return uc.links(o), nil
+}
+
+func (uc *feedUseCase) links(o *domain.FeedOwner) *domain.FeedLinks {
+	base := uc.opts.BaseURL + "/v1/ics/" + o.Token
+	l := &domain.FeedLinks{Lessons: base + "/lessons.ics", LessonsWebcal: webcal(base + "/lessons.ics")}
+	if o.IsTutor {
+		l.Availability = base + "/availability.ics"
+		l.AvailabilityWebcal = webcal(l.Availability)
+	}
+	return l
+}
+
+// webcal — та же ссылка со схемой webcal://: по ней календари сразу предлагают подписку.
+func webcal(u string) string {
+	if i := strings.Index(u, "://"); i >= 0 {
+		return "webcal" + u[i:]
+	}
+	return u
+}
+
+func newFeedToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (uc *feedUseCase) owner(ctx context.Context, token string) (*domain.FeedOwner, *time.Location, error) {
+	if token == "" {
+		return nil, nil, repository.ErrFeedNotFound
+	}
+	o, err := uc.repo.OwnerByToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	tz := o.Timezone
+	if tz == "" {
+		tz = uc.opts.DefaultTimezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	return o, loc, nil
+}
+
+func (uc *feedUseCase) Lessons(ctx context.Context, token string) ([]byte, error) {
+	o, loc, err := uc.owner(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	lessons, err := uc.repo.Lessons(ctx, o.UserID, time.Now().Add(-uc.opts.History), feedLimit)
+	if err != nil {
+		return nil, err
+	}
+	cal := ics.Calendar{Name: feedName(o.Locale, false), Location: loc, Refresh: uc.opts.Refresh}
+	for _, l := range lessons {
+		status := ics.StatusConfirmed
+		if l.Status == domain.LessonCancelled {
+			status = ics.StatusCancelled
+		}
+		cal.Events = append(cal.Events, ics.Event{
+			// тот же UID в лентах обоих участников не мешает: у каждого своя подписка
+			UID:          "lesson-" + l.ID + "@alem.kz",
+			Start:        l.StartsAt,
+			Duration:     l.Duration,
+			Summary:      lessonTitle(o.Locale, l.Role, l.PeerName, l.SubjectName),
+			Description:  "Alem lesson " + l.ID,
+			Status:       status,
+			Sequence:     l.Sequence,
+			LastModified: l.UpdatedAt,
+		})
+	}
+	return cal.Encode(), nil
+}
+
+func (uc *feedUseCase) Availability(ctx context.Context, token string) ([]byte, error) {
+	o, loc, err := uc.owner(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !o.IsTutor {
+		return nil, ErrFeedNotTutor
+	}
+	slots, modified, err := uc.repo.WeeklySlots(ctx, o.UserID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	busy, err := uc.repo.CalendarBusy(ctx, o.UserID, now, now.Add(uc.opts.BusyWindow))
+	if err != nil {
+		return nil, err
+	}
+	cal := ics.Calendar{Name: feedName(o.Locale, true), Location: loc, Refresh: uc.opts.Refresh}
+	seq := 0
+	if modified.After(seqEpoch) {
+		seq = int(modified.Sub(seqEpoch) / time.Second)
+	}
+	// DTSTART — от момента изменения расписания, а не от "сейчас": иначе событие менялось бы каждый день
+	since := modified
+	if since.IsZero() {
+		since = now
+	}
+	title := availabilityTitle(o.Locale)
+	for _, b := range weeklyBlocks(slots) {
+		uid := "availability-" + o.UserID + "-" + b.day + "-" + fmt.Sprintf("%02d%02d", b.minute/60, b.minute%60)
+		start := nextWeekday(since.In(loc), b.day, b.minute)
+		// повторения, задетые внешним календарём, выпадают из RRULE и заменяются свободными остатками
+		skipped, free := subtractBusy(start, b.length, busy, now, now.Add(uc.opts.BusyWindow))
+		cal.Events = append(cal.Events, ics.Event{
+			UID:          uid + "@alem.kz",
+			Start:        start,
+			Duration:     b.length,
+			RRule:        "FREQ=WEEKLY;BYDAY=" + b.day,
+			ExDates:      skipped,
+			Summary:      title,
+			Transparent:  true,
+			Sequence:     seq,
+			LastModified: modified,
+		})
+		for _, f := range free {
+			cal.Events = append(cal.Events, ics.Event{
+				UID:          uid + "-" + f.start.UTC().Format("20060102T1504") + "@alem.kz",
+				Start:        f.start,
+				Duration:     f.end.Sub(f.start),
+				Summary:      title,
+				Transparent:  true,
+				Sequence:     seq,
+				LastModified: modified,
+			})
+		}
+	}
+	return cal.Encode(), nil
+}
+
+type span struct{ start, end time.Time }
+
+// subtractBusy проходит по еженедельным повторениям блока [first, first+length) внутри
+// окна [from, to) и возвращает те, что пересекаются с busy (для EXDATE), и свободные
+// остатки этих повторений не короче слота. busy отсортирован по началу.
+func subtractBusy(first time.Time, length time.Duration, busy []domain.BusyInterval, from, to time.Time) ([]time.Time, []span) {
+	if len(busy) == 0 {
+		return nil, nil
+	}
+	week := 7 * 24 * time.Hour
+	k := 0
+	if from.After(first) {
+		// на переходах часового пояса неделя бывает на час короче — берём с запасом
+		k = max(int(from.Sub(first)/week)-1, 0)
+	}
+	var skipped []time.Time
+	var free []span
+	for ; ; k++ {
+		s := time.Date(first.Year(), first.Month(), first.Day()+7*k, first.Hour(), first.Minute(), 0, 0, first.Location())
+		if !s.Before(to) {
+			break
+		}
+		e := s.Add(length)
+		if !e.After(from) {
+			continue
+		}
+		hit, cur := false, s
+		for _, b := range busy {
+			if !b.End.After(s) || !b.Start.Before(e) {
+				continue
+			}
+			hit = true
+			if b.Start.Sub(cur) >= slotLength {
+				free = append(free, span{cur, b.Start})
+			}
+			if b.End.After(cur) {
+				cur = b.End
+			}
+		}
+		if !hit {
+			continue
+		}
+		skipped = append(skipped, s)
+		if e.Sub(cur) >= slotLength {
+			free = append(free, span{cur, e})
+		}
+	}
+	return skipped, free
+}
+
+type weeklyBlock struct {
+	day    string
+	minute int
+	length time.Duration
+}
+
+// weeklyBlocks склеивает соседние 30-минутные слоты дня в непрерывные интервалы.
+func weeklyBlocks(slots []domain.WeeklySlot) []weeklyBlock {
+	byDay := map[string][]int{}
+	for _, s := range slots {
+		byDay[s.Day] = append(byDay[s.Day], s.Minute)
+	}
+	step := int(slotLength / time.Minute)
+	var out []weeklyBlock
+	for _, day := range []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"} {
+		mins := byDay[day]
+		sort.Ints(mins)
+		for i := 0; i < len(mins); {
+			j := i
+			for j+1 < len(mins) && mins[j+1] <= mins[j]+step {
+				j++
+			}
+			out = append(out, weeklyBlock{
+				day:    day,
+				minute: mins[i],
+				length: time.Duration(mins[j]-mins[i]+step) * time.Minute,
+			})
+			i = j + 1
+		}
+	}
+	return out
+}
+
+// nextWeekday — ближайшее (начиная с дня now) наступление дня недели в заданную минуту:
+// с него начинается RRULE, так что DTSTART совпадает с первым повторением.
+func nextWeekday(now time.Time, day string, minute int) time.Time {
+	target := map[string]time.Weekday{
+		"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+		"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+	}[day]
+	diff := (int(target) - int(now.Weekday()) + 7) % 7
+	d := now.AddDate(0, 0, diff)
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, minute, 0, 0, now.Location())
+}
+
+func feedName(locale string, availability bool) string {
+	names := map[string][2]string{ // [уроки, доступность]
+		"ru": {"Alem — уроки", "Alem — свободное время"},
+		"kk": {"Alem — сабақтар", "Alem — бос уақыт"},
+		"en": {"Alem lessons", "Alem availability"},
+	}[notify.NormalizeLocale(locale)]
+	if availability {
+		return names[1]
+	}
+	return names[0]
+}
+
+func availabilityTitle(locale string) string {
+	return map[string]string{
+		"ru": "Свободно для уроков",
+		"kk": "Сабаққа бос",
+		"en": "Available for lessons",
+	}[notify.NormalizeLocale(locale)]
+}
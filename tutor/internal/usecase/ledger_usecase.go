@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"math"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+type LedgerUseCase interface {
+	// MyWallets — кошельки текущего пользователя (tutor/student по роли из токена).
+	MyWallets(ctx context.Context, userID, role string) ([]domain.Wallet, error)
+	MyStatement(ctx context.Context, userID, role, currency string, page, limit int) ([]domain.WalletEntry, *domain.Pagination, error)
+
+	Wallets(ctx context.Context, ownerType, ownerID string) ([]domain.Wallet, error)
+	Statement(ctx context.Context, ownerType, ownerID, currency string, page, limit int) ([]domain.WalletEntry, *domain.Pagination, error)
+	Check(ctx context.Context) ([]domain.LedgerIssue, error)
+}
+
+type ledgerUseCase struct {
+	repo repository.LedgerRepository
+}
+
+func NewLedgerUseCase(repo repository.LedgerRepository) LedgerUseCase {
+	return &ledgerUseCase{repo: repo}
+}
+
+func ownerTypeForRole(role string) (string, error) {
+	switch role {
+	case domain.OwnerTutor, domain.OwnerStudent:
+		return role, nil
+	}
+	return "", ErrForbidden
+}
+
+func (uc *ledgerUseCase) MyWallets(ctx context.Context, userID, role string) ([]domain.Wallet, error) {
+	ot, err := ownerTypeForRole(role)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.Wallets(ctx, ot, userID)
+}
+
+func (uc *ledgerUseCase) MyStatement(ctx context.Context, userID, role, currency string, page, limit int) ([]domain.WalletEntry, *domain.Pagination, error) {
+	ot, err := ownerTypeForRole(role)
+	if err != nil {
+		return nil, nil, err
+	}
+	return uc.Statement(ctx, ot, userID, currency, page, limit)
+}
+
+func (uc *ledgerUseCase) Wallets(ctx context.Context, ownerType, ownerID string) ([]domain.Wallet, error) {
+	return uc.repo.Wallets(ctx, ownerType, ownerID)
+}
+
+func (uc *ledgerUseCase) Statement(ctx context.Context, ownerType, ownerID, currency string, page, limit int) ([]domain.WalletEntry, *domain.Pagination, error) {
+	currency = domain.NormalizeCurrency(currency)
+	if !domain.IsValidCurrency(currency) {
+		return nil, nil, ErrInvalidCurrency
+	}
+	list, total, err := uc.repo.Statement(ctx, ownerType, ownerID, currency, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *ledgerUseCase) Check(ctx context.Context) ([]domain.LedgerIssue, error) {
+	return uc.repo.Check(ctx)
+}
+
+func paginate(page, limit, total int) *domain.Pagination {
+	return &domain.Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}
+}
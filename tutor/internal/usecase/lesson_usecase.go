@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+var ErrLessonNotStarted = errors.New("lesson has not started yet")
+
+type LessonUseCase interface {
+	Get(ctx context.Context, userID, role, lessonID string) (*domain.Lesson, error)
+	// Complete — урок проведён: списываем авторизацию, считаем комиссию по действующей
+	// версии правил и распределяем деньги в леджере.
+	Complete(ctx context.Context, userID, role, lessonID string) (*domain.Commission, error)
+}
+
+type lessonUseCase struct {
+	repo        repository.LessonRepository
+	payments    repository.PaymentRepository
+	paymentUC   PaymentUseCase
+	commissions CommissionUseCase
+}
+
+func NewLessonUseCase(repo repository.LessonRepository, payments repository.PaymentRepository, paymentUC PaymentUseCase, commissions CommissionUseCase) LessonUseCase {
+	return &lessonUseCase{repo: repo, payments: payments, paymentUC: paymentUC, commissions: commissions}
+}
+
+func (uc *lessonUseCase) Get(ctx context.Context, userID, role, lessonID string) (*domain.Lesson, error) {
+	l, err := uc.repo.FindLesson(ctx, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && l.TutorID != userID && l.StudentID != userID {
+		return nil, ErrForbidden
+	}
+	return l, nil
+}
+
+func (uc *lessonUseCase) Complete(ctx context.Context, userID, role, lessonID string) (*domain.Commission, error) {
+	l, err := uc.repo.FindLesson(ctx, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && l.TutorID != userID {
+		return nil, ErrForbidden
+	}
+	if l.Status != domain.LessonScheduled && l.Status != domain.LessonInProgress {
+		return nil, fmt.Errorf("%w: lesson is %s", repository.ErrLessonTransition, l.Status)
+	}
+	now := time.Now().UTC()
+	if l.StartedAt == nil || now.Before(*l.StartedAt) {
+		return nil, ErrLessonNotStarted
+	}
+
+	var p *domain.Payment
+	gross := domain.Money{Currency: domain.DefaultCurrency}
+	if l.PaymentID != "" {
+		if p, err = uc.payments.FindPayment(ctx, l.PaymentID); err != nil {
+			return nil, err
+		}
+		if p.Status == domain.PaymentAuthorized {
+			if p, err = uc.paymentUC.Capture(ctx, p.ID); err != nil {
+				return nil, err
+			}
+		}
+		// делится только то, что осталось после возвратов; возвращённый или
+		// отменённый платёж урок не оплачивает
+		gross = domain.Money{Currency: p.Currency}
+		if p.Status == domain.PaymentCaptured {
+			refunded, err := uc.payments.RefundedMinor(ctx, p.ID)
+			if err != nil {
+				return nil, err
+			}
+			gross.AmountMinor = p.AmountMinor - refunded
+		}
+	}
+
+	c, err := uc.commissions.Calculate(ctx, l, gross, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.CompleteLesson(ctx, l.ID, *c, p); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"shared/storage"
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrMediaKind     = errors.New("kind must be avatar or video")
+	ErrMediaType     = errors.New("unsupported media type")
+	ErrMediaTooLarge = errors.New("file is too large")
+	ErrMediaNotFound = errors.New("uploaded file not found; request a new upload URL")
+	ErrMediaURL      = errors.New("media must be uploaded via /v1/media/uploads")
+)
+
+const (
+	DefaultMaxAvatarSize = 5 << 20
+	DefaultMaxVideoSize  = 200 << 20
+)
+
+// mediaKind — куда и что можно загружать. Тип определяется по содержимому файла;
+// расширение ключа задаётся типом, так что хранилище отдаёт файл с верным Content-Type.
+type mediaKind struct {
+	prefix  string
+	types   map[string]string // content type → расширение
+	maxSize int64
+}
+
+// mediaPrefixes — всё, что сервис кладёт в хранилище; сборщик мусора обходит только их.
+var mediaPrefixes = []string{"tutors/", "credentials/"}
+
+// MediaUseCase — аватары и видео анкеты в хранилище: прямая загрузка по подписанной ссылке,
+// проверка загруженного файла и уборка файлов, на которые ничто не ссылается.
+type MediaUseCase interface {
+	PresignUpload(ctx context.Context, userID, kind, contentType string, size int64) (*domain.MediaUpload, error)
+	// Attach проверяет файл, указанный в анкете (ключ или URL из PresignUpload), и возвращает его
+	// публичный URL и прежнее значение поля. Пустая строка и уже сохранённое значение проходят как есть.
+	Attach(ctx context.Context, userID, kind, ref string) (value, old string, err error)
+	// Release удаляет файл, который анкета больше не использует. Адрес может быть под любым
+	// прежним публичным URL; внешние ссылки и чужие файлы пропускаются.
+	Release(ctx context.Context, userID, rawURL string)
+	// CollectGarbage удаляет объекты старше периода ожидания, на которые нет ссылок; возвращает их число.
+	CollectGarbage(ctx context.Context) (int, error)
+}
+
+type MediaOptions struct {
+	MaxAvatarSize int64         // 0 — DefaultMaxAvatarSize
+	MaxVideoSize  int64         // 0 — DefaultMaxVideoSize
+	UploadTTL     time.Duration // срок подписанной ссылки; 0 — 15 минут
+	// Grace — сколько ждать, пока загруженный файл сохранят в анкете, прежде чем считать его мусором.
+	// 0 — 24 часа.
+	Grace time.Duration
+}
+
+type mediaUseCase struct {
+	repo  repository.MediaRepository
+	store storage.Storage
+	kinds map[string]mediaKind
+	ttl   time.Duration
+	grace time.Duration
+}
+
+func NewMediaUseCase(repo repository.MediaRepository, store storage.Storage, opts MediaOptions) MediaUseCase {
+	if opts.MaxAvatarSize <= 0 {
+		opts.MaxAvatarSize = DefaultMaxAvatarSize
+	}
+	if opts.MaxVideoSize <= 0 {
+		opts.MaxVideoSize = DefaultMaxVideoSize
+	}
+	if opts.UploadTTL <= 0 {
+		opts.UploadTTL = 15 * time.Minute
+	}
+	if opts.Grace <= 0 {
+		opts.Grace = 24 * time.Hour
+	}
+	return &mediaUseCase{
+		repo:  repo,
+		store: store,
+		kinds: map[string]mediaKind{
+			domain.MediaAvatar: {
+				prefix:  "tutors/avatars",
+				types:   map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/webp": ".webp"},
+				maxSize: opts.MaxAvatarSize,
+			},
+			domain.MediaVideo: {
+				prefix:  "tutors/videos",
+				types:   map[string]string{"video/mp4": ".mp4", "video/webm": ".webm"},
+				maxSize: opts.MaxVideoSize,
+			},
+		},
+		ttl:   opts.UploadTTL,
+		grace: opts.Grace,
+	}
+}
+
+func (uc *mediaUseCase) PresignUpload(ctx context.Context, userID, kind, contentType string, size int64) (*domain.MediaUpload, error) {
+	k, ok := uc.kinds[kind]
+	if !ok {
+		return nil, ErrMediaKind
+	}
+	ct, _, _ := mime.ParseMediaType(contentType)
+	ext, ok := k.types[ct]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMediaType, contentType)
+	}
+	if size <= 0 || size > k.maxSize {
+		return nil, fmt.Errorf("%w: max %d bytes", ErrMediaTooLarge, k.maxSize)
+	}
+	key := storage.NewKey(path.Join(k.prefix, userID), ext)
+	up, err := uc.store.PresignPut(ctx, key, ct, size, uc.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.MediaUpload{
+		Kind:      kind,
+		Key:       key,
+		URL:       uc.store.URL(key),
+		Method:    up.Method,
+		UploadURL: up.URL,
+		Headers:   up.Headers,
+		MaxSize:   k.maxSize,
+		ExpiresAt: up.ExpiresAt,
+	}, nil
+}
+
+func (uc *mediaUseCase) Attach(ctx context.Context, userID, kind, ref string) (string, string, error) {
+	if _, ok := uc.kinds[kind]; !ok {
+		return "", "", ErrMediaKind
+	}
+	avatar, video, err := uc.repo.CurrentMedia(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	old := avatar
+	if kind == domain.MediaVideo {
+		old = video
+	}
+	ref = strings.TrimSpace(ref)
+	if ref == "" || ref == old {
+		return ref, old, nil
+	}
+	v, err := uc.resolve(ctx, userID, kind, ref)
+	return v, old, err
+}
+
+// resolve — публичный URL своей загрузки после проверки размера и содержимого.
+func (uc *mediaUseCase) resolve(ctx context.Context, userID, kind, ref string) (string, error) {
+	k := uc.kinds[kind]
+	key, ok := storage.KeyFromURL(uc.store, ref)
+	if !ok {
+		if u, err := url.Parse(ref); err == nil && u.Scheme != "" {
+			// видео можно дать ссылкой на внешний хостинг (YouTube и т.п.), аватар — только из хранилища
+			if kind == domain.MediaVideo && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+				return ref, nil
+			}
+			return "", ErrMediaURL
+		}
+		key = ref
+	}
+	// в анкету можно поставить только свою загрузку
+	if !strings.HasPrefix(key, path.Join(k.prefix, userID)+"/") {
+		return "", ErrMediaNotFound
+	}
+	obj, err := uc.store.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return "", ErrMediaNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	// файл, пролежавший без ссылки полпериода ожидания, может в любой момент убрать сборщик мусора
+	if time.Since(obj.ModTime) > uc.grace/2 {
+		return "", ErrMediaNotFound
+	}
+	if obj.Size > k.maxSize {
+		dropDocuments(ctx, uc.store, key)
+		return "", fmt.Errorf("%w: max %d bytes", ErrMediaTooLarge, k.maxSize)
+	}
+	ct, err := sniffObject(ctx, uc.store, key)
+	if err != nil {
+		return "", err
+	}
+	if ext, ok := k.types[ct]; !ok || ext != path.Ext(key) {
+		// подписанная ссылка фиксирует заявленный тип, но не содержимое
+		dropDocuments(ctx, uc.store, key)
+		return "", fmt.Errorf("%w: file content is %s", ErrMediaType, ct)
+	}
+	return uc.store.URL(key), nil
+}
+
+func (uc *mediaUseCase) Release(ctx context.Context, userID, rawURL string) {
+	for _, k := range uc.kinds {
+		own := path.Join(k.prefix, userID) + "/"
+		if key, ok := storage.KeyFromPath(rawURL, own); ok {
+			dropDocuments(ctx, uc.store, key)
+			return
+		}
+	}
+}
+
+func (uc *mediaUseCase) CollectGarbage(ctx context.Context) (int, error) {
+	const batchSize = 500
+	cutoff := time.Now().Add(-uc.grace)
+	deleted := 0
+	var keys []string
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		used, err := uc.repo.Referenced(ctx, keys)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if used[k] {
+				continue
+			}
+			if err := uc.store.Delete(ctx, k); err != nil {
+				log.Printf("[STORAGE] gc delete %s: %v", k, err)
+				continue
+			}
+			deleted++
+		}
+		keys = keys[:0]
+		return nil
+	}
+	for _, prefix := range mediaPrefixes {
+		err := uc.store.List(ctx, prefix, func(o storage.Object) error {
+			if o.ModTime.After(cutoff) {
+				return nil
+			}
+			keys = append(keys, o.Key)
+			if len(keys) >= batchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+		if err := flush(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// sniffObject — тип содержимого по первым 512 байтам объекта.
+func sniffObject(ctx context.Context, store storage.Storage, key string) (string, error) {
+	rc, _, err := store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrMediaNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(rc, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	ct, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	return ct, nil
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrInvalidReport   = errors.New("invalid report")
+	ErrInvalidDecision = errors.New("invalid moderation decision")
+)
+
+const maxReportReasonRunes = 1000
+
+type ModerationUseCase interface {
+	// Report — жалоба пользователя; targetType: tutor|user|lesson|message.
+	Report(ctx context.Context, userID, targetType, targetID, reason, details string) (*domain.Report, error)
+	MyReports(ctx context.Context, userID string, page, limit int) ([]domain.Report, *domain.Pagination, error)
+
+	List(ctx context.Context, adminID string, f domain.ReportFilter, page, limit int) ([]domain.Report, *domain.Pagination, error)
+	Get(ctx context.Context, reportID string) (*domain.Report, error)
+	// Assign — assigneeID "me" назначает на себя, "" снимает назначение.
+	Assign(ctx context.Context, adminID, reportID, assigneeID string) (*domain.Report, error)
+	// SetStatus — только open/in_review; закрытие — через Resolve.
+	SetStatus(ctx context.Context, adminID, reportID, status string) (*domain.Report, error)
+	Resolve(ctx context.Context, adminID, reportID string, d domain.ModerationDecision) (*domain.Report, error)
+}
+
+type moderationUseCase struct {
+	repo repository.ModerationRepository
+}
+
+func NewModerationUseCase(repo repository.ModerationRepository) ModerationUseCase {
+	return &moderationUseCase{repo: repo}
+}
+
+func (uc *moderationUseCase) Report(ctx context.Context, userID, targetType, targetID, reason, details string) (*domain.Report, error) {
+	targetType = strings.ToLower(strings.TrimSpace(targetType))
+	if targetType == "tutor" {
+		targetType = domain.ReportTargetUser
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxReportReasonRunes {
+		return nil, fmt.Errorf("%w: reason must be 1..%d characters", ErrInvalidReport, maxReportReasonRunes)
+	}
+	parties, err := uc.repo.TargetParties(ctx, targetType, strings.TrimSpace(targetID))
+	if err != nil {
+		return nil, err
+	}
+	switch targetType {
+	case domain.ReportTargetUser:
+		if parties[0] == userID {
+			return nil, fmt.Errorf("%w: cannot report yourself", ErrInvalidReport)
+		}
+	default:
+		// на урок и сообщение жалуется только участник
+		if !contains(parties, userID) {
+			return nil, ErrForbidden
+		}
+		if targetType == domain.ReportTargetMessage && parties[0] == userID {
+			return nil, fmt.Errorf("%w: cannot report your own message", ErrInvalidReport)
+		}
+	}
+
+	rp := &domain.Report{
+		ReporterID: userID,
+		TargetType: targetType,
+		TargetID:   strings.TrimSpace(targetID),
+		Reason:     reason,
+		Details:    strings.TrimSpace(details),
+	}
+	if err := uc.repo.CreateReport(ctx, rp); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+func (uc *moderationUseCase) MyReports(ctx context.Context, userID string, page, limit int) ([]domain.Report, *domain.Pagination, error) {
+	list, total, err := uc.repo.ListReports(ctx, domain.ReportFilter{ReporterID: userID}, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	// решение модератора репортёру не показываем — только статус
+	for i := range list {
+		list[i].AssigneeID, list[i].ResolutionNote, list[i].ResolvedBy = "", "", ""
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *moderationUseCase) List(ctx context.Context, adminID string, f domain.ReportFilter, page, limit int) ([]domain.Report, *domain.Pagination, error) {
+	if f.AssigneeID == "me" {
+		f.AssigneeID = adminID
+	}
+	list, total, err := uc.repo.ListReports(ctx, f, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *moderationUseCase) Get(ctx context.Context, reportID string) (*domain.Report, error) {
+	return uc.repo.FindReport(ctx, reportID)
+}
+
+func (uc *moderationUseCase) Assign(ctx context.Context, adminID, reportID, assigneeID string) (*domain.Report, error) {
+	if assigneeID == "me" {
+		assigneeID = adminID
+	}
+	return uc.repo.Assign(ctx, reportID, strings.TrimSpace(assigneeID), adminID)
+}
+
+func (uc *moderationUseCase) SetStatus(ctx context.Context, adminID, reportID, status string) (*domain.Report, error) {
+	if status != domain.ReportOpen && status != domain.ReportInReview {
+		return nil, fmt.Errorf("%w: use resolve to close a report", ErrInvalidDecision)
+	}
+	return uc.repo.SetStatus(ctx, reportID, status, adminID)
+}
+
+func (uc *moderationUseCase) Resolve(ctx context.Context, adminID, reportID string, d domain.ModerationDecision) (*domain.Report, error) {
+	rp, err := uc.repo.FindReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Action == "" {
+		d.Action = domain.ModerationNone
+	}
+	d.Note = strings.TrimSpace(d.Note)
+	switch {
+	case d.Status != domain.ReportResolved && d.Status != domain.ReportRejected:
+		return nil, fmt.Errorf("%w: status must be resolved or rejected", ErrInvalidDecision)
+	case !domain.ModerationActions[d.Action]:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidDecision, d.Action)
+	case d.Status == domain.ReportRejected && d.Action != domain.ModerationNone:
+		return nil, fmt.Errorf("%w: rejected report cannot carry an action", ErrInvalidDecision)
+	case d.Action == domain.ModerationHideMessage && rp.TargetType != domain.ReportTargetMessage:
+		return nil, fmt.Errorf("%w: hide_message applies to message reports only", ErrInvalidDecision)
+	}
+
+	if d.Action == domain.ModerationWarn || d.Action == domain.ModerationBlockUser {
+		parties, err := uc.repo.TargetParties(ctx, rp.TargetType, rp.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		// по умолчанию — пользователь, автор сообщения или репетитор урока
+		if d.UserID == "" {
+			d.UserID = parties[0]
+		}
+		if !contains(parties, d.UserID) {
+			return nil, fmt.Errorf("%w: userId is not a party of the reported %s", ErrInvalidDecision, rp.TargetType)
+		}
+		if d.UserID == adminID {
+			return nil, fmt.Errorf("%w: cannot act on yourself", ErrInvalidDecision)
+		}
+	} else {
+		d.UserID = ""
+	}
+	return uc.repo.Resolve(ctx, reportID, d, adminID)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
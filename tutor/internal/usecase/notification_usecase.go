@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"strings"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/notify"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("unknown notification template")
+	ErrInvalidChannel  = errors.New("invalid notification channel")
+	ErrInvalidDevice   = errors.New("invalid push device")
+)
+
+type NotificationOptions struct {
+	MaxAttempts int           // после стольких попыток уведомление становится failed
+	Backoff     time.Duration // пауза перед 2-й попыткой, дальше удваивается (до часа)
+	Lease       time.Duration // сколько взятое воркером уведомление недоступно другим
+	BatchSize   int
+	// DefaultTimezone — пояс для времени урока в тексте, если получатель не указал свой.
+	DefaultTimezone string
+}
+
+type NotificationUseCase interface {
+	// Notify ставит уведомление в очередь по каналам шаблона (или только по указанным).
+	// Настройки пользователя проверяются при отправке, а не здесь.
+	Notify(ctx context.Context, userID, templateKey string, payload map[string]any, channels ...string) error
+	// Dispatch — один проход воркера: рендер, проверка настроек, отправка, ретраи.
+	Dispatch(ctx context.Context) (sent, failed int, err error)
+
+	RegisterDevice(ctx context.Context, userID, platform, token string) error
+	RemoveDevice(ctx context.Context, userID, token string) error
+}
+
+type notificationUseCase struct {
+	repo     repository.NotificationRepository
+	channels notify.Registry
+	opts     NotificationOptions
+	defLoc   *time.Location
+}
+
+func NewNotificationUseCase(repo repository.NotificationRepository, channels notify.Registry, opts NotificationOptions) NotificationUseCase {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 30 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	loc, err := time.LoadLocation(opts.DefaultTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return &notificationUseCase{repo: repo, channels: channels, opts: opts, defLoc: loc}
+}
+
+func (uc *notificationUseCase) Notify(ctx context.Context, userID, templateKey string, payload map[string]any, channels ...string) error {
+	tpl, ok := notify.Lookup(templateKey)
+	if !ok {
+		return ErrUnknownTemplate
+	}
+	if len(channels) == 0 {
+		channels = tpl.Channels
+	}
+	ns := make([]domain.Notification, 0, len(channels))
+	for _, ch := range channels {
+		if !validChannel(ch) {
+			return ErrInvalidChannel
+		}
+		ns = append(ns, domain.Notification{UserID: userID, Channel: ch, TemplateKey: templateKey, Payload: payload})
+	}
+	return uc.repo.Enqueue(ctx, ns)
+}
+
+func validChannel(ch string) bool {
+	switch ch {
+	case notify.ChannelEmail, notify.ChannelSMS, notify.ChannelPush, notify.ChannelInApp:
+		return true
+	}
+	return false
+}
+
+func (uc *notificationUseCase) Dispatch(ctx context.Context) (int, int, error) {
+	jobs, err := uc.repo.Claim(ctx, uc.opts.BatchSize, uc.opts.Lease)
+	if err != nil {
+		return 0, 0, err
+	}
+	sent, failed := 0, 0
+	for _, j := range jobs {
+		switch uc.deliver(ctx, j) {
+		case domain.NotificationSent:
+			sent++
+		case domain.NotificationFailed:
+			failed++
+		}
+	}
+	return sent, failed, nil
+}
+
+// deliver отправляет одно уведомление и возвращает его итоговый статус
+// (queued — будет повторная попытка).
+func (uc *notificationUseCase) deliver(ctx context.Context, j domain.NotificationJob) string {
+	tpl, ok := notify.Lookup(j.TemplateKey)
+	if !ok {
+		return uc.fail(ctx, j, "unknown template "+j.TemplateKey)
+	}
+	if j.Deleted {
+		return uc.skip(ctx, j, "user deleted")
+	}
+	if !j.Prefs.Allows(tpl.Category) {
+		return uc.skip(ctx, j, "disabled by user: "+tpl.Category)
+	}
+	ch, err := uc.channels.Get(j.Channel)
+	if err != nil {
+		return uc.fail(ctx, j, "channel not configured: "+j.Channel)
+	}
+	j.Payload = uc.fillPayload(j)
+	subject, body, err := notify.Render(j.TemplateKey, j.Locale, j.Payload)
+	if err != nil {
+		return uc.fail(ctx, j, "render: "+err.Error())
+	}
+
+	msg := notify.Message{
+		ID: j.ID, UserID: j.UserID, TemplateKey: j.TemplateKey, Locale: notify.NormalizeLocale(j.Locale),
+		Email: j.Email, Phone: j.Phone, Subject: subject, Body: body, Data: j.Payload,
+	}
+	for _, d := range j.Devices {
+		msg.Devices = append(msg.Devices, notify.Device{Platform: d.Platform, Token: d.Token})
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	err = ch.Send(sendCtx, msg)
+	cancel()
+	switch {
+	case err == nil:
+		if err := uc.repo.MarkSent(ctx, j.ID, subject, body); err != nil {
+			log.Printf("[NOTIFY] mark sent %s: %v", j.ID, err)
+		}
+		return domain.NotificationSent
+	case errors.Is(err, notify.ErrPermanent) || j.Attempts >= uc.opts.MaxAttempts:
+		return uc.fail(ctx, j, err.Error())
+	}
+
+	at := time.Now().Add(uc.backoff(j.Attempts))
+	if err := uc.repo.Retry(ctx, j.ID, err.Error(), at); err != nil {
+		log.Printf("[NOTIFY] retry %s: %v", j.ID, err)
+	}
+	return domain.NotificationQueued
+}
+
+// fillPayload дописывает то, что зависит от получателя: время урока в его часовом поясе и
+// имя второй стороны, если оно не заполнено. Уведомления из транзакций репозиториев несут
+// только startsAt, timezone и role.
+func (uc *notificationUseCase) fillPayload(j domain.NotificationJob) map[string]any {
+	out := maps.Clone(j.Payload)
+	if out == nil {
+		out = map[string]any{}
+	}
+	locale := notify.NormalizeLocale(j.Locale)
+	raw, _ := out["startsAt"].(string)
+	startsAt, err := time.Parse(time.RFC3339, raw)
+	lesson := err == nil
+	if _, ok := out["startsAtLocal"]; lesson && !ok {
+		tz, _ := out["timezone"].(string)
+		loc, ok := lessonLocation(tz, uc.defLoc)
+		if !ok {
+			log.Printf("[NOTIFY] user %s: bad timezone %q", j.UserID, tz)
+		}
+		out["startsAtLocal"] = formatLessonTime(startsAt, loc)
+		out["timezone"] = loc.String()
+	}
+	role, _ := out["role"].(string)
+	if peer, _ := out["peerName"].(string); peer == "" && role != "" {
+		if lesson {
+			out["peerName"] = peerFallback(locale, role)
+		} else {
+			out["peerName"] = senderFallback(locale, role)
+		}
+	}
+	return out
+}
+
+// senderFallback — автор сообщения без имени, в начале фразы; role — роль получателя.
+func senderFallback(locale, role string) string {
+	names := map[string][2]string{ // [для ученика, для репетитора]
+		"ru": {"Репетитор", "Ученик"},
+		"kk": {"Репетитор", "Оқушы"},
+		"en": {"Your tutor", "Your student"},
+	}[locale]
+	if role == "tutor" {
+		return names[1]
+	}
+	return names[0]
+}
+
+func (uc *notificationUseCase) backoff(attempt int) time.Duration {
+	d := uc.opts.Backoff
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
+
+func (uc *notificationUseCase) fail(ctx context.Context, j domain.NotificationJob, reason string) string {
+	log.Printf("[NOTIFY] %s %s → %s failed: %s", j.TemplateKey, j.Channel, j.UserID, reason)
+	if err := uc.repo.MarkFailed(ctx, j.ID, reason); err != nil {
+		log.Printf("[NOTIFY] mark failed %s: %v", j.ID, err)
+	}
+	return domain.NotificationFailed
+}
+
+func (uc *notificationUseCase) skip(ctx context.Context, j domain.NotificationJob, reason string) string {
+	if err := uc.repo.MarkSkipped(ctx, j.ID, reason); err != nil {
+		log.Printf("[NOTIFY] mark skipped %s: %v", j.ID, err)
+	}
+	return domain.NotificationSkipped
+}
+
+func (uc *notificationUseCase) RegisterDevice(ctx context.Context, userID, platform, token string) error {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	token = strings.TrimSpace(token)
+	if platform != notify.PlatformFCM && platform != notify.PlatformAPNs {
+		return fmt.Errorf("%w: platform must be fcm or apns", ErrInvalidDevice)
+	}
+	if token == "" || len(token) > 4096 {
+		return fmt.Errorf("%w: token is required", ErrInvalidDevice)
+	}
+	return uc.repo.UpsertDevice(ctx, domain.PushDevice{Token: token, UserID: userID, Platform: platform})
+}
+
+func (uc *notificationUseCase) RemoveDevice(ctx context.Context, userID, token string) error {
+	return uc.repo.DeleteDevice(ctx, userID, strings.TrimSpace(token))
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/notify"
+)
+
+// fakeNotificationRepo отдаёт заранее заданные задания и запоминает итог каждой.
+type fakeNotificationRepo struct {
+	jobs    []domain.NotificationJob
+	status  map[string]string
+	reason  map[string]string
+	retryAt map[string]time.Time
+}
+
+func newFakeNotificationRepo(jobs ...domain.NotificationJob) *fakeNotificationRepo {
+	return &fakeNotificationRepo{
+		jobs:    jobs,
+		status:  map[string]string{},
+		reason:  map[string]string{},
+		retryAt: map[string]time.Time{},
+	}
+}
+
+func (r *fakeNotificationRepo) Enqueue(context.Context, []domain.Notification) error { return nil }
+
+func (r *fakeNotificationRepo) Claim(context.Context, int, time.Duration) ([]domain.NotificationJob, error) {
+	jobs := r.jobs
+	r.jobs = nil
+	return jobs, nil
+}
+
+func (r *fakeNotificationRepo) MarkSent(_ context.Context, id, _, _ string) error {
+	r.status[id] = domain.NotificationSent
+	return nil
+}
+
+func (r *fakeNotificationRepo) MarkSkipped(_ context.Context, id, reason string) error {
+	r.status[id], r.reason[id] = domain.NotificationSkipped, reason
+	return nil
+}
+
+func (r *fakeNotificationRepo) MarkFailed(_ context.Context, id, reason string) error {
+	r.status[id], r.reason[id] = domain.NotificationFailed, reason
+	return nil
+}
+
+func (r *fakeNotificationRepo) Retry(_ context.Context, id, reason string, at time.Time) error {
+	r.status[id], r.reason[id], r.retryAt[id] = domain.NotificationQueued, reason, at
+	return nil
+}
+
+func (r *fakeNotificationRepo) UpsertDevice(context.Context, domain.PushDevice) error { return nil }
+
+func (r *fakeNotificationRepo) DeleteDevice(context.Context, string, string) error { return nil }
+
+// rejectingChannel — провайдер, отвергающий получателя.
+type rejectingChannel struct{}
+
+func (rejectingChannel) Name() string { return notify.ChannelEmail }
+
+func (rejectingChannel) Send(context.Context, notify.Message) error {
+	return notify.Permanent(errors.New("mailbox does not exist"))
+}
+
+func notificationJob(id, templateKey string, attempts int, payload map[string]any) domain.NotificationJob {
+	return domain.NotificationJob{
+		Notification: domain.Notification{
+			ID: id, UserID: "user-1", Channel: notify.ChannelInApp, TemplateKey: templateKey,
+			Payload: payload, Attempts: attempts,
+		},
+		Locale: "ru",
+		Prefs:  domain.NotificationPrefs{Lessons: true, Messages: true, Reminders: true},
+	}
+}
+
+func TestDispatchRetriesTemporaryFailure(t *testing.T) {
+	ctx := context.Background()
+	inapp := notify.NewFakeChannel(notify.ChannelInApp)
+	inapp.FailNext(1)
+	repo := newFakeNotificationRepo(notificationJob("n1", "admin.test", 1, nil))
+	uc := NewNotificationUseCase(repo, notify.NewRegistry(inapp), NotificationOptions{MaxAttempts: 3, Backoff: time.Minute})
+
+	before := time.Now()
+	sent, failed, err := uc.Dispatch(ctx)
+	if err != nil || sent != 0 || failed != 0 {
+		t.Fatalf("first pass: sent=%d failed=%d err=%v, want 0/0/nil", sent, failed, err)
+	}
+	if repo.status["n1"] != domain.NotificationQueued {
+		t.Fatalf("first pass: status %q, want queued", repo.status["n1"])
+	}
+	if at := repo.retryAt["n1"]; at.Before(before.Add(time.Minute)) || at.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("first pass: retry at %v, want about a minute from now", at)
+	}
+
+	repo.jobs = []domain.NotificationJob{notificationJob("n1", "admin.test", 2, nil)}
+	sent, failed, err = uc.Dispatch(ctx)
+	if err != nil || sent != 1 || failed != 0 {
+		t.Fatalf("second pass: sent=%d failed=%d err=%v, want 1/0/nil", sent, failed, err)
+	}
+	if repo.status["n1"] != domain.NotificationSent || len(inapp.Sent()) != 1 {
+		t.Fatalf("second pass: status %q, delivered %d", repo.status["n1"], len(inapp.Sent()))
+	}
+}
+
+func TestDispatchFailsAfterMaxAttempts(t *testing.T) {
+	inapp := notify.NewFakeChannel(notify.ChannelInApp)
+	inapp.FailNext(1)
+	repo := newFakeNotificationRepo(notificationJob("n1", "admin.test", 3, nil))
+	uc := NewNotificationUseCase(repo, notify.NewRegistry(inapp), NotificationOptions{MaxAttempts: 3})
+
+	sent, failed, err := uc.Dispatch(context.Background())
+	if err != nil || sent != 0 || failed != 1 {
+		t.Fatalf("sent=%d failed=%d err=%v, want 0/1/nil", sent, failed, err)
+	}
+	if repo.status["n1"] != domain.NotificationFailed {
+		t.Fatalf("status %q, want failed", repo.status["n1"])
+	}
+}
+
+func TestDispatchPermanentFailure(t *testing.T) {
+	job := notificationJob("n1", "admin.test", 1, nil)
+	job.Channel = notify.ChannelEmail
+	repo := newFakeNotificationRepo(job)
+	uc := NewNotificationUseCase(repo, notify.NewRegistry(rejectingChannel{}), NotificationOptions{MaxAttempts: 5})
+
+	sent, failed, err := uc.Dispatch(context.Background())
+	if err != nil || sent != 0 || failed != 1 {
+		t.Fatalf("sent=%d failed=%d err=%v, want 0/1/nil", sent, failed, err)
+	}
+	if repo.status["n1"] != domain.NotificationFailed || !strings.Contains(repo.reason["n1"], "mailbox does not exist") {
+		t.Fatalf("status %q reason %q, want failed with provider error", repo.status["n1"], repo.reason["n1"])
+	}
+	if _, retried := repo.retryAt["n1"]; retried {
+		t.Fatalf("permanent failure was scheduled for retry")
+	}
+}
+
+func TestDispatchRespectsPreferences(t *testing.T) {
+	off := domain.NotificationPrefs{}
+	lesson := notificationJob("lesson", "booking.confirmed", 1, nil)
+	lesson.Prefs = off
+	payout := notificationJob("payout", "payout.paid", 1, map[string]any{"amount": "1500.00", "currency": "KZT"})
+	payout.Prefs = off
+	inapp := notify.NewFakeChannel(notify.ChannelInApp)
+	repo := newFakeNotificationRepo(lesson, payout)
+	uc := NewNotificationUseCase(repo, notify.NewRegistry(inapp), NotificationOptions{})
+
+	sent, failed, err := uc.Dispatch(context.Background())
+	if err != nil || sent != 1 || failed != 0 {
+		t.Fatalf("sent=%d failed=%d err=%v, want 1/0/nil", sent, failed, err)
+	}
+	if repo.status["lesson"] != domain.NotificationSkipped {
+		t.Fatalf("lesson: status %q, want skipped", repo.status["lesson"])
+	}
+	// системные уведомления настройками не отключаются
+	if repo.status["payout"] != domain.NotificationSent {
+		t.Fatalf("payout: status %q, want sent", repo.status["payout"])
+	}
+	if got := inapp.Sent(); len(got) != 1 || got[0].ID != "payout" {
+		t.Fatalf("delivered %+v, want only payout", got)
+	}
+}
+
+func TestDispatchFillsLessonPayload(t *testing.T) {
+	job := notificationJob("n1", "booking.confirmed", 1, map[string]any{
+		"startsAt": "2030-01-10T09:00:00Z",
+		"timezone": "Europe/Moscow",
+		"peerName": "",
+		"role":     "student",
+		"subject":  "Математика",
+	})
+	inapp := notify.NewFakeChannel(notify.ChannelInApp)
+	repo := newFakeNotificationRepo(job)
+	uc := NewNotificationUseCase(repo, notify.NewRegistry(inapp), NotificationOptions{DefaultTimezone: "UTC"})
+
+	if _, _, err := uc.Dispatch(context.Background()); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	got := inapp.Sent()
+	if len(got) != 1 {
+		t.Fatalf("delivered %d messages, want 1", len(got))
+	}
+	want := "Урок «Математика» с репетитором подтверждён на 10.01.2030 12:00 (Europe/Moscow)."
+	if got[0].Body != want {
+		t.Fatalf("body %q, want %q", got[0].Body, want)
+	}
+}
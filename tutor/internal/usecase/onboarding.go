@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"tutor/internal/domain"
+)
+
+// Минимальные требования к анкете перед публикацией
+const (
+	minBioLength = 50
+)
+
+// ErrDraftIncomplete — Complete вызван до того, как обязательные шаги валидны.
+type ErrDraftIncomplete struct {
+	Steps []domain.WizardStep
+}
+
+func (e *ErrDraftIncomplete) Error() string {
+	keys := make([]string, 0, len(e.Steps))
+	for _, s := range e.Steps {
+		if s.Required && !s.Valid {
+			keys = append(keys, s.Key)
+		}
+	}
+	return fmt.Sprintf("onboarding incomplete: %s", strings.Join(keys, ", "))
+}
+
+// validateDraft проставляет состояние каждого шага мастера и CanComplete.
+func validateDraft(d *domain.TutorDraft) {
+	p := d.Profile
+	steps := []domain.WizardStep{
+		step(domain.StepAbout, true, validateAbout(p)),
+		step(domain.StepAvailability, true, validateAvailability(p, d.Availability)),
+		step(domain.StepEducation, true, validateEducation(p)),
+		step(domain.StepSubjects, true, validateSubjects(p)),
+		videoStep(p),
+	}
+
+	canComplete := true
+	for _, s := range steps {
+		if s.Required && !s.Valid {
+			canComplete = false
+		}
+	}
+	steps = append(steps, domain.WizardStep{Key: domain.StepComplete, Required: true, Valid: d.CompletedAt != nil})
+
+	d.Steps = steps
+	d.CanComplete = canComplete
+}
+
+func step(key string, required bool, errs []domain.StepError) domain.WizardStep {
+	return domain.WizardStep{Key: key, Required: required, Valid: len(errs) == 0, Errors: errs}
+}
+
+func required(field, msg string) domain.StepError {
+	return domain.StepError{Field: field, Code: "REQUIRED", Message: msg}
+}
+func invalid(field, msg string) domain.StepError {
+	return domain.StepError{Field: field, Code: "INVALID", Message: msg}
+}
+
+func validateAbout(p domain.TutorProfile) []domain.StepError {
+	var errs []domain.StepError
+	if strings.TrimSpace(p.FirstName) == "" {
+		errs = append(errs, required("firstName", "first name is required"))
+	}
+	if strings.TrimSpace(p.LastName) == "" {
+		errs = append(errs, required("lastName", "last name is required"))
+	}
+	if len(p.Languages) == 0 {
+		errs = append(errs, required("languages", "at least one language is required"))
+	}
+	return errs
+}
+
+func validateAvailability(p domain.TutorProfile, days []domain.AvailabilityDay) []domain.StepError {
+	var errs []domain.StepError
+	slots := 0
+	for _, d := range days {
+		slots += len(d.Slots)
+	}
+	if slots == 0 {
+		errs = append(errs, required("days", "at least one availability slot is required"))
+	}
+	if strings.TrimSpace(p.Timezone) == "" {
+		errs = append(errs, required("timezone", "timezone is required"))
+	} else if _, err := time.LoadLocation(p.Timezone); err != nil {
+		errs = append(errs, invalid("timezone", "unknown timezone"))
+	}
+	return errs
+}
+
+func validateEducation(p domain.TutorProfile) []domain.StepError {
+	var errs []domain.StepError
+	bio := strings.TrimSpace(p.Bio)
+	if bio == "" {
+		errs = append(errs, required("description", "bio is required"))
+	} else if len([]rune(bio)) < minBioLength {
+		errs = append(errs, invalid("description", fmt.Sprintf("bio must be at least %d characters", minBioLength)))
+	}
+	for i, e := range p.Education {
+		if strings.TrimSpace(e.Institution) == "" {
+			errs = append(errs, required(fmt.Sprintf("education[%d].institution", i), "institution is required"))
+		}
+		if strings.TrimSpace(e.Degree) == "" {
+			errs = append(errs, required(fmt.Sprintf("education[%d].degree", i), "degree is required"))
+		}
+	}
+	for i, c := range p.Certificates {
+		if strings.TrimSpace(c.Name) == "" {
+			errs = append(errs, required(fmt.Sprintf("certificates[%d].name", i), "certificate name is required"))
+		}
+	}
+	return errs
+}
+
+func validateSubjects(p domain.TutorProfile) []domain.StepError {
+	if len(p.Subjects) == 0 {
+		return []domain.StepError{required("items", "at least one subject is required")}
+	}
+	for _, s := range p.Subjects {
+		if s.PriceMinor > 0 {
+			return nil
+		}
+	}
+	return []domain.StepError{required("items.priceMinor", "at least one subject must have a price")}
+}
+
+// videoStep — необязательный шаг: пустое видео не ошибка, но и не "valid".
+func videoStep(p domain.TutorProfile) domain.WizardStep {
+	st := domain.WizardStep{Key: domain.StepVideo}
+	if strings.TrimSpace(p.VideoURL) == "" {
+		return st
+	}
+	u, err := url.Parse(p.VideoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		st.Errors = []domain.StepError{invalid("videoUrl", "video URL must be an absolute http(s) URL")}
+		return st
+	}
+	st.Valid = true
+	return st
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/events"
+	"tutor/internal/repository"
+)
+
+type OutboxOptions struct {
+	MaxAttempts int           // после стольких попыток событие уходит в dead letter (status=failed)
+	Backoff     time.Duration // пауза перед 2-й попыткой, дальше удваивается (до часа)
+	Lease       time.Duration
+	BatchSize   int
+}
+
+type OutboxUseCase interface {
+	// Relay — один проход: публикует готовые события в брокер с соблюдением порядка агрегата.
+	Relay(ctx context.Context) (sent, dead int, err error)
+	DeadLetters(ctx context.Context, eventType string, page, limit int) ([]domain.OutboxEvent, *domain.Pagination, error)
+	Requeue(ctx context.Context, id string) (*domain.OutboxEvent, error)
+}
+
+type outboxUseCase struct {
+	repo      repository.OutboxRepository
+	publisher events.Publisher
+	opts      OutboxOptions
+}
+
+func NewOutboxUseCase(repo repository.OutboxRepository, publisher events.Publisher, opts OutboxOptions) OutboxUseCase {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 5 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	return &outboxUseCase{repo: repo, publisher: publisher, opts: opts}
+}
+
+func (uc *outboxUseCase) Relay(ctx context.Context) (int, int, error) {
+	sent, dead := 0, 0
+	// за проход уходит только голова каждого агрегата — крутим, пока есть что брать
+	for {
+		list, err := uc.repo.Claim(ctx, uc.opts.BatchSize, uc.opts.Lease)
+		if err != nil {
+			return sent, dead, err
+		}
+		progressed := false
+		for _, e := range list {
+			switch uc.publish(ctx, e) {
+			case domain.OutboxSent:
+				sent++
+				progressed = true
+			case domain.OutboxFailed:
+				dead++
+				progressed = true
+			}
+		}
+		if !progressed || ctx.Err() != nil {
+			return sent, dead, nil
+		}
+	}
+}
+
+func (uc *outboxUseCase) publish(ctx context.Context, e domain.OutboxEvent) string {
+	pubCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	err := uc.publisher.Publish(pubCtx, events.Event{
+		ID: e.ID, Aggregate: e.Aggregate, AggregateID: e.AggregateID,
+		Type: e.EventType, Payload: e.Payload, OccurredAt: e.CreatedAt,
+	})
+	cancel()
+	if err == nil {
+		if err := uc.repo.MarkSent(ctx, e.ID); err != nil {
+			log.Printf("[OUTBOX] mark sent %s: %v", e.ID, err)
+		}
+		return domain.OutboxSent
+	}
+	if e.Attempts >= uc.opts.MaxAttempts {
+		log.Printf("[OUTBOX] %s %s dead-lettered after %d attempts: %v", e.EventType, e.ID, e.Attempts, err)
+		if err := uc.repo.DeadLetter(ctx, e.ID, err.Error()); err != nil {
+			log.Printf("[OUTBOX] dead letter %s: %v", e.ID, err)
+		}
+		return domain.OutboxFailed
+	}
+	d := uc.opts.Backoff
+	for i := 1; i < e.Attempts && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	if err := uc.repo.Retry(ctx, e.ID, err.Error(), time.Now().Add(d)); err != nil {
+		log.Printf("[OUTBOX] retry %s: %v", e.ID, err)
+	}
+	return domain.OutboxPending
+}
+
+func (uc *outboxUseCase) DeadLetters(ctx context.Context, eventType string, page, limit int) ([]domain.OutboxEvent, *domain.Pagination, error) {
+	list, total, err := uc.repo.ListDead(ctx, eventType, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *outboxUseCase) Requeue(ctx context.Context, id string) (*domain.OutboxEvent, error) {
+	return uc.repo.Requeue(ctx, id)
+}
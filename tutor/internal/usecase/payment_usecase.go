@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"tutor/internal/domain"
+	"tutor/internal/payment"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrForbidden     = errors.New("forbidden")
+	ErrPaymentState  = errors.New("payment is not in a valid state for this operation")
+	ErrRefundAmount  = errors.New("refund amount must be positive and not exceed the payment")
+	ErrBookingClosed = errors.New("booking cannot be paid in its current status")
+	// ErrWebhookMismatch — сумма или валюта события не совпадает с платежом.
+	ErrWebhookMismatch = errors.New("webhook amount or currency does not match the payment")
+)
+
+type PaymentUseCase interface {
+	// CreateForBooking создаёт платёж по брони студента; повтор с тем же ключом вернёт тот же платёж.
+	CreateForBooking(ctx context.Context, studentID, bookingID, idempotencyKey string) (*domain.Payment, error)
+	Get(ctx context.Context, userID, role, paymentID string) (*domain.Payment, error)
+	// GetByIntent — платёж студента по интенту провайдера; чужой платёж — ErrForbidden.
+	GetByIntent(ctx context.Context, studentID, provider, intentID string) (*domain.Payment, error)
+	// HandleWebhook проверяет подпись провайдера и применяет событие к платежу/брони.
+	HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) error
+
+	Capture(ctx context.Context, paymentID string) (*domain.Payment, error)
+	Void(ctx context.Context, paymentID string) (*domain.Payment, error)
+	// Refund — amountMinor=0 означает полный возврат.
+	Refund(ctx context.Context, paymentID string, amountMinor int64) (*domain.PaymentRefund, error)
+}
+
+type paymentUseCase struct {
+	repo      repository.PaymentRepository
+	providers payment.Registry
+	provider  string // провайдер для новых платежей
+}
+
+func NewPaymentUseCase(repo repository.PaymentRepository, providers payment.Registry, defaultProvider string) PaymentUseCase {
+	return &paymentUseCase{repo: repo, providers: providers, provider: defaultProvider}
+}
+
+func (uc *paymentUseCase) CreateForBooking(ctx context.Context, studentID, bookingID, idempotencyKey string) (*domain.Payment, error) {
+	if idempotencyKey != "" {
+		p, err := uc.repo.FindByIdempotencyKey(ctx, idempotencyKey)
+		if err == nil {
+			if p.StudentID != studentID || p.BookingID != bookingID {
+				return nil, repository.ErrDuplicateIdempotency
+			}
+			return p, nil
+		}
+		if !errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, err
+		}
+	}
+
+	b, err := uc.repo.FindBooking(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b.StudentID != studentID {
+		return nil, ErrForbidden
+	}
+	if b.Status != domain.BookingPending && b.Status != domain.BookingAwaitingPayment {
+		return nil, fmt.Errorf("%w: %s", ErrBookingClosed, b.Status)
+	}
+	// незавершённый платёж по брони переиспользуем, а не плодим интенты
+	if p, err := uc.repo.FindActiveForBooking(ctx, bookingID); err == nil {
+		return p, nil
+	} else if !errors.Is(err, repository.ErrPaymentNotFound) {
+		return nil, err
+	}
+
+	price, err := uc.repo.BookingPrice(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	prov, err := uc.providers.Get(uc.provider)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &domain.Payment{
+		BookingID:   b.ID,
+		StudentID:   studentID,
+		AmountMinor: price.AmountMinor,
+		Currency:    price.Currency,
+		Provider:    prov.Name(),
+	}
+	if err := uc.repo.CreatePayment(ctx, p, idempotencyKey); err != nil {
+		if errors.Is(err, repository.ErrActivePayment) {
+			return p, nil
+		}
+		if errors.Is(err, repository.ErrDuplicateIdempotency) {
+			// параллельный запрос с тем же ключом успел первым
+			return uc.repo.FindByIdempotencyKey(ctx, idempotencyKey)
+		}
+		if errors.Is(err, repository.ErrPaymentTransition) {
+			return nil, fmt.Errorf("%w: %v", ErrBookingClosed, err)
+		}
+		return nil, err
+	}
+
+	intent, err := prov.CreateIntent(ctx, payment.IntentRequest{
+		PaymentID:      p.ID,
+		BookingID:      b.ID,
+		AmountMinor:    p.AmountMinor,
+		Currency:       p.Currency,
+		Description:    "Lesson " + b.StartsAt.UTC().Format("2006-01-02 15:04") + " UTC",
+		IdempotencyKey: "payment-" + p.ID,
+	})
+	if err != nil {
+		if _, aerr := uc.repo.ApplyStatus(ctx, p.ID, domain.PaymentFailed, "", nil); aerr != nil {
+			log.Printf("[PAYMENT] mark failed %s: %v", p.ID, aerr)
+		}
+		return nil, fmt.Errorf("create intent: %w", err)
+	}
+	payload := map[string]any{"intent": rawOrNil(intent.Raw)}
+	if intent.ClientSecret != "" {
+		payload["client_secret"] = intent.ClientSecret
+	}
+	if intent.RedirectURL != "" {
+		payload["redirect_url"] = intent.RedirectURL
+	}
+	if err := uc.repo.AttachIntent(ctx, p.ID, intent.ID, domain.PaymentRequiresAction, payload); err != nil {
+		return nil, err
+	}
+	p.ProviderIntentID, p.ClientSecret, p.RedirectURL = intent.ID, intent.ClientSecret, intent.RedirectURL
+
+	// провайдер мог сразу вернуть финальный статус (без 3DS/редиректа)
+	if intent.Status != domain.PaymentRequiresAction {
+		return uc.repo.ApplyStatus(ctx, p.ID, intent.Status, "", intent.Raw)
+	}
+	return p, nil
+}
+
+func (uc *paymentUseCase) Get(ctx context.Context, userID, role, paymentID string) (*domain.Payment, error) {
+	p, err := uc.repo.FindPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && p.StudentID != userID {
+		return nil, ErrForbidden
+	}
+	return p, nil
+}
+
+func (uc *paymentUseCase) GetByIntent(ctx context.Context, studentID, provider, intentID string) (*domain.Payment, error) {
+	p, err := uc.repo.FindByIntent(ctx, provider, intentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.StudentID != studentID {
+		return nil, ErrForbidden
+	}
+	return p, nil
+}
+
+func (uc *paymentUseCase) HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) error {
+	prov, err := uc.providers.Get(provider)
+	if err != nil {
+		return err
+	}
+	ev, err := prov.ParseWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+	p, err := uc.repo.FindByIntent(ctx, prov.Name(), ev.IntentID)
+	if err != nil {
+		return err
+	}
+	if ev.AmountMinor != p.AmountMinor || !strings.EqualFold(ev.Currency, p.Currency) {
+		log.Printf("[PAYMENT] webhook %s for %s: %d %s, payment is %d %s",
+			ev.ID, p.ID, ev.AmountMinor, ev.Currency, p.AmountMinor, p.Currency)
+		return fmt.Errorf("%w: event %s", ErrWebhookMismatch, ev.ID)
+	}
+	p, err = uc.repo.ApplyStatus(ctx, p.ID, ev.Status, ev.ID, ev.Raw)
+	if err != nil {
+		return err
+	}
+	// авторизация пришла, когда бронь уже отменена или истекла — деньги не держим
+	if p.Status == domain.PaymentAuthorized && p.BookingID != "" {
+		b, err := uc.repo.FindBooking(ctx, p.BookingID)
+		if err != nil {
+			return err
+		}
+		if b.Status != domain.BookingConfirmed {
+			log.Printf("[PAYMENT] late authorization %s for booking %s (%s): voiding", p.ID, b.ID, b.Status)
+			if _, err := uc.Void(ctx, p.ID); err != nil {
+				return fmt.Errorf("void late authorization: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (uc *paymentUseCase) Capture(ctx context.Context, paymentID string) (*domain.Payment, error) {
+	p, prov, err := uc.load(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.PaymentAuthorized {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentState, p.Status)
+	}
+	in, err := prov.Capture(ctx, p.ProviderIntentID, p.AmountMinor)
+	if err != nil {
+		return nil, fmt.Errorf("capture: %w", err)
+	}
+	return uc.repo.ApplyStatus(ctx, p.ID, domain.PaymentCaptured, "", in.Raw)
+}
+
+func (uc *paymentUseCase) Void(ctx context.Context, paymentID string) (*domain.Payment, error) {
+	p, prov, err := uc.load(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.PaymentAuthorized && p.Status != domain.PaymentRequiresAction {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentState, p.Status)
+	}
+	in, err := prov.Void(ctx, p.ProviderIntentID)
+	if err != nil {
+		return nil, fmt.Errorf("void: %w", err)
+	}
+	return uc.repo.ApplyStatus(ctx, p.ID, domain.PaymentVoided, "", in.Raw)
+}
+
+func (uc *paymentUseCase) Refund(ctx context.Context, paymentID string, amountMinor int64) (*domain.PaymentRefund, error) {
+	p, prov, err := uc.load(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.PaymentCaptured {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentState, p.Status)
+	}
+	// сумма занимается pending-возвратом до похода к провайдеру: параллельный
+	// возврат увидит её в остатке и не превысит платёж
+	rf, full, err := uc.repo.ReserveRefund(ctx, p.ID, amountMinor)
+	switch {
+	case errors.Is(err, repository.ErrRefundExceeds):
+		return nil, fmt.Errorf("%w: %v", ErrRefundAmount, err)
+	case errors.Is(err, repository.ErrPaymentTransition):
+		return nil, fmt.Errorf("%w: %v", ErrPaymentState, err)
+	case err != nil:
+		return nil, err
+	}
+	res, err := prov.Refund(ctx, p.ProviderIntentID, rf.AmountMinor, "refund-"+rf.ID)
+	if err != nil {
+		if _, ferr := uc.repo.FinishRefund(ctx, rf.ID, "failed", nil); ferr != nil {
+			log.Printf("[PAYMENT] mark refund %s failed: %v", rf.ID, ferr)
+		}
+		return nil, fmt.Errorf("refund: %w", err)
+	}
+	out, err := uc.repo.FinishRefund(ctx, rf.ID, res.Status, res.Raw)
+	if err != nil {
+		return nil, err
+	}
+	if out.Status == "succeeded" && full {
+		if _, err := uc.repo.ApplyStatus(ctx, p.ID, domain.PaymentRefunded, "", res.Raw); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (uc *paymentUseCase) load(ctx context.Context, paymentID string) (*domain.Payment, payment.Provider, error) {
+	p, err := uc.repo.FindPayment(ctx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	prov, err := uc.providers.Get(p.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.ProviderIntentID == "" {
+		return nil, nil, fmt.Errorf("%w: no provider intent", ErrPaymentState)
+	}
+	return p, prov, nil
+}
+
+func rawOrNil(b []byte) any {
+	if !json.Valid(b) {
+		return nil
+	}
+	return json.RawMessage(b)
+}
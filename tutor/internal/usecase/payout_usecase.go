@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/payout"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrBelowMinPayout = errors.New("payout amount is below the minimum")
+	ErrInvalidPayout  = errors.New("invalid payout request")
+	// ErrNoPayoutProvider — провайдер выплат не настроен: одобрять и отправлять нечем.
+	ErrNoPayoutProvider = errors.New("payout provider is not configured")
+)
+
+type PayoutOptions struct {
+	MinAmountMinor int64         // минимальная сумма выплаты (в minor units любой валюты)
+	Hold           time.Duration // заработок моложе hold нельзя вывести
+	BatchSize      int
+}
+
+type PayoutRequest struct {
+	AmountMinor int64          `json:"amountMinor"`
+	Currency    string         `json:"currency"`
+	Method      string         `json:"method"`
+	Destination map[string]any `json:"destination"`
+}
+
+type PayoutUseCase interface {
+	Balance(ctx context.Context, tutorID, role, currency string) (domain.PayoutBalance, error)
+	Request(ctx context.Context, tutorID, role string, req PayoutRequest, idempotencyKey string) (*domain.Payout, error)
+	ListMine(ctx context.Context, tutorID, status string, page, limit int) ([]domain.Payout, *domain.Pagination, error)
+	// Cancel — репетитор отменяет свою выплату до отправки провайдеру (админ — любую).
+	Cancel(ctx context.Context, userID, role, payoutID string) (*domain.Payout, error)
+
+	List(ctx context.Context, status, ownerID string, page, limit int) ([]domain.Payout, *domain.Pagination, error)
+	ApproveBatch(ctx context.Context, adminID string, ids []string) ([]string, error)
+	// Process — один проход воркера: отправка одобренных и опрос зависших в processing.
+	Process(ctx context.Context) (sent, finished int, err error)
+}
+
+type payoutUseCase struct {
+	repo     repository.PayoutRepository
+	provider payout.Provider
+	opts     PayoutOptions
+}
+
+// NewPayoutUseCase; provider nil — провайдер не настроен, выплаты только копятся в requested.
+func NewPayoutUseCase(repo repository.PayoutRepository, provider payout.Provider, opts PayoutOptions) PayoutUseCase {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	return &payoutUseCase{repo: repo, provider: provider, opts: opts}
+}
+
+func (uc *payoutUseCase) Balance(ctx context.Context, tutorID, role, currency string) (domain.PayoutBalance, error) {
+	if role != domain.OwnerTutor {
+		return domain.PayoutBalance{}, ErrForbidden
+	}
+	currency = domain.NormalizeCurrency(currency)
+	if !domain.IsValidCurrency(currency) {
+		return domain.PayoutBalance{}, ErrInvalidCurrency
+	}
+	b, err := uc.repo.Balance(ctx, domain.OwnerTutor, tutorID, currency, uc.opts.Hold)
+	b.MinPayoutMinor = uc.opts.MinAmountMinor
+	return b, err
+}
+
+func (uc *payoutUseCase) Request(ctx context.Context, tutorID, role string, req PayoutRequest, idempotencyKey string) (*domain.Payout, error) {
+	if role != domain.OwnerTutor {
+		return nil, ErrForbidden
+	}
+	req.Currency = domain.NormalizeCurrency(req.Currency)
+	req.Method = strings.ToLower(strings.TrimSpace(req.Method))
+	if !domain.IsValidCurrency(req.Currency) {
+		return nil, ErrInvalidCurrency
+	}
+	if !domain.PayoutMethods[req.Method] {
+		return nil, fmt.Errorf("%w: unsupported method %q", ErrInvalidPayout, req.Method)
+	}
+	if len(req.Destination) == 0 {
+		return nil, fmt.Errorf("%w: destination is required", ErrInvalidPayout)
+	}
+	if req.AmountMinor < uc.opts.MinAmountMinor || req.AmountMinor <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrBelowMinPayout, uc.opts.MinAmountMinor)
+	}
+	// повтор с тем же Idempotency-Key возвращает уже созданную выплату
+	if idempotencyKey != "" {
+		p, err := uc.repo.FindByIdempotencyKey(ctx, idempotencyKey)
+		if err == nil {
+			if p.OwnerID != tutorID {
+				return nil, repository.ErrDuplicateIdempotency
+			}
+			return p, nil
+		}
+		if !errors.Is(err, repository.ErrPayoutNotFound) {
+			return nil, err
+		}
+	}
+
+	p := &domain.Payout{
+		OwnerType:   domain.OwnerTutor,
+		OwnerID:     tutorID,
+		AmountMinor: req.AmountMinor,
+		Currency:    req.Currency,
+		Method:      req.Method,
+		Destination: req.Destination,
+		RequestedBy: tutorID,
+	}
+	if err := uc.repo.Create(ctx, p, idempotencyKey, uc.opts.Hold); err != nil {
+		if errors.Is(err, repository.ErrDuplicateIdempotency) {
+			// параллельный запрос с тем же ключом успел первым
+			return uc.repo.FindByIdempotencyKey(ctx, idempotencyKey)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (uc *payoutUseCase) ListMine(ctx context.Context, tutorID, status string, page, limit int) ([]domain.Payout, *domain.Pagination, error) {
+	list, total, err := uc.repo.List(ctx, domain.OwnerTutor, tutorID, status, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *payoutUseCase) Cancel(ctx context.Context, userID, role, payoutID string) (*domain.Payout, error) {
+	p, err := uc.repo.FindPayout(ctx, payoutID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && p.OwnerID != userID {
+		return nil, ErrForbidden
+	}
+	return uc.repo.Cancel(ctx, payoutID, userID)
+}
+
+func (uc *payoutUseCase) List(ctx context.Context, status, ownerID string, page, limit int) ([]domain.Payout, *domain.Pagination, error) {
+	list, total, err := uc.repo.List(ctx, "", ownerID, status, page, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, paginate(page, limit, total), nil
+}
+
+func (uc *payoutUseCase) ApproveBatch(ctx context.Context, adminID string, ids []string) ([]string, error) {
+	if uc.provider == nil {
+		return nil, ErrNoPayoutProvider
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: ids are required", ErrInvalidPayout)
+	}
+	return uc.repo.Approve(ctx, ids, adminID)
+}
+
+func (uc *payoutUseCase) Process(ctx context.Context) (int, int, error) {
+	if uc.provider == nil {
+		return 0, 0, ErrNoPayoutProvider
+	}
+	sent, finished := 0, 0
+
+	claimed, err := uc.repo.ClaimApproved(ctx, uc.provider.Name(), uc.opts.BatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, p := range claimed {
+		if ok := uc.send(ctx, p); ok {
+			sent++
+		}
+	}
+
+	// processing без ответа провайдера (упали после claim) отправляем повторно — Send идемпотентен
+	stale, err := uc.repo.ListProcessing(ctx, time.Minute, uc.opts.BatchSize)
+	if err != nil {
+		return sent, finished, err
+	}
+	for _, p := range stale {
+		if p.ProviderRef == "" {
+			uc.send(ctx, p)
+			continue
+		}
+		res, err := uc.provider.Status(ctx, p.ProviderRef)
+		if err != nil {
+			log.Printf("[PAYOUT] status %s: %v", p.ID, err)
+			continue
+		}
+		if uc.finish(ctx, p.ID, res) {
+			finished++
+		}
+	}
+	return sent, finished, nil
+}
+
+func (uc *payoutUseCase) send(ctx context.Context, p domain.Payout) bool {
+	res, err := uc.provider.Send(ctx, payout.Request{
+		PayoutID:    p.ID,
+		AmountMinor: p.AmountMinor,
+		Currency:    p.Currency,
+		Method:      p.Method,
+		Destination: p.Destination,
+	})
+	if err != nil {
+		// остаётся processing без ref — повторим на следующем проходе
+		log.Printf("[PAYOUT] send %s: %v", p.ID, err)
+		return false
+	}
+	if err := uc.repo.SetProviderRef(ctx, p.ID, res.Ref, res.Raw); err != nil {
+		log.Printf("[PAYOUT] save ref %s: %v", p.ID, err)
+		return false
+	}
+	uc.finish(ctx, p.ID, res)
+	return true
+}
+
+func (uc *payoutUseCase) finish(ctx context.Context, id string, res *payout.Result) bool {
+	var status string
+	switch res.Status {
+	case payout.StatusPaid:
+		status = domain.PayoutPaid
+	case payout.StatusFailed:
+		status = domain.PayoutFailed
+	default:
+		return false
+	}
+	if _, err := uc.repo.Finish(ctx, id, status, res.Reason, res.Raw); err != nil {
+		log.Printf("[PAYOUT] finish %s → %s: %v", id, status, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"tutor/internal/domain"
+	"tutor/internal/repository"
+)
+
+var (
+	ErrInvalidPrice    = errors.New("invalid price")
+	ErrInvalidCurrency = errors.New("invalid currency (ISO 4217 expected)")
+	ErrNoFXRate        = errors.New("no fx rate for currency pair")
+)
+
+type PricingUseCase interface {
+	ReplacePrices(ctx context.Context, tutorID string, items []domain.LessonPrice) error
+	// ListPrices — прайс репетитора; displayCurrency (опц.) добавляет пересчёт в валюту студента.
+	ListPrices(ctx context.Context, tutorID, displayCurrency string) ([]domain.LessonPrice, error)
+
+	SetFXRate(ctx context.Context, base, quote string, rate float64) error
+	ListFXRates(ctx context.Context) ([]domain.FXRate, error)
+	// Converter загружает таблицу курсов один раз — для пересчёта целого листинга.
+	Converter(ctx context.Context) (*FXConverter, error)
+}
+
+type pricingUseCase struct {
+	repo repository.PricingRepository
+}
+
+func NewPricingUseCase(repo repository.PricingRepository) PricingUseCase {
+	return &pricingUseCase{repo: repo}
+}
+
+func (uc *pricingUseCase) ReplacePrices(ctx context.Context, tutorID string, items []domain.LessonPrice) error {
+	normalized, err := validatePrices(items)
+	if err != nil {
+		return err
+	}
+	return uc.repo.ReplacePrices(ctx, tutorID, normalized)
+}
+
+func (uc *pricingUseCase) ListPrices(ctx context.Context, tutorID, displayCurrency string) ([]domain.LessonPrice, error) {
+	list, err := uc.repo.ListPrices(ctx, tutorID)
+	if err != nil {
+		return nil, err
+	}
+	annotatePackages(list)
+
+	displayCurrency = strings.ToUpper(strings.TrimSpace(displayCurrency))
+	if displayCurrency == "" {
+		return list, nil
+	}
+	if !domain.IsValidCurrency(displayCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	conv, err := uc.Converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		m, err := conv.Convert(domain.Money{AmountMinor: list[i].PriceMinor, Currency: list[i].Currency}, displayCurrency)
+		if err != nil {
+			// нет курса — отдаём цену в исходной валюте без пересчёта
+			continue
+		}
+		list[i].Display = &m
+	}
+	return list, nil
+}
+
+func (uc *pricingUseCase) SetFXRate(ctx context.Context, base, quote string, rate float64) error {
+	base, quote = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+	if !domain.IsValidCurrency(base) || !domain.IsValidCurrency(quote) {
+		return ErrInvalidCurrency
+	}
+	if base == quote || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return fmt.Errorf("%w: rate must be positive and currencies must differ", ErrInvalidPrice)
+	}
+	return uc.repo.UpsertFXRate(ctx, base, quote, rate)
+}
+
+func (uc *pricingUseCase) ListFXRates(ctx context.Context) ([]domain.FXRate, error) {
+	return uc.repo.ListFXRates(ctx)
+}
+
+func (uc *pricingUseCase) Converter(ctx context.Context) (*FXConverter, error) {
+	rates, err := uc.repo.ListFXRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewFXConverter(rates), nil
+}
+
+// ------------------ FX ------------------
+
+// FXConverter пересчитывает суммы в minor units с учётом разрядности валют.
+// Курс ищется напрямую, через обратную пару или через одну промежуточную валюту.
+type FXConverter struct {
+	graph map[string]map[string]float64
+}
+
+func NewFXConverter(rates []domain.FXRate) *FXConverter {
+	g := map[string]map[string]float64{}
+	put := func(a, b string, r float64) {
+		if g[a] == nil {
+			g[a] = map[string]float64{}
+		}
+		if _, ok := g[a][b]; !ok {
+			g[a][b] = r
+		}
+	}
+	// прямые курсы приоритетнее обратных
+	for _, x := range rates {
+		put(x.Base, x.Quote, x.Rate)
+	}
+	for _, x := range rates {
+		put(x.Quote, x.Base, 1/x.Rate)
+	}
+	return &FXConverter{graph: g}
+}
+
+func (c *FXConverter) rate(from, to string) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	if r, ok := c.graph[from][to]; ok {
+		return r, true
+	}
+	// через промежуточную валюту: сначала базовая валюта платформы, затем по алфавиту —
+	// при нескольких путях курс не зависит от порядка обхода map
+	mids := make([]string, 0, len(c.graph[from]))
+	for mid := range c.graph[from] {
+		if mid != domain.DefaultCurrency {
+			mids = append(mids, mid)
+		}
+	}
+	sort.Strings(mids)
+	if _, ok := c.graph[from][domain.DefaultCurrency]; ok {
+		mids = append([]string{domain.DefaultCurrency}, mids...)
+	}
+	for _, mid := range mids {
+		if r2, ok := c.graph[mid][to]; ok {
+			return c.graph[from][mid] * r2, true
+		}
+	}
+	return 0, false
+}
+
+func (c *FXConverter) Convert(m domain.Money, to string) (domain.Money, error) {
+	from, to := strings.ToUpper(strings.TrimSpace(m.Currency)), strings.ToUpper(strings.TrimSpace(to))
+	if !domain.IsValidCurrency(from) || !domain.IsValidCurrency(to) {
+		return domain.Money{}, fmt.Errorf("%w: %s/%s", ErrInvalidCurrency, from, to)
+	}
+	r, ok := c.rate(from, to)
+	if !ok {
+		return domain.Money{}, fmt.Errorf("%w: %s/%s", ErrNoFXRate, from, to)
+	}
+	major := float64(m.AmountMinor) / math.Pow10(domain.MinorUnits(from))
+	return domain.Money{
+		AmountMinor: int64(math.Round(major * r * math.Pow10(domain.MinorUnits(to)))),
+		Currency:    to,
+	}, nil
+}
+
+// ------------------ validation ------------------
+
+type priceKey struct {
+	target   string
+	duration int
+	count    int
+	trial    bool
+}
+
+func validatePrices(items []domain.LessonPrice) ([]domain.LessonPrice, error) {
+	out := make([]domain.LessonPrice, 0, len(items))
+	seen := map[priceKey]bool{}
+	singles := map[priceKey]domain.LessonPrice{}
+
+	for i, it := range items {
+		it.SubjectID = strings.TrimSpace(it.SubjectID)
+		it.SubjectSlug = strings.ToLower(strings.TrimSpace(it.SubjectSlug))
+		it.SubdirectionID = strings.TrimSpace(it.SubdirectionID)
+		it.SubdirectionSlug = strings.ToLower(strings.TrimSpace(it.SubdirectionSlug))
+		it.Currency = domain.NormalizeCurrency(it.Currency)
+		if it.LessonsCount == 0 {
+			it.LessonsCount = 1
+		}
+
+		subject := it.SubjectID != "" || it.SubjectSlug != ""
+		subdir := it.SubdirectionID != "" || it.SubdirectionSlug != ""
+		if subject == subdir {
+			return nil, fmt.Errorf("%w: items[%d]: exactly one of subject or subdirection is required", ErrInvalidPrice, i)
+		}
+		if !domain.IsValidDuration(it.DurationMinutes) {
+			return nil, fmt.Errorf("%w: items[%d]: duration must be one of %v minutes", ErrInvalidPrice, i, domain.LessonDurations)
+		}
+		if it.LessonsCount < 1 || it.LessonsCount > domain.MaxPackageLessons {
+			return nil, fmt.Errorf("%w: items[%d]: lessonsCount must be between 1 and %d", ErrInvalidPrice, i, domain.MaxPackageLessons)
+		}
+		if it.Trial && it.LessonsCount != 1 {
+			return nil, fmt.Errorf("%w: items[%d]: trial lesson cannot be a package", ErrInvalidPrice, i)
+		}
+		if it.PriceMinor < 0 || (it.PriceMinor == 0 && !it.Trial) {
+			return nil, fmt.Errorf("%w: items[%d]: price must be positive", ErrInvalidPrice, i)
+		}
+		if !domain.IsValidCurrency(it.Currency) {
+			return nil, fmt.Errorf("%w: items[%d]: %s", ErrInvalidCurrency, i, it.Currency)
+		}
+
+		k := priceKey{target: it.SubjectID + it.SubjectSlug + "|" + it.SubdirectionID + it.SubdirectionSlug,
+			duration: it.DurationMinutes, count: it.LessonsCount, trial: it.Trial}
+		if seen[k] {
+			return nil, fmt.Errorf("%w: items[%d]: duplicate price", ErrInvalidPrice, i)
+		}
+		seen[k] = true
+		if it.LessonsCount == 1 && !it.Trial {
+			singles[k] = it
+		}
+		out = append(out, it)
+	}
+
+	// пакет должен опираться на разовую цену той же длительности и быть не дороже её
+	for i, it := range out {
+		if it.LessonsCount == 1 {
+			continue
+		}
+		k := priceKey{target: it.SubjectID + it.SubjectSlug + "|" + it.SubdirectionID + it.SubdirectionSlug,
+			duration: it.DurationMinutes, count: 1}
+		single, ok := singles[k]
+		if !ok {
+			return nil, fmt.Errorf("%w: items[%d]: package requires a single-lesson price of the same duration", ErrInvalidPrice, i)
+		}
+		if single.Currency != it.Currency {
+			return nil, fmt.Errorf("%w: items[%d]: package currency must match the single-lesson price", ErrInvalidPrice, i)
+		}
+		if it.PriceMinor > single.PriceMinor*int64(it.LessonsCount) {
+			return nil, fmt.Errorf("%w: items[%d]: package is more expensive than %d single lessons", ErrInvalidPrice, i, it.LessonsCount)
+		}
+	}
+	return out, nil
+}
+
+// annotatePackages заполняет цену за занятие и скидку пакета относительно разовой цены.
+func annotatePackages(list []domain.LessonPrice) {
+	singles := map[string]int64{}
+	key := func(p domain.LessonPrice) string {
+		return fmt.Sprintf("%s|%s|%d|%s", p.SubjectID, p.SubdirectionID, p.DurationMinutes, p.Currency)
+	}
+	for _, p := range list {
+		if p.LessonsCount == 1 && !p.Trial {
+			singles[key(p)] = p.PriceMinor
+		}
+	}
+	for i := range list {
+		p := &list[i]
+		p.PerLessonMinor = p.PriceMinor / int64(p.LessonsCount)
+		if single, ok := singles[key(*p)]; ok && p.LessonsCount > 1 && single > 0 {
+			full := single * int64(p.LessonsCount)
+			p.DiscountPercent = int(math.Round(float64(full-p.PriceMinor) * 100 / float64(full)))
+		}
+	}
+}
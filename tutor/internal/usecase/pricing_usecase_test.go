@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"tutor/internal/domain"
+)
+
+func TestFXConverterConvert(t *testing.T) {
+	conv := NewFXConverter([]domain.FXRate{
+		{Base: "USD", Quote: "KZT", Rate: 500},
+		{Base: "EUR", Quote: "USD", Rate: 1.25},
+		{Base: "RUB", Quote: "KZT", Rate: 5},
+		{Base: "RUB", Quote: "USD", Rate: 0.011},
+		{Base: "KZT", Quote: "GBP", Rate: 0.0016},
+		{Base: "USD", Quote: "GBP", Rate: 0.8},
+		{Base: "USD", Quote: "JPY", Rate: 150.4},
+		{Base: "USD", Quote: "KWD", Rate: 0.30745},
+		{Base: "CNY", Quote: "KZT", Rate: 70},
+		{Base: "KZT", Quote: "CNY", Rate: 0.02},
+	})
+	tests := []struct {
+		name string
+		in   domain.Money
+		to   string
+		want domain.Money
+	}{
+		{"same currency", domain.Money{AmountMinor: 12345, Currency: "KZT"}, "KZT", domain.Money{AmountMinor: 12345, Currency: "KZT"}},
+		{"direct", domain.Money{AmountMinor: 1000, Currency: "USD"}, "KZT", domain.Money{AmountMinor: 500000, Currency: "KZT"}},
+		{"inverse", domain.Money{AmountMinor: 100000, Currency: "KZT"}, "USD", domain.Money{AmountMinor: 200, Currency: "USD"}},
+		{"direct wins over inverse", domain.Money{AmountMinor: 100000, Currency: "KZT"}, "CNY", domain.Money{AmountMinor: 2000, Currency: "CNY"}},
+		{"lower case codes", domain.Money{AmountMinor: 100, Currency: "usd"}, " kzt ", domain.Money{AmountMinor: 50000, Currency: "KZT"}},
+		// RUB → GBP есть и через KZT (5 × 0.0016), и через USD (0.011 × 0.8): берётся KZT
+		{"pivot prefers default currency", domain.Money{AmountMinor: 100000, Currency: "RUB"}, "GBP", domain.Money{AmountMinor: 800, Currency: "GBP"}},
+		{"pivot through inverse pair", domain.Money{AmountMinor: 100, Currency: "EUR"}, "KZT", domain.Money{AmountMinor: 62500, Currency: "KZT"}},
+		{"to zero-decimal currency", domain.Money{AmountMinor: 250, Currency: "USD"}, "JPY", domain.Money{AmountMinor: 376, Currency: "JPY"}},
+		{"from zero-decimal currency", domain.Money{AmountMinor: 1504, Currency: "JPY"}, "USD", domain.Money{AmountMinor: 1000, Currency: "USD"}},
+		{"to three-decimal currency", domain.Money{AmountMinor: 10000, Currency: "USD"}, "KWD", domain.Money{AmountMinor: 30745, Currency: "KWD"}},
+		{"rounds fraction down", domain.Money{AmountMinor: 1, Currency: "KZT"}, "USD", domain.Money{AmountMinor: 0, Currency: "USD"}},
+		{"rounds fraction up", domain.Money{AmountMinor: 7, Currency: "JPY"}, "USD", domain.Money{AmountMinor: 5, Currency: "USD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := conv.Convert(tt.in, tt.to)
+			if err != nil {
+				t.Fatalf("Convert(%v, %q): %v", tt.in, tt.to, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Convert(%v, %q) = %v, want %v", tt.in, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFXConverterErrors(t *testing.T) {
+	conv := NewFXConverter([]domain.FXRate{{Base: "USD", Quote: "KZT", Rate: 500}})
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want error
+	}{
+		{"unknown source code", "ABC", "KZT", ErrInvalidCurrency},
+		{"unknown target code", "USD", "XYZ", ErrInvalidCurrency},
+		{"same unknown code", "ABC", "ABC", ErrInvalidCurrency},
+		{"empty code", "", "KZT", ErrInvalidCurrency},
+		{"not a code", "US", "KZT", ErrInvalidCurrency},
+		{"no rate", "EUR", "KZT", ErrNoFXRate},
+		{"no path through pivot", "EUR", "USD", ErrNoFXRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := conv.Convert(domain.Money{AmountMinor: 100, Currency: tt.from}, tt.to)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Convert(%q → %q) error %v, want %v", tt.from, tt.to, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePricesNormalizes(t *testing.T) {
+	got, err := validatePrices([]domain.LessonPrice{
+		{SubjectSlug: " Math ", DurationMinutes: 60, PriceMinor: 500000},
+		{SubjectSlug: "math", DurationMinutes: 60, LessonsCount: 10, PriceMinor: 4500000, Currency: "kzt"},
+		{SubdirectionID: "sd-1", DurationMinutes: 30, Trial: true, Currency: "usd"},
+	})
+	if err != nil {
+		t.Fatalf("validatePrices: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d prices, want 3", len(got))
+	}
+	if got[0].SubjectSlug != "math" || got[0].LessonsCount != 1 || got[0].Currency != domain.DefaultCurrency {
+		t.Fatalf("single price %+v, want slug math, 1 lesson, %s", got[0], domain.DefaultCurrency)
+	}
+	if got[1].Currency != "KZT" {
+		t.Fatalf("package currency %q, want KZT", got[1].Currency)
+	}
+	if got[2].Currency != "USD" || got[2].PriceMinor != 0 {
+		t.Fatalf("free trial %+v, want USD with zero price", got[2])
+	}
+}
+
+func TestValidatePricesRejects(t *testing.T) {
+	single := domain.LessonPrice{SubjectSlug: "math", DurationMinutes: 60, PriceMinor: 500000}
+	tests := []struct {
+		name  string
+		items []domain.LessonPrice
+		want  error
+	}{
+		{"no target", []domain.LessonPrice{{DurationMinutes: 60, PriceMinor: 100}}, ErrInvalidPrice},
+		{"subject and subdirection", []domain.LessonPrice{{SubjectSlug: "math", SubdirectionSlug: "algebra", DurationMinutes: 60, PriceMinor: 100}}, ErrInvalidPrice},
+		{"unsupported duration", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 50, PriceMinor: 100}}, ErrInvalidPrice},
+		{"negative lessons count", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 60, LessonsCount: -1, PriceMinor: 100}}, ErrInvalidPrice},
+		{"package too large", []domain.LessonPrice{single, {SubjectSlug: "math", DurationMinutes: 60, LessonsCount: domain.MaxPackageLessons + 1, PriceMinor: 100}}, ErrInvalidPrice},
+		{"trial package", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 60, LessonsCount: 5, Trial: true, PriceMinor: 100}}, ErrInvalidPrice},
+		{"zero regular price", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 60}}, ErrInvalidPrice},
+		{"negative trial price", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 60, Trial: true, PriceMinor: -1}}, ErrInvalidPrice},
+		{"invalid currency", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 60, PriceMinor: 100, Currency: "ABC"}}, ErrInvalidCurrency},
+		{"duplicate after normalization", []domain.LessonPrice{single, {SubjectSlug: " MATH ", DurationMinutes: 60, PriceMinor: 600000}}, ErrInvalidPrice},
+		{"package without single price", []domain.LessonPrice{{SubjectSlug: "math", DurationMinutes: 60, LessonsCount: 5, PriceMinor: 100}}, ErrInvalidPrice},
+		{"package of another duration", []domain.LessonPrice{single, {SubjectSlug: "math", DurationMinutes: 90, LessonsCount: 5, PriceMinor: 100}}, ErrInvalidPrice},
+		{"package in another currency", []domain.LessonPrice{single, {SubjectSlug: "math", DurationMinutes: 60, LessonsCount: 5, PriceMinor: 100, Currency: "USD"}}, ErrInvalidPrice},
+		{"package dearer than singles", []domain.LessonPrice{single, {SubjectSlug: "math", DurationMinutes: 60, LessonsCount: 5, PriceMinor: 2500001}}, ErrInvalidPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validatePrices(tt.items); !errors.Is(err, tt.want) {
+				t.Fatalf("validatePrices error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
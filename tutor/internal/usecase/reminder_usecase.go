@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"tutor/internal/domain"
+	"tutor/internal/notify"
+	"tutor/internal/repository"
+)
+
+const reminderTemplate = "lesson.reminder"
+
+type ReminderOptions struct {
+	Leads           []time.Duration // за сколько до начала напоминать, например 24h и 15m
+	DefaultTimezone string          // если участник не указал свой
+	BatchSize       int
+}
+
+// ReminderUseCase — планировщик напоминаний об уроках. Флаг Notifications.Reminders
+// проверяет диспетчер уведомлений при отправке (выключенные помечаются skipped).
+type ReminderUseCase interface {
+	// Tick — один проход: ставит в очередь все наступившие напоминания.
+	Tick(ctx context.Context) (int, error)
+}
+
+type reminderUseCase struct {
+	repo   repository.ReminderRepository
+	opts   ReminderOptions
+	defLoc *time.Location
+}
+
+func NewReminderUseCase(repo repository.ReminderRepository, opts ReminderOptions) ReminderUseCase {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 200
+	}
+	loc, err := time.LoadLocation(opts.DefaultTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return &reminderUseCase{repo: repo, opts: opts, defLoc: loc}
+}
+
+func (uc *reminderUseCase) Tick(ctx context.Context) (int, error) {
+	total := 0
+	for _, lead := range uc.opts.Leads {
+		// пачками, пока окно не опустеет: после простоя воркера накопленное уйдёт за один проход
+		for {
+			n, err := uc.repo.Schedule(ctx, lead, uc.opts.BatchSize, uc.build)
+			if err != nil {
+				return total, fmt.Errorf("reminders %s: %w", lead, err)
+			}
+			total += n
+			if n < uc.opts.BatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+func (uc *reminderUseCase) build(rm domain.LessonReminder) []domain.Notification {
+	loc, ok := lessonLocation(rm.Timezone, uc.defLoc)
+	if !ok {
+		log.Printf("[REMINDER] user %s: bad timezone %q", rm.UserID, rm.Timezone)
+	}
+	locale := notify.NormalizeLocale(rm.Locale)
+	peer := rm.PeerName
+	if peer == "" {
+		peer = peerFallback(locale, rm.Role)
+	}
+	payload := map[string]any{
+		"lessonId":      rm.LessonID,
+		"startsAt":      rm.StartsAt.UTC().Format(time.RFC3339),
+		"startsAtLocal": formatLessonTime(rm.StartsAt, loc),
+		"timezone":      loc.String(),
+		"leadTime":      formatLead(locale, rm.Lead),
+		"leadSeconds":   int(rm.Lead.Seconds()),
+		"peerName":      peer,
+		"role":          rm.Role,
+	}
+	tpl, _ := notify.Lookup(reminderTemplate)
+	out := make([]domain.Notification, 0, len(tpl.Channels))
+	for _, ch := range tpl.Channels {
+		out = append(out, domain.Notification{UserID: rm.UserID, Channel: ch, TemplateKey: reminderTemplate, Payload: payload})
+	}
+	return out
+}
+
+// lessonLocation — часовой пояс участника; пустой или неизвестный (ok == false) — def.
+func lessonLocation(tz string, def *time.Location) (loc *time.Location, ok bool) {
+	if tz == "" {
+		return def, true
+	}
+	l, err := time.LoadLocation(tz)
+	if err != nil {
+		return def, false
+	}
+	return l, true
+}
+
+// formatLessonTime — "10.01.2030 15:00 (Asia/Almaty)".
+func formatLessonTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("02.01.2006 15:04") + " (" + loc.String() + ")"
+}
+
+// formatLead — "24 ч" / "24 сағ" / "24 h", некратное часу — в минутах.
+func formatLead(locale string, d time.Duration) string {
+	units := map[string][2]string{
+		"ru": {"ч", "мин"},
+		"kk": {"сағ", "мин"},
+		"en": {"h", "min"},
+	}[locale]
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d %s", int(d.Hours()), units[0])
+	}
+	return fmt.Sprintf("%d %s", int(d.Minutes()), units[1])
+}
+
+// peerFallback — как назвать второго участника, если у него не заполнено имя.
+func peerFallback(locale, role string) string {
+	names := map[string][2]string{ // [для ученика, для репетитора]
+		"ru": {"репетитором", "учеником"},
+		"kk": {"репетитор", "оқушы"},
+		"en": {"your tutor", "your student"},
+	}[locale]
+	if role == "tutor" {
+		return names[1]
+	}
+	return names[0]
+}
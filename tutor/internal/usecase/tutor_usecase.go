@@ -2,20 +2,32 @@ package usecase
 
 import (
 	"context"
-	"math"
+	"errors"
+	"strings"
 
+	"shared/flags"
+	"shared/storage"
 	"tutor/internal/domain"
 	"tutor/internal/repository"
 )
 
+// ErrMixedCurrency — предметы в разных валютах, а для цены поднаправления валюта не указана.
+var (
+	ErrMixedCurrency = errors.New("subjects use different currencies; set a currency for each subdirection price")
+	ErrTutorNotFound = errors.New("tutor not found")
+)
+
 type TutorUseCase interface {
 	// Wizard steps
 	UpsertAbout(ctx context.Context, userID, first, last, phone, gender, avatar string, langs []domain.TutorLanguage) error
 	ReplaceAvailability(ctx context.Context, userID, timezone string, days []domain.AvailabilityDay) error
 	UpsertEducation(ctx context.Context, userID string, bio string, education []domain.Education, certs []domain.Certification) error
-	ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular map[string]int64, trial map[string]int64) error
+	// ReplaceSubjects: currencies — валюта цен поднаправления по slug; без неё берётся общая
+	// валюта предметов.
+	ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular, trial map[string]int64, currencies map[string]string) error
 	SetVideo(ctx context.Context, userID, videoURL string) error
 	Complete(ctx context.Context, userID string) error
+	GetDraft(ctx context.Context, userID string) (*domain.TutorDraft, error)
 
 	// Queries
 	List(ctx context.Context, filters map[string]string, page, limit int) ([]domain.TutorProfile, *domain.Pagination, error)
@@ -23,47 +35,168 @@ type TutorUseCase interface {
 }
 
 type tutorUseCase struct {
-	repo repository.TutorRepository
+	repo           repository.TutorRepository
+	pricing        PricingUseCase
+	hideUnverified bool
+	flags          flags.Client
+	documents      storage.Storage
+	media          MediaUseCase
+}
+
+type Options struct {
+	HideUnverified bool            // каталог показывает только verified-репетиторов
+	Flags          flags.Client    // nil — флаги не проверяются, всё включено
+	Documents      storage.Storage // сканы документов; файлы удалённых записей удаляются отсюда
+	Media          MediaUseCase    // nil — аватар и видео сохраняются как есть, без проверки
 }
 
-func NewTutorUseCase(repo repository.TutorRepository) TutorUseCase {
-	return &tutorUseCase{repo: repo}
+func NewTutorUseCase(repo repository.TutorRepository, pricing PricingUseCase, opts Options) TutorUseCase {
+	return &tutorUseCase{repo: repo, pricing: pricing, hideUnverified: opts.HideUnverified, flags: opts.Flags, documents: opts.Documents, media: opts.Media}
 }
 
 // Steps
 func (uc *tutorUseCase) UpsertAbout(ctx context.Context, userID, first, last, phone, gender, avatar string, langs []domain.TutorLanguage) error {
-	return uc.repo.UpsertAbout(ctx, userID, first, last, phone, gender, avatar, langs)
+	return uc.withMedia(ctx, userID, domain.MediaAvatar, avatar, func(avatar string) error {
+		return uc.repo.UpsertAbout(ctx, userID, first, last, phone, gender, avatar, langs)
+	})
 }
 func (uc *tutorUseCase) ReplaceAvailability(ctx context.Context, userID, tz string, days []domain.AvailabilityDay) error {
 	return uc.repo.ReplaceWeeklyAvailability(ctx, userID, tz, days)
 }
 func (uc *tutorUseCase) UpsertEducation(ctx context.Context, userID string, bio string, education []domain.Education, certs []domain.Certification) error {
-	return uc.repo.UpsertEducation(ctx, userID, bio, education, certs)
+	removed, err := uc.repo.UpsertEducation(ctx, userID, bio, education, certs)
+	if err != nil {
+		return err
+	}
+	dropDocuments(ctx, uc.documents, removed...)
+	return nil
 }
-func (uc *tutorUseCase) ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular map[string]int64, trial map[string]int64) error {
-	return uc.repo.ReplaceSubjects(ctx, userID, items, regular, trial)
+func (uc *tutorUseCase) ReplaceSubjects(ctx context.Context, userID string, items []domain.TutorSubjectDTO, regular, trial map[string]int64, currencies map[string]string) error {
+	common, mixed := "", false
+	for i := range items {
+		cur := domain.NormalizeCurrency(items[i].Currency)
+		if !domain.IsValidCurrency(cur) {
+			return ErrInvalidCurrency
+		}
+		items[i].Currency = cur
+		if items[i].PriceMinor <= 0 {
+			continue
+		}
+		if common == "" {
+			common = cur
+		} else if common != cur {
+			mixed = true
+		}
+	}
+	if common == "" {
+		common = domain.DefaultCurrency
+	}
+	resolved := make(map[string]string, len(regular))
+	for slug := range regular {
+		cur := strings.TrimSpace(currencies[slug])
+		if cur == "" && mixed {
+			return ErrMixedCurrency
+		}
+		if cur == "" {
+			cur = common
+		}
+		cur = domain.NormalizeCurrency(cur)
+		if !domain.IsValidCurrency(cur) {
+			return ErrInvalidCurrency
+		}
+		resolved[slug] = cur
+	}
+	return uc.repo.ReplaceSubjects(ctx, userID, items, regular, trial, resolved)
 }
 func (uc *tutorUseCase) SetVideo(ctx context.Context, userID, videoURL string) error {
-	return uc.repo.SetVideo(ctx, userID, videoURL)
+	return uc.withMedia(ctx, userID, domain.MediaVideo, videoURL, func(videoURL string) error {
+		return uc.repo.SetVideo(ctx, userID, videoURL)
+	})
+}
+
+// withMedia проверяет загруженный файл перед сохранением шага и удаляет заменённый после.
+func (uc *tutorUseCase) withMedia(ctx context.Context, userID, kind, ref string, save func(string) error) error {
+	if uc.media == nil {
+		return save(ref)
+	}
+	v, old, err := uc.media.Attach(ctx, userID, kind, ref)
+	if err != nil {
+		return err
+	}
+	if err := save(v); err != nil {
+		return err
+	}
+	if old != v {
+		uc.media.Release(ctx, userID, old)
+	}
+	return nil
 }
 func (uc *tutorUseCase) Complete(ctx context.Context, userID string) error {
+	d, err := uc.GetDraft(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !d.CanComplete {
+		return &ErrDraftIncomplete{Steps: d.Steps}
+	}
 	return uc.repo.MarkCompleted(ctx, userID)
 }
+func (uc *tutorUseCase) GetDraft(ctx context.Context, userID string) (*domain.TutorDraft, error) {
+	d, err := uc.repo.FindDraft(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	validateDraft(d)
+	return d, nil
+}
 
 // Queries
 func (uc *tutorUseCase) List(ctx context.Context, filters map[string]string, page, limit int) ([]domain.TutorProfile, *domain.Pagination, error) {
+	if uc.hideUnverified {
+		filters["verification"] = "verified"
+	}
+	// поиск за флагом: выключенный — каталог отдаётся без фильтра, а не ошибкой
+	if uc.flags != nil && !uc.flags.Enabled(ctx, flags.TutorsSearch, true) {
+		delete(filters, "search")
+	}
 	list, total, err := uc.repo.FindTutorCardList(ctx, filters, page, limit)
 	if err != nil {
 		return nil, nil, err
 	}
-	p := &domain.Pagination{
-		Page:       page,
-		Limit:      limit,
-		Total:      total,
-		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	if cur := strings.ToUpper(strings.TrimSpace(filters["currency"])); cur != "" {
+		if !domain.IsValidCurrency(cur) {
+			return nil, nil, ErrInvalidCurrency
+		}
+		conv, err := uc.pricing.Converter(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range list {
+			if list[i].HourlyRate == nil {
+				continue
+			}
+			if m, err := conv.Convert(*list[i].HourlyRate, cur); err == nil {
+				list[i].HourlyRateDisplay = &m
+			}
+		}
 	}
-	return list, p, nil
+	return list, paginate(page, limit, total), nil
 }
 func (uc *tutorUseCase) GetDetails(ctx context.Context, id string) (*domain.TutorProfile, error) {
-	return uc.repo.FindTutorDetails(ctx, id)
+	p, err := uc.repo.FindTutorDetails(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// скрытая из каталога анкета не открывается и по прямой ссылке
+	if uc.hideUnverified && p.Verification != "verified" {
+		return nil, ErrTutorNotFound
+	}
+	// публичная анкета показывает только статус проверки; сканы видят владелец и админы
+	for i := range p.Education {
+		p.Education[i].Document = nil
+	}
+	for i := range p.Certificates {
+		p.Certificates[i].Document = nil
+	}
+	return p, nil
 }
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"tutor/internal/domain"
+	"tutor/internal/notify"
+	"tutor/internal/repository"
+)
+
+var ErrMatchScore = errors.New("minScore must be between 0.3 and 1")
+
+const (
+	suggestMinQuery = 2 // короче — слишком много совпадений, список бесполезен
+	suggestLimit    = 10
+	suggestMaxLimit = 20
+	// DefaultMatchScore — порог word_similarity для автопривязки: ниже — много ложных совпадений
+	// ("Университет" похож на любой университет)
+	DefaultMatchScore = 0.6
+	matchBatch        = 1000
+)
+
+// UniversityUseCase — справочник вузов для анкеты: автодополнение и привязка свободного текста.
+type UniversityUseCase interface {
+	Suggest(ctx context.Context, q, country, locale string, limit int) ([]domain.University, error)
+	// Match ищет для записей об образовании без ссылки самый похожий вуз; без dryRun — привязывает.
+	Match(ctx context.Context, actorID string, minScore float64, dryRun bool) (*domain.UniversityMatchReport, error)
+}
+
+type universityUseCase struct {
+	repo repository.UniversityRepository
+}
+
+func NewUniversityUseCase(repo repository.UniversityRepository) UniversityUseCase {
+	return &universityUseCase{repo: repo}
+}
+
+func (uc *universityUseCase) Suggest(ctx context.Context, q, country, locale string, limit int) ([]domain.University, error) {
+	q = strings.TrimSpace(q)
+	if utf8.RuneCountInString(q) < suggestMinQuery {
+		return []domain.University{}, nil
+	}
+	if limit <= 0 {
+		limit = suggestLimit
+	}
+	if limit > suggestMaxLimit {
+		limit = suggestMaxLimit
+	}
+	list, err := uc.repo.Suggest(ctx, q, country, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Label = universityLabel(list[i], locale)
+	}
+	return list, nil
+}
+
+func (uc *universityUseCase) Match(ctx context.Context, actorID string, minScore float64, dryRun bool) (*domain.UniversityMatchReport, error) {
+	if minScore == 0 {
+		minScore = DefaultMatchScore
+	}
+	if minScore < 0.3 || minScore > 1 {
+		return nil, ErrMatchScore
+	}
+	matches, err := uc.repo.MatchCandidates(ctx, minScore, matchBatch)
+	if err != nil {
+		return nil, err
+	}
+	rep := &domain.UniversityMatchReport{MinScore: minScore, DryRun: dryRun, Matches: matches}
+	for i := range rep.Matches {
+		rep.Matches[i].University.Label = universityLabel(rep.Matches[i].University, "")
+	}
+	if dryRun || len(matches) == 0 {
+		return rep, nil
+	}
+	if rep.Linked, err = uc.repo.Link(ctx, actorID, matches); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// universityLabel — название на языке пользователя, дальше ru → en → kk, в крайнем случае slug.
+func universityLabel(u domain.University, locale string) string {
+	for _, l := range []string{notify.NormalizeLocale(locale), notify.DefaultLocale, "en", "kk"} {
+		if s, _ := u.Name[l].(string); strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return u.Slug
+}
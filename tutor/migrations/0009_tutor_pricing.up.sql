@@ -0,0 +1,55 @@
+-- Единая модель цен: предмет/поднаправление × длительность × пакет
+CREATE TABLE IF NOT EXISTS tutor_prices (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    tutor_id         uuid NOT NULL REFERENCES tutor_profiles(user_id),
+    subject_id       uuid REFERENCES subjects(id) ON DELETE CASCADE,
+    subdirection_id  uuid REFERENCES subdirections(id) ON DELETE CASCADE,
+    duration_minutes int NOT NULL CHECK (duration_minutes IN (30,45,60,90)),
+    lessons_count    int NOT NULL DEFAULT 1 CHECK (lessons_count BETWEEN 1 AND 50),
+    is_trial         boolean NOT NULL DEFAULT false,
+    price_minor      bigint NOT NULL CHECK (price_minor >= 0),
+    currency         char(3) NOT NULL,
+    created_at       timestamptz NOT NULL DEFAULT now(),
+    updated_at       timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT tutor_prices_target_ck CHECK ((subject_id IS NULL) <> (subdirection_id IS NULL)),
+    CONSTRAINT tutor_prices_trial_ck CHECK (NOT is_trial OR lessons_count = 1)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tutor_prices_subject_uniq
+    ON tutor_prices (tutor_id, subject_id, duration_minutes, lessons_count, is_trial) WHERE subject_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS tutor_prices_subdir_uniq
+    ON tutor_prices (tutor_id, subdirection_id, duration_minutes, lessons_count, is_trial) WHERE subdirection_id IS NOT NULL;
+
+CREATE TRIGGER trg_tutor_prices_updated BEFORE UPDATE ON tutor_prices FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Курсы валют: 1 base = rate quote
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base       char(3) NOT NULL,
+    quote      char(3) NOT NULL,
+    rate       numeric(20,10) NOT NULL CHECK (rate > 0),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (base, quote)
+);
+
+-- переносим разрозненные цены: tutor_subjects/tutor_subdirections → 60 минут, разовое занятие
+INSERT INTO tutor_prices (tutor_id, subject_id, duration_minutes, lessons_count, price_minor, currency)
+SELECT tutor_id, subject_id, 60, 1, price_minor, COALESCE(currency,'KZT')
+FROM tutor_subjects WHERE COALESCE(price_minor,0) > 0
+ON CONFLICT DO NOTHING;
+
+INSERT INTO tutor_prices (tutor_id, subdirection_id, duration_minutes, lessons_count, price_minor, currency)
+SELECT tutor_id, subdirection_id, 60, 1, price_minor, COALESCE(currency,'KZT')
+FROM tutor_subdirections WHERE COALESCE(price_minor,0) > 0
+ON CONFLICT DO NOTHING;
+
+-- props.prices: {subdirection_slug: {regular_minor, trial_minor}}
+INSERT INTO tutor_prices (tutor_id, subdirection_id, duration_minutes, lessons_count, is_trial, price_minor, currency)
+SELECT tp.user_id, sd.id, 60, 1, v.is_trial, v.price_minor, tp.currency
+FROM tutor_profiles tp
+CROSS JOIN LATERAL jsonb_each(COALESCE(tp.props->'prices','{}'::jsonb)) p
+JOIN subdirections sd ON sd.slug = p.key
+CROSS JOIN LATERAL (VALUES
+    (false, (p.value->>'regular_minor')::bigint),
+    (true,  (p.value->>'trial_minor')::bigint)
+) AS v(is_trial, price_minor)
+WHERE jsonb_typeof(p.value) = 'object' AND COALESCE(v.price_minor,0) > 0
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS wallet_entries_wallet_seq_uniq;
+DROP INDEX IF EXISTS wallet_entries_txn_idx;
+ALTER TABLE wallet_entries DROP CONSTRAINT IF EXISTS wallet_entries_amount_positive;
+ALTER TABLE wallet_entries DROP COLUMN IF EXISTS seq, DROP COLUMN IF EXISTS txn_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS created_at, DROP COLUMN IF EXISTS balance_minor;
+DROP TABLE IF EXISTS ledger_transactions;
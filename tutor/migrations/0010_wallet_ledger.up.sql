@@ -0,0 +1,24 @@
+-- Двойная запись: каждая проводка — транзакция с набором entries, сумма debit = сумма credit
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    key        text NOT NULL UNIQUE, -- идемпотентность: "payment:<id>:captured" и т.п.
+    kind       text NOT NULL,
+    currency   char(3) NOT NULL,
+    payment_id uuid REFERENCES payments(id),
+    lesson_id  uuid REFERENCES lessons(id),
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS ledger_transactions_payment_idx ON ledger_transactions (payment_id);
+
+-- текущий баланс хранится в кошельке и меняется только под FOR UPDATE
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS balance_minor bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+
+ALTER TABLE wallet_entries
+    ADD COLUMN IF NOT EXISTS txn_id uuid REFERENCES ledger_transactions(id),
+    ADD COLUMN IF NOT EXISTS seq bigint GENERATED ALWAYS AS IDENTITY;
+ALTER TABLE wallet_entries
+    ADD CONSTRAINT wallet_entries_amount_positive CHECK (amount_minor > 0);
+CREATE INDEX IF NOT EXISTS wallet_entries_txn_idx ON wallet_entries (txn_id);
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_entries_wallet_seq_uniq ON wallet_entries (wallet_id, seq);
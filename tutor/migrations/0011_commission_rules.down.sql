@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS lessons_tutor_completed_idx;
+DROP TABLE IF EXISTS commission_rules;
@@ -0,0 +1,17 @@
+-- Версии правил комиссии: версия неизменяема, действует с effective_from до следующей.
+-- Урок хранит commissions.rule_version той версии, по которой был посчитан.
+CREATE TABLE IF NOT EXISTS commission_rules (
+    version        text PRIMARY KEY,
+    rules          jsonb NOT NULL,
+    effective_from timestamptz NOT NULL,
+    created_by     uuid REFERENCES users(id),
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS commission_rules_effective_uniq ON commission_rules (effective_from);
+
+INSERT INTO commission_rules (version, rules, effective_from)
+VALUES ('v1', '{"currency":"KZT","defaultPercent":20}'::jsonb, '2020-01-01T00:00:00Z')
+ON CONFLICT (version) DO NOTHING;
+
+-- объём репетитора для тиров считается по commissions его уроков
+CREATE INDEX IF NOT EXISTS lessons_tutor_completed_idx ON lessons (tutor_id) WHERE status = 'completed';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS payouts_owner_created_idx;
+DROP INDEX IF EXISTS payouts_status_created_idx;
+DROP INDEX IF EXISTS ledger_transactions_payout_idx;
+ALTER TABLE ledger_transactions DROP COLUMN IF EXISTS payout_id;
@@ -0,0 +1,6 @@
+-- проводки выплат ссылаются на payouts (резерв / выплачено / возврат резерва)
+ALTER TABLE ledger_transactions ADD COLUMN IF NOT EXISTS payout_id uuid REFERENCES payouts(id);
+CREATE INDEX IF NOT EXISTS ledger_transactions_payout_idx ON ledger_transactions (payout_id);
+
+CREATE INDEX IF NOT EXISTS payouts_status_created_idx ON payouts (status, created_at);
+CREATE INDEX IF NOT EXISTS payouts_owner_created_idx ON payouts (owner_type, owner_id, created_at DESC);
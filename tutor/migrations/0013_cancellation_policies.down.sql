@@ -0,0 +1,8 @@
+ALTER TABLE cancellations DROP COLUMN IF EXISTS initiator;
+ALTER TABLE cancellations DROP COLUMN IF EXISTS booking_id;
+ALTER TABLE bookings DROP COLUMN IF EXISTS cancellation_policy_id;
+ALTER TABLE tutor_profiles DROP COLUMN IF EXISTS cancellation_policy_id;
+DELETE FROM cancellation_policies WHERE is_default AND name = 'standard';
+DROP INDEX IF EXISTS cancellation_policies_default_uniq;
+ALTER TABLE cancellation_policies DROP COLUMN IF EXISTS created_at;
+ALTER TABLE cancellation_policies DROP COLUMN IF EXISTS is_default;
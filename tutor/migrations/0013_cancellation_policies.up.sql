@@ -0,0 +1,21 @@
+-- политика отмены: по умолчанию платформенная, репетитор может выбрать свою;
+-- при оплате политика фиксируется на брони, чтобы смена политики не меняла условия задним числом
+ALTER TABLE cancellation_policies
+    ADD COLUMN IF NOT EXISTS is_default boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+CREATE UNIQUE INDEX IF NOT EXISTS cancellation_policies_default_uniq ON cancellation_policies (is_default) WHERE is_default;
+
+ALTER TABLE tutor_profiles ADD COLUMN IF NOT EXISTS cancellation_policy_id uuid REFERENCES cancellation_policies(id);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancellation_policy_id uuid REFERENCES cancellation_policies(id);
+
+-- отменить можно и неподтверждённую бронь (урока ещё нет)
+ALTER TABLE cancellations
+    ADD COLUMN IF NOT EXISTS booking_id uuid UNIQUE REFERENCES bookings(id),
+    ADD COLUMN IF NOT EXISTS initiator text CHECK (initiator IN ('student','tutor'));
+
+INSERT INTO cancellation_policies (name, is_default, rules)
+SELECT 'standard', true, '{
+  "student": [{"minHoursBefore": 24, "refundPercent": 100}, {"minHoursBefore": 2, "refundPercent": 50}],
+  "tutor":   [{"minHoursBefore": 0, "refundPercent": 100}]
+}'::jsonb
+WHERE NOT EXISTS (SELECT 1 FROM cancellation_policies WHERE is_default);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS messages_conv_created_id_idx;
+DROP TABLE IF EXISTS conversation_reads;
+DROP INDEX IF EXISTS conversations_tutor_last_idx;
+DROP INDEX IF EXISTS conversations_student_last_idx;
+ALTER TABLE conversations DROP COLUMN IF EXISTS last_message_at;
+ALTER TABLE conversations DROP COLUMN IF EXISTS blocked_by;
@@ -0,0 +1,16 @@
+-- кто и когда заблокировал чат; время последнего сообщения — для сортировки списка диалогов
+ALTER TABLE conversations
+    ADD COLUMN IF NOT EXISTS blocked_by uuid REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS last_message_at timestamptz;
+CREATE INDEX IF NOT EXISTS conversations_student_last_idx ON conversations (student_id, last_message_at DESC NULLS LAST);
+CREATE INDEX IF NOT EXISTS conversations_tutor_last_idx ON conversations (tutor_id, last_message_at DESC NULLS LAST);
+
+-- отметки прочтения (read receipts): до какого момента участник прочитал диалог
+CREATE TABLE IF NOT EXISTS conversation_reads (
+    conversation_id uuid NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES users(id),
+    last_read_at timestamptz NOT NULL,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS messages_conv_created_id_idx ON messages (conversation_id, created_at DESC, id DESC);
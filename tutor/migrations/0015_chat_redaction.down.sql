@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS messages_sender_redacted_idx;
+DROP TABLE IF EXISTS chat_offenders;
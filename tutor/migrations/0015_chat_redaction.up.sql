@@ -0,0 +1,13 @@
+-- нарушители: сколько сообщений с контактами за окно; flagged_at — ждёт проверки модератором
+CREATE TABLE IF NOT EXISTS chat_offenders (
+    user_id uuid PRIMARY KEY REFERENCES users(id),
+    violations int NOT NULL DEFAULT 0,
+    last_violation_at timestamptz NOT NULL,
+    flagged_at timestamptz,
+    reviewed_at timestamptz,
+    reviewed_by uuid REFERENCES users(id),
+    review_note text
+);
+CREATE INDEX IF NOT EXISTS chat_offenders_flagged_idx ON chat_offenders (flagged_at) WHERE flagged_at IS NOT NULL AND reviewed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS messages_sender_redacted_idx ON messages (sender_id, created_at) WHERE body_redacted IS NOT NULL;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS audit_log_entity_idx;
+DROP TABLE IF EXISTS user_warnings;
+ALTER TABLE messages DROP COLUMN IF EXISTS hidden_by;
+ALTER TABLE messages DROP COLUMN IF EXISTS hidden_at;
+DROP INDEX IF EXISTS reports_reporter_target_open_uniq;
+DROP INDEX IF EXISTS reports_assignee_idx;
+DROP INDEX IF EXISTS reports_target_idx;
+DROP INDEX IF EXISTS reports_status_created_idx;
+ALTER TABLE reports
+    DROP COLUMN IF EXISTS resolved_at,
+    DROP COLUMN IF EXISTS resolved_by,
+    DROP COLUMN IF EXISTS resolution_note,
+    DROP COLUMN IF EXISTS resolution_action,
+    DROP COLUMN IF EXISTS assignee_id,
+    DROP COLUMN IF EXISTS details;
@@ -0,0 +1,30 @@
+-- очередь модерации: назначение, решение и применённое действие
+ALTER TABLE reports
+    ADD COLUMN IF NOT EXISTS details text,
+    ADD COLUMN IF NOT EXISTS assignee_id uuid REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS resolution_action text CHECK (resolution_action IN ('none','warn','block_user','hide_message')),
+    ADD COLUMN IF NOT EXISTS resolution_note text,
+    ADD COLUMN IF NOT EXISTS resolved_by uuid REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS resolved_at timestamptz;
+CREATE INDEX IF NOT EXISTS reports_status_created_idx ON reports (status, created_at);
+CREATE INDEX IF NOT EXISTS reports_target_idx ON reports (target_type, target_id);
+CREATE INDEX IF NOT EXISTS reports_assignee_idx ON reports (assignee_id) WHERE status IN ('open','in_review');
+-- одна незакрытая жалоба от пользователя на один объект
+CREATE UNIQUE INDEX IF NOT EXISTS reports_reporter_target_open_uniq ON reports (reporter_id, target_type, target_id)
+    WHERE status IN ('open','in_review');
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS hidden_at timestamptz,
+    ADD COLUMN IF NOT EXISTS hidden_by uuid REFERENCES users(id);
+
+CREATE TABLE IF NOT EXISTS user_warnings (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id),
+    report_id uuid REFERENCES reports(id),
+    issued_by uuid NOT NULL REFERENCES users(id),
+    reason text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS user_warnings_user_idx ON user_warnings (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, created_at DESC);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS push_devices;
+DROP INDEX IF EXISTS notifications_user_created_idx;
+DROP INDEX IF EXISTS notifications_queued_idx;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS sent_at,
+    DROP COLUMN IF EXISTS body,
+    DROP COLUMN IF EXISTS subject,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts;
+DELETE FROM notifications WHERE status = 'skipped';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('queued','sent','failed'));
+-- колонки настроек в users остаются: на них опирается user-сервис
@@ -0,0 +1,29 @@
+-- настройки уведомлений, которые читает и пишет user-сервис
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS notifications_lessons boolean NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS notifications_messages boolean NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS notifications_reminders boolean NOT NULL DEFAULT true;
+
+-- диспетчер: ретраи с backoff; skipped — пользователь отключил категорию
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('queued','sent','failed','skipped'));
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS attempts int NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS last_error text,
+    ADD COLUMN IF NOT EXISTS subject text,
+    ADD COLUMN IF NOT EXISTS body text,
+    ADD COLUMN IF NOT EXISTS sent_at timestamptz;
+CREATE INDEX IF NOT EXISTS notifications_queued_idx ON notifications (next_attempt_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS notifications_user_created_idx ON notifications (user_id, created_at DESC);
+
+-- push-токены устройств (FCM / APNs)
+CREATE TABLE IF NOT EXISTS push_devices (
+    token text PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES users(id),
+    platform text NOT NULL CHECK (platform IN ('fcm','apns')),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS push_devices_user_idx ON push_devices (user_id);
@@ -0,0 +1,8 @@
+-- отправленные напоминания: PK даёт at-most-once на (урок, участник, интервал) при любом числе реплик
+CREATE TABLE IF NOT EXISTS lesson_reminders (
+    lesson_id uuid NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES users(id),
+    lead_seconds int NOT NULL CHECK (lead_seconds > 0),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (lesson_id, user_id, lead_seconds)
+);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS outbox_failed_idx;
+DROP INDEX IF EXISTS outbox_pending_seq_idx;
+DROP INDEX IF EXISTS outbox_pending_aggregate_idx;
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,10 @@
+-- relay: seq задаёт порядок внутри агрегата, failed — dead letter после исчерпания попыток
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS seq bigint GENERATED ALWAYS AS IDENTITY,
+    ADD COLUMN IF NOT EXISTS attempts int NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS last_error text,
+    ADD COLUMN IF NOT EXISTS published_at timestamptz;
+CREATE INDEX IF NOT EXISTS outbox_pending_aggregate_idx ON outbox_events (aggregate, aggregate_id, seq) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS outbox_pending_seq_idx ON outbox_events (seq) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS outbox_failed_idx ON outbox_events (created_at DESC) WHERE status = 'failed';
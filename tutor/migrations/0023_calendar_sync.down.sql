@@ -0,0 +1,23 @@
+DROP TRIGGER IF EXISTS trg_lessons_calendar_sync ON lessons;
+DROP FUNCTION IF EXISTS calendar_sync_lesson();
+DROP TABLE IF EXISTS calendar_busy;
+
+DROP INDEX IF EXISTS calendar_events_pending_idx;
+DROP INDEX IF EXISTS calendar_events_lesson_account_uniq;
+DELETE FROM calendar_events WHERE event_id IS NULL;
+ALTER TABLE calendar_events
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS revision,
+    ALTER COLUMN event_id SET NOT NULL;
+-- прежняя схема допускала одно событие на урок
+DELETE FROM calendar_events e USING calendar_events d
+ WHERE e.lesson_id = d.lesson_id AND e.created_at > d.created_at;
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_events_lesson_uniq ON calendar_events (lesson_id);
+
+DROP INDEX IF EXISTS calendar_accounts_user_provider_uniq;
+ALTER TABLE calendar_accounts
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS busy_synced_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,61 @@
+-- Google Calendar: одно подключение на пользователя и провайдера, токены — в oauth_tokens
+ALTER TABLE calendar_accounts
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'active' CHECK (status IN ('active','revoked')),
+    ADD COLUMN IF NOT EXISTS busy_synced_at timestamptz,
+    ADD COLUMN IF NOT EXISTS last_error text,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_accounts_user_provider_uniq ON calendar_accounts (user_id, provider);
+
+-- событие на каждый подключённый календарь участника; event_id появляется после создания у провайдера.
+-- revision растёт при каждом изменении урока: воркер не затрёт более новое изменение своим synced.
+DROP INDEX IF EXISTS calendar_events_lesson_uniq;
+ALTER TABLE calendar_events
+    ALTER COLUMN event_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS revision int NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS attempts int NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamptz NOT NULL DEFAULT now();
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_events_lesson_account_uniq ON calendar_events (lesson_id, account_id);
+CREATE INDEX IF NOT EXISTS calendar_events_pending_idx ON calendar_events (next_attempt_at)
+    WHERE status IN ('to_create','to_update','to_delete');
+
+-- занятость из внешнего календаря; окно целиком перезаписывается при каждом опросе
+CREATE TABLE IF NOT EXISTS calendar_busy (
+    account_id uuid NOT NULL REFERENCES calendar_accounts(id) ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES users(id),
+    starts_at timestamptz NOT NULL,
+    ends_at timestamptz NOT NULL CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS calendar_busy_user_idx ON calendar_busy (user_id, starts_at);
+
+-- изменения урока ставят события участников в очередь синхронизации
+CREATE OR REPLACE FUNCTION calendar_sync_lesson() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+     AND NEW.status IS NOT DISTINCT FROM OLD.status
+     AND NEW.started_at IS NOT DISTINCT FROM OLD.started_at
+     AND NEW.duration_seconds IS NOT DISTINCT FROM OLD.duration_seconds THEN
+    RETURN NEW;
+  END IF;
+
+  IF NEW.status = 'cancelled' THEN
+    -- ещё не созданные у провайдера удалять негде
+    DELETE FROM calendar_events WHERE lesson_id = NEW.id AND event_id IS NULL;
+    UPDATE calendar_events
+       SET status = 'to_delete', revision = revision + 1, attempts = 0,
+           next_attempt_at = now(), error_msg = NULL, updated_at = now()
+     WHERE lesson_id = NEW.id;
+  ELSIF NEW.status = 'scheduled' AND NEW.started_at IS NOT NULL THEN
+    INSERT INTO calendar_events (lesson_id, account_id, provider, status)
+    SELECT NEW.id, a.id, a.provider, 'to_create'
+      FROM calendar_accounts a
+     WHERE a.user_id IN (NEW.student_id, NEW.tutor_id) AND a.status = 'active'
+    ON CONFLICT (lesson_id, account_id) DO UPDATE
+       SET status = CASE WHEN calendar_events.event_id IS NULL THEN 'to_create' ELSE 'to_update' END,
+           revision = calendar_events.revision + 1, attempts = 0,
+           next_attempt_at = now(), error_msg = NULL, updated_at = now();
+  END IF;
+  RETURN NEW;
+END;$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_lessons_calendar_sync AFTER INSERT OR UPDATE OF status, started_at, duration_seconds ON lessons
+    FOR EACH ROW EXECUTE FUNCTION calendar_sync_lesson();
@@ -0,0 +1,7 @@
+-- секретные ссылки на ICS-подписку: токен в URL и есть авторизация, смена токена отзывает старую ссылку
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    rotated_at timestamptz
+);
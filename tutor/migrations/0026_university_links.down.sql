@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tutor_profiles_education_idx;
+DROP INDEX IF EXISTS universities_search_trgm_idx;
+ALTER TABLE universities DROP COLUMN IF EXISTS search_text;
+-- pg_trgm не удаляем: расширение могут использовать и другие объекты
@@ -0,0 +1,10 @@
+-- Нечёткий поиск вузов (автодополнение, сопоставление свободного текста из анкет)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS search_text text GENERATED ALWAYS AS (
+    lower(slug || ' ' || COALESCE(name->>'ru','') || ' ' || COALESCE(name->>'kk','') || ' ' || COALESCE(name->>'en',''))
+) STORED;
+CREATE INDEX IF NOT EXISTS universities_search_trgm_idx ON universities USING gin (search_text gin_trgm_ops);
+
+-- образование в анкете ссылается на вуз: props->'education' @> '[{"universityId": "..."}]'
+CREATE INDEX IF NOT EXISTS tutor_profiles_education_idx ON tutor_profiles USING gin ((props->'education') jsonb_path_ops);
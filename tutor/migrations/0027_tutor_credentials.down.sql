@@ -0,0 +1,19 @@
+-- обратно в props; документы и отметки о проверке теряются
+UPDATE tutor_profiles tp
+SET props = tp.props || jsonb_build_object(
+      'education', COALESCE((
+        SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+                 'degree', e.degree, 'institution', e.institution, 'universityId', e.university_id::text,
+                 'year', e.year, 'field', e.field)) ORDER BY e.position, e.created_at)
+        FROM tutor_education e WHERE e.tutor_id = tp.user_id), '[]'::jsonb),
+      'certificates', COALESCE((
+        SELECT jsonb_agg(jsonb_build_object('name', c.name, 'issuer', c.issuer, 'year', c.year)
+                         ORDER BY c.position, c.created_at)
+        FROM tutor_certificates c WHERE c.tutor_id = tp.user_id), '[]'::jsonb))
+WHERE EXISTS (SELECT 1 FROM tutor_education e WHERE e.tutor_id = tp.user_id)
+   OR EXISTS (SELECT 1 FROM tutor_certificates c WHERE c.tutor_id = tp.user_id);
+
+CREATE INDEX IF NOT EXISTS tutor_profiles_education_idx ON tutor_profiles USING gin ((props->'education') jsonb_path_ops);
+
+DROP TABLE IF EXISTS tutor_certificates;
+DROP TABLE IF EXISTS tutor_education;
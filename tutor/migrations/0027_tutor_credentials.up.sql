@@ -0,0 +1,74 @@
+-- Образование и сертификаты из props анкеты — в отдельные таблицы: по записям можно искать,
+-- проверять их по одной и прикладывать скан документа (сам файл — в хранилище, здесь только ключ)
+CREATE TABLE IF NOT EXISTS tutor_education (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    tutor_id      uuid NOT NULL REFERENCES tutor_profiles(user_id) ON DELETE CASCADE,
+    position      int  NOT NULL DEFAULT 0,
+    degree        text NOT NULL DEFAULT '',
+    institution   text NOT NULL DEFAULT '',
+    university_id uuid REFERENCES universities(id),
+    year          text NOT NULL DEFAULT '',
+    field         text NOT NULL DEFAULT '',
+    document_key  text,
+    document_name text,
+    document_type text,
+    document_size bigint,
+    document_uploaded_at timestamptz,
+    verification      text NOT NULL DEFAULT 'pending' CHECK (verification IN ('pending','verified','rejected')),
+    verification_note text,
+    verified_by       uuid REFERENCES users(id) ON DELETE SET NULL,
+    verified_at       timestamptz,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    updated_at    timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS tutor_education_tutor_idx      ON tutor_education (tutor_id, position);
+CREATE INDEX IF NOT EXISTS tutor_education_university_idx ON tutor_education (university_id) WHERE university_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tutor_education_review_idx     ON tutor_education (verification, updated_at);
+
+CREATE TABLE IF NOT EXISTS tutor_certificates (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    tutor_id      uuid NOT NULL REFERENCES tutor_profiles(user_id) ON DELETE CASCADE,
+    position      int  NOT NULL DEFAULT 0,
+    name          text NOT NULL DEFAULT '',
+    issuer        text NOT NULL DEFAULT '',
+    year          text NOT NULL DEFAULT '',
+    document_key  text,
+    document_name text,
+    document_type text,
+    document_size bigint,
+    document_uploaded_at timestamptz,
+    verification      text NOT NULL DEFAULT 'pending' CHECK (verification IN ('pending','verified','rejected')),
+    verification_note text,
+    verified_by       uuid REFERENCES users(id) ON DELETE SET NULL,
+    verified_at       timestamptz,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    updated_at    timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS tutor_certificates_tutor_idx  ON tutor_certificates (tutor_id, position);
+CREATE INDEX IF NOT EXISTS tutor_certificates_review_idx ON tutor_certificates (verification, updated_at);
+
+-- перенос из props; ссылки на несуществующие вузы отбрасываем
+INSERT INTO tutor_education (tutor_id, position, degree, institution, university_id, year, field)
+SELECT tp.user_id, x.ord - 1,
+       COALESCE(btrim(x.e->>'degree'),''), COALESCE(btrim(x.e->>'institution'),''), un.id,
+       COALESCE(btrim(x.e->>'year'),''), COALESCE(btrim(x.e->>'field'),'')
+FROM tutor_profiles tp
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(tp.props->'education') = 'array' THEN tp.props->'education' ELSE '[]'::jsonb END
+) WITH ORDINALITY AS x(e, ord)
+LEFT JOIN universities un ON un.id::text = x.e->>'universityId'
+WHERE jsonb_typeof(x.e) = 'object';
+
+INSERT INTO tutor_certificates (tutor_id, position, name, issuer, year)
+SELECT tp.user_id, x.ord - 1,
+       COALESCE(btrim(x.e->>'name'),''), COALESCE(btrim(x.e->>'issuer'),''), COALESCE(btrim(x.e->>'year'),'')
+FROM tutor_profiles tp
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(tp.props->'certificates') = 'array' THEN tp.props->'certificates' ELSE '[]'::jsonb END
+) WITH ORDINALITY AS x(e, ord)
+WHERE jsonb_typeof(x.e) = 'object';
+
+UPDATE tutor_profiles SET props = props - 'education' - 'certificates'
+WHERE props ?| ARRAY['education','certificates'];
+
+DROP INDEX IF EXISTS tutor_profiles_education_idx;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tutor_certificates_document_idx;
+DROP INDEX IF EXISTS tutor_education_document_idx;
+DROP INDEX IF EXISTS tutor_profiles_video_key_idx;
+DROP INDEX IF EXISTS tutor_profiles_avatar_key_idx;
+ALTER TABLE tutor_profiles DROP COLUMN IF EXISTS video_key, DROP COLUMN IF EXISTS avatar_key;
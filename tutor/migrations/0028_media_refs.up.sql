@@ -0,0 +1,13 @@
+-- Сборщик мусора хранилища ищет ссылки на объекты пачками по ключу. Аватар и видео анкеты хранятся
+-- публичными адресами, поэтому ключ выделяется из пути адреса: смена MEDIA_PUBLIC_URL (CDN, бакет)
+-- не делает файлы, сохранённые под прежним адресом, ничьими. Внешние ссылки на видео ключа не дают.
+ALTER TABLE tutor_profiles
+    ADD COLUMN IF NOT EXISTS avatar_key text
+        GENERATED ALWAYS AS (substring(props->>'avatar_url' from '/(tutors/avatars/[^?#]+)')) STORED,
+    ADD COLUMN IF NOT EXISTS video_key text
+        GENERATED ALWAYS AS (substring(video_url from '/(tutors/videos/[^?#]+)')) STORED;
+
+CREATE INDEX IF NOT EXISTS tutor_profiles_avatar_key_idx ON tutor_profiles (avatar_key) WHERE avatar_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tutor_profiles_video_key_idx  ON tutor_profiles (video_key) WHERE video_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tutor_education_document_idx    ON tutor_education (document_key) WHERE document_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tutor_certificates_document_idx ON tutor_certificates (document_key) WHERE document_key IS NOT NULL;
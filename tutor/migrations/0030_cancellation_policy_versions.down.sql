@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS cancellations_unsettled_idx;
+ALTER TABLE cancellations
+    DROP COLUMN IF EXISTS settle_next_at,
+    DROP COLUMN IF EXISTS settle_attempts;
+DROP TRIGGER IF EXISTS trg_cancellation_policies_immutable ON cancellation_policies;
+DROP FUNCTION IF EXISTS cancellation_policies_immutable();
+ALTER TABLE cancellation_policies
+    DROP COLUMN IF EXISTS superseded_at,
+    DROP COLUMN IF EXISTS superseded_by,
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,29 @@
+-- Политика отмены неизменяема, как правила комиссии: правка создаёт новую версию, а прежняя
+-- остаётся за бронями, на которых она зафиксирована. Репетиторы переходят на новую версию.
+ALTER TABLE cancellation_policies
+    ADD COLUMN IF NOT EXISTS version int NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS superseded_by uuid REFERENCES cancellation_policies(id),
+    ADD COLUMN IF NOT EXISTS superseded_at timestamptz;
+
+CREATE OR REPLACE FUNCTION cancellation_policies_immutable() RETURNS trigger AS $$
+BEGIN
+    IF NEW.name IS DISTINCT FROM OLD.name OR NEW.rules IS DISTINCT FROM OLD.rules
+       OR NEW.version IS DISTINCT FROM OLD.version THEN
+        RAISE EXCEPTION 'cancellation policy % is immutable; create a new version', OLD.id;
+    END IF;
+    RETURN NEW;
+END;$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_cancellation_policies_immutable ON cancellation_policies;
+CREATE TRIGGER trg_cancellation_policies_immutable BEFORE UPDATE ON cancellation_policies
+    FOR EACH ROW EXECUTE FUNCTION cancellation_policies_immutable();
+
+-- отмены, по которым деньги не удалось вернуть автоматически: их повторяет воркер.
+-- settle_next_at — аренда попытки (другие реплики строку не берут) и одновременно пауза
+-- до следующей; после исчерпания попыток отмена остаётся с outcome.error для ручного разбора.
+ALTER TABLE cancellations
+    ADD COLUMN IF NOT EXISTS settle_attempts int NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS settle_next_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS cancellations_unsettled_idx ON cancellations (created_at)
+    WHERE outcome->>'error' IS NOT NULL;
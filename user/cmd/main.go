@@ -12,7 +12,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"shared/flags"
+	"shared/storage"
 	delivery "user/internal/delivery/http"
 	"user/internal/repository"
 	"user/internal/usecase"
@@ -39,16 +44,56 @@ func main() {
 	}
 	defer dbpool.Close()
 
+	// хранилище аватаров: STORAGE_BACKEND=local (файлы раздаёт сам сервис под /media/) или s3
+	store, mediaFiles, err := openStorage(jwtSecret, os.Getenv("APP_ENV") == "local")
+	if err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+	maxAvatar := envInt64("AVATAR_MAX_SIZE", usecase.DefaultMaxAvatarSize)
+
+	// feature flags: кеш в памяти, обновляется по NOTIFY
+	flagClient := flags.New(dbpool, flags.Options{Refresh: envDuration("FLAGS_REFRESH_INTERVAL", time.Minute)})
+	go flagClient.Run(context.Background())
+
 	userRepo := repository.NewUserPostgresRepo(dbpool)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	userUseCase := usecase.NewUserUseCase(userRepo, store, usecase.AvatarOptions{
+		MaxSize:   maxAvatar,
+		UploadTTL: envDuration("MEDIA_UPLOAD_TTL", 15*time.Minute),
+		Grace:     envDuration("STORAGE_GC_GRACE", 24*time.Hour),
+	}, flagClient)
 	tokenUseCase := usecase.NewTokenUseCase(jwtSecret)
 
-	userHandler := delivery.NewUserHandler(userUseCase, tokenUseCase)
+	userHandler := delivery.NewUserHandler(userUseCase, tokenUseCase, maxAvatar)
+
+	notificationUseCase := usecase.NewNotificationUseCase(repository.NewNotificationPostgresRepo(dbpool))
+	go notificationUseCase.Run(context.Background())
+	notificationHandler := delivery.NewNotificationHandler(notificationUseCase, userHandler.JWTMiddleware)
 
 	router := mux.NewRouter()
+	router.Use(flags.Middleware)
 
+	// аватары, загруженные до перехода на хранилище
 	router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
+	if mediaFiles != nil {
+		router.PathPrefix("/media/").Handler(http.StripPrefix("/media/", mediaFiles))
+	}
 
+	// уборка хранилища: заменённые аватары и загрузки, которые так и не поставили аватаром
+	if gcInterval := envDuration("STORAGE_GC_INTERVAL", time.Hour); gcInterval > 0 {
+		go func() {
+			t := time.NewTicker(gcInterval)
+			defer t.Stop()
+			for range t.C {
+				if n, err := userUseCase.CollectGarbage(context.Background()); err != nil {
+					log.Printf("ERROR: storage gc: %v", err)
+				} else if n > 0 {
+					log.Printf("storage gc: deleted %d objects", n)
+				}
+			}
+		}()
+	}
+
+	notificationHandler.RegisterRoutes(router)
 	userHandler.RegisterRoutes(router)
 
 	port := os.Getenv("PORT")
@@ -58,6 +103,76 @@ func main() {
 	}
 }
 
+// openStorage - хранилище по STORAGE_BACKEND; для local возвращает и обработчик /media/,
+// которым сервис раздаёт аватары и принимает прямые загрузки. Адрес и секрет подписи по умолчанию
+// годятся только для локальной разработки (APP_ENV=local), в остальных окружениях их задают явно.
+func openStorage(jwtSecret string, local bool) (storage.Storage, http.Handler, error) {
+	publicURL := os.Getenv("MEDIA_PUBLIC_URL")
+	switch backend := envOr("STORAGE_BACKEND", "local"); backend {
+	case "s3":
+		s, err := storage.NewS3(storage.S3Options{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    envOr("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+			PublicURL: publicURL,
+		})
+		return s, nil, err
+	case "local":
+		secret := envOr("STORAGE_SIGNING_SECRET", "")
+		if secret != "" && secret == jwtSecret {
+			return nil, nil, errors.New("STORAGE_SIGNING_SECRET must differ from JWT_SECRET")
+		}
+		if !local && publicURL == "" {
+			return nil, nil, errors.New("MEDIA_PUBLIC_URL is required outside APP_ENV=local")
+		}
+		if !local && secret == "" {
+			return nil, nil, errors.New("STORAGE_SIGNING_SECRET is required outside APP_ENV=local")
+		}
+		if publicURL == "" {
+			// порт user-service в docker-compose
+			publicURL = "http://localhost:8082/media/"
+		}
+		// пустой секрет - случайный: подписанные ссылки живут до рестарта
+		s, err := storage.NewLocal(envOr("STORAGE_LOCAL_DIR", "./storage"), storage.LocalOptions{
+			BaseURL: publicURL,
+			Secret:  secret,
+			Public:  []string{"users/"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Handler(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown STORAGE_BACKEND %q", backend)
+	}
+}
+
+func envOr(k, d string) string {
+	if v := strings.TrimSpace(os.Getenv(k)); v != "" {
+		return v
+	}
+	return d
+}
+
+func envInt64(k string, d int64) int64 {
+	v, err := strconv.ParseInt(envOr(k, ""), 10, 64)
+	if err != nil {
+		return d
+	}
+	return v
+}
+
+func envDuration(k string, d time.Duration) time.Duration {
+	v, err := time.ParseDuration(envOr(k, ""))
+	if err != nil {
+		return d
+	}
+	return v
+}
+
 func runMigrations(dbURL string) error {
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
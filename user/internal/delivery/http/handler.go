@@ -3,13 +3,11 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/gorilla/mux"
-	"io"
 	"log" // <--- Добавлен импорт
 	"net/http"
-	"os"
-	"path/filepath"
+	"shared/flags"
 	"strconv"
 	"strings"
 	"user/internal/domain"
@@ -21,6 +19,7 @@ import (
 type UserHandler struct {
 	userUseCase  usecase.UserUseCase
 	tokenUseCase usecase.TokenUseCase
+	maxAvatar    int64
 }
 
 // CtxKey - тип для ключа в контексте запроса.
@@ -31,8 +30,12 @@ const (
 	UserRoleKey CtxKey = "userRole"
 )
 
-func NewUserHandler(uc usecase.UserUseCase, tuc usecase.TokenUseCase) *UserHandler {
-	return &UserHandler{userUseCase: uc, tokenUseCase: tuc}
+// NewUserHandler - maxAvatar ограничивает тело загрузки аватара (0 - usecase.DefaultMaxAvatarSize).
+func NewUserHandler(uc usecase.UserUseCase, tuc usecase.TokenUseCase, maxAvatar int64) *UserHandler {
+	if maxAvatar <= 0 {
+		maxAvatar = usecase.DefaultMaxAvatarSize
+	}
+	return &UserHandler{userUseCase: uc, tokenUseCase: tuc, maxAvatar: maxAvatar}
 }
 
 func (h *UserHandler) RegisterRoutes(router *mux.Router) {
@@ -41,6 +44,8 @@ func (h *UserHandler) RegisterRoutes(router *mux.Router) {
 	protected.HandleFunc("/profile", h.getProfile).Methods("GET")
 	protected.HandleFunc("/profile", h.updateProfile).Methods("PUT")
 	protected.HandleFunc("/upload-avatar", h.uploadAvatar).Methods("POST")
+	protected.HandleFunc("/avatar/upload-url", h.avatarUploadURL).Methods("POST")
+	protected.HandleFunc("/avatar", h.setAvatar).Methods("PUT")
 
 	students := router.PathPrefix("/api/students").Subrouter()
 	students.Use(h.JWTMiddleware)
@@ -111,6 +116,7 @@ func (h *UserHandler) JWTMiddleware(next http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = flags.WithUser(ctx, claims.UserID, claims.Role)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -144,6 +150,10 @@ func (h *UserHandler) updateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	updatedUser, err := h.userUseCase.UpdateProfile(r.Context(), userToUpdate)
+	if err != nil && isAvatarError(err) {
+		writeAvatarError(w, userID, err)
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to update profile for userID %s: %v", userID, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -159,6 +169,7 @@ func (h *UserHandler) updateProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// uploadAvatar принимает multipart/form-data с полем avatar (JPEG, PNG или WebP).
 func (h *UserHandler) uploadAvatar(w http.ResponseWriter, r *http.Request) {
 	// Получаем userID из токена
 	userID, ok := r.Context().Value(UserIDKey).(string)
@@ -168,69 +179,103 @@ func (h *UserHandler) uploadAvatar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Устанавливаем максимальный размер файла (например, 10 MB)
-	r.ParseMultipartForm(10 << 20)
-
-	// Получаем файл из multipart-формы. "avatar" - это имя поля в форме
-	file, handler, err := r.FormFile("avatar")
+	// запас на заголовки multipart; точный размер файла проверяет usecase
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxAvatar+1<<20)
+	file, fh, err := r.FormFile("avatar")
 	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeAvatarError(w, userID, usecase.ErrAvatarTooLarge)
+			return
+		}
 		log.Printf("ERROR: Error retrieving file from form-data: %v", err)
 		http.Error(w, "Invalid file", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
-	// --- Логика сохранения файла ---
-	// В реальном приложении здесь будет вызов API Cloudflare.
-	// Мы же для примера сохраним файл локально.
-
-	// Создаем директорию для загрузок, если ее нет
-	uploadDir := "./uploads"
-	os.MkdirAll(uploadDir, os.ModePerm)
+	avatarURL, err := h.userUseCase.UploadAvatar(r.Context(), userID, fh.Size, file)
+	if err != nil {
+		writeAvatarError(w, userID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    map[string]string{"avatarUrl": avatarURL},
+	})
+}
 
-	// Формируем уникальное имя файла, чтобы избежать коллизий
-	fileName := fmt.Sprintf("%s%s", userID, filepath.Ext(handler.Filename))
-	filePath := filepath.Join(uploadDir, fileName)
+type avatarUploadURLRequest struct {
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
 
-	// Создаем файл на сервере
-	dst, err := os.Create(filePath)
+// avatarUploadURL выдаёт ссылку для загрузки аватара напрямую в хранилище.
+func (h *UserHandler) avatarUploadURL(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	var req avatarUploadURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	up, err := h.userUseCase.PresignAvatar(r.Context(), userID, req.ContentType, req.Size)
 	if err != nil {
-		log.Printf("ERROR: Could not create file on server: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeAvatarError(w, userID, err)
 		return
 	}
-	defer dst.Close()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "data": up})
+}
 
-	// Копируем содержимое загруженного файла в созданный файл
-	if _, err := io.Copy(dst, file); err != nil {
-		log.Printf("ERROR: Could not save file: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+type setAvatarRequest struct {
+	Avatar string `json:"avatar"` // key или url из avatar/upload-url
+}
+
+// setAvatar ставит аватаром файл, загруженный по ссылке из avatarUploadURL.
+func (h *UserHandler) setAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	var req setAvatarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	avatarURL, err := h.userUseCase.SetAvatar(r.Context(), userID, req.Avatar)
+	if err != nil {
+		writeAvatarError(w, userID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    map[string]string{"avatarUrl": avatarURL},
+	})
+}
 
-	// --- Конец логики сохранения файла ---
-
-	// Формируем URL, по которому будет доступен файл
-	// (в вашем случае это будет URL от Cloudflare)
-	avatarURL := "http://localhost:8082/" + filePath // Пример для локального сервера
+func isAvatarError(err error) bool {
+	return errors.Is(err, usecase.ErrAvatarType) || errors.Is(err, usecase.ErrAvatarTooLarge) ||
+		errors.Is(err, usecase.ErrAvatarNotFound) || errors.Is(err, usecase.ErrAvatarURL)
+}
 
-	// Обновляем URL аватара в базе данных
-	if err := h.userUseCase.UpdateAvatar(r.Context(), userID, avatarURL); err != nil {
-		log.Printf("ERROR: Could not update avatar URL in DB: %v", err)
+func writeAvatarError(w http.ResponseWriter, userID string, err error) {
+	status, code := 0, ""
+	switch {
+	case errors.Is(err, usecase.ErrAvatarType):
+		status, code = http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"
+	case errors.Is(err, usecase.ErrAvatarTooLarge):
+		status, code = http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE"
+	case errors.Is(err, usecase.ErrAvatarNotFound):
+		status, code = http.StatusBadRequest, "MEDIA_NOT_FOUND"
+	case errors.Is(err, usecase.ErrAvatarURL):
+		status, code = http.StatusBadRequest, "VALIDATION_ERROR"
+	case errors.Is(err, usecase.ErrAvatarDirect):
+		status, code = http.StatusForbidden, "FEATURE_DISABLED"
+	default:
+		log.Printf("ERROR: avatar for userID %s: %v", userID, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-
-	// Отправляем успешный ответ
-	response := map[string]interface{}{
-		"success": true,
-		"data": map[string]string{
-			"avatarUrl": avatarURL,
-		},
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, status, map[string]interface{}{
+		"success": false,
+		"error":   map[string]string{"code": code, "message": err.Error()},
+	})
 }
 
 func (h *UserHandler) listStudents(w http.ResponseWriter, r *http.Request) {
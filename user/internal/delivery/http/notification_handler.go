@@ -0,0 +1,206 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"user/internal/repository"
+	"user/internal/usecase"
+)
+
+// NotificationHandler - in-app центр уведомлений.
+type NotificationHandler struct {
+	notificationUseCase usecase.NotificationUseCase
+	auth                mux.MiddlewareFunc
+}
+
+// NewNotificationHandler принимает JWT-middleware юзер-сервиса (UserHandler.JWTMiddleware).
+func NewNotificationHandler(nuc usecase.NotificationUseCase, auth mux.MiddlewareFunc) *NotificationHandler {
+	return &NotificationHandler{notificationUseCase: nuc, auth: auth}
+}
+
+// RegisterRoutes нужно вызвать до UserHandler.RegisterRoutes: префикс /api/users у них общий.
+func (h *NotificationHandler) RegisterRoutes(router *mux.Router) {
+	n := router.PathPrefix("/api/users/notifications").Subrouter()
+	n.Use(tokenFromQuery("/api/users/notifications/stream"), h.auth)
+	n.HandleFunc("", h.list).Methods("GET")
+	n.HandleFunc("/unread-count", h.unreadCount).Methods("GET")
+	n.HandleFunc("/read", h.markRead).Methods("POST")
+	n.HandleFunc("/read-all", h.markAllRead).Methods("POST")
+	n.HandleFunc("/{id}/read", h.markOneRead).Methods("POST")
+	n.HandleFunc("/stream", h.stream).Methods("GET")
+}
+
+// tokenFromQuery - EventSource не умеет заголовки, поэтому для SSE токен можно передать в ?access_token=.
+func tokenFromQuery(path string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == path && r.Header.Get("Authorization") == "" {
+				if t := r.URL.Query().Get("access_token"); t != "" {
+					r.Header.Set("Authorization", "Bearer "+t)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type markReadRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (h *NotificationHandler) list(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	page, err := h.notificationUseCase.Inbox(r.Context(), userID, q.Get("cursor"), q.Get("unread") == "true", limit)
+	if err != nil {
+		writeNotificationError(w, userID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "data": page})
+}
+
+func (h *NotificationHandler) unreadCount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	count, err := h.notificationUseCase.UnreadCount(r.Context(), userID)
+	if err != nil {
+		writeNotificationError(w, userID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    map[string]int{"unreadCount": count},
+	})
+}
+
+func (h *NotificationHandler) markRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	var req markReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	h.respondRead(w, r, userID, req.IDs)
+}
+
+func (h *NotificationHandler) markOneRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	h.respondRead(w, r, userID, []string{mux.Vars(r)["id"]})
+}
+
+func (h *NotificationHandler) respondRead(w http.ResponseWriter, r *http.Request, userID string, ids []string) {
+	updated, err := h.notificationUseCase.MarkRead(r.Context(), userID, ids)
+	if err != nil {
+		writeNotificationError(w, userID, err)
+		return
+	}
+	count, err := h.notificationUseCase.UnreadCount(r.Context(), userID)
+	if err != nil {
+		writeNotificationError(w, userID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    map[string]int{"updated": updated, "unreadCount": count},
+	})
+}
+
+func (h *NotificationHandler) markAllRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	updated, err := h.notificationUseCase.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		writeNotificationError(w, userID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    map[string]int{"updated": updated, "unreadCount": 0},
+	})
+}
+
+// stream - SSE: событие "notification" на каждое новое уведомление, "read"/"read_all" - при
+// отметке прочтения на любом устройстве; комментарий-heartbeat раз в 25 секунд.
+func (h *NotificationHandler) stream(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(string)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// поток живёт дольше WriteTimeout сервера
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	events, cancel := h.notificationUseCase.Subscribe(userID)
+	defer cancel()
+
+	count, err := h.notificationUseCase.UnreadCount(r.Context(), userID)
+	if err != nil {
+		writeNotificationError(w, userID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: unread\ndata: {\"unreadCount\":%d}\n\n", count)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(25 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			b, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeNotificationError(w http.ResponseWriter, userID string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCursor), errors.Is(err, usecase.ErrInvalidIDs):
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"code": "VALIDATION_ERROR", "message": err.Error()},
+		})
+	case errors.Is(err, repository.ErrNotificationNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"success": false,
+			"error":   map[string]string{"code": "NOT_FOUND", "message": err.Error()},
+		})
+	default:
+		log.Printf("ERROR: notifications for userID %s: %v", userID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Notification - элемент in-app центра уведомлений (notifications с channel='inapp').
+type Notification struct {
+	ID          string                 `json:"id"`
+	TemplateKey string                 `json:"type"`
+	Title       string                 `json:"title"`
+	Body        string                 `json:"body"`
+	Payload     map[string]interface{} `json:"payload"`
+	Read        bool                   `json:"read"`
+	ReadAt      *time.Time             `json:"readAt,omitempty"`
+	CreatedAt   time.Time              `json:"createdAt"`
+}
+
+// NotificationPage - страница входящих с курсором на следующую.
+type NotificationPage struct {
+	Items       []Notification `json:"notifications"`
+	NextCursor  string         `json:"nextCursor,omitempty"`
+	UnreadCount int            `json:"unreadCount"`
+}
+
+// NotificationEvent - событие для SSE-потока.
+type NotificationEvent struct {
+	Type         string        `json:"type"` // notification | read | read_all
+	Notification *Notification `json:"notification,omitempty"`
+	IDs          []string      `json:"ids,omitempty"`
+	UnreadCount  int           `json:"unreadCount"`
+}
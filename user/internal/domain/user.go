@@ -31,3 +31,15 @@ type Pagination struct {
 	Total      int `json:"total"`
 	TotalPages int `json:"totalPages"`
 }
+
+// AvatarUpload - разрешение на прямую загрузку аватара в хранилище: файл отправляется методом Method
+// на UploadURL с заголовками Headers, затем Key или URL передаётся в PUT /api/users/avatar.
+type AvatarUpload struct {
+	Key       string            `json:"key"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	UploadURL string            `json:"uploadUrl"`
+	Headers   map[string]string `json:"headers"`
+	MaxSize   int64             `json:"maxSize"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
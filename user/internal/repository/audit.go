@@ -0,0 +1,10 @@
+package repository
+
+// auditUserProfile - снимок редактируемых полей профиля ($1 - id пользователя).
+const auditUserProfile = `
+SELECT jsonb_build_object(
+         'name', name, 'age', age, 'avatar', avatar, 'learningGoals', learning_goals,
+         'description', description,
+         'notifications', jsonb_build_object('lessons', notifications_lessons,
+           'messages', notifications_messages, 'reminders', notifications_reminders))
+FROM users WHERE id = $1`
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"user/internal/domain"
+)
+
+// NotificationsChannel - канал LISTEN/NOTIFY: новые in-app уведомления (триггер) и отметки прочтения.
+const NotificationsChannel = "user_notifications"
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationSignal - payload NOTIFY в канале NotificationsChannel.
+type NotificationSignal struct {
+	Type   string   `json:"type"` // notification | read | read_all
+	ID     string   `json:"id,omitempty"`
+	UserID string   `json:"userId"`
+	IDs    []string `json:"ids,omitempty"`
+}
+
+type NotificationRepository interface {
+	// List - входящие пользователя от новых к старым, начиная после курсора (createdAt, id).
+	List(ctx context.Context, userID string, afterAt *time.Time, afterID string, unreadOnly bool, limit int) ([]domain.Notification, error)
+	Find(ctx context.Context, userID, id string) (*domain.Notification, error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	// MarkRead отмечает прочитанными переданные id; возвращает реально изменённые.
+	MarkRead(ctx context.Context, userID string, ids []string) ([]string, error)
+	MarkAllRead(ctx context.Context, userID string) (int, error)
+	// Listen блокируется и отдаёт сигналы канала в fn до отмены ctx или ошибки соединения.
+	Listen(ctx context.Context, fn func(NotificationSignal)) error
+}
+
+type notificationPostgresRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationPostgresRepo(db *pgxpool.Pool) NotificationRepository {
+	return &notificationPostgresRepo{db: db}
+}
+
+const inboxColumns = `id::text, template_key, COALESCE(subject,''), COALESCE(body,''), payload, read_at, created_at`
+
+// inboxWhere - что считается входящим: отрендеренные диспетчером in-app уведомления.
+const inboxWhere = `user_id = $1 AND channel = 'inapp' AND status = 'sent'`
+
+func scanNotification(row pgx.Row) (*domain.Notification, error) {
+	var n domain.Notification
+	var payload []byte
+	if err := row.Scan(&n.ID, &n.TemplateKey, &n.Title, &n.Body, &payload, &n.ReadAt, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal(payload, &n.Payload)
+	n.Read = n.ReadAt != nil
+	return &n, nil
+}
+
+func (r *notificationPostgresRepo) List(ctx context.Context, userID string, afterAt *time.Time, afterID string, unreadOnly bool, limit int) ([]domain.Notification, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT `+inboxColumns+` FROM notifications
+WHERE `+inboxWhere+`
+  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+  AND (NOT $4 OR read_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT $5`, userID, afterAt, nullUUID(afterID), unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *n)
+	}
+	return items, rows.Err()
+}
+
+func (r *notificationPostgresRepo) Find(ctx context.Context, userID, id string) (*domain.Notification, error) {
+	n, err := scanNotification(r.db.QueryRow(ctx, `
+SELECT `+inboxColumns+` FROM notifications WHERE `+inboxWhere+` AND id = $2`, userID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (r *notificationPostgresRepo) UnreadCount(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `
+SELECT COUNT(*) FROM notifications WHERE `+inboxWhere+` AND read_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+func (r *notificationPostgresRepo) MarkRead(ctx context.Context, userID string, ids []string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, `
+UPDATE notifications SET read_at = now()
+WHERE `+inboxWhere+` AND id = ANY($2::uuid[]) AND read_at IS NULL
+RETURNING id::text`, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	changed := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changed = append(changed, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(changed) > 0 {
+		if err := notifySignal(ctx, tx, NotificationSignal{Type: "read", UserID: userID, IDs: changed}); err != nil {
+			return nil, err
+		}
+	}
+	return changed, tx.Commit(ctx)
+}
+
+func (r *notificationPostgresRepo) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+UPDATE notifications SET read_at = now() WHERE `+inboxWhere+` AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() > 0 {
+		if err := notifySignal(ctx, tx, NotificationSignal{Type: "read_all", UserID: userID}); err != nil {
+			return 0, err
+		}
+	}
+	return int(tag.RowsAffected()), tx.Commit(ctx)
+}
+
+// notifySignal - NOTIFY уходит слушателям только после commit транзакции.
+func notifySignal(ctx context.Context, tx pgx.Tx, s NotificationSignal) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `SELECT pg_notify($1, $2)`, NotificationsChannel, string(b))
+	return err
+}
+
+func (r *notificationPostgresRepo) Listen(ctx context.Context, fn func(NotificationSignal)) error {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+NotificationsChannel); err != nil {
+		return err
+	}
+	defer func() {
+		// соединение вернётся в пул — снимаем подписку отдельным контекстом
+		c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = conn.Exec(c, "UNLISTEN "+NotificationsChannel)
+	}()
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var s NotificationSignal
+		if err := json.Unmarshal([]byte(n.Payload), &s); err != nil {
+			continue
+		}
+		fn(s)
+	}
+}
+
+func nullUUID(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lib/pq"
+	"shared/audit"
 	"user/internal/domain"
 )
 
@@ -13,6 +14,9 @@ type UserRepository interface {
 	GetProfileByID(ctx context.Context, id string) (*domain.User, error)
 	UpdateProfile(ctx context.Context, user *domain.User) error
 	UpdateAvatarURL(ctx context.Context, userID, avatarURL string) error
+	// AvatarsInUse - какие из ключей объектов стоят аватаром у кого-либо (для уборки хранилища).
+	// Ключ выделяется из сохранённого адреса (users.avatar_key), так что базовый URL не важен.
+	AvatarsInUse(ctx context.Context, keys []string) (map[string]bool, error)
 	FindStudents(ctx context.Context, page, limit int) ([]domain.User, int, error) // New method
 }
 
@@ -80,7 +84,7 @@ func (r *userPostgresRepo) UpdateProfile(ctx context.Context, user *domain.User)
 			updated_at = NOW()
 		WHERE id = $9
 	`
-	_, err := r.db.Exec(ctx, query,
+	return r.updateAudited(ctx, user.ID, "user.profile", query,
 		user.Name,
 		user.Age,
 		user.Avatar,
@@ -91,14 +95,53 @@ func (r *userPostgresRepo) UpdateProfile(ctx context.Context, user *domain.User)
 		user.Notifications.Reminders,
 		user.ID, // ID пользователя для условия WHERE
 	)
-
-	return err
 }
 
 func (r *userPostgresRepo) UpdateAvatarURL(ctx context.Context, userID, avatarURL string) error {
 	query := `UPDATE users SET avatar = $1, updated_at = NOW() WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, avatarURL, userID)
-	return err
+	return r.updateAudited(ctx, userID, "user.avatar", query, avatarURL, userID)
+}
+
+func (r *userPostgresRepo) AvatarsInUse(ctx context.Context, keys []string) (map[string]bool, error) {
+	rows, err := r.db.Query(ctx, `SELECT DISTINCT avatar_key FROM users WHERE avatar_key = ANY($1)`, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	used := map[string]bool{}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		used[k] = true
+	}
+	return used, rows.Err()
+}
+
+// updateAudited - правка профиля самим пользователем со снимками до/после в audit_log.
+func (r *userPostgresRepo) updateAudited(ctx context.Context, userID, action, query string, args ...any) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	before, err := audit.Snapshot(ctx, tx, auditUserProfile+" FOR UPDATE", userID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+	after, err := audit.Snapshot(ctx, tx, auditUserProfile, userID)
+	if err != nil {
+		return err
+	}
+	if err := audit.Change(ctx, tx, userID, "user", userID, action, before, after); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *userPostgresRepo) FindStudents(ctx context.Context, page, limit int) ([]domain.User, int, error) {
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"shared/flags"
+	"shared/storage"
+	"user/internal/domain"
+)
+
+var (
+	ErrAvatarType     = errors.New("avatar must be a JPEG, PNG or WebP image")
+	ErrAvatarTooLarge = errors.New("avatar is too large")
+	ErrAvatarNotFound = errors.New("uploaded avatar not found; request a new upload URL")
+	ErrAvatarURL      = errors.New("avatar must be uploaded via /api/users/avatar/upload-url or /api/users/upload-avatar")
+	ErrAvatarDirect   = errors.New("direct avatar upload is disabled; use /api/users/upload-avatar")
+)
+
+// DefaultMaxAvatarSize - предел аватара по умолчанию.
+const DefaultMaxAvatarSize = 5 << 20
+
+const (
+	defaultUploadTTL = 15 * time.Minute
+	defaultGrace     = 24 * time.Hour
+	avatarPrefix     = "users/avatars"
+)
+
+// avatarTypes - допустимые типы → расширение ключа. Тип определяется по содержимому файла,
+// а не по имени и Content-Type клиента.
+var avatarTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+type AvatarOptions struct {
+	MaxSize   int64         // байт; 0 - DefaultMaxAvatarSize
+	UploadTTL time.Duration // срок ссылки для прямой загрузки; 0 - 15 минут
+	// Grace - сколько загруженный файл ждёт, пока его поставят аватаром, прежде чем считаться мусором; 0 - 24 часа.
+	Grace time.Duration
+}
+
+func (uc *userUseCase) UploadAvatar(ctx context.Context, userID string, size int64, r io.Reader) (string, error) {
+	if size > uc.avatars.MaxSize {
+		return "", fmt.Errorf("%w: max %d bytes", ErrAvatarTooLarge, uc.avatars.MaxSize)
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	ct := sniff(head[:n])
+	ext, ok := avatarTypes[ct]
+	if !ok || n == 0 {
+		return "", ErrAvatarType
+	}
+	key := storage.NewKey(path.Join(avatarPrefix, userID), ext)
+	if err := uc.store.Put(ctx, key, ct, io.MultiReader(bytes.NewReader(head[:n]), r), size); err != nil {
+		return "", err
+	}
+	avatarURL, err := uc.setAvatar(ctx, userID, uc.store.URL(key))
+	if err != nil {
+		uc.dropObject(ctx, key)
+		return "", err
+	}
+	return avatarURL, nil
+}
+
+func (uc *userUseCase) PresignAvatar(ctx context.Context, userID, contentType string, size int64) (*domain.AvatarUpload, error) {
+	// прямая загрузка за флагом: выключенная - клиент загружает через сервис (multipart)
+	if uc.flags != nil && !uc.flags.Enabled(ctx, flags.AvatarDirectUpload, true) {
+		return nil, ErrAvatarDirect
+	}
+	ct, _, _ := mime.ParseMediaType(contentType)
+	ext, ok := avatarTypes[ct]
+	if !ok {
+		return nil, ErrAvatarType
+	}
+	if size <= 0 || size > uc.avatars.MaxSize {
+		return nil, fmt.Errorf("%w: max %d bytes", ErrAvatarTooLarge, uc.avatars.MaxSize)
+	}
+	key := storage.NewKey(path.Join(avatarPrefix, userID), ext)
+	up, err := uc.store.PresignPut(ctx, key, ct, size, uc.avatars.UploadTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.AvatarUpload{
+		Key:       key,
+		URL:       uc.store.URL(key),
+		Method:    up.Method,
+		UploadURL: up.URL,
+		Headers:   up.Headers,
+		MaxSize:   uc.avatars.MaxSize,
+		ExpiresAt: up.ExpiresAt,
+	}, nil
+}
+
+func (uc *userUseCase) SetAvatar(ctx context.Context, userID, ref string) (string, error) {
+	avatarURL, err := uc.resolveAvatar(ctx, userID, ref)
+	if err != nil {
+		return "", err
+	}
+	return uc.setAvatar(ctx, userID, avatarURL)
+}
+
+// setAvatar сохраняет адрес аватара и удаляет прежний файл.
+func (uc *userUseCase) setAvatar(ctx context.Context, userID, avatarURL string) (string, error) {
+	current, err := uc.userRepo.GetProfileByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := uc.userRepo.UpdateAvatarURL(ctx, userID, avatarURL); err != nil {
+		return "", err
+	}
+	if current.Avatar != avatarURL {
+		uc.releaseAvatar(ctx, userID, current.Avatar)
+	}
+	return avatarURL, nil
+}
+
+// resolveAvatar - публичный адрес своей загрузки (ключ или URL) после проверки размера и содержимого.
+func (uc *userUseCase) resolveAvatar(ctx context.Context, userID, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	key, ok := storage.KeyFromURL(uc.store, ref)
+	if !ok {
+		if u, err := url.Parse(ref); err != nil || u.Scheme != "" {
+			return "", ErrAvatarURL
+		}
+		key = ref
+	}
+	if !strings.HasPrefix(key, path.Join(avatarPrefix, userID)+"/") {
+		return "", ErrAvatarNotFound
+	}
+	obj, err := uc.store.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return "", ErrAvatarNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	// файл, пролежавший без ссылки полпериода ожидания, может в любой момент убрать сборщик мусора
+	if time.Since(obj.ModTime) > uc.avatars.Grace/2 {
+		return "", ErrAvatarNotFound
+	}
+	if obj.Size > uc.avatars.MaxSize {
+		uc.dropObject(ctx, key)
+		return "", fmt.Errorf("%w: max %d bytes", ErrAvatarTooLarge, uc.avatars.MaxSize)
+	}
+	rc, _, err := uc.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrAvatarNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(rc, head)
+	rc.Close()
+	// подписанная ссылка фиксирует заявленный тип, но не содержимое
+	if ext, ok := avatarTypes[sniff(head[:n])]; !ok || ext != path.Ext(key) {
+		uc.dropObject(ctx, key)
+		return "", ErrAvatarType
+	}
+	return uc.store.URL(key), nil
+}
+
+// releaseAvatar удаляет файл прежнего аватара пользователя, даже если он сохранён под прежним
+// публичным адресом хранилища; внешние ссылки и чужие файлы пропускаются.
+func (uc *userUseCase) releaseAvatar(ctx context.Context, userID, avatarURL string) {
+	if key, ok := storage.KeyFromPath(avatarURL, path.Join(avatarPrefix, userID)+"/"); ok {
+		uc.dropObject(ctx, key)
+	}
+}
+
+func (uc *userUseCase) CollectGarbage(ctx context.Context) (int, error) {
+	const batchSize = 500
+	cutoff := time.Now().Add(-uc.avatars.Grace)
+	deleted := 0
+	var keys []string
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		used, err := uc.userRepo.AvatarsInUse(ctx, keys)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if used[k] {
+				continue
+			}
+			if err := uc.store.Delete(ctx, k); err != nil {
+				log.Printf("ERROR: storage gc delete %s: %v", k, err)
+				continue
+			}
+			deleted++
+		}
+		keys = keys[:0]
+		return nil
+	}
+	err := uc.store.List(ctx, avatarPrefix+"/", func(o storage.Object) error {
+		if o.ModTime.After(cutoff) {
+			return nil
+		}
+		keys = append(keys, o.Key)
+		if len(keys) >= batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// dropObject - ошибка удаления не откатывает изменение: оставшийся файл уберёт CollectGarbage.
+func (uc *userUseCase) dropObject(ctx context.Context, key string) {
+	if err := uc.store.Delete(ctx, key); err != nil {
+		log.Printf("ERROR: storage delete %s: %v", key, err)
+	}
+}
+
+func sniff(head []byte) string {
+	ct, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return ct
+}
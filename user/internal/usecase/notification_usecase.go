@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"user/internal/domain"
+	"user/internal/repository"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidIDs    = errors.New("ids must be a non-empty list of notification ids")
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type NotificationUseCase interface {
+	Inbox(ctx context.Context, userID, cursor string, unreadOnly bool, limit int) (*domain.NotificationPage, error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	MarkRead(ctx context.Context, userID string, ids []string) (int, error)
+	MarkAllRead(ctx context.Context, userID string) (int, error)
+	// Subscribe - поток событий для SSE; cancel обязательно вызвать при отключении клиента.
+	Subscribe(userID string) (<-chan domain.NotificationEvent, func())
+	// Run слушает LISTEN/NOTIFY и раздаёт события подписчикам этого инстанса (переподключается сам).
+	Run(ctx context.Context)
+}
+
+type notificationUseCase struct {
+	repo repository.NotificationRepository
+
+	mu   sync.RWMutex
+	subs map[string]map[chan domain.NotificationEvent]struct{} // userID → каналы
+}
+
+func NewNotificationUseCase(repo repository.NotificationRepository) NotificationUseCase {
+	return &notificationUseCase{repo: repo, subs: map[string]map[chan domain.NotificationEvent]struct{}{}}
+}
+
+func (uc *notificationUseCase) Inbox(ctx context.Context, userID, cursor string, unreadOnly bool, limit int) (*domain.NotificationPage, error) {
+	afterAt, afterID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	// берём на один больше, чтобы понять, есть ли следующая страница
+	items, err := uc.repo.List(ctx, userID, afterAt, afterID, unreadOnly, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.NotificationPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	if page.UnreadCount, err = uc.repo.UnreadCount(ctx, userID); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (uc *notificationUseCase) UnreadCount(ctx context.Context, userID string) (int, error) {
+	return uc.repo.UnreadCount(ctx, userID)
+}
+
+func (uc *notificationUseCase) MarkRead(ctx context.Context, userID string, ids []string) (int, error) {
+	if len(ids) == 0 || len(ids) > 500 {
+		return 0, ErrInvalidIDs
+	}
+	for _, id := range ids {
+		if !uuidRe.MatchString(id) {
+			return 0, ErrInvalidIDs
+		}
+	}
+	changed, err := uc.repo.MarkRead(ctx, userID, ids)
+	return len(changed), err
+}
+
+func (uc *notificationUseCase) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	return uc.repo.MarkAllRead(ctx, userID)
+}
+
+// Курсор - base64("<createdAt RFC3339Nano>|<id>") последнего элемента страницы.
+func encodeCursor(at time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeCursor(cursor string) (*time.Time, string, error) {
+	if cursor == "" {
+		return nil, "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || !uuidRe.MatchString(parts[1]) {
+		return nil, "", ErrInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+	return &at, parts[1], nil
+}
+
+func (uc *notificationUseCase) Subscribe(userID string) (<-chan domain.NotificationEvent, func()) {
+	ch := make(chan domain.NotificationEvent, 16)
+	uc.mu.Lock()
+	if uc.subs[userID] == nil {
+		uc.subs[userID] = map[chan domain.NotificationEvent]struct{}{}
+	}
+	uc.subs[userID][ch] = struct{}{}
+	uc.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			uc.mu.Lock()
+			delete(uc.subs[userID], ch)
+			if len(uc.subs[userID]) == 0 {
+				delete(uc.subs, userID)
+			}
+			uc.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (uc *notificationUseCase) hasSubscribers(userID string) bool {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return len(uc.subs[userID]) > 0
+}
+
+// broadcast не блокируется: медленный клиент теряет событие, но догонит через GET.
+func (uc *notificationUseCase) broadcast(userID string, ev domain.NotificationEvent) {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	for ch := range uc.subs[userID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (uc *notificationUseCase) Run(ctx context.Context) {
+	for {
+		err := uc.repo.Listen(ctx, func(s repository.NotificationSignal) {
+			if !uc.hasSubscribers(s.UserID) {
+				return
+			}
+			uc.handle(ctx, s)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("WARN: notifications listener stopped: %v; reconnecting", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (uc *notificationUseCase) handle(ctx context.Context, s repository.NotificationSignal) {
+	ev := domain.NotificationEvent{Type: s.Type, IDs: s.IDs}
+	if s.Type == "notification" {
+		n, err := uc.repo.Find(ctx, s.UserID, s.ID)
+		if err != nil {
+			log.Printf("ERROR: load notification %s: %v", s.ID, err)
+			return
+		}
+		ev.Notification = n
+	}
+	count, err := uc.repo.UnreadCount(ctx, s.UserID)
+	if err != nil {
+		log.Printf("ERROR: unread count for %s: %v", s.UserID, err)
+		return
+	}
+	ev.UnreadCount = count
+	uc.broadcast(s.UserID, ev)
+}
@@ -2,7 +2,10 @@ package usecase
 
 import (
 	"context"
+	"io"
 	"math"
+	"shared/flags"
+	"shared/storage"
 	"user/internal/domain"
 	"user/internal/repository"
 )
@@ -10,16 +13,35 @@ import (
 type UserUseCase interface {
 	GetProfile(ctx context.Context, id string) (*domain.User, error)
 	UpdateProfile(ctx context.Context, user *domain.User) (*domain.User, error)
-	UpdateAvatar(ctx context.Context, userID, avatarURL string) error
+	// UploadAvatar - загрузка аватара через сервис (multipart); size - размер файла из запроса.
+	UploadAvatar(ctx context.Context, userID string, size int64, r io.Reader) (string, error)
+	// PresignAvatar - ссылка для прямой загрузки аватара в хранилище.
+	PresignAvatar(ctx context.Context, userID, contentType string, size int64) (*domain.AvatarUpload, error)
+	// SetAvatar ставит аватаром файл, загруженный по ссылке из PresignAvatar (ключ или URL).
+	SetAvatar(ctx context.Context, userID, ref string) (string, error)
+	// CollectGarbage удаляет из хранилища аватары, которые никому не принадлежат.
+	CollectGarbage(ctx context.Context) (int, error)
 	ListStudents(ctx context.Context, page, limit int) ([]domain.User, *domain.Pagination, error)
 }
 
 type userUseCase struct {
 	userRepo repository.UserRepository
+	store    storage.Storage
+	avatars  AvatarOptions
+	flags    flags.Client // nil - флаги не проверяются, всё включено
 }
 
-func NewUserUseCase(repo repository.UserRepository) UserUseCase {
-	return &userUseCase{userRepo: repo}
+func NewUserUseCase(repo repository.UserRepository, store storage.Storage, opts AvatarOptions, f flags.Client) UserUseCase {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxAvatarSize
+	}
+	if opts.UploadTTL <= 0 {
+		opts.UploadTTL = defaultUploadTTL
+	}
+	if opts.Grace <= 0 {
+		opts.Grace = defaultGrace
+	}
+	return &userUseCase{userRepo: repo, store: store, avatars: opts, flags: f}
 }
 
 func (uc *userUseCase) GetProfile(ctx context.Context, id string) (*domain.User, error) {
@@ -42,8 +64,12 @@ func (uc *userUseCase) UpdateProfile(ctx context.Context, user *domain.User) (*d
 	if user.Age != 0 {
 		currentUser.Age = user.Age
 	}
-	if user.Avatar != "" {
-		currentUser.Avatar = user.Avatar
+	// новый аватар - только свой файл из хранилища; заменённый удаляем после сохранения
+	oldAvatar := currentUser.Avatar
+	if user.Avatar != "" && user.Avatar != currentUser.Avatar {
+		if currentUser.Avatar, err = uc.resolveAvatar(ctx, user.ID, user.Avatar); err != nil {
+			return nil, err
+		}
 	}
 	if len(user.LearningGoals) > 0 {
 		currentUser.LearningGoals = user.LearningGoals
@@ -58,15 +84,14 @@ func (uc *userUseCase) UpdateProfile(ctx context.Context, user *domain.User) (*d
 	if err := uc.userRepo.UpdateProfile(ctx, currentUser); err != nil {
 		return nil, err
 	}
+	if oldAvatar != currentUser.Avatar {
+		uc.releaseAvatar(ctx, user.ID, oldAvatar)
+	}
 
 	// Возвращаем полностью обновленный профиль.
 	return currentUser, nil
 }
 
-func (uc *userUseCase) UpdateAvatar(ctx context.Context, userID, avatarURL string) error {
-	return uc.userRepo.UpdateAvatarURL(ctx, userID, avatarURL)
-}
-
 func (uc *userUseCase) ListStudents(ctx context.Context, page, limit int) ([]domain.User, *domain.Pagination, error) {
 	students, total, err := uc.userRepo.FindStudents(ctx, page, limit)
 	if err != nil {
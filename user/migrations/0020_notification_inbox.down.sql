@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_notifications_inapp ON notifications;
+DROP FUNCTION IF EXISTS notify_inapp_notification();
+DROP INDEX IF EXISTS notifications_inbox_unread_idx;
+DROP INDEX IF EXISTS notifications_inbox_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS read_at;
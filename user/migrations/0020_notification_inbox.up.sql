@@ -0,0 +1,20 @@
+-- in-app центр уведомлений: отметка прочтения и live-доставка через LISTEN/NOTIFY
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS read_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS notifications_inbox_idx
+    ON notifications (user_id, created_at DESC, id DESC) WHERE channel = 'inapp' AND status = 'sent';
+CREATE INDEX IF NOT EXISTS notifications_inbox_unread_idx
+    ON notifications (user_id) WHERE channel = 'inapp' AND status = 'sent' AND read_at IS NULL;
+
+-- уведомление появляется во входящих, когда диспетчер отрендерил его и пометил sent
+CREATE OR REPLACE FUNCTION notify_inapp_notification() RETURNS trigger AS $$
+BEGIN
+  IF NEW.channel = 'inapp' AND NEW.status = 'sent'
+     AND (TG_OP = 'INSERT' OR OLD.status IS DISTINCT FROM 'sent') THEN
+    PERFORM pg_notify('user_notifications', json_build_object('type', 'notification', 'id', NEW.id, 'userId', NEW.user_id)::text);
+  END IF;
+  RETURN NEW;
+END;$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notifications_inapp AFTER INSERT OR UPDATE OF status ON notifications
+    FOR EACH ROW EXECUTE FUNCTION notify_inapp_notification();
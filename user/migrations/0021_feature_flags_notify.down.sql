@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_feature_flags_notify ON feature_flags;
+DROP FUNCTION IF EXISTS notify_feature_flag();
+DELETE FROM feature_flags WHERE key IN ('auth.otp_login', 'tutors.search');
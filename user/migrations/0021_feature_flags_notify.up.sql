@@ -0,0 +1,22 @@
+-- feature flags: кеши сервисов обновляются по NOTIFY с ключом изменённого флага
+CREATE OR REPLACE FUNCTION notify_feature_flag() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM pg_notify('feature_flags', OLD.key);
+    RETURN OLD;
+  END IF;
+  PERFORM pg_notify('feature_flags', NEW.key);
+  IF TG_OP = 'UPDATE' AND OLD.key <> NEW.key THEN
+    PERFORM pg_notify('feature_flags', OLD.key);
+  END IF;
+  RETURN NEW;
+END;$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_feature_flags_notify AFTER INSERT OR UPDATE OR DELETE ON feature_flags
+    FOR EACH ROW EXECUTE FUNCTION notify_feature_flag();
+
+-- флаги, которые проверяет код; по умолчанию включены всем, как было до флагов
+INSERT INTO feature_flags (key, enabled, rules) VALUES
+    ('auth.otp_login', true, '{}'::jsonb),
+    ('tutors.search', true, '{}'::jsonb)
+ON CONFLICT (key) DO NOTHING;
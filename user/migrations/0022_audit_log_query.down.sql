@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS audit_log_created_idx;
+DROP INDEX IF EXISTS audit_log_actor_idx;
+DELETE FROM audit_log WHERE entity_id !~ '^[0-9a-fA-F-]{36}$';
+ALTER TABLE audit_log ALTER COLUMN entity_id TYPE uuid USING entity_id::uuid;
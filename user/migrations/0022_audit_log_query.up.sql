@@ -0,0 +1,5 @@
+-- audit_log: сущности не только с uuid (языки по коду), индексы под фильтры админки
+ALTER TABLE audit_log ALTER COLUMN entity_id TYPE text USING entity_id::text;
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, created_at DESC) WHERE actor_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS audit_log_created_idx ON audit_log (created_at DESC);
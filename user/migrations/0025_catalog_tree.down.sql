@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_users_catalog ON users;
+DROP TRIGGER IF EXISTS trg_tutor_profiles_catalog ON tutor_profiles;
+DROP TRIGGER IF EXISTS trg_tutor_subdirections_catalog ON tutor_subdirections;
+DROP TRIGGER IF EXISTS trg_subdirections_catalog ON subdirections;
+DROP TRIGGER IF EXISTS trg_directions_catalog ON directions;
+DROP FUNCTION IF EXISTS touch_catalog_tree();
+DROP TABLE IF EXISTS catalog_versions;
@@ -0,0 +1,24 @@
+-- Версия дерева каталога для ETag/Last-Modified: меняется при любой правке направлений,
+-- поднаправлений, привязок репетиторов и их статуса (statement-триггеры — одна запись на запрос)
+CREATE TABLE IF NOT EXISTS catalog_versions (
+    name       text PRIMARY KEY,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+INSERT INTO catalog_versions (name) VALUES ('tree') ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION touch_catalog_tree() RETURNS trigger AS $$
+BEGIN
+    UPDATE catalog_versions SET changed_at = clock_timestamp() WHERE name = 'tree';
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_directions_catalog AFTER INSERT OR UPDATE OR DELETE ON directions
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_subdirections_catalog AFTER INSERT OR UPDATE OR DELETE ON subdirections
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_tutor_subdirections_catalog AFTER INSERT OR UPDATE OR DELETE ON tutor_subdirections
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_tutor_profiles_catalog AFTER UPDATE OF verification, deleted_at ON tutor_profiles
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_users_catalog AFTER UPDATE OF deleted_at ON users
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_avatar_key_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_key;
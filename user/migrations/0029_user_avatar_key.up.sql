@@ -0,0 +1,9 @@
+-- Сборщик мусора хранилища сравнивает ключи объектов, а аватар хранится публичным адресом: ключ
+-- выделяется из пути, чтобы смена MEDIA_PUBLIC_URL (CDN, бакет) не делала прежние аватары ничьими.
+-- Колонку avatar сервис использует давно, но в миграциях её не было.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar text;
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS avatar_key text
+        GENERATED ALWAYS AS (substring(avatar from '/(users/avatars/[^?#]+)')) STORED;
+
+CREATE INDEX IF NOT EXISTS users_avatar_key_idx ON users (avatar_key) WHERE avatar_key IS NOT NULL;
@@ -0,0 +1,36 @@
+DROP INDEX IF EXISTS users_deleted_idx;
+DROP INDEX IF EXISTS tutor_profiles_updated_idx;
+DROP INDEX IF EXISTS tutor_subdirections_updated_idx;
+DROP INDEX IF EXISTS subdirections_updated_idx;
+DROP INDEX IF EXISTS directions_updated_idx;
+
+DROP TRIGGER IF EXISTS trg_tutor_subdirections_updated ON tutor_subdirections;
+DROP TRIGGER IF EXISTS trg_subdirections_updated ON subdirections;
+DROP TRIGGER IF EXISTS trg_directions_updated ON directions;
+
+ALTER TABLE tutor_subdirections DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE subdirections DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE directions DROP COLUMN IF EXISTS updated_at;
+
+CREATE TABLE IF NOT EXISTS catalog_versions (
+    name       text PRIMARY KEY,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+INSERT INTO catalog_versions (name) VALUES ('tree') ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION touch_catalog_tree() RETURNS trigger AS $$
+BEGIN
+    UPDATE catalog_versions SET changed_at = clock_timestamp() WHERE name = 'tree';
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_directions_catalog AFTER INSERT OR UPDATE OR DELETE ON directions
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_subdirections_catalog AFTER INSERT OR UPDATE OR DELETE ON subdirections
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_tutor_subdirections_catalog AFTER INSERT OR UPDATE OR DELETE ON tutor_subdirections
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_tutor_profiles_catalog AFTER UPDATE OF verification, deleted_at ON tutor_profiles
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
+CREATE TRIGGER trg_users_catalog AFTER UPDATE OF deleted_at ON users
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog_tree();
@@ -0,0 +1,26 @@
+-- Версия дерева каталога считается по самим таблицам: statement-триггеры из 0025 обновляли
+-- одну строку catalog_versions('tree'), и любые две правки привязок ждали друг друга до коммита.
+-- Теперь версия - max(updated_at) по таблицам дерева плюс число их строк (удаление не двигает
+-- updated_at, но меняет счёт).
+DROP TRIGGER IF EXISTS trg_users_catalog ON users;
+DROP TRIGGER IF EXISTS trg_tutor_profiles_catalog ON tutor_profiles;
+DROP TRIGGER IF EXISTS trg_tutor_subdirections_catalog ON tutor_subdirections;
+DROP TRIGGER IF EXISTS trg_subdirections_catalog ON subdirections;
+DROP TRIGGER IF EXISTS trg_directions_catalog ON directions;
+DROP FUNCTION IF EXISTS touch_catalog_tree();
+DROP TABLE IF EXISTS catalog_versions;
+
+ALTER TABLE directions ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE subdirections ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE tutor_subdirections ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
+
+CREATE TRIGGER trg_directions_updated BEFORE UPDATE ON directions FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER trg_subdirections_updated BEFORE UPDATE ON subdirections FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER trg_tutor_subdirections_updated BEFORE UPDATE ON tutor_subdirections FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- max() по индексу - без прохода по таблице
+CREATE INDEX IF NOT EXISTS directions_updated_idx ON directions (updated_at);
+CREATE INDEX IF NOT EXISTS subdirections_updated_idx ON subdirections (updated_at);
+CREATE INDEX IF NOT EXISTS tutor_subdirections_updated_idx ON tutor_subdirections (updated_at);
+CREATE INDEX IF NOT EXISTS tutor_profiles_updated_idx ON tutor_profiles (updated_at);
+CREATE INDEX IF NOT EXISTS users_deleted_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;